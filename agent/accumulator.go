@@ -17,6 +17,10 @@ type accumulator struct {
 	maker     MetricMaker
 	metrics   chan<- telegraf.Metric
 	precision time.Duration
+
+	// clock returns the time for metrics added without timestamp, if unset
+	// the current time is used
+	clock func() time.Time
 }
 
 func NewAccumulator(
@@ -113,6 +117,8 @@ func (ac *accumulator) getTime(t []time.Time) time.Time {
 	var timestamp time.Time
 	if len(t) > 0 {
 		timestamp = t[0]
+	} else if ac.clock != nil {
+		timestamp = ac.clock()
 	} else {
 		timestamp = time.Now()
 	}
@@ -5,8 +5,10 @@ import (
 	"errors"
 	"fmt"
 	"log"
+	"maps"
 	"os"
 	"runtime"
+	"slices"
 	"sync"
 	"time"
 
@@ -17,6 +19,7 @@ import (
 	"github.com/influxdata/telegraf/internal"
 	"github.com/influxdata/telegraf/internal/snmp"
 	"github.com/influxdata/telegraf/models"
+	"github.com/influxdata/telegraf/persister"
 	"github.com/influxdata/telegraf/plugins/processors"
 	"github.com/influxdata/telegraf/plugins/serializers/influx"
 )
@@ -24,6 +27,17 @@ import (
 // Agent runs a set of plugins.
 type Agent struct {
 	Config *config.Config
+
+	// ReloadFunc is called to request a reload of the configuration, e.g. via
+	// the control API. Reloading is not supported if not set.
+	ReloadFunc func()
+
+	// Protects the plugin lists of the config against concurrent reloads
+	pluginsLock sync.RWMutex
+
+	// Units of the running agent used for reloading individual plugins
+	reloadLock sync.Mutex
+	running    *pipeline
 }
 
 // NewAgent returns an Agent for the given Config.
@@ -48,6 +62,14 @@ func NewAgent(cfg *config.Config) *Agent {
 type inputUnit struct {
 	dst    chan<- telegraf.Metric
 	inputs []*models.RunningInput
+
+	// Gather loops of the running inputs, protected by the lock to allow
+	// exchanging inputs on reload
+	sync.Mutex
+	ctx    context.Context
+	loops  map[*models.RunningInput]*pluginLoop
+	wg     sync.WaitGroup
+	closed bool
 }
 
 //  ______     ┌───────────┐     ______
@@ -81,6 +103,12 @@ type aggregatorUnit struct {
 	aggC        chan<- telegraf.Metric
 	outputC     chan<- telegraf.Metric
 	aggregators []*models.RunningAggregator
+
+	// Aggregators continuing from a previous processing chain keep their
+	// aggregation window and aggregators kept for the next processing chain
+	// are not pushed when stopping. Both are only set on reload.
+	resumed map[*models.RunningAggregator]bool
+	keep    map[*models.RunningAggregator]bool
 }
 
 // outputUnit is a group of Outputs and their source channel.  Metrics on the
@@ -99,6 +127,68 @@ type aggregatorUnit struct {
 type outputUnit struct {
 	src     <-chan telegraf.Metric
 	outputs []*models.RunningOutput
+	groups  []*models.OutputGroupConfig
+
+	// Routes receiving the metrics, i.e. the output groups and all outputs
+	// not being member of a group
+	routes []outputRoute
+
+	// Flush loops of the running outputs, protected by the lock to allow
+	// exchanging outputs on reload
+	sync.RWMutex
+	ctx    context.Context
+	loops  map[*models.RunningOutput]*pluginLoop
+	wg     sync.WaitGroup
+	closed bool
+}
+
+// outputRoute receives metrics for a single output or an output group
+type outputRoute interface {
+	AddMetric(telegraf.Metric)
+	AddMetricNoCopy(telegraf.Metric)
+}
+
+// updateRoutes determines the routes for the current outputs of the unit and
+// connects the outputs to their dead-letter outputs. Dead-letter outputs only
+// receive the metrics forwarded to them and are thus excluded from routing.
+// The caller must hold the lock of the unit.
+func (unit *outputUnit) updateRoutes() {
+	members := make(map[*models.RunningOutput]bool, len(unit.outputs))
+	for _, output := range unit.outputs {
+		if output.Config.DeadLetterOutput == "" {
+			continue
+		}
+		idx := slices.IndexFunc(unit.outputs, func(o *models.RunningOutput) bool {
+			return o.Config.Alias == output.Config.DeadLetterOutput
+		})
+		if idx < 0 {
+			output.SetDeadLetterOutput(nil)
+			continue
+		}
+		output.SetDeadLetterOutput(unit.outputs[idx])
+		members[unit.outputs[idx]] = true
+	}
+
+	routes := make([]outputRoute, 0, len(unit.outputs))
+	for _, cfg := range unit.groups {
+		group := models.NewOutputGroup(cfg, unit.outputs)
+		for _, output := range group.Outputs() {
+			members[output] = true
+		}
+		routes = append(routes, group)
+	}
+	for _, output := range unit.outputs {
+		if !members[output] {
+			routes = append(routes, output)
+		}
+	}
+	unit.routes = routes
+}
+
+// pluginLoop is the goroutine periodically gathering or flushing a plugin.
+type pluginLoop struct {
+	cancel context.CancelFunc
+	done   chan struct{}
 }
 
 // Run starts and runs the Agent until the context is done.
@@ -138,36 +228,29 @@ func (a *Agent) Run(ctx context.Context) error {
 	startTime := time.Now()
 
 	log.Printf("D! [agent] Connecting outputs")
-	next, ou, err := a.startOutputs(ctx, a.Config.Outputs)
+	outputC, ou, err := a.startOutputs(ctx, a.Config.Outputs)
 	if err != nil {
 		return err
 	}
 
-	var apu []*processorUnit
-	var au *aggregatorUnit
-	if len(a.Config.Aggregators) != 0 {
-		aggC := next
-		if len(a.Config.AggProcessors) != 0 && !*a.Config.Agent.SkipProcessorsAfterAggregators {
-			aggC, apu, err = a.startProcessors(next, a.Config.AggProcessors)
-			if err != nil {
-				return err
-			}
-		}
+	inputC := make(chan telegraf.Metric, 100)
+	cu, err := a.startChain(inputC, outputC, a.Config.Processors, a.Config.AggProcessors, a.Config.Aggregators)
+	if err != nil {
+		return err
+	}
 
-		next, au = a.startAggregators(aggC, next, a.Config.Aggregators)
+	iu, err := a.startInputs(inputC, a.Config.Inputs)
+	if err != nil {
+		return err
 	}
 
-	var pu []*processorUnit
-	if len(a.Config.Processors) != 0 {
-		next, pu, err = a.startProcessors(next, a.Config.Processors)
+	if a.Config.Agent.ControlAPIAddress != "" {
+		log.Printf("D! [agent] Starting control API")
+		srv, err := a.startControlAPI()
 		if err != nil {
 			return err
 		}
-	}
-
-	iu, err := a.startInputs(next, a.Config.Inputs)
-	if err != nil {
-		return err
+		defer stopControlAPI(srv)
 	}
 
 	var wg sync.WaitGroup
@@ -177,36 +260,37 @@ func (a *Agent) Run(ctx context.Context) error {
 		a.runOutputs(ou)
 	}()
 
-	if au != nil {
-		wg.Add(1)
-		go func() {
-			defer wg.Done()
-			a.runProcessors(apu)
-		}()
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		a.runChainUnit(startTime, cu)
+	}()
 
-		wg.Add(1)
-		go func() {
-			defer wg.Done()
-			a.runAggregators(startTime, au)
-		}()
-	}
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		a.runInputs(ctx, startTime, iu)
+	}()
 
-	if pu != nil {
+	a.reloadLock.Lock()
+	a.running = &pipeline{ctx: ctx, inputs: iu, chain: cu, outputs: ou}
+	a.reloadLock.Unlock()
+
+	if a.Config.Persister != nil && a.Config.Agent.StatefileInterval > 0 {
 		wg.Add(1)
 		go func() {
 			defer wg.Done()
-			a.runProcessors(pu)
+			a.checkpointStates(ctx, time.Duration(a.Config.Agent.StatefileInterval))
 		}()
 	}
 
-	wg.Add(1)
-	go func() {
-		defer wg.Done()
-		a.runInputs(ctx, startTime, iu)
-	}()
-
 	wg.Wait()
 
+	// Wait for ongoing reloads to finish
+	a.reloadLock.Lock()
+	a.running = nil
+	a.reloadLock.Unlock()
+
 	if a.Config.Persister != nil {
 		log.Printf("D! [agent] Persisting plugin states")
 		if err := a.Config.Persister.Store(); err != nil {
@@ -220,7 +304,23 @@ func (a *Agent) Run(ctx context.Context) error {
 
 // InitPlugins runs the Init function on plugins.
 func (a *Agent) InitPlugins() error {
-	for _, input := range a.Config.Inputs {
+	return a.initPlugins(a.plugins())
+}
+
+// plugins returns the plugins of the agent's configuration
+func (a *Agent) plugins() *config.PluginSet {
+	return &config.PluginSet{
+		Inputs:        a.Config.Inputs,
+		Processors:    a.Config.Processors,
+		AggProcessors: a.Config.AggProcessors,
+		Aggregators:   a.Config.Aggregators,
+		Outputs:       a.Config.Outputs,
+	}
+}
+
+// initPlugins runs the Init function on the given plugins.
+func (a *Agent) initPlugins(plugins *config.PluginSet) error {
+	for _, input := range plugins.Inputs {
 		// Share the snmp translator setting with plugins that need it.
 		if tp, ok := input.Input.(snmp.TranslatorPlugin); ok {
 			tp.SetTranslator(a.Config.Agent.SnmpTranslator)
@@ -230,27 +330,27 @@ func (a *Agent) InitPlugins() error {
 			return fmt.Errorf("could not initialize input %s: %w", input.LogName(), err)
 		}
 	}
-	for _, processor := range a.Config.Processors {
+	for _, processor := range plugins.Processors {
 		err := processor.Init()
 		if err != nil {
 			return fmt.Errorf("could not initialize processor %s: %w", processor.LogName(), err)
 		}
 	}
-	for _, aggregator := range a.Config.Aggregators {
+	for _, aggregator := range plugins.Aggregators {
 		err := aggregator.Init()
 		if err != nil {
 			return fmt.Errorf("could not initialize aggregator %s: %w", aggregator.LogName(), err)
 		}
 	}
 	if !*a.Config.Agent.SkipProcessorsAfterAggregators {
-		for _, processor := range a.Config.AggProcessors {
+		for _, processor := range plugins.AggProcessors {
 			err := processor.Init()
 			if err != nil {
 				return fmt.Errorf("could not initialize processor %s: %w", processor.LogName(), err)
 			}
 		}
 	}
-	for _, output := range a.Config.Outputs {
+	for _, output := range plugins.Outputs {
 		err := output.Init()
 		if err != nil {
 			return fmt.Errorf("could not initialize output %s: %w", output.LogName(), err)
@@ -259,26 +359,59 @@ func (a *Agent) InitPlugins() error {
 	return nil
 }
 
+// checkpointStates periodically persists the plugin states until the context
+// is done.
+func (a *Agent) checkpointStates(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			log.Printf("D! [agent] Checkpointing plugin states")
+			if err := a.Config.Persister.Store(); err != nil {
+				log.Printf("E! [agent] Checkpointing plugin states failed: %v", err)
+			}
+		}
+	}
+}
+
 // initPersister initializes the persister and registers the plugins.
 func (a *Agent) initPersister() error {
 	if err := a.Config.Persister.Init(); err != nil {
 		return err
 	}
 
-	for _, input := range a.Config.Inputs {
+	return registerStatefulPlugins(a.Config.Persister, a.plugins())
+}
+
+// registerStatefulPlugins registers all stateful plugins of the set with the
+// given persister.
+func registerStatefulPlugins(p *persister.Persister, plugins *config.PluginSet) error {
+	return forEachStatefulPlugin(plugins, func(kind, name, id string, plugin telegraf.StatefulPlugin) error {
+		if err := p.Register(id, plugin); err != nil {
+			return fmt.Errorf("could not register %s %s: %w", kind, name, err)
+		}
+		return nil
+	})
+}
+
+// forEachStatefulPlugin calls the given function for all stateful plugins of
+// the set.
+func forEachStatefulPlugin(plugins *config.PluginSet, fn func(kind, name, id string, plugin telegraf.StatefulPlugin) error) error {
+	for _, input := range plugins.Inputs {
 		plugin, ok := input.Input.(telegraf.StatefulPlugin)
 		if !ok {
 			continue
 		}
-
-		name := input.LogName()
-		id := input.ID()
-		if err := a.Config.Persister.Register(id, plugin); err != nil {
-			return fmt.Errorf("could not register input %s: %w", name, err)
+		if err := fn("input", input.LogName(), input.ID(), plugin); err != nil {
+			return err
 		}
 	}
 
-	for _, processor := range a.Config.Processors {
+	for _, processor := range plugins.Processors {
 		var plugin telegraf.StatefulPlugin
 		if p, ok := processor.Processor.(processors.HasUnwrap); ok {
 			plugin, ok = p.Unwrap().(telegraf.StatefulPlugin)
@@ -291,57 +424,45 @@ func (a *Agent) initPersister() error {
 				continue
 			}
 		}
-
-		name := processor.LogName()
-		id := processor.ID()
-		if err := a.Config.Persister.Register(id, plugin); err != nil {
-			return fmt.Errorf("could not register processor %s: %w", name, err)
+		if err := fn("processor", processor.LogName(), processor.ID(), plugin); err != nil {
+			return err
 		}
 	}
 
-	for _, aggregator := range a.Config.Aggregators {
+	for _, aggregator := range plugins.Aggregators {
 		plugin, ok := aggregator.Aggregator.(telegraf.StatefulPlugin)
 		if !ok {
 			continue
 		}
-
-		name := aggregator.LogName()
-		id := aggregator.ID()
-		if err := a.Config.Persister.Register(id, plugin); err != nil {
-			return fmt.Errorf("could not register aggregator %s: %w", name, err)
+		if err := fn("aggregator", aggregator.LogName(), aggregator.ID(), plugin); err != nil {
+			return err
 		}
 	}
 
-	for _, processor := range a.Config.AggProcessors {
+	for _, processor := range plugins.AggProcessors {
 		plugin, ok := processor.Processor.(telegraf.StatefulPlugin)
 		if !ok {
 			continue
 		}
-
-		name := processor.LogName()
-		id := processor.ID()
-		if err := a.Config.Persister.Register(id, plugin); err != nil {
-			return fmt.Errorf("could not register aggregating processor %s: %w", name, err)
+		if err := fn("aggregating processor", processor.LogName(), processor.ID(), plugin); err != nil {
+			return err
 		}
 	}
 
-	for _, output := range a.Config.Outputs {
+	for _, output := range plugins.Outputs {
 		plugin, ok := output.Output.(telegraf.StatefulPlugin)
 		if !ok {
 			continue
 		}
-
-		name := output.LogName()
-		id := output.ID()
-		if err := a.Config.Persister.Register(id, plugin); err != nil {
-			return fmt.Errorf("could not register output %s: %w", name, err)
+		if err := fn("output", output.LogName(), output.ID(), plugin); err != nil {
+			return err
 		}
 	}
 
 	return nil
 }
 
-func (*Agent) startInputs(dst chan<- telegraf.Metric, inputs []*models.RunningInput) (*inputUnit, error) {
+func (a *Agent) startInputs(dst chan<- telegraf.Metric, inputs []*models.RunningInput) (*inputUnit, error) {
 	log.Printf("D! [agent] Starting service inputs")
 
 	unit := &inputUnit{
@@ -349,45 +470,58 @@ func (*Agent) startInputs(dst chan<- telegraf.Metric, inputs []*models.RunningIn
 	}
 
 	for _, input := range inputs {
-		// Service input plugins are not normally subject to timestamp
-		// rounding except for when precision is set on the input plugin.
-		//
-		// This only applies to the accumulator passed to Start(), the
-		// Gather() accumulator does apply rounding according to the
-		// precision and interval agent/plugin settings.
-		var interval time.Duration
-		var precision time.Duration
-		if input.Config.Precision != 0 {
-			precision = input.Config.Precision
-		}
-
-		acc := NewAccumulator(input, dst)
-		acc.SetPrecision(getPrecision(precision, interval))
-
-		if err := input.Start(acc); err != nil {
-			// If the model tells us to remove the plugin we do so without error
-			var fatalErr *internal.FatalError
-			if errors.As(err, &fatalErr) {
-				log.Printf("I! [agent] Failed to start %s, shutting down plugin: %s", input.LogName(), err)
-				continue
-			}
-
+		started, err := a.startInput(dst, input)
+		if err != nil {
 			stopRunningInputs(unit.inputs)
 
-			return nil, fmt.Errorf("starting input %s: %w", input.LogName(), err)
+			return nil, err
 		}
-		if err := input.Probe(); err != nil {
-			// Probe failures are non-fatal to the agent but should only remove the plugin
-			log.Printf("I! [agent] Failed to probe %s, shutting down plugin: %s", input.LogName(), err)
-			input.Stop()
-			continue
+		if started {
+			unit.inputs = append(unit.inputs, input)
 		}
-		unit.inputs = append(unit.inputs, input)
 	}
 
 	return unit, nil
 }
 
+// startInput starts the input if it is a service input and probes the input.
+// The function returns false if the input failed to start or to probe and
+// should be removed.
+func (*Agent) startInput(dst chan<- telegraf.Metric, input *models.RunningInput) (bool, error) {
+	// Service input plugins are not normally subject to timestamp
+	// rounding except for when precision is set on the input plugin.
+	//
+	// This only applies to the accumulator passed to Start(), the
+	// Gather() accumulator does apply rounding according to the
+	// precision and interval agent/plugin settings.
+	var interval time.Duration
+	var precision time.Duration
+	if input.Config.Precision != 0 {
+		precision = input.Config.Precision
+	}
+
+	acc := NewAccumulator(input, dst)
+	acc.SetPrecision(getPrecision(precision, interval))
+
+	if err := input.Start(acc); err != nil {
+		// If the model tells us to remove the plugin we do so without error
+		var fatalErr *internal.FatalError
+		if errors.As(err, &fatalErr) {
+			log.Printf("I! [agent] Failed to start %s, shutting down plugin: %s", input.LogName(), err)
+			return false, nil
+		}
+
+		return false, fmt.Errorf("starting input %s: %w", input.LogName(), err)
+	}
+	if err := input.Probe(); err != nil {
+		// Probe failures are non-fatal to the agent but should only remove the plugin
+		log.Printf("I! [agent] Failed to probe %s, shutting down plugin: %s", input.LogName(), err)
+		input.Stop()
+		return false, nil
+	}
+	return true, nil
+}
+
 // runInputs starts and triggers the periodic gather for Inputs.
 //
 // When the context is done the timers are stopped and this function returns
@@ -397,58 +531,76 @@ func (a *Agent) runInputs(
 	startTime time.Time,
 	unit *inputUnit,
 ) {
-	var wg sync.WaitGroup
-	tickers := make([]Ticker, 0, len(unit.inputs))
+	unit.Lock()
+	unit.ctx = ctx
+	unit.loops = make(map[*models.RunningInput]*pluginLoop, len(unit.inputs))
 	for _, input := range unit.inputs {
-		// Overwrite agent interval if this plugin has its own.
-		interval := time.Duration(a.Config.Agent.Interval)
-		if input.Config.Interval != 0 {
-			interval = input.Config.Interval
-		}
+		a.startGatherLoop(unit, startTime, input)
+	}
+	unit.Unlock()
 
-		// Overwrite agent precision if this plugin has its own.
-		precision := time.Duration(a.Config.Agent.Precision)
-		if input.Config.Precision != 0 {
-			precision = input.Config.Precision
-		}
+	<-ctx.Done()
 
-		// Overwrite agent collection_jitter if this plugin has its own.
-		jitter := time.Duration(a.Config.Agent.CollectionJitter)
-		if input.Config.CollectionJitter != 0 {
-			jitter = input.Config.CollectionJitter
-		}
+	unit.Lock()
+	unit.closed = true
+	unit.Unlock()
+	unit.wg.Wait()
 
-		// Overwrite agent collection_offset if this plugin has its own.
-		offset := time.Duration(a.Config.Agent.CollectionOffset)
-		if input.Config.CollectionOffset != 0 {
-			offset = input.Config.CollectionOffset
-		}
+	log.Printf("D! [agent] Stopping service inputs")
+	stopRunningInputs(unit.inputs)
 
-		var ticker Ticker
-		if a.Config.Agent.RoundInterval {
-			ticker = NewAlignedTicker(startTime, interval, jitter, offset)
-		} else {
-			ticker = NewUnalignedTicker(interval, jitter, offset)
-		}
-		tickers = append(tickers, ticker)
+	close(unit.dst)
+	log.Printf("D! [agent] Input channel closed")
+}
 
-		acc := NewAccumulator(input, unit.dst)
-		acc.SetPrecision(getPrecision(precision, interval))
+// startGatherLoop starts the periodic gather of the input. The caller must
+// hold the lock of the unit.
+func (a *Agent) startGatherLoop(unit *inputUnit, startTime time.Time, input *models.RunningInput) {
+	// Overwrite agent interval if this plugin has its own.
+	interval := time.Duration(a.Config.Agent.Interval)
+	if input.Config.Interval != 0 {
+		interval = input.Config.Interval
+	}
 
-		wg.Add(1)
-		go func(input *models.RunningInput) {
-			defer wg.Done()
-			a.gatherLoop(ctx, acc, input, ticker, interval)
-		}(input)
+	// Overwrite agent precision if this plugin has its own.
+	precision := time.Duration(a.Config.Agent.Precision)
+	if input.Config.Precision != 0 {
+		precision = input.Config.Precision
 	}
-	defer stopTickers(tickers)
-	wg.Wait()
 
-	log.Printf("D! [agent] Stopping service inputs")
-	stopRunningInputs(unit.inputs)
+	// Overwrite agent collection_jitter if this plugin has its own.
+	jitter := time.Duration(a.Config.Agent.CollectionJitter)
+	if input.Config.CollectionJitter != 0 {
+		jitter = input.Config.CollectionJitter
+	}
 
-	close(unit.dst)
-	log.Printf("D! [agent] Input channel closed")
+	// Overwrite agent collection_offset if this plugin has its own.
+	offset := time.Duration(a.Config.Agent.CollectionOffset)
+	if input.Config.CollectionOffset != 0 {
+		offset = input.Config.CollectionOffset
+	}
+
+	var ticker Ticker
+	if a.Config.Agent.RoundInterval {
+		ticker = NewAlignedTicker(startTime, interval, jitter, offset)
+	} else {
+		ticker = NewUnalignedTicker(interval, jitter, offset)
+	}
+
+	acc := NewAccumulator(input, unit.dst)
+	acc.SetPrecision(getPrecision(precision, interval))
+
+	ctx, cancel := context.WithCancel(unit.ctx)
+	loop := &pluginLoop{cancel: cancel, done: make(chan struct{})}
+	unit.loops[input] = loop
+
+	unit.wg.Add(1)
+	go func() {
+		defer unit.wg.Done()
+		defer close(loop.done)
+		defer ticker.Stop()
+		a.gatherLoop(ctx, acc, input, ticker, interval)
+	}()
 }
 
 // testStartInputs is a variation of startInputs for use in --test and --once mode.
@@ -572,6 +724,9 @@ func (a *Agent) gatherLoop(
 	for {
 		select {
 		case <-ticker.Elapsed():
+			if input.Paused() {
+				continue
+			}
 			err := a.gatherOnce(acc, input, ticker, interval)
 			if err != nil {
 				acc.AddError(err)
@@ -692,7 +847,10 @@ func (a *Agent) runAggregators(
 
 	// Before calling Add, initialize the aggregation window.  This ensures
 	// that any metric created after start time will be aggregated.
-	for _, agg := range a.Config.Aggregators {
+	for _, agg := range unit.aggregators {
+		if unit.resumed[agg] {
+			continue
+		}
 		since, until := updateWindow(startTime, a.Config.Agent.RoundInterval, agg.Period())
 		agg.UpdateWindow(since, until)
 	}
@@ -703,7 +861,7 @@ func (a *Agent) runAggregators(
 		defer wg.Done()
 		for metric := range unit.src {
 			var dropOriginal bool
-			for _, agg := range a.Config.Aggregators {
+			for _, agg := range unit.aggregators {
 				if ok := agg.Add(metric); ok {
 					dropOriginal = true
 				}
@@ -718,7 +876,7 @@ func (a *Agent) runAggregators(
 		cancel()
 	}()
 
-	for _, agg := range a.Config.Aggregators {
+	for _, agg := range unit.aggregators {
 		wg.Add(1)
 		go func(agg *models.RunningAggregator) {
 			defer wg.Done()
@@ -729,6 +887,12 @@ func (a *Agent) runAggregators(
 			acc := NewAccumulator(agg, unit.aggC)
 			acc.SetPrecision(getPrecision(precision, interval))
 			a.push(ctx, agg, acc)
+
+			// Push the remaining metrics unless the aggregator continues
+			// in the next processing chain
+			if !unit.keep[agg] {
+				agg.Push(acc)
+			}
 		}(agg)
 	}
 
@@ -769,7 +933,6 @@ func (*Agent) push(ctx context.Context, aggregator *models.RunningAggregator, ac
 		case <-time.After(until):
 			aggregator.Push(acc)
 		case <-ctx.Done():
-			aggregator.Push(acc)
 			return
 		}
 	}
@@ -782,7 +945,7 @@ func (a *Agent) startOutputs(
 	outputs []*models.RunningOutput,
 ) (chan<- telegraf.Metric, *outputUnit, error) {
 	src := make(chan telegraf.Metric, 100)
-	unit := &outputUnit{src: src}
+	unit := &outputUnit{src: src, groups: a.Config.OutputGroups}
 	for _, output := range outputs {
 		if err := a.connectOutput(ctx, output); err != nil {
 			var fatalErr *internal.FatalError
@@ -801,6 +964,7 @@ func (a *Agent) startOutputs(
 
 		unit.outputs = append(unit.outputs, output)
 	}
+	unit.updateRoutes()
 
 	return src, unit, nil
 }
@@ -829,56 +993,92 @@ func (*Agent) connectOutput(ctx context.Context, output *models.RunningOutput) e
 func (a *Agent) runOutputs(
 	unit *outputUnit,
 ) {
-	var wg sync.WaitGroup
-
-	// Start flush loop
-	interval := time.Duration(a.Config.Agent.FlushInterval)
-	jitter := time.Duration(a.Config.Agent.FlushJitter)
-
 	ctx, cancel := context.WithCancel(context.Background())
 
+	// Start flush loop
+	unit.Lock()
+	unit.ctx = ctx
+	unit.loops = make(map[*models.RunningOutput]*pluginLoop, len(unit.outputs))
 	for _, output := range unit.outputs {
-		interval := interval
-		// Overwrite agent flush_interval if this plugin has its own.
-		if output.Config.FlushInterval != 0 {
-			interval = output.Config.FlushInterval
-		}
-
-		jitter := jitter
-		// Overwrite agent flush_jitter if this plugin has its own.
-		if output.Config.FlushJitter != 0 {
-			jitter = output.Config.FlushJitter
-		}
-
-		wg.Add(1)
-		go func(output *models.RunningOutput) {
-			defer wg.Done()
-
-			ticker := NewRollingTicker(interval, jitter)
-			defer ticker.Stop()
-
-			a.flushLoop(ctx, output, ticker)
-		}(output)
+		a.startFlushLoop(unit, output)
 	}
+	unit.Unlock()
 
 	for metric := range unit.src {
-		for i, output := range unit.outputs {
-			if i == len(unit.outputs)-1 {
-				output.AddMetricNoCopy(metric)
+		unit.RLock()
+		for i, route := range unit.routes {
+			if i == len(unit.routes)-1 {
+				route.AddMetricNoCopy(metric)
 			} else {
-				output.AddMetric(metric)
+				route.AddMetric(metric)
 			}
 		}
+		unit.RUnlock()
 	}
 
 	log.Println("I! [agent] Hang on, flushing any cached metrics before shutdown")
+	unit.Lock()
+	unit.closed = true
+	loops := maps.Clone(unit.loops)
+	unit.Unlock()
+	stopFlushLoops(loops)
 	cancel()
-	wg.Wait()
+	unit.wg.Wait()
 
 	log.Println("I! [agent] Stopping running outputs")
 	stopRunningOutputs(unit.outputs)
 }
 
+// startFlushLoop starts the periodic flush of the output. The caller must
+// hold the lock of the unit.
+func (a *Agent) startFlushLoop(unit *outputUnit, output *models.RunningOutput) {
+	// Overwrite agent flush_interval if this plugin has its own.
+	interval := time.Duration(a.Config.Agent.FlushInterval)
+	if output.Config.FlushInterval != 0 {
+		interval = output.Config.FlushInterval
+	}
+
+	// Overwrite agent flush_jitter if this plugin has its own.
+	jitter := time.Duration(a.Config.Agent.FlushJitter)
+	if output.Config.FlushJitter != 0 {
+		jitter = output.Config.FlushJitter
+	}
+
+	ctx, cancel := context.WithCancel(unit.ctx)
+	loop := &pluginLoop{cancel: cancel, done: make(chan struct{})}
+	unit.loops[output] = loop
+
+	unit.wg.Add(1)
+	go func() {
+		defer unit.wg.Done()
+		defer close(loop.done)
+
+		ticker := NewRollingTicker(interval, jitter)
+		defer ticker.Stop()
+
+		a.flushLoop(ctx, output, ticker)
+	}()
+}
+
+// stopFlushLoops stops the given flush loops and waits for the final write of
+// the outputs. Outputs forwarding metrics to a dead-letter output are stopped
+// first so the metrics rejected or expired during their final write are still
+// written by the dead-letter output.
+func stopFlushLoops(loops map[*models.RunningOutput]*pluginLoop) {
+	for _, sources := range []bool{true, false} {
+		for output, loop := range loops {
+			if (output.Config.DeadLetterOutput != "") == sources {
+				loop.cancel()
+			}
+		}
+		for output, loop := range loops {
+			if (output.Config.DeadLetterOutput != "") == sources {
+				<-loop.done
+			}
+		}
+	}
+}
+
 // flushLoop runs an output's flush function periodically until the context is
 // done.
 func (a *Agent) flushLoop(
@@ -901,19 +1101,21 @@ func (a *Agent) flushLoop(
 		// Favor shutdown over other methods.
 		select {
 		case <-ctx.Done():
-			logError(a.flushOnce(output, ticker, output.Write))
+			logError(a.flushOnce(output, ticker, output.WriteFinal))
 			return
 		default:
 		}
 
 		select {
 		case <-ctx.Done():
-			logError(a.flushOnce(output, ticker, output.Write))
+			logError(a.flushOnce(output, ticker, output.WriteFinal))
 			return
 		case <-ticker.Elapsed():
 			logError(a.flushOnce(output, ticker, output.Write))
 		case <-flushRequested:
 			logError(a.flushOnce(output, ticker, output.Write))
+		case <-output.FlushRequested:
+			logError(a.flushOnce(output, ticker, output.Write))
 		case <-output.BatchReady:
 			logError(a.flushBatch(output, output.WriteBatch))
 		}
@@ -1207,9 +1409,3 @@ func panicRecover(input *models.RunningInput) {
 			"https://github.com/influxdata/telegraf/issues/new/choose")
 	}
 }
-
-func stopTickers(tickers []Ticker) {
-	for _, ticker := range tickers {
-		ticker.Stop()
-	}
-}
@@ -14,6 +14,7 @@ import (
 
 	"github.com/influxdata/telegraf"
 	"github.com/influxdata/telegraf/config"
+	"github.com/influxdata/telegraf/internal"
 	"github.com/influxdata/telegraf/models"
 	_ "github.com/influxdata/telegraf/plugins/aggregators/all"
 	_ "github.com/influxdata/telegraf/plugins/inputs/all"
@@ -177,6 +178,55 @@ func TestWindow(t *testing.T) {
 	}
 }
 
+// rejectingOutput rejects all metrics after a delay giving other outputs the
+// chance to finish first
+type rejectingOutput struct{}
+
+func (*rejectingOutput) SampleConfig() string { return "" }
+func (*rejectingOutput) Connect() error       { return nil }
+func (*rejectingOutput) Close() error         { return nil }
+
+func (*rejectingOutput) Write(metrics []telegraf.Metric) error {
+	time.Sleep(100 * time.Millisecond)
+	werr := &internal.PartialWriteError{Err: internal.ErrSerialization}
+	for i := range metrics {
+		werr.MetricsReject = append(werr.MetricsReject, i)
+	}
+	return werr
+}
+
+func TestFinalWriteDeadLetter(t *testing.T) {
+	cfg := config.NewConfig()
+	cfg.Agent.FlushInterval = config.Duration(time.Hour)
+
+	target := &reloadOutput{}
+	outputs := []*models.RunningOutput{
+		models.NewRunningOutput(target, &models.OutputConfig{Name: "target", Alias: "dlq", ID: "output-1"}, 10, 100),
+		models.NewRunningOutput(&rejectingOutput{}, &models.OutputConfig{Name: "source", ID: "output-2", DeadLetterOutput: "dlq"}, 10, 100),
+	}
+	for _, output := range outputs {
+		require.NoError(t, output.Init())
+	}
+
+	a := NewAgent(cfg)
+	src, unit, err := a.startOutputs(t.Context(), outputs)
+	require.NoError(t, err)
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		a.runOutputs(unit)
+	}()
+	src <- testutil.MustMetric("rejected", map[string]string{}, map[string]interface{}{"value": 1}, time.Unix(0, 0))
+	close(src)
+	<-done
+
+	// The metric rejected during the final write of the source must still be
+	// written by the dead-letter output before it is closed
+	require.True(t, target.has("rejected"))
+	require.True(t, target.closed.Load())
+}
+
 func TestCases(t *testing.T) {
 	// Get all directories in testcases
 	folders, err := os.ReadDir("testcases")
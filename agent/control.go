@@ -0,0 +1,285 @@
+package agent
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/models"
+)
+
+// controlPluginInfo describes a running plugin in the control API
+type controlPluginInfo struct {
+	Type   string `json:"type"`
+	Name   string `json:"name"`
+	Alias  string `json:"alias,omitempty"`
+	ID     string `json:"id"`
+	Paused *bool  `json:"paused,omitempty"`
+}
+
+// controlOutputInfo describes the buffer state of an output in the control API
+type controlOutputInfo struct {
+	Name         string             `json:"name"`
+	Alias        string             `json:"alias,omitempty"`
+	ID           string             `json:"id"`
+	BufferLength int                `json:"buffer_length"`
+	BufferStats  controlBufferStats `json:"buffer_stats"`
+}
+
+type controlBufferStats struct {
+	MetricsAdded    int64 `json:"metrics_added"`
+	MetricsWritten  int64 `json:"metrics_written"`
+	MetricsRejected int64 `json:"metrics_rejected"`
+	MetricsDropped  int64 `json:"metrics_dropped"`
+	BufferSize      int64 `json:"buffer_size"`
+	BufferLimit     int64 `json:"buffer_limit"`
+}
+
+// startControlAPI starts the control API server listening on the configured
+// address.
+func (a *Agent) startControlAPI() (*http.Server, error) {
+	if a.Config.Agent.ControlAPIToken.Empty() {
+		return nil, errors.New("control API requires 'control_api_token' to be set")
+	}
+
+	listener, err := net.Listen("tcp", a.Config.Agent.ControlAPIAddress)
+	if err != nil {
+		return nil, fmt.Errorf("starting control API failed: %w", err)
+	}
+
+	srv := &http.Server{
+		Handler:           a.controlHandler(),
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+	go func() {
+		if err := srv.Serve(listener); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			log.Printf("E! [agent] Control API failed: %v", err)
+		}
+	}()
+	log.Printf("I! [agent] Control API listening on %s", listener.Addr())
+
+	return srv, nil
+}
+
+// stopControlAPI shuts down the control API server
+func stopControlAPI(srv *http.Server) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := srv.Shutdown(ctx); err != nil {
+		log.Printf("E! [agent] Stopping control API failed: %v", err)
+	}
+}
+
+// controlHandler returns the handler for the control API endpoints
+func (a *Agent) controlHandler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /api/v1/plugins", a.handleListPlugins)
+	mux.HandleFunc("GET /api/v1/outputs", a.handleListOutputs)
+	mux.HandleFunc("POST /api/v1/outputs/{id}/flush", a.handleFlushOutput)
+	mux.HandleFunc("POST /api/v1/inputs/{id}/pause", a.handlePauseInput)
+	mux.HandleFunc("POST /api/v1/inputs/{id}/resume", a.handleResumeInput)
+	mux.HandleFunc("POST /api/v1/reload", a.handleReload)
+
+	return a.authenticate(mux)
+}
+
+// authenticate checks the bearer token of all requests
+func (a *Agent) authenticate(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		provided, found := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if !found {
+			writeControlError(w, http.StatusUnauthorized, "missing bearer token")
+			return
+		}
+
+		valid, err := a.Config.Agent.ControlAPIToken.EqualTo([]byte(provided))
+		if err != nil {
+			log.Printf("E! [agent] Checking control API token failed: %v", err)
+			writeControlError(w, http.StatusInternalServerError, "checking token failed")
+			return
+		}
+		if !valid {
+			writeControlError(w, http.StatusUnauthorized, "invalid bearer token")
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+func (a *Agent) handleListPlugins(w http.ResponseWriter, _ *http.Request) {
+	a.pluginsLock.RLock()
+	defer a.pluginsLock.RUnlock()
+
+	plugins := make([]controlPluginInfo, 0)
+	for _, input := range a.Config.Inputs {
+		paused := input.Paused()
+		plugins = append(plugins, controlPluginInfo{
+			Type:   "inputs",
+			Name:   input.Config.Name,
+			Alias:  input.Config.Alias,
+			ID:     input.ID(),
+			Paused: &paused,
+		})
+	}
+	for _, processor := range a.Config.Processors {
+		plugins = append(plugins, controlPluginInfo{
+			Type:  "processors",
+			Name:  processor.Config.Name,
+			Alias: processor.Config.Alias,
+			ID:    processor.ID(),
+		})
+	}
+	for _, aggregator := range a.Config.Aggregators {
+		plugins = append(plugins, controlPluginInfo{
+			Type:  "aggregators",
+			Name:  aggregator.Config.Name,
+			Alias: aggregator.Config.Alias,
+			ID:    aggregator.ID(),
+		})
+	}
+	for _, output := range a.Config.Outputs {
+		plugins = append(plugins, controlPluginInfo{
+			Type:  "outputs",
+			Name:  output.Config.Name,
+			Alias: output.Config.Alias,
+			ID:    output.ID(),
+		})
+	}
+
+	writeControlResponse(w, http.StatusOK, plugins)
+}
+
+func (a *Agent) handleListOutputs(w http.ResponseWriter, _ *http.Request) {
+	a.pluginsLock.RLock()
+	defer a.pluginsLock.RUnlock()
+
+	outputs := make([]controlOutputInfo, 0, len(a.Config.Outputs))
+	for _, output := range a.Config.Outputs {
+		stats := output.BufferStats()
+		outputs = append(outputs, controlOutputInfo{
+			Name:         output.Config.Name,
+			Alias:        output.Config.Alias,
+			ID:           output.ID(),
+			BufferLength: output.BufferLength(),
+			BufferStats: controlBufferStats{
+				MetricsAdded:    stats.MetricsAdded.Get(),
+				MetricsWritten:  stats.MetricsWritten.Get(),
+				MetricsRejected: stats.MetricsRejected.Get(),
+				MetricsDropped:  stats.MetricsDropped.Get(),
+				BufferSize:      stats.BufferSize.Get(),
+				BufferLimit:     stats.BufferLimit.Get(),
+			},
+		})
+	}
+
+	writeControlResponse(w, http.StatusOK, outputs)
+}
+
+func (a *Agent) handleFlushOutput(w http.ResponseWriter, r *http.Request) {
+	outputs := a.findOutputs(r.PathValue("id"))
+	if len(outputs) == 0 {
+		writeControlError(w, http.StatusNotFound, "output not found")
+		return
+	}
+
+	for _, output := range outputs {
+		output.RequestFlush()
+	}
+	writeControlResponse(w, http.StatusAccepted, map[string]string{"status": "flush requested"})
+}
+
+func (a *Agent) handlePauseInput(w http.ResponseWriter, r *http.Request) {
+	a.setInputPaused(w, r.PathValue("id"), true)
+}
+
+func (a *Agent) handleResumeInput(w http.ResponseWriter, r *http.Request) {
+	a.setInputPaused(w, r.PathValue("id"), false)
+}
+
+func (a *Agent) setInputPaused(w http.ResponseWriter, id string, paused bool) {
+	inputs := a.findInputs(id)
+	if len(inputs) == 0 {
+		writeControlError(w, http.StatusNotFound, "input not found")
+		return
+	}
+
+	// Service inputs push metrics on their own so we cannot pause them by
+	// skipping the gather cycles.
+	for _, input := range inputs {
+		if _, ok := input.Input.(telegraf.ServiceInput); ok {
+			writeControlError(w, http.StatusConflict, "pausing service inputs is not supported")
+			return
+		}
+	}
+
+	status := "resumed"
+	for _, input := range inputs {
+		if paused {
+			input.Pause()
+			status = "paused"
+		} else {
+			input.Resume()
+		}
+		log.Printf("I! [agent] Input %s %s via control API", input.LogName(), status)
+	}
+	writeControlResponse(w, http.StatusOK, map[string]string{"status": status})
+}
+
+func (a *Agent) handleReload(w http.ResponseWriter, _ *http.Request) {
+	if a.ReloadFunc == nil {
+		writeControlError(w, http.StatusNotImplemented, "reloading is not supported")
+		return
+	}
+
+	log.Printf("I! [agent] Reload requested via control API")
+	a.ReloadFunc()
+	writeControlResponse(w, http.StatusAccepted, map[string]string{"status": "reload requested"})
+}
+
+// findInputs returns all inputs with the given ID
+func (a *Agent) findInputs(id string) []*models.RunningInput {
+	a.pluginsLock.RLock()
+	defer a.pluginsLock.RUnlock()
+
+	var inputs []*models.RunningInput
+	for _, input := range a.Config.Inputs {
+		if input.ID() == id {
+			inputs = append(inputs, input)
+		}
+	}
+	return inputs
+}
+
+// findOutputs returns all outputs with the given ID
+func (a *Agent) findOutputs(id string) []*models.RunningOutput {
+	a.pluginsLock.RLock()
+	defer a.pluginsLock.RUnlock()
+
+	var outputs []*models.RunningOutput
+	for _, output := range a.Config.Outputs {
+		if output.ID() == id {
+			outputs = append(outputs, output)
+		}
+	}
+	return outputs
+}
+
+func writeControlResponse(w http.ResponseWriter, code int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		log.Printf("E! [agent] Writing control API response failed: %v", err)
+	}
+}
+
+func writeControlError(w http.ResponseWriter, code int, msg string) {
+	writeControlResponse(w, code, map[string]string{"error": msg})
+}
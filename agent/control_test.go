@@ -0,0 +1,144 @@
+package agent
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/config"
+	"github.com/influxdata/telegraf/metric"
+	"github.com/influxdata/telegraf/models"
+)
+
+type controlInput struct{}
+
+func (*controlInput) SampleConfig() string                { return "" }
+func (*controlInput) Gather(_ telegraf.Accumulator) error { return nil }
+
+type controlServiceInput struct {
+	controlInput
+}
+
+func (*controlServiceInput) Start(_ telegraf.Accumulator) error { return nil }
+func (*controlServiceInput) Stop()                              {}
+
+type controlOutput struct{}
+
+func (*controlOutput) SampleConfig() string            { return "" }
+func (*controlOutput) Connect() error                  { return nil }
+func (*controlOutput) Close() error                    { return nil }
+func (*controlOutput) Write(_ []telegraf.Metric) error { return nil }
+
+func newControlTestAgent(t *testing.T) *Agent {
+	t.Helper()
+
+	c := config.NewConfig()
+	c.Agent.ControlAPIToken = config.NewSecret([]byte("secret"))
+	c.Inputs = append(c.Inputs,
+		models.NewRunningInput(&controlInput{}, &models.InputConfig{Name: "polled", ID: "input-1"}),
+		models.NewRunningInput(&controlServiceInput{}, &models.InputConfig{Name: "service", ID: "input-2"}),
+	)
+	c.Outputs = append(c.Outputs,
+		models.NewRunningOutput(&controlOutput{}, &models.OutputConfig{Name: "out", ID: "output-1"}, 10, 100),
+	)
+	return NewAgent(c)
+}
+
+func controlRequest(t *testing.T, h http.Handler, method, path, token string) *httptest.ResponseRecorder {
+	t.Helper()
+
+	req := httptest.NewRequest(method, path, nil)
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestControlAPIAuthentication(t *testing.T) {
+	h := newControlTestAgent(t).controlHandler()
+
+	require.Equal(t, http.StatusUnauthorized, controlRequest(t, h, "GET", "/api/v1/plugins", "").Code)
+	require.Equal(t, http.StatusUnauthorized, controlRequest(t, h, "GET", "/api/v1/plugins", "wrong").Code)
+	require.Equal(t, http.StatusOK, controlRequest(t, h, "GET", "/api/v1/plugins", "secret").Code)
+}
+
+func TestControlAPIListPlugins(t *testing.T) {
+	h := newControlTestAgent(t).controlHandler()
+
+	rec := controlRequest(t, h, "GET", "/api/v1/plugins", "secret")
+	require.Equal(t, http.StatusOK, rec.Code)
+
+	var plugins []controlPluginInfo
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &plugins))
+	require.Len(t, plugins, 3)
+	require.Equal(t, "inputs", plugins[0].Type)
+	require.Equal(t, "input-1", plugins[0].ID)
+	require.NotNil(t, plugins[0].Paused)
+	require.False(t, *plugins[0].Paused)
+	require.Equal(t, "outputs", plugins[2].Type)
+	require.Equal(t, "output-1", plugins[2].ID)
+}
+
+func TestControlAPIListOutputs(t *testing.T) {
+	a := newControlTestAgent(t)
+	h := a.controlHandler()
+
+	m := metric.New("cpu", map[string]string{}, map[string]interface{}{"value": 42}, time.Unix(0, 0))
+	a.Config.Outputs[0].AddMetric(m)
+
+	rec := controlRequest(t, h, "GET", "/api/v1/outputs", "secret")
+	require.Equal(t, http.StatusOK, rec.Code)
+
+	var outputs []controlOutputInfo
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &outputs))
+	require.Len(t, outputs, 1)
+	require.Equal(t, "output-1", outputs[0].ID)
+	require.Equal(t, 1, outputs[0].BufferLength)
+	require.Equal(t, int64(100), outputs[0].BufferStats.BufferLimit)
+}
+
+func TestControlAPIFlushOutput(t *testing.T) {
+	a := newControlTestAgent(t)
+	h := a.controlHandler()
+
+	require.Equal(t, http.StatusNotFound, controlRequest(t, h, "POST", "/api/v1/outputs/unknown/flush", "secret").Code)
+	require.Equal(t, http.StatusAccepted, controlRequest(t, h, "POST", "/api/v1/outputs/output-1/flush", "secret").Code)
+	require.Len(t, a.Config.Outputs[0].FlushRequested, 1)
+
+	// Requests must be coalesced while a flush is pending
+	require.Equal(t, http.StatusAccepted, controlRequest(t, h, "POST", "/api/v1/outputs/output-1/flush", "secret").Code)
+	require.Len(t, a.Config.Outputs[0].FlushRequested, 1)
+}
+
+func TestControlAPIPauseResumeInput(t *testing.T) {
+	a := newControlTestAgent(t)
+	h := a.controlHandler()
+
+	require.Equal(t, http.StatusOK, controlRequest(t, h, "POST", "/api/v1/inputs/input-1/pause", "secret").Code)
+	require.True(t, a.Config.Inputs[0].Paused())
+	require.Equal(t, http.StatusOK, controlRequest(t, h, "POST", "/api/v1/inputs/input-1/resume", "secret").Code)
+	require.False(t, a.Config.Inputs[0].Paused())
+
+	require.Equal(t, http.StatusConflict, controlRequest(t, h, "POST", "/api/v1/inputs/input-2/pause", "secret").Code)
+	require.False(t, a.Config.Inputs[1].Paused())
+	require.Equal(t, http.StatusNotFound, controlRequest(t, h, "POST", "/api/v1/inputs/unknown/pause", "secret").Code)
+}
+
+func TestControlAPIReload(t *testing.T) {
+	a := newControlTestAgent(t)
+	h := a.controlHandler()
+
+	require.Equal(t, http.StatusNotImplemented, controlRequest(t, h, "POST", "/api/v1/reload", "secret").Code)
+
+	var reloaded bool
+	a.ReloadFunc = func() { reloaded = true }
+	require.Equal(t, http.StatusAccepted, controlRequest(t, h, "POST", "/api/v1/reload", "secret").Code)
+	require.True(t, reloaded)
+}
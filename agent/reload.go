@@ -0,0 +1,676 @@
+package agent
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/config"
+	"github.com/influxdata/telegraf/internal"
+	"github.com/influxdata/telegraf/models"
+	"github.com/influxdata/telegraf/persister"
+)
+
+var errAgentStopping = errors.New("agent is stopping")
+
+// pipeline contains the units of a running agent.
+type pipeline struct {
+	ctx     context.Context
+	inputs  *inputUnit
+	chain   *chainUnit
+	outputs *outputUnit
+}
+
+// chainUnit is the chain of processors and aggregators between the inputs and
+// the outputs. The chain is decoupled from the input and output channels, so
+// it can be rebuilt on reload while inputs and outputs keep running.
+// Processors and aggregators being part of both the old and the new chain
+// keep running across rebuilds including their state.
+//
+//	 ______     ┌────────────┐     ┌─────────────┐     ┌────────────┐     ______
+//	()_____)──▶ │ Processors │──▶ │ Aggregators │──▶ │ Processors │──▶ ()_____)
+//	            └────────────┘     └─────────────┘     └────────────┘
+type chainUnit struct {
+	src <-chan telegraf.Metric
+	dst chan<- telegraf.Metric
+
+	processors    models.RunningProcessors
+	aggProcessors models.RunningProcessors
+	aggregators   []*models.RunningAggregator
+
+	// Channels receiving the metrics of the started processors emitted
+	// outside of Add, e.g. by background tasks of streaming processors. The
+	// channels are kept across rebuilds as the processors keep running.
+	started map[*models.RunningProcessor]chan telegraf.Metric
+
+	current *chain
+	rebuild chan *chainRebuild
+	done    chan struct{}
+}
+
+// chain is a single instance of the processing chain.
+type chain struct {
+	head chan<- telegraf.Metric
+	tail <-chan telegraf.Metric
+	apu  []*chainProcessor
+	au   *aggregatorUnit
+	pu   []*chainProcessor
+
+	// Processors kept running for the next chain instance, must be set
+	// before closing the head channel
+	keep map[*models.RunningProcessor]bool
+}
+
+// chainProcessor is a processor of a chain instance.
+type chainProcessor struct {
+	src       <-chan telegraf.Metric
+	dst       chan<- telegraf.Metric
+	emitted   <-chan telegraf.Metric
+	processor *models.RunningProcessor
+}
+
+// chainRebuild requests rebuilding the chain with the given plugins.
+type chainRebuild struct {
+	processors    models.RunningProcessors
+	aggProcessors models.RunningProcessors
+	aggregators   []*models.RunningAggregator
+	err           chan error
+}
+
+// startChain sets up the processing chain and calls Start on all processors.
+func (a *Agent) startChain(
+	src <-chan telegraf.Metric,
+	dst chan<- telegraf.Metric,
+	processors, aggProcessors models.RunningProcessors,
+	aggregators []*models.RunningAggregator,
+) (*chainUnit, error) {
+	unit := &chainUnit{
+		src:           src,
+		dst:           dst,
+		processors:    processors,
+		aggProcessors: aggProcessors,
+		aggregators:   aggregators,
+		started:       make(map[*models.RunningProcessor]chan telegraf.Metric),
+		rebuild:       make(chan *chainRebuild),
+		done:          make(chan struct{}),
+	}
+
+	c, err := a.buildChain(unit, processors, aggProcessors, aggregators, nil)
+	if err != nil {
+		return nil, err
+	}
+	unit.current = c
+
+	return unit, nil
+}
+
+// buildChain creates a new instance of the processing chain. Only processors
+// not already running are started and aggregators of the previous chain
+// instance keep their aggregation window. If an error occurs all processors
+// started by this call are stopped.
+func (a *Agent) buildChain(
+	unit *chainUnit,
+	processors, aggProcessors models.RunningProcessors,
+	aggregators []*models.RunningAggregator,
+	previous *chain,
+) (*chain, error) {
+	tail := make(chan telegraf.Metric, 100)
+	c := &chain{tail: tail}
+
+	running := make(map[*models.RunningProcessor]bool, len(unit.started))
+	for p := range unit.started {
+		running[p] = true
+	}
+	stopStarted := func() {
+		for p := range unit.started {
+			if !running[p] {
+				unit.stopProcessor(p)
+			}
+		}
+	}
+
+	var err error
+	next := chan<- telegraf.Metric(tail)
+	if len(aggregators) != 0 {
+		aggC := next
+		if len(aggProcessors) != 0 && !*a.Config.Agent.SkipProcessorsAfterAggregators {
+			aggC, c.apu, err = unit.startProcessors(next, aggProcessors)
+			if err != nil {
+				stopStarted()
+				return nil, err
+			}
+		}
+
+		next, c.au = a.startAggregators(aggC, next, aggregators)
+		if previous != nil && previous.au != nil {
+			c.au.resumed = make(map[*models.RunningAggregator]bool, len(aggregators))
+			for _, agg := range aggregators {
+				c.au.resumed[agg] = slices.Contains(previous.au.aggregators, agg)
+			}
+		}
+	}
+
+	if len(processors) != 0 {
+		next, c.pu, err = unit.startProcessors(next, processors)
+		if err != nil {
+			stopStarted()
+			return nil, err
+		}
+	}
+	c.head = next
+
+	return c, nil
+}
+
+// startProcessors sets up the processors of a chain instance and calls Start
+// on the processors not already running.
+func (unit *chainUnit) startProcessors(
+	dst chan<- telegraf.Metric,
+	processors models.RunningProcessors,
+) (chan<- telegraf.Metric, []*chainProcessor, error) {
+	var src chan telegraf.Metric
+	units := make([]*chainProcessor, 0, len(processors))
+	// Construct the chain from the output side, see Agent.startProcessors
+	for i := len(processors) - 1; i >= 0; i-- {
+		processor := processors[i]
+
+		emitted, found := unit.started[processor]
+		if !found {
+			emitted = make(chan telegraf.Metric, 100)
+			if err := processor.Start(NewAccumulator(processor, emitted)); err != nil {
+				return nil, nil, fmt.Errorf("starting processor %s: %w", processor.LogName(), err)
+			}
+			unit.started[processor] = emitted
+		}
+
+		src = make(chan telegraf.Metric, 100)
+		units = append(units, &chainProcessor{
+			src:       src,
+			dst:       dst,
+			emitted:   emitted,
+			processor: processor,
+		})
+		dst = src
+	}
+
+	return src, units, nil
+}
+
+// stopProcessor stops a processor not being part of a running chain instance
+// passing the metrics emitted when stopping to the destination of the unit.
+func (unit *chainUnit) stopProcessor(processor *models.RunningProcessor) {
+	emitted := unit.started[processor]
+	delete(unit.started, processor)
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for m := range emitted {
+			unit.dst <- m
+		}
+	}()
+	processor.Stop()
+	close(emitted)
+	<-done
+}
+
+// runChain runs the chain instance until its head channel is closed and all
+// metrics passed the chain.
+func (a *Agent) runChain(startTime time.Time, c *chain) {
+	var wg sync.WaitGroup
+	if c.au != nil {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			c.runProcessors(c.apu)
+		}()
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			a.runAggregators(startTime, c.au)
+		}()
+	}
+
+	if c.pu != nil {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			c.runProcessors(c.pu)
+		}()
+	}
+	wg.Wait()
+}
+
+// runProcessors processes the metrics until the source channels are closed.
+// Processors not kept for the next chain instance are stopped.
+func (c *chain) runProcessors(units []*chainProcessor) {
+	var wg sync.WaitGroup
+	for _, unit := range units {
+		processed := make(chan struct{})
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			defer close(processed)
+
+			acc := NewAccumulator(unit.processor, unit.dst)
+			for m := range unit.src {
+				if err := unit.processor.Add(m, acc); err != nil {
+					acc.AddError(err)
+					m.Drop()
+				}
+			}
+			if !c.keep[unit.processor] {
+				unit.processor.Stop()
+			}
+		}()
+
+		// Forward the metrics emitted outside of Add until the processor is
+		// done, remaining metrics of kept processors are forwarded by the
+		// next chain instance
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				select {
+				case m := <-unit.emitted:
+					unit.dst <- m
+				case <-processed:
+					for {
+						select {
+						case m := <-unit.emitted:
+							unit.dst <- m
+						default:
+							close(unit.dst)
+							log.Printf("D! [agent] Processor channel closed")
+							return
+						}
+					}
+				}
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+// runChainUnit passes the metrics of the source channel through the chain
+// until the source channel is closed. The chain is rebuilt whenever requested.
+func (a *Agent) runChainUnit(startTime time.Time, unit *chainUnit) {
+	defer close(unit.done)
+
+	c := unit.current
+	for {
+		var wg sync.WaitGroup
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			a.runChain(startTime, c)
+		}()
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for m := range c.tail {
+				unit.dst <- m
+			}
+		}()
+
+		// Feed the chain until the inputs are stopped or a rebuild is
+		// requested and wait for all metrics to pass the chain. Plugins
+		// being part of the rebuilt chain keep running.
+		req := unit.feed(c.head)
+		if req != nil {
+			c.keepPlugins(req)
+		}
+		close(c.head)
+		wg.Wait()
+
+		for p, emitted := range unit.started {
+			if !c.keep[p] {
+				delete(unit.started, p)
+				close(emitted)
+			}
+		}
+
+		if req == nil {
+			close(unit.dst)
+			log.Printf("D! [agent] Processing chain closed")
+			return
+		}
+
+		log.Printf("D! [agent] Rebuilding processing chain")
+		startTime = time.Now()
+		next, err := a.buildChain(unit, req.processors, req.aggProcessors, req.aggregators, c)
+		if err == nil {
+			unit.processors = req.processors
+			unit.aggProcessors = req.aggProcessors
+			unit.aggregators = req.aggregators
+		} else {
+			// Fallback to the previous processing chain to keep the
+			// pipeline running until the agent is restarted
+			var ferr error
+			next, ferr = a.buildChain(unit, unit.processors, unit.aggProcessors, unit.aggregators, c)
+			if ferr != nil {
+				log.Printf("E! [agent] Restoring processing chain failed, passing metrics unprocessed: %v", ferr)
+				for p := range unit.started {
+					unit.stopProcessor(p)
+				}
+				next, _ = a.buildChain(unit, nil, nil, nil, nil)
+			}
+		}
+		unit.current = next
+		c = next
+		req.err <- err
+	}
+}
+
+// keepPlugins marks the processors and aggregators of the chain also being
+// part of the requested chain to keep them running.
+func (c *chain) keepPlugins(req *chainRebuild) {
+	c.keep = make(map[*models.RunningProcessor]bool)
+	for _, p := range slices.Concat(req.processors, req.aggProcessors) {
+		c.keep[p] = true
+	}
+	if c.au != nil {
+		c.au.keep = make(map[*models.RunningAggregator]bool, len(req.aggregators))
+		for _, agg := range req.aggregators {
+			c.au.keep[agg] = true
+		}
+	}
+}
+
+// feed passes the metrics of the source channel to the given channel until
+// the source channel is closed or a rebuild is requested.
+func (unit *chainUnit) feed(dst chan<- telegraf.Metric) *chainRebuild {
+	for {
+		select {
+		case m, ok := <-unit.src:
+			if !ok {
+				return nil
+			}
+			dst <- m
+		case req := <-unit.rebuild:
+			return req
+		}
+	}
+}
+
+// restart rebuilds the processing chain with the given plugins. Metrics
+// already passed to the chain are processed by the current chain instance.
+func (unit *chainUnit) restart(
+	processors, aggProcessors models.RunningProcessors,
+	aggregators []*models.RunningAggregator,
+) error {
+	req := &chainRebuild{
+		processors:    processors,
+		aggProcessors: aggProcessors,
+		aggregators:   aggregators,
+		err:           make(chan error, 1),
+	}
+
+	select {
+	case unit.rebuild <- req:
+	case <-unit.done:
+		return errAgentStopping
+	}
+	return <-req.err
+}
+
+// Reload applies the given configuration to the running agent by comparing
+// the plugins of the running and the given configuration using their plugin
+// ID. Only added and removed plugins are started and stopped, unchanged
+// plugins keep running including their state and buffered metrics.
+// An error is returned if the configuration cannot be applied without
+// restarting the agent, e.g. if the agent settings changed. In this case, the
+// added plugins already started are stopped again.
+func (a *Agent) Reload(cfg *config.Config) error {
+	a.reloadLock.Lock()
+	defer a.reloadLock.Unlock()
+
+	if a.running == nil {
+		return errors.New("agent is not running")
+	}
+
+	diff := cfg.Diff(a.Config)
+	if diff.SettingsChanged {
+		return errors.New("agent settings, global tags, secret-stores or output groups changed")
+	}
+	if diff.Empty() {
+		log.Printf("I! [agent] Configuration unchanged, nothing to reload")
+		return nil
+	}
+	cfg.ReuseUnchanged(a.Config)
+
+	log.Printf("D! [agent] Initializing added plugins")
+	if err := a.initPlugins(&diff.Added); err != nil {
+		return err
+	}
+
+	if a.Config.Persister != nil {
+		if err := a.reloadStates(diff); err != nil {
+			return err
+		}
+	}
+
+	// Add the new outputs before exchanging the other plugins to keep
+	// writing metrics to all unchanged outputs.
+	p := a.running
+	if err := a.addOutputs(p.ctx, p.outputs, diff.Added.Outputs); err != nil {
+		return err
+	}
+
+	if diff.ProcessingChanged {
+		if err := p.chain.restart(cfg.Processors, cfg.AggProcessors, cfg.Aggregators); err != nil {
+			a.discardAdded(p, &diff.Added)
+			return fmt.Errorf("rebuilding processing chain failed: %w", err)
+		}
+	}
+
+	a.removeInputs(p.inputs, diff.Removed.Inputs)
+	if err := a.addInputs(p.inputs, diff.Added.Inputs); err != nil {
+		a.discardAdded(p, &diff.Added)
+		return err
+	}
+
+	a.removeOutputs(p.outputs, diff.Removed.Outputs)
+
+	a.pluginsLock.Lock()
+	a.Config.Inputs = cfg.Inputs
+	a.Config.Processors = cfg.Processors
+	a.Config.AggProcessors = cfg.AggProcessors
+	a.Config.Aggregators = cfg.Aggregators
+	a.Config.Outputs = cfg.Outputs
+	a.pluginsLock.Unlock()
+
+	log.Printf("I! [agent] Reloaded configuration: inputs %d added %d removed, processors %d added %d removed, "+
+		"aggregators %d added %d removed, outputs %d added %d removed",
+		len(diff.Added.Inputs), len(diff.Removed.Inputs),
+		len(diff.Added.Processors), len(diff.Removed.Processors),
+		len(diff.Added.Aggregators), len(diff.Removed.Aggregators),
+		len(diff.Added.Outputs), len(diff.Removed.Outputs),
+	)
+
+	return nil
+}
+
+// reloadStates persists the states of the running plugins and restores the
+// states of the added plugins.
+func (a *Agent) reloadStates(diff *config.Diff) error {
+	p := a.Config.Persister
+	if err := p.Store(); err != nil {
+		return fmt.Errorf("persisting plugin states failed: %w", err)
+	}
+
+	err := forEachStatefulPlugin(&diff.Removed, func(_, _, id string, _ telegraf.StatefulPlugin) error {
+		p.Unregister(id)
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	// Only restore the states of the added plugins as the running plugins
+	// already have the current state.
+	states := &persister.Persister{
+		Filename:    p.Filename,
+		BackendType: p.BackendType,
+	}
+	if err := states.Init(); err != nil {
+		return err
+	}
+	if err := registerStatefulPlugins(states, &diff.Added); err != nil {
+		return err
+	}
+	if err := states.Load(); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+
+	return registerStatefulPlugins(p, &diff.Added)
+}
+
+// addInputs starts the given inputs and their gather loops.
+func (a *Agent) addInputs(unit *inputUnit, inputs []*models.RunningInput) error {
+	for _, input := range inputs {
+		log.Printf("D! [agent] Starting input %s", input.LogName())
+		started, err := a.startInput(unit.dst, input)
+		if err != nil {
+			return err
+		}
+		if !started {
+			continue
+		}
+
+		unit.Lock()
+		if unit.closed {
+			unit.Unlock()
+			input.Stop()
+			return errAgentStopping
+		}
+		unit.inputs = append(unit.inputs, input)
+		a.startGatherLoop(unit, time.Now(), input)
+		unit.Unlock()
+	}
+
+	return nil
+}
+
+// removeInputs stops the gather loops of the given inputs and stops the
+// inputs.
+func (*Agent) removeInputs(unit *inputUnit, inputs []*models.RunningInput) {
+	unit.Lock()
+	if unit.closed {
+		// The inputs are stopped on shutdown
+		unit.Unlock()
+		return
+	}
+	loops := make([]*pluginLoop, 0, len(inputs))
+	for _, input := range inputs {
+		unit.inputs = slices.DeleteFunc(unit.inputs, func(i *models.RunningInput) bool { return i == input })
+		if loop, found := unit.loops[input]; found {
+			loop.cancel()
+			loops = append(loops, loop)
+			delete(unit.loops, input)
+		}
+	}
+	unit.Unlock()
+
+	for _, loop := range loops {
+		<-loop.done
+	}
+	for _, input := range inputs {
+		log.Printf("D! [agent] Stopping input %s", input.LogName())
+		input.Stop()
+	}
+}
+
+// discardAdded stops the added plugins already running after a failed reload
+// as those are not part of the agent's configuration.
+func (a *Agent) discardAdded(p *pipeline, added *config.PluginSet) {
+	p.inputs.Lock()
+	inputs := make([]*models.RunningInput, 0, len(added.Inputs))
+	for _, input := range added.Inputs {
+		if slices.Contains(p.inputs.inputs, input) {
+			inputs = append(inputs, input)
+		}
+	}
+	p.inputs.Unlock()
+	a.removeInputs(p.inputs, inputs)
+
+	p.outputs.Lock()
+	outputs := make([]*models.RunningOutput, 0, len(added.Outputs))
+	for _, output := range added.Outputs {
+		if slices.Contains(p.outputs.outputs, output) {
+			outputs = append(outputs, output)
+		}
+	}
+	p.outputs.Unlock()
+	a.removeOutputs(p.outputs, outputs)
+}
+
+// addOutputs connects the given outputs and starts writing metrics to them.
+// If an error occurs, the outputs added by this call are removed again.
+func (a *Agent) addOutputs(ctx context.Context, unit *outputUnit, outputs []*models.RunningOutput) error {
+	added := make([]*models.RunningOutput, 0, len(outputs))
+	for _, output := range outputs {
+		if err := a.connectOutput(ctx, output); err != nil {
+			output.Close()
+
+			var fatalErr *internal.FatalError
+			if errors.As(err, &fatalErr) {
+				// If the model tells us to remove the plugin we do so without error
+				log.Printf("I! [agent] Failed to connect to [%s], error was %q;  shutting down plugin...", output.LogName(), err)
+				continue
+			}
+			a.removeOutputs(unit, added)
+			return fmt.Errorf("connecting output %s: %w", output.LogName(), err)
+		}
+
+		unit.Lock()
+		if unit.closed {
+			unit.Unlock()
+			output.Close()
+			return errAgentStopping
+		}
+		unit.outputs = append(unit.outputs, output)
+		unit.updateRoutes()
+		a.startFlushLoop(unit, output)
+		unit.Unlock()
+		added = append(added, output)
+	}
+
+	return nil
+}
+
+// removeOutputs stops writing metrics to the given outputs, flushes the
+// outputs one last time and closes them.
+func (*Agent) removeOutputs(unit *outputUnit, outputs []*models.RunningOutput) {
+	unit.Lock()
+	if unit.closed {
+		// The outputs are flushed and closed on shutdown
+		unit.Unlock()
+		return
+	}
+	loops := make(map[*models.RunningOutput]*pluginLoop, len(outputs))
+	for _, output := range outputs {
+		unit.outputs = slices.DeleteFunc(unit.outputs, func(o *models.RunningOutput) bool { return o == output })
+		if loop, found := unit.loops[output]; found {
+			loops[output] = loop
+			delete(unit.loops, output)
+		}
+	}
+	unit.updateRoutes()
+	unit.Unlock()
+
+	stopFlushLoops(loops)
+	for _, output := range outputs {
+		log.Printf("D! [agent] Closing output %s", output.LogName())
+		output.Close()
+	}
+}
@@ -0,0 +1,238 @@
+package agent
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/config"
+	"github.com/influxdata/telegraf/models"
+)
+
+type reloadInput struct {
+	gathered atomic.Int64
+}
+
+func (*reloadInput) SampleConfig() string { return "" }
+
+func (i *reloadInput) Gather(acc telegraf.Accumulator) error {
+	i.gathered.Add(1)
+	acc.AddFields("test", map[string]interface{}{"value": 42}, nil)
+	return nil
+}
+
+type reloadOutput struct {
+	fail   bool
+	closed atomic.Bool
+
+	sync.Mutex
+	metrics []telegraf.Metric
+}
+
+func (*reloadOutput) SampleConfig() string { return "" }
+func (*reloadOutput) Connect() error       { return nil }
+
+func (o *reloadOutput) Close() error {
+	o.closed.Store(true)
+	return nil
+}
+
+func (o *reloadOutput) Write(metrics []telegraf.Metric) error {
+	if o.fail {
+		return errors.New("failing on purpose")
+	}
+	o.Lock()
+	o.metrics = append(o.metrics, metrics...)
+	o.Unlock()
+	return nil
+}
+
+func (o *reloadOutput) has(name string) bool {
+	o.Lock()
+	defer o.Unlock()
+	for _, m := range o.metrics {
+		if m.Name() == name {
+			return true
+		}
+	}
+	return false
+}
+
+type reloadProcessor struct {
+	started atomic.Int64
+	stopped atomic.Int64
+	acc     telegraf.Accumulator
+}
+
+func (*reloadProcessor) SampleConfig() string { return "" }
+
+func (p *reloadProcessor) Start(acc telegraf.Accumulator) error {
+	p.started.Add(1)
+	p.acc = acc
+	return nil
+}
+
+func (*reloadProcessor) Add(m telegraf.Metric, acc telegraf.Accumulator) error {
+	acc.AddMetric(m)
+	return nil
+}
+
+func (p *reloadProcessor) Stop() {
+	p.stopped.Add(1)
+}
+
+func newReloadTestConfig() *config.Config {
+	c := config.NewConfig()
+	c.Agent.Interval = config.Duration(10 * time.Millisecond)
+	c.Agent.FlushInterval = config.Duration(10 * time.Millisecond)
+	c.Agent.Quiet = true
+	skip := false
+	c.Agent.SkipProcessorsAfterAggregators = &skip
+	return c
+}
+
+func TestReload(t *testing.T) {
+	// Setup the initial configuration with one failing output to be able to
+	// check that the buffer is kept across reloads
+	unchangedInput := &reloadInput{}
+	failingOutput := &reloadOutput{fail: true}
+	removedOutput := &reloadOutput{}
+
+	cfg := newReloadTestConfig()
+	cfg.Inputs = append(cfg.Inputs,
+		models.NewRunningInput(unchangedInput, &models.InputConfig{Name: "unchanged", ID: "input-1"}),
+	)
+	cfg.Outputs = append(cfg.Outputs,
+		models.NewRunningOutput(failingOutput, &models.OutputConfig{Name: "failing", ID: "output-1"}, 10, 100),
+		models.NewRunningOutput(removedOutput, &models.OutputConfig{Name: "removed", ID: "output-2"}, 10, 100),
+	)
+	buffered := cfg.Outputs[0]
+
+	a := NewAgent(cfg)
+	ctx, cancel := context.WithCancel(t.Context())
+	defer cancel()
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		if err := a.Run(ctx); err != nil {
+			t.Errorf("running agent failed: %v", err)
+		}
+	}()
+	require.Eventually(t, func() bool {
+		return buffered.BufferLength() > 0
+	}, 5*time.Second, 10*time.Millisecond)
+
+	// Reload with an added input and the second output removed
+	addedInput := &reloadInput{}
+	newCfg := newReloadTestConfig()
+	newCfg.Inputs = append(newCfg.Inputs,
+		models.NewRunningInput(&reloadInput{}, &models.InputConfig{Name: "unchanged", ID: "input-1"}),
+		models.NewRunningInput(addedInput, &models.InputConfig{Name: "added", ID: "input-2"}),
+	)
+	newCfg.Outputs = append(newCfg.Outputs,
+		models.NewRunningOutput(&reloadOutput{fail: true}, &models.OutputConfig{Name: "failing", ID: "output-1"}, 10, 100),
+	)
+	length := buffered.BufferLength()
+	require.NoError(t, a.Reload(newCfg))
+
+	// The unchanged plugins must keep running including their buffer
+	require.Len(t, a.Config.Inputs, 2)
+	require.Same(t, unchangedInput, a.Config.Inputs[0].Input)
+	require.Same(t, addedInput, a.Config.Inputs[1].Input)
+	require.Len(t, a.Config.Outputs, 1)
+	require.Same(t, buffered, a.Config.Outputs[0])
+	require.GreaterOrEqual(t, buffered.BufferLength(), length)
+	require.True(t, removedOutput.closed.Load())
+	require.False(t, failingOutput.closed.Load())
+
+	// The added input must be gathered
+	require.Eventually(t, func() bool {
+		return addedInput.gathered.Load() > 0
+	}, 5*time.Second, 10*time.Millisecond)
+
+	// Reloading the same configuration must not change anything
+	require.NoError(t, a.Reload(newCfg))
+	require.Same(t, buffered, a.Config.Outputs[0])
+
+	cancel()
+	wg.Wait()
+	require.True(t, failingOutput.closed.Load())
+
+	// Reloading a stopped agent must fail
+	require.ErrorContains(t, a.Reload(newCfg), "not running")
+}
+
+func TestReloadProcessors(t *testing.T) {
+	unchanged := &reloadProcessor{}
+	output := &reloadOutput{}
+
+	cfg := newReloadTestConfig()
+	cfg.Inputs = append(cfg.Inputs,
+		models.NewRunningInput(&reloadInput{}, &models.InputConfig{Name: "input", ID: "input-1"}),
+	)
+	cfg.Processors = append(cfg.Processors,
+		models.NewRunningProcessor(unchanged, &models.ProcessorConfig{Name: "unchanged", ID: "processor-1"}),
+	)
+	cfg.Outputs = append(cfg.Outputs,
+		models.NewRunningOutput(output, &models.OutputConfig{Name: "output", ID: "output-1"}, 10, 100),
+	)
+
+	a := NewAgent(cfg)
+	ctx, cancel := context.WithCancel(t.Context())
+	defer cancel()
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		if err := a.Run(ctx); err != nil {
+			t.Errorf("running agent failed: %v", err)
+		}
+	}()
+	require.Eventually(t, func() bool {
+		return output.has("test")
+	}, 5*time.Second, 10*time.Millisecond)
+
+	// Add a processor in front of the unchanged one
+	added := &reloadProcessor{}
+	newCfg := newReloadTestConfig()
+	newCfg.Inputs = append(newCfg.Inputs,
+		models.NewRunningInput(&reloadInput{}, &models.InputConfig{Name: "input", ID: "input-1"}),
+	)
+	newCfg.Processors = append(newCfg.Processors,
+		models.NewRunningProcessor(added, &models.ProcessorConfig{Name: "added", ID: "processor-2"}),
+		models.NewRunningProcessor(&reloadProcessor{}, &models.ProcessorConfig{Name: "unchanged", ID: "processor-1"}),
+	)
+	newCfg.Outputs = append(newCfg.Outputs,
+		models.NewRunningOutput(&reloadOutput{}, &models.OutputConfig{Name: "output", ID: "output-1"}, 10, 100),
+	)
+	require.NoError(t, a.Reload(newCfg))
+
+	// The unchanged processor must keep running while the added processor is
+	// started
+	require.Len(t, a.Config.Processors, 2)
+	require.Same(t, unchanged, a.Config.Processors[1].Processor)
+	require.Equal(t, int64(1), unchanged.started.Load())
+	require.Equal(t, int64(0), unchanged.stopped.Load())
+	require.Equal(t, int64(1), added.started.Load())
+
+	// Metrics emitted by the unchanged processor outside of Add must still
+	// reach the outputs
+	unchanged.acc.AddFields("emitted", map[string]interface{}{"value": 1}, nil)
+	require.Eventually(t, func() bool {
+		return output.has("emitted")
+	}, 5*time.Second, 10*time.Millisecond)
+
+	cancel()
+	wg.Wait()
+	require.Equal(t, int64(1), unchanged.stopped.Load())
+	require.Equal(t, int64(1), added.stopped.Load())
+}
@@ -0,0 +1,179 @@
+package agent
+
+import (
+	"log"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/fatih/color"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/config"
+	"github.com/influxdata/telegraf/models"
+)
+
+// Replay passes the given, previously recorded metrics through the configured
+// processors and aggregators and returns the resulting metrics ordered by
+// their timestamp. Inputs and outputs are not used.
+// Instead of the wall-clock, the aggregation periods are driven by a simulated
+// clock advancing with the timestamps of the recorded metrics. Aggregations
+// are pushed with the end of their period as timestamp, so the result only
+// depends on the recorded metrics and the configuration.
+func (a *Agent) Replay(metrics []telegraf.Metric) ([]telegraf.Metric, error) {
+	// Set the default for processor skipping
+	if a.Config.Agent.SkipProcessorsAfterAggregators == nil {
+		msg := `The default value of 'skip_processors_after_aggregators' will change to 'true' with Telegraf v1.40.0! `
+		msg += `If you need the current default behavior, please explicitly set the option to 'false'!`
+		log.Print("W! [agent] ", color.YellowString(msg))
+		skipProcessorsAfterAggregators := false
+		a.Config.Agent.SkipProcessorsAfterAggregators = &skipProcessorsAfterAggregators
+	}
+
+	log.Printf("D! [agent] Initializing plugins")
+	err := a.initPlugins(&config.PluginSet{
+		Processors:    a.Config.Processors,
+		AggProcessors: a.Config.AggProcessors,
+		Aggregators:   a.Config.Aggregators,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	log.Printf("D! [agent] Replaying %d metrics", len(metrics))
+	processed, err := a.replayProcessors(a.Config.Processors, metrics)
+	if err != nil {
+		return nil, err
+	}
+
+	result := processed
+	if len(a.Config.Aggregators) > 0 {
+		originals, aggregates := a.replayAggregators(processed)
+		if !*a.Config.Agent.SkipProcessorsAfterAggregators {
+			aggregates, err = a.replayProcessors(a.Config.AggProcessors, aggregates)
+			if err != nil {
+				return nil, err
+			}
+		}
+		result = append(originals, aggregates...)
+	}
+
+	sort.SliceStable(result, func(i, j int) bool {
+		return result[i].Time().Before(result[j].Time())
+	})
+
+	return result, nil
+}
+
+// replayProcessors passes the metrics through the given processor chain and
+// collects the output of the chain.
+func (a *Agent) replayProcessors(processors models.RunningProcessors, metrics []telegraf.Metric) ([]telegraf.Metric, error) {
+	if len(processors) == 0 {
+		return metrics, nil
+	}
+
+	dst := make(chan telegraf.Metric, 100)
+	src, units, err := a.startProcessors(dst, processors)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]telegraf.Metric, 0, len(metrics))
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for m := range dst {
+			result = append(result, m)
+		}
+	}()
+
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		a.runProcessors(units)
+	}()
+
+	for _, m := range metrics {
+		src <- m
+	}
+	close(src)
+	wg.Wait()
+
+	return result, nil
+}
+
+// replayAggregators adds the metrics to the aggregators and pushes the
+// aggregations whenever the simulated clock, i.e. the latest timestamp of the
+// metrics seen so far, passes the end of an aggregation period. The metrics
+// not dropped by the aggregators are returned as originals.
+func (a *Agent) replayAggregators(metrics []telegraf.Metric) (originals, aggregates []telegraf.Metric) {
+	if len(metrics) == 0 {
+		return nil, nil
+	}
+
+	aggC := make(chan telegraf.Metric, 100)
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for m := range aggC {
+			aggregates = append(aggregates, m)
+		}
+	}()
+
+	// Setup the aggregation windows starting at the first recorded metric
+	// and use the end of the pushed period as timestamp for the aggregations.
+	interval := time.Duration(a.Config.Agent.Interval)
+	precision := time.Duration(a.Config.Agent.Precision)
+	now := metrics[0].Time()
+	accs := make([]*accumulator, 0, len(a.Config.Aggregators))
+	for _, agg := range a.Config.Aggregators {
+		since, until := updateWindow(now, a.Config.Agent.RoundInterval, agg.Period())
+		agg.UpdateWindow(since, until)
+
+		accs = append(accs, &accumulator{
+			maker:     agg,
+			metrics:   aggC,
+			precision: getPrecision(precision, interval),
+		})
+	}
+	push := func(i int, agg *models.RunningAggregator) {
+		end := agg.EndPeriod()
+		accs[i].clock = func() time.Time { return end }
+		agg.PushAt(accs[i], now)
+	}
+
+	originals = make([]telegraf.Metric, 0, len(metrics))
+	for _, m := range metrics {
+		if m.Time().After(now) {
+			now = m.Time()
+		}
+		for i, agg := range a.Config.Aggregators {
+			if !now.Before(agg.EndPeriod()) {
+				push(i, agg)
+			}
+		}
+
+		var dropOriginal bool
+		for _, agg := range a.Config.Aggregators {
+			if ok := agg.Add(m); ok {
+				dropOriginal = true
+			}
+		}
+		if !dropOriginal {
+			originals = append(originals, m)
+		} else {
+			m.Drop()
+		}
+	}
+
+	// Push the remaining aggregations similar to the agent shutting down
+	for i, agg := range a.Config.Aggregators {
+		push(i, agg)
+	}
+	close(aggC)
+	wg.Wait()
+
+	return originals, aggregates
+}
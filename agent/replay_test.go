@@ -0,0 +1,83 @@
+package agent
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/config"
+	"github.com/influxdata/telegraf/metric"
+	"github.com/influxdata/telegraf/models"
+	"github.com/influxdata/telegraf/plugins/processors"
+	"github.com/influxdata/telegraf/testutil"
+)
+
+type replayProcessor struct{}
+
+func (*replayProcessor) SampleConfig() string { return "" }
+
+func (*replayProcessor) Apply(in ...telegraf.Metric) []telegraf.Metric {
+	for _, m := range in {
+		m.AddTag("processed", "true")
+	}
+	return in
+}
+
+type replayAggregator struct {
+	count int64
+}
+
+func (*replayAggregator) SampleConfig() string { return "" }
+
+func (a *replayAggregator) Add(telegraf.Metric) {
+	a.count++
+}
+
+func (a *replayAggregator) Push(acc telegraf.Accumulator) {
+	if a.count > 0 {
+		acc.AddFields("count", map[string]interface{}{"value": a.count}, nil)
+	}
+}
+
+func (a *replayAggregator) Reset() {
+	a.count = 0
+}
+
+func TestReplay(t *testing.T) {
+	cfg := config.NewConfig()
+	cfg.Agent.Interval = config.Duration(10 * time.Second)
+	cfg.Agent.RoundInterval = true
+	skip := true
+	cfg.Agent.SkipProcessorsAfterAggregators = &skip
+	cfg.Processors = append(cfg.Processors, models.NewRunningProcessor(
+		processors.NewStreamingProcessorFromProcessor(&replayProcessor{}),
+		&models.ProcessorConfig{Name: "replay"},
+	))
+	cfg.Aggregators = append(cfg.Aggregators, models.NewRunningAggregator(
+		&replayAggregator{},
+		&models.AggregatorConfig{Name: "replay", Period: 10 * time.Second},
+	))
+
+	input := make([]telegraf.Metric, 0, 5)
+	for _, ts := range []int64{0, 1, 5, 11, 25} {
+		input = append(input, metric.New("test", nil, map[string]interface{}{"value": ts}, time.Unix(ts, 0)))
+	}
+
+	expected := []telegraf.Metric{
+		testutil.MustMetric("test", map[string]string{"processed": "true"}, map[string]interface{}{"value": 0}, time.Unix(0, 0)),
+		testutil.MustMetric("test", map[string]string{"processed": "true"}, map[string]interface{}{"value": 1}, time.Unix(1, 0)),
+		testutil.MustMetric("test", map[string]string{"processed": "true"}, map[string]interface{}{"value": 5}, time.Unix(5, 0)),
+		testutil.MustMetric("count", map[string]string{}, map[string]interface{}{"value": 3}, time.Unix(10, 0)),
+		testutil.MustMetric("test", map[string]string{"processed": "true"}, map[string]interface{}{"value": 11}, time.Unix(11, 0)),
+		testutil.MustMetric("count", map[string]string{}, map[string]interface{}{"value": 1}, time.Unix(20, 0)),
+		testutil.MustMetric("test", map[string]string{"processed": "true"}, map[string]interface{}{"value": 25}, time.Unix(25, 0)),
+		testutil.MustMetric("count", map[string]string{}, map[string]interface{}{"value": 1}, time.Unix(30, 0)),
+	}
+
+	a := NewAgent(cfg)
+	actual, err := a.Replay(input)
+	require.NoError(t, err)
+	testutil.RequireMetricsEqual(t, expected, actual)
+}
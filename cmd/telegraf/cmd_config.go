@@ -2,19 +2,23 @@
 package main
 
 import (
+	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
 	"log"
+	"maps"
 	"net/url"
 	"os"
 	"path/filepath"
+	"slices"
 
 	"github.com/fatih/color"
 	"github.com/urfave/cli/v2"
 
 	"github.com/influxdata/telegraf/agent"
 	"github.com/influxdata/telegraf/config"
+	"github.com/influxdata/telegraf/internal"
 	"github.com/influxdata/telegraf/logger"
 	"github.com/influxdata/telegraf/migrations"
 )
@@ -99,6 +103,82 @@ func getConfigCommands(configHandlingFlags []cli.Flag, outputBuffer io.Writer) [
 						return ag.InitPlugins()
 					},
 				},
+				{
+					Name:  "lint",
+					Usage: "lint configuration file(s) for likely mistakes",
+					Description: `
+The 'lint' command reads the configuration files specified via '--config' or
+'--config-directory' and reports settings that do not prevent Telegraf from
+starting but are likely mistakes. This includes namepass or tagpass patterns
+never selecting a metric, processors sharing the same 'order', aggregators
+with a 'period' shorter than the interval of the inputs they select, secrets
+referencing unknown secret-stores and deprecated plugins or options. If no
+configuration file is explicitly specified the command reads the default
+locations and uses those configuration files.
+The findings are printed as JSON or as SARIF report and the command fails if
+any issue was found.
+
+To lint the file 'mysettings.conf' and produce a SARIF report use
+
+> telegraf config lint --config mysettings.conf --format sarif
+`,
+					Flags: append([]cli.Flag{
+						&cli.StringFlag{
+							Name:  "format",
+							Usage: "output format of the findings, available are 'json' and 'sarif'",
+							Value: "json",
+						},
+					}, configHandlingFlags...),
+					Action: func(cCtx *cli.Context) error {
+						// Setup logging
+						logConfig := &logger.Config{Debug: cCtx.Bool("debug")}
+						if err := logger.SetupLogging(logConfig); err != nil {
+							return err
+						}
+
+						format := cCtx.String("format")
+						if format != "json" && format != "sarif" {
+							return fmt.Errorf("invalid format %q", format)
+						}
+
+						// Collect the given configuration files
+						configFiles := cCtx.StringSlice("config")
+						configDir := cCtx.StringSlice("config-directory")
+						for _, fConfigDirectory := range configDir {
+							files, err := config.WalkDirectory(fConfigDirectory)
+							if err != nil {
+								return err
+							}
+							configFiles = append(configFiles, files...)
+						}
+
+						// If no "config" or "config-directory" flag(s) was
+						// provided we should load default configuration files
+						if len(configFiles) == 0 {
+							paths, err := config.GetDefaultConfigPath()
+							if err != nil {
+								return err
+							}
+							configFiles = paths
+						}
+
+						// Load the config and check for issues
+						c := config.NewConfig()
+						c.Agent.Quiet = cCtx.Bool("quiet")
+						findings, err := c.Lint(configFiles...)
+						if err != nil {
+							return err
+						}
+
+						if err := printLintFindings(outputBuffer, format, findings); err != nil {
+							return err
+						}
+						if len(findings) > 0 {
+							return fmt.Errorf("found %d issue(s)", len(findings))
+						}
+						return nil
+					},
+				},
 				{
 					Name:  "create",
 					Usage: "create a full sample configuration and show it",
@@ -238,3 +318,119 @@ To migrate the file 'mysettings.conf' use
 		},
 	}
 }
+
+// SARIF v2.1.0 report structures, see
+// https://docs.oasis-open.org/sarif/sarif/v2.1.0/sarif-v2.1.0.html
+type sarifReport struct {
+	Schema  string     `json:"$schema"`
+	Version string     `json:"version"`
+	Runs    []sarifRun `json:"runs"`
+}
+
+type sarifRun struct {
+	Tool    sarifTool     `json:"tool"`
+	Results []sarifResult `json:"results"`
+}
+
+type sarifTool struct {
+	Driver sarifDriver `json:"driver"`
+}
+
+type sarifDriver struct {
+	Name           string      `json:"name"`
+	Version        string      `json:"version,omitempty"`
+	InformationURI string      `json:"informationUri"`
+	Rules          []sarifRule `json:"rules"`
+}
+
+type sarifRule struct {
+	ID               string       `json:"id"`
+	ShortDescription sarifMessage `json:"shortDescription"`
+}
+
+type sarifMessage struct {
+	Text string `json:"text"`
+}
+
+type sarifResult struct {
+	RuleID     string                 `json:"ruleId"`
+	Level      string                 `json:"level"`
+	Message    sarifMessage           `json:"message"`
+	Locations  []sarifLocation        `json:"locations,omitempty"`
+	Properties map[string]interface{} `json:"properties,omitempty"`
+}
+
+type sarifLocation struct {
+	PhysicalLocation sarifPhysicalLocation `json:"physicalLocation"`
+}
+
+type sarifPhysicalLocation struct {
+	ArtifactLocation sarifArtifactLocation `json:"artifactLocation"`
+}
+
+type sarifArtifactLocation struct {
+	URI string `json:"uri"`
+}
+
+func printLintFindings(w io.Writer, format string, findings []config.LintFinding) error {
+	var report interface{} = findings
+	if format == "sarif" {
+		report = newSarifReport(findings)
+	}
+
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(report)
+}
+
+func newSarifReport(findings []config.LintFinding) *sarifReport {
+	rules := make([]sarifRule, 0, len(config.LintRules))
+	for _, id := range slices.Sorted(maps.Keys(config.LintRules)) {
+		rules = append(rules, sarifRule{
+			ID:               id,
+			ShortDescription: sarifMessage{Text: config.LintRules[id]},
+		})
+	}
+
+	results := make([]sarifResult, 0, len(findings))
+	for _, f := range findings {
+		properties := map[string]interface{}{"plugin": f.Plugin}
+		if f.Option != "" {
+			properties["option"] = f.Option
+		}
+		if f.Deprecation != nil {
+			properties["deprecation"] = f.Deprecation
+		}
+
+		result := sarifResult{
+			RuleID:     f.Rule,
+			Level:      f.Level,
+			Message:    sarifMessage{Text: f.Plugin + ": " + f.Message},
+			Properties: properties,
+		}
+		if f.Source != "" {
+			result.Locations = []sarifLocation{
+				{PhysicalLocation: sarifPhysicalLocation{ArtifactLocation: sarifArtifactLocation{URI: f.Source}}},
+			}
+		}
+		results = append(results, result)
+	}
+
+	return &sarifReport{
+		Schema:  "https://json.schemastore.org/sarif-2.1.0.json",
+		Version: "2.1.0",
+		Runs: []sarifRun{
+			{
+				Tool: sarifTool{
+					Driver: sarifDriver{
+						Name:           "telegraf",
+						Version:        internal.Version,
+						InformationURI: "https://github.com/influxdata/telegraf",
+						Rules:          rules,
+					},
+				},
+				Results: results,
+			},
+		},
+	}
+}
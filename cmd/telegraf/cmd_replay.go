@@ -0,0 +1,289 @@
+// Command handling for the "replay" command
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/urfave/cli/v2"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/agent"
+	"github.com/influxdata/telegraf/config"
+	"github.com/influxdata/telegraf/logger"
+	"github.com/influxdata/telegraf/metric"
+	"github.com/influxdata/telegraf/plugins/parsers/influx"
+	serializers_influx "github.com/influxdata/telegraf/plugins/serializers/influx"
+)
+
+func getReplayCommands(configHandlingFlags []cli.Flag, outputBuffer io.Writer) []*cli.Command {
+	return []*cli.Command{
+		{
+			Name:  "replay",
+			Usage: "replay recorded metrics through the configured processors and aggregators",
+			Description: `
+The 'replay' command reads recorded metrics from the file specified via
+'--input' and passes them through the processors and aggregators of the
+configuration files specified via '--config' or '--config-directory'. Inputs
+and outputs of the configuration are not started. Instead of the wall-clock,
+the aggregation periods are driven by the timestamps of the recorded metrics.
+The resulting metrics are printed in InfluxDB line protocol ordered by time.
+If no configuration file is explicitly specified the command reads the default
+locations and uses those configuration files.
+
+Recorded metrics are read in InfluxDB line protocol or in the JSON format
+produced by Telegraf's 'json' serializer.
+
+To replay the metrics in 'recorded.influx' using 'processing.conf' use
+
+> telegraf replay --config processing.conf --input recorded.influx
+
+To compare the result with the metrics in 'expected.influx' use
+
+> telegraf replay --config processing.conf --input recorded.influx --expected expected.influx
+
+In this case differences are printed and the command fails if the result
+differs from the expectation.
+`,
+			Flags: append([]cli.Flag{
+				&cli.StringFlag{
+					Name:     "input",
+					Usage:    "file containing the recorded metrics, use '-' to read from stdin",
+					Required: true,
+				},
+				&cli.StringFlag{
+					Name:  "format",
+					Usage: "format of the recorded metrics, available are 'influx' and 'json'",
+					Value: "influx",
+				},
+				&cli.StringFlag{
+					Name:  "json-timestamp-units",
+					Usage: "units of the timestamps of recorded metrics in JSON format",
+					Value: "1s",
+				},
+				&cli.StringFlag{
+					Name:  "expected",
+					Usage: "file containing the expected metrics in InfluxDB line protocol",
+				},
+			}, configHandlingFlags...),
+			Action: func(cCtx *cli.Context) error {
+				// Setup logging
+				logConfig := &logger.Config{Debug: cCtx.Bool("debug")}
+				if err := logger.SetupLogging(logConfig); err != nil {
+					return err
+				}
+
+				// Read the recorded metrics
+				data, err := readReplayFile(cCtx.String("input"))
+				if err != nil {
+					return err
+				}
+				var metrics []telegraf.Metric
+				switch format := cCtx.String("format"); format {
+				case "influx":
+					metrics, err = parseInfluxMetrics(data)
+				case "json":
+					var units time.Duration
+					units, err = time.ParseDuration(cCtx.String("json-timestamp-units"))
+					if err != nil {
+						return fmt.Errorf("parsing JSON timestamp units failed: %w", err)
+					}
+					metrics, err = parseJSONMetrics(data, units)
+				default:
+					return fmt.Errorf("invalid format %q", format)
+				}
+				if err != nil {
+					return fmt.Errorf("parsing recorded metrics failed: %w", err)
+				}
+
+				// Collect the given configuration files
+				configFiles := cCtx.StringSlice("config")
+				configDir := cCtx.StringSlice("config-directory")
+				for _, fConfigDirectory := range configDir {
+					files, err := config.WalkDirectory(fConfigDirectory)
+					if err != nil {
+						return err
+					}
+					configFiles = append(configFiles, files...)
+				}
+
+				// If no "config" or "config-directory" flag(s) was
+				// provided we should load default configuration files
+				if len(configFiles) == 0 {
+					paths, err := config.GetDefaultConfigPath()
+					if err != nil {
+						return err
+					}
+					configFiles = paths
+				}
+
+				// Load the config and replay the metrics
+				c := config.NewConfig()
+				c.Agent.Quiet = cCtx.Bool("quiet")
+				if err := c.LoadAll(configFiles...); err != nil {
+					return err
+				}
+
+				ag := agent.NewAgent(c)
+				result, err := ag.Replay(metrics)
+				if err != nil {
+					return err
+				}
+				actual, err := serializeReplayMetrics(result)
+				if err != nil {
+					return err
+				}
+
+				// Print the result if we should not compare with the
+				// expectation
+				if cCtx.String("expected") == "" {
+					for _, line := range actual {
+						fmt.Fprintln(outputBuffer, line)
+					}
+					return nil
+				}
+
+				data, err = readReplayFile(cCtx.String("expected"))
+				if err != nil {
+					return err
+				}
+				metrics, err = parseInfluxMetrics(data)
+				if err != nil {
+					return fmt.Errorf("parsing expected metrics failed: %w", err)
+				}
+				expected, err := serializeReplayMetrics(metrics)
+				if err != nil {
+					return err
+				}
+				if diff := cmp.Diff(expected, actual); diff != "" {
+					fmt.Fprintln(outputBuffer, "Result differs from expectation (-want +got):")
+					fmt.Fprint(outputBuffer, diff)
+					return errors.New("result does not match expectation")
+				}
+				return nil
+			},
+		},
+	}
+}
+
+func readReplayFile(fn string) ([]byte, error) {
+	if fn == "-" {
+		return io.ReadAll(os.Stdin)
+	}
+	data, err := os.ReadFile(fn)
+	if err != nil {
+		return nil, fmt.Errorf("reading %q failed: %w", fn, err)
+	}
+	return data, nil
+}
+
+func parseInfluxMetrics(data []byte) ([]telegraf.Metric, error) {
+	parser := &influx.Parser{}
+	if err := parser.Init(); err != nil {
+		return nil, err
+	}
+	return parser.Parse(data)
+}
+
+// jsonMetric is a metric in the format of Telegraf's JSON serializer
+type jsonMetric struct {
+	Name      string                 `json:"name"`
+	Tags      map[string]string      `json:"tags"`
+	Fields    map[string]interface{} `json:"fields"`
+	Timestamp json.Number            `json:"timestamp"`
+}
+
+// parseJSONMetrics parses metrics in the format of Telegraf's JSON serializer
+// in both, the single metric and the batch format. Multiple JSON documents
+// may be concatenated, e.g. one per line.
+func parseJSONMetrics(data []byte, units time.Duration) ([]telegraf.Metric, error) {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+
+	var metrics []telegraf.Metric
+	for {
+		var entry struct {
+			jsonMetric
+			Metrics []jsonMetric `json:"metrics"`
+		}
+		if err := decoder.Decode(&entry); err != nil {
+			if errors.Is(err, io.EOF) {
+				break
+			}
+			return nil, err
+		}
+
+		entries := entry.Metrics
+		if entries == nil {
+			entries = []jsonMetric{entry.jsonMetric}
+		}
+		for _, e := range entries {
+			m, err := e.toMetric(units)
+			if err != nil {
+				return nil, err
+			}
+			metrics = append(metrics, m)
+		}
+	}
+
+	return metrics, nil
+}
+
+func (e *jsonMetric) toMetric(units time.Duration) (telegraf.Metric, error) {
+	if e.Name == "" {
+		return nil, errors.New("metric without name")
+	}
+
+	fields := make(map[string]interface{}, len(e.Fields))
+	for k, v := range e.Fields {
+		n, ok := v.(json.Number)
+		if !ok {
+			fields[k] = v
+			continue
+		}
+		if i, err := n.Int64(); err == nil {
+			fields[k] = i
+		} else if f, err := n.Float64(); err == nil {
+			fields[k] = f
+		} else {
+			return nil, fmt.Errorf("invalid value for field %q of metric %q: %w", k, e.Name, err)
+		}
+	}
+
+	// Use integer arithmetic if possible to not lose precision for
+	// timestamps with small units
+	var t time.Time
+	if i, err := e.Timestamp.Int64(); err == nil {
+		t = time.Unix(0, i*int64(units))
+	} else if f, err := e.Timestamp.Float64(); err == nil {
+		t = time.Unix(0, int64(f*float64(units)))
+	} else {
+		return nil, fmt.Errorf("invalid timestamp of metric %q: %w", e.Name, err)
+	}
+
+	return metric.New(e.Name, e.Tags, fields, t), nil
+}
+
+func serializeReplayMetrics(metrics []telegraf.Metric) ([]string, error) {
+	serializer := &serializers_influx.Serializer{SortFields: true, UintSupport: true}
+	if err := serializer.Init(); err != nil {
+		return nil, err
+	}
+
+	lines := make([]string, 0, len(metrics))
+	for _, m := range metrics {
+		octets, err := serializer.Serialize(m)
+		if err != nil {
+			return nil, fmt.Errorf("serializing metric %q failed: %w", m.Name(), err)
+		}
+		lines = append(lines, strings.TrimSuffix(string(octets), "\n"))
+	}
+	return lines, nil
+}
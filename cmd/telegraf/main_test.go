@@ -2,10 +2,12 @@ package main
 
 import (
 	"bytes"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
 	"os"
+	"path/filepath"
 	"strconv"
 	"strings"
 	"testing"
@@ -427,6 +429,100 @@ func TestCommandConfig(t *testing.T) {
 	}
 }
 
+func TestCommandConfigLint(t *testing.T) {
+	cfg := `
+[[inputs.cpu]]
+  namepass = ["cpu", "cpu"]
+
+[[outputs.http]]
+  url = "http://localhost:8080"
+  password = "@{unknown:password}"
+`
+	fn := filepath.Join(t.TempDir(), "telegraf.conf")
+	require.NoError(t, os.WriteFile(fn, []byte(cfg), 0600))
+
+	// JSON output
+	buf := new(bytes.Buffer)
+	args := []string{os.Args[0], "config", "lint", "--config", fn}
+	err := runApp(args, buf, NewMockServer(), NewMockConfig(buf), NewMockTelegraf())
+	require.ErrorContains(t, err, "found 2 issue(s)")
+
+	var findings []config.LintFinding
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &findings))
+	require.Len(t, findings, 2)
+	require.Equal(t, config.LintRuleUnusedPattern, findings[0].Rule)
+	require.Equal(t, "inputs.cpu", findings[0].Plugin)
+	require.Equal(t, config.LintRuleUnknownSecretStore, findings[1].Rule)
+	require.Equal(t, "outputs.http", findings[1].Plugin)
+	require.Equal(t, fn, findings[1].Source)
+
+	// SARIF output
+	buf.Reset()
+	args = []string{os.Args[0], "config", "lint", "--config", fn, "--format", "sarif"}
+	err = runApp(args, buf, NewMockServer(), NewMockConfig(buf), NewMockTelegraf())
+	require.ErrorContains(t, err, "found 2 issue(s)")
+
+	var report sarifReport
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &report))
+	require.Equal(t, "2.1.0", report.Version)
+	require.Len(t, report.Runs, 1)
+	require.Len(t, report.Runs[0].Tool.Driver.Rules, len(config.LintRules))
+	require.Len(t, report.Runs[0].Results, 2)
+	result := report.Runs[0].Results[1]
+	require.Equal(t, config.LintRuleUnknownSecretStore, result.RuleID)
+	require.Equal(t, config.LintLevelError, result.Level)
+	require.Equal(t, fn, result.Locations[0].PhysicalLocation.ArtifactLocation.URI)
+}
+
+func TestCommandReplay(t *testing.T) {
+	cfg := `
+[agent]
+  round_interval = true
+  skip_processors_after_aggregators = true
+
+[[processors.override]]
+  [processors.override.tags]
+    source = "replay"
+
+[[aggregators.minmax]]
+  period = "10s"
+  drop_original = true
+`
+	input := `{"name":"test","tags":{"host":"a"},"fields":{"value":1},"timestamp":0}
+{"metrics":[{"name":"test","tags":{"host":"a"},"fields":{"value":3},"timestamp":5},{"name":"test","tags":{"host":"a"},"fields":{"value":2},"timestamp":12}]}
+`
+	expected := `
+test,host=a,source=replay value_max=3,value_min=1 10000000000
+test,host=a,source=replay value_max=2,value_min=2 20000000000
+`
+	dir := t.TempDir()
+	fnConfig := filepath.Join(dir, "telegraf.conf")
+	require.NoError(t, os.WriteFile(fnConfig, []byte(cfg), 0600))
+	fnInput := filepath.Join(dir, "input.json")
+	require.NoError(t, os.WriteFile(fnInput, []byte(input), 0600))
+	fnExpected := filepath.Join(dir, "expected.influx")
+	require.NoError(t, os.WriteFile(fnExpected, []byte(expected), 0600))
+
+	// Print the result
+	buf := new(bytes.Buffer)
+	args := []string{os.Args[0], "replay", "--config", fnConfig, "--input", fnInput, "--format", "json"}
+	require.NoError(t, runApp(args, buf, NewMockServer(), NewMockConfig(buf), NewMockTelegraf()))
+	require.Equal(t, strings.TrimPrefix(expected, "\n"), buf.String())
+
+	// Compare with the expectation
+	buf.Reset()
+	args = append(args, "--expected", fnExpected)
+	require.NoError(t, runApp(args, buf, NewMockServer(), NewMockConfig(buf), NewMockTelegraf()))
+	require.Empty(t, buf.String())
+
+	// Compare with a wrong expectation
+	require.NoError(t, os.WriteFile(fnExpected, []byte("test,host=a value=1 0\n"), 0600))
+	buf.Reset()
+	err := runApp(args, buf, NewMockServer(), NewMockConfig(buf), NewMockTelegraf())
+	require.ErrorContains(t, err, "result does not match expectation")
+	require.Contains(t, buf.String(), "differs from expectation")
+}
+
 func TestCommandVersion(t *testing.T) {
 	tests := []struct {
 		Version        string
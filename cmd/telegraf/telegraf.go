@@ -10,6 +10,7 @@ import (
 	"os"
 	"os/signal"
 	"strings"
+	"sync/atomic"
 	"syscall"
 	"time"
 
@@ -79,7 +80,9 @@ type Telegraf struct {
 	configFiles        []string
 	secretstoreFilters []string
 
-	cfg *config.Config
+	cfg     *config.Config
+	signals chan os.Signal
+	agent   atomic.Pointer[agent.Agent]
 
 	GlobalFlags
 	WindowFlags
@@ -149,57 +152,41 @@ func (t *Telegraf) reloadLoop() error {
 		signals := make(chan os.Signal, 1)
 		signal.Notify(signals, os.Interrupt, syscall.SIGHUP,
 			syscall.SIGTERM, syscall.SIGINT)
-		if t.watchConfig != "" {
-			for _, fConfig := range t.configFiles {
-				if isURL(fConfig) {
-					continue
-				}
-
-				if _, err := os.Stat(fConfig); err != nil {
-					log.Printf("W! Cannot watch config %s: %s", fConfig, err)
-				} else {
-					go t.watchLocalConfig(ctx, signals, fConfig)
-				}
-			}
-			for _, fConfigDirectory := range t.configDir {
-				if _, err := os.Stat(fConfigDirectory); err != nil {
-					log.Printf("W! Cannot watch config directory %s: %s", fConfigDirectory, err)
-				} else {
-					go t.watchLocalConfig(ctx, signals, fConfigDirectory)
-				}
-			}
-		}
-		if t.configURLWatchInterval > 0 {
-			remoteConfigs := make([]string, 0)
-			for _, fConfig := range t.configFiles {
-				if isURL(fConfig) {
-					remoteConfigs = append(remoteConfigs, fConfig)
-				}
-			}
-			if len(remoteConfigs) > 0 {
-				go t.watchRemoteConfigs(ctx, signals, t.configURLWatchInterval, remoteConfigs)
-			}
-		}
+		t.signals = signals
 		go func() {
-			select {
-			case sig := <-signals:
-				if sig == syscall.SIGHUP {
-					log.Println("I! Reloading Telegraf config")
-					// May need to update the list of known config files
-					// if a delete or create occured. That way on the reload
-					// we ensure we watch the correct files.
-					if err := t.getConfigFiles(); err != nil {
-						log.Println("E! Error loading config files: ", err)
+			for {
+				watchCtx, watchCancel := context.WithCancel(ctx)
+				t.watchConfigs(watchCtx, signals)
+
+				select {
+				case sig := <-signals:
+					watchCancel()
+					if sig == syscall.SIGHUP {
+						log.Println("I! Reloading Telegraf config")
+						// May need to update the list of known config files
+						// if a delete or create occured. That way on the reload
+						// we ensure we watch the correct files.
+						if err := t.getConfigFiles(); err != nil {
+							log.Println("E! Error loading config files: ", err)
+						}
+						// Try to only restart the changed plugins and keep
+						// the agent running
+						if t.reloadPlugins() {
+							continue
+						}
+						<-reload
+						reload <- true
 					}
-					<-reload
-					reload <- true
+					cancel()
+				case err := <-t.pprofErr:
+					watchCancel()
+					log.Printf("E! pprof server failed: %v", err)
+					cancel()
+				case <-stop:
+					watchCancel()
+					cancel()
 				}
-				cancel()
-			case err := <-t.pprofErr:
-				log.Printf("E! pprof server failed: %v", err)
-				cancel()
-			case <-stop:
-				cancel()
+				return
 			}
 		}()
 
@@ -213,6 +200,67 @@ func (t *Telegraf) reloadLoop() error {
 	return nil
 }
 
+// watchConfigs starts watching the local and remote configuration files for
+// changes if requested.
+func (t *Telegraf) watchConfigs(ctx context.Context, signals chan os.Signal) {
+	if t.watchConfig != "" {
+		for _, fConfig := range t.configFiles {
+			if isURL(fConfig) {
+				continue
+			}
+
+			if _, err := os.Stat(fConfig); err != nil {
+				log.Printf("W! Cannot watch config %s: %s", fConfig, err)
+			} else {
+				go t.watchLocalConfig(ctx, signals, fConfig)
+			}
+		}
+		for _, fConfigDirectory := range t.configDir {
+			if _, err := os.Stat(fConfigDirectory); err != nil {
+				log.Printf("W! Cannot watch config directory %s: %s", fConfigDirectory, err)
+			} else {
+				go t.watchLocalConfig(ctx, signals, fConfigDirectory)
+			}
+		}
+	}
+	if t.configURLWatchInterval > 0 {
+		remoteConfigs := make([]string, 0)
+		for _, fConfig := range t.configFiles {
+			if isURL(fConfig) {
+				remoteConfigs = append(remoteConfigs, fConfig)
+			}
+		}
+		if len(remoteConfigs) > 0 {
+			go t.watchRemoteConfigs(ctx, signals, t.configURLWatchInterval, remoteConfigs)
+		}
+	}
+}
+
+// reloadPlugins applies the changed configuration to the running agent by
+// only restarting the changed plugins. It returns false if the agent must be
+// restarted completely.
+func (t *Telegraf) reloadPlugins() bool {
+	ag := t.agent.Load()
+	if ag == nil {
+		return false
+	}
+
+	// Errors are reported when restarting the agent
+	c, err := t.loadConfiguration()
+	if err != nil {
+		return false
+	}
+	if err := t.checkConfiguration(c); err != nil {
+		return false
+	}
+
+	if err := ag.Reload(c); err != nil {
+		log.Printf("I! Restarting all plugins: %v", err)
+		return false
+	}
+	return true
+}
+
 func (t *Telegraf) watchLocalConfig(ctx context.Context, signals chan os.Signal, fConfig string) {
 	var mytomb tomb.Tomb
 	var watcher watch.FileWatcher
@@ -352,15 +400,8 @@ func (t *Telegraf) getConfigFiles() error {
 	return nil
 }
 
-func (t *Telegraf) runAgent(ctx context.Context, reloadConfig bool) error {
-	c := t.cfg
-	var err error
-	if reloadConfig {
-		if c, err = t.loadConfiguration(); err != nil {
-			return err
-		}
-	}
-
+// checkConfiguration checks if the configuration is runnable
+func (t *Telegraf) checkConfiguration(c *config.Config) error {
 	if !t.test && t.testWait == 0 && len(c.Outputs) == 0 {
 		return errors.New("no outputs found, probably invalid config file provided")
 	}
@@ -375,6 +416,21 @@ func (t *Telegraf) runAgent(ctx context.Context, reloadConfig bool) error {
 	if int64(c.Agent.FlushInterval) <= 0 {
 		return fmt.Errorf("agent flush_interval must be positive; found %v", c.Agent.Interval)
 	}
+	return nil
+}
+
+func (t *Telegraf) runAgent(ctx context.Context, reloadConfig bool) error {
+	c := t.cfg
+	var err error
+	if reloadConfig {
+		if c, err = t.loadConfiguration(); err != nil {
+			return err
+		}
+	}
+
+	if err := t.checkConfiguration(c); err != nil {
+		return err
+	}
 
 	// Setup logging as configured.
 	logConfig := &logger.Config{
@@ -444,6 +500,14 @@ func (t *Telegraf) runAgent(ctx context.Context, reloadConfig bool) error {
 		}
 	}
 	ag := agent.NewAgent(c)
+	if signals := t.signals; signals != nil {
+		ag.ReloadFunc = func() {
+			select {
+			case signals <- syscall.SIGHUP:
+			default:
+			}
+		}
+	}
 
 	// Notify systemd that telegraf is ready
 	// SdNotify() only tries to notify if the NOTIFY_SOCKET environment is set, so it's safe to call when systemd isn't present.
@@ -483,6 +547,9 @@ func (t *Telegraf) runAgent(ctx context.Context, reloadConfig bool) error {
 		}
 	}
 
+	t.agent.Store(ag)
+	defer t.agent.Store(nil)
+
 	return ag.Run(ctx)
 }
 
@@ -14,6 +14,7 @@ import (
 	"reflect"
 	"regexp"
 	"runtime"
+	"slices"
 	"sort"
 	"strconv"
 	"strings"
@@ -86,6 +87,8 @@ type Config struct {
 	Inputs      []*models.RunningInput
 	Outputs     []*models.RunningOutput
 	Aggregators []*models.RunningAggregator
+	// Output groups route metrics to a single output of the group
+	OutputGroups []*models.OutputGroupConfig
 	// Processors have a slice wrapper type because they need to be sorted
 	Processors        models.RunningProcessors
 	AggProcessors     models.RunningProcessors
@@ -103,6 +106,10 @@ type Config struct {
 
 	seenAgentTable     bool
 	seenAgentTableOnce sync.Once
+
+	// IDs of the agent, global-tags, secret-store and output-group tables used
+	// to detect changes of those settings
+	settingIDs []string
 }
 
 // Ordered plugins used to keep the order in which they appear in a file
@@ -275,6 +282,25 @@ type AgentConfig struct {
 	// the state in the file will be restored for the plugins.
 	Statefile string `toml:"statefile"`
 
+	// Type of the backend used for storing the plugin states. Supported types
+	// are "file" (default) storing all states in a single file, "boltdb"
+	// storing the states in an embedded key-value database and "directory"
+	// storing the state of each plugin in a separate file within the
+	// 'statefile' directory.
+	StatefileBackend string `toml:"statefile_backend"`
+
+	// Interval for periodically storing the plugin states while Telegraf is
+	// running in addition to storing the states on termination. Zero disables
+	// the periodic checkpoints.
+	StatefileInterval Duration `toml:"statefile_interval"`
+
+	// Address to serve the local control API on, e.g. "localhost:8687". The
+	// API is disabled if empty.
+	ControlAPIAddress string `toml:"control_api_address"`
+
+	// Bearer token required to authenticate requests to the control API.
+	ControlAPIToken Secret `toml:"control_api_token"`
+
 	// Flag to always keep tags explicitly defined in the plugin itself and
 	// ensure those tags always pass filtering.
 	AlwaysIncludeLocalTags bool `toml:"always_include_local_tags"`
@@ -293,12 +319,21 @@ type AgentConfig struct {
 	ConfigURLRetryAttempts int `toml:"config_url_retry_attempts"`
 
 	// BufferStrategy is the metric buffer type to use for a given output plugin.
-	// Supported types currently are "memory" and "disk".
+	// Supported types currently are "memory", "disk" and "segmented".
 	BufferStrategy string `toml:"buffer_strategy"`
 
 	// BufferDirectory is the directory to store buffer files for serialized
-	// to disk metrics when using the "disk" buffer strategy.
+	// to disk metrics when using the "disk" or "segmented" buffer strategy.
 	BufferDirectory string `toml:"buffer_directory"`
+
+	// BufferMaxBytes is the maximum size of the buffer files of each output
+	// when using the "segmented" buffer strategy. The oldest segments are
+	// dropped when exceeding the limit.
+	BufferMaxBytes Size `toml:"buffer_max_bytes"`
+
+	// BufferMaxAge is the maximum age of metrics kept in the buffer of each
+	// output when using the "segmented" buffer strategy.
+	BufferMaxAge Duration `toml:"buffer_max_age"`
 }
 
 // InputNames returns a list of strings of the configured inputs.
@@ -553,6 +588,17 @@ func (c *Config) LoadConfig(path string) error {
 }
 
 func (c *Config) LoadAll(configFiles ...string) error {
+	if err := c.loadAll(configFiles...); err != nil {
+		return err
+	}
+
+	// Let's link all secrets to their secret-stores
+	return c.LinkSecrets()
+}
+
+// loadAll loads the given configuration files without linking the secrets
+// to their secret-stores.
+func (c *Config) loadAll(configFiles ...string) error {
 	for _, fConfig := range configFiles {
 		if err := c.LoadConfig(fConfig); err != nil {
 			return err
@@ -564,6 +610,13 @@ func (c *Config) LoadAll(configFiles ...string) error {
 	sort.Stable(c.Processors)
 	sort.Stable(c.AggProcessors)
 
+	if err := c.checkOutputGroups(); err != nil {
+		return err
+	}
+	if err := c.checkDeadLetterOutputs(); err != nil {
+		return err
+	}
+
 	// Set snmp agent translator default
 	if c.Agent.SnmpTranslator == "" {
 		c.Agent.SnmpTranslator = "netsnmp"
@@ -577,8 +630,7 @@ func (c *Config) LoadAll(configFiles ...string) error {
 	}
 	c.NumberSecrets = uint64(count)
 
-	// Let's link all secrets to their secret-stores
-	return c.LinkSecrets()
+	return nil
 }
 
 type cfgDataOptions struct {
@@ -610,6 +662,9 @@ func (c *Config) LoadConfigData(data []byte, path string) error {
 			if err = c.toml.UnmarshalTable(subTable, c.Tags); err != nil {
 				return fmt.Errorf("error parsing table name %q: %w", tableName, err)
 			}
+			if err := c.addSettingID(tableName, subTable); err != nil {
+				return err
+			}
 		}
 	}
 
@@ -629,6 +684,9 @@ func (c *Config) LoadConfigData(data []byte, path string) error {
 		if err = c.toml.UnmarshalTable(subTable, c.Agent); err != nil {
 			return fmt.Errorf("error parsing [agent]: %w", err)
 		}
+		if err := c.addSettingID("agent", subTable); err != nil {
+			return err
+		}
 	}
 
 	if !c.Agent.OmitHostname {
@@ -656,7 +714,8 @@ func (c *Config) LoadConfigData(data []byte, path string) error {
 	// Set up the persister if requested
 	if c.Agent.Statefile != "" {
 		c.Persister = &persister.Persister{
-			Filename: c.Agent.Statefile,
+			Filename:    c.Agent.Statefile,
+			BackendType: c.Agent.StatefileBackend,
 		}
 	}
 
@@ -671,8 +730,25 @@ func (c *Config) LoadConfigData(data []byte, path string) error {
 	c.fileProcessors = make(OrderedPlugins, 0)
 	c.fileAggProcessors = make(OrderedPlugins, 0)
 
+	// Parse output groups:
+	if val, ok := tbl.Fields["output_groups"]; ok {
+		subTables, ok := val.([]*ast.Table)
+		if !ok {
+			return errors.New("invalid configuration, error parsing output_groups, expected array of tables")
+		}
+		for _, subTable := range subTables {
+			if err := c.addOutputGroup(subTable); err != nil {
+				return fmt.Errorf("error parsing output group: %w", err)
+			}
+		}
+	}
+
 	// Parse all the rest of the plugins:
 	for name, val := range tbl.Fields {
+		if name == "output_groups" {
+			continue
+		}
+
 		subTable, ok := val.(*ast.Table)
 		if !ok {
 			return fmt.Errorf("invalid configuration, error parsing field %q as table", name)
@@ -1009,6 +1085,9 @@ func (c *Config) addSecretStore(name, source string, table *ast.Table) error {
 	if _, found := c.SecretStores[storeID]; found {
 		return fmt.Errorf("duplicate ID %q for secretstore %q", storeID, name)
 	}
+	if err := c.addSettingID("secretstores."+name, table); err != nil {
+		return err
+	}
 	c.SecretStores[storeID] = store
 	if _, found := c.secretStoreSource[name]; !found {
 		c.secretStoreSource[name] = make([]string, 0)
@@ -1017,6 +1096,122 @@ func (c *Config) addSecretStore(name, source string, table *ast.Table) error {
 	return nil
 }
 
+func (c *Config) addOutputGroup(table *ast.Table) error {
+	var settings struct {
+		Name    string   `toml:"name"`
+		Mode    string   `toml:"mode"`
+		Outputs []string `toml:"outputs"`
+		HashTag string   `toml:"hash_tag"`
+	}
+	if err := c.toml.UnmarshalTable(table, &settings); err != nil {
+		return err
+	}
+	if len(c.UnusedFields) > 0 {
+		return fmt.Errorf("line %d: configuration specified the fields %q, but they were not used", table.Line, keys(c.UnusedFields))
+	}
+
+	group := &models.OutputGroupConfig{
+		Name:    settings.Name,
+		Mode:    settings.Mode,
+		Outputs: settings.Outputs,
+		HashTag: settings.HashTag,
+	}
+	if err := group.Check(); err != nil {
+		return fmt.Errorf("line %d: %w", table.Line, err)
+	}
+	for _, g := range c.OutputGroups {
+		if g.Name == group.Name {
+			return fmt.Errorf("duplicate output group %q", group.Name)
+		}
+	}
+
+	if err := c.addSettingID("output_groups."+group.Name, table); err != nil {
+		return err
+	}
+	c.OutputGroups = append(c.OutputGroups, group)
+	return nil
+}
+
+// checkOutputGroups verifies that all outputs referenced by the output groups
+// exist and that each output is member of one group at most
+func (c *Config) checkOutputGroups() error {
+	members := make(map[string]string)
+	for _, group := range c.OutputGroups {
+		for _, alias := range group.Outputs {
+			var count int
+			for _, output := range c.Outputs {
+				if output.Config.Alias == alias {
+					count++
+				}
+			}
+			switch count {
+			case 0:
+				return fmt.Errorf("output group %q references unknown output %q", group.Name, alias)
+			case 1:
+			default:
+				return fmt.Errorf("output group %q references ambiguous output %q used by %d outputs", group.Name, alias, count)
+			}
+
+			if other, found := members[alias]; found {
+				return fmt.Errorf("output %q is member of output groups %q and %q", alias, other, group.Name)
+			}
+			members[alias] = group.Name
+		}
+	}
+	return nil
+}
+
+// checkDeadLetterOutputs verifies that the dead-letter outputs exist and are
+// neither member of an output group nor forward metrics to a dead-letter
+// output themselves
+func (c *Config) checkDeadLetterOutputs() error {
+	for _, output := range c.Outputs {
+		alias := output.Config.DeadLetterOutput
+		if alias == "" {
+			continue
+		}
+
+		var targets []*models.RunningOutput
+		for _, o := range c.Outputs {
+			if o.Config.Alias == alias {
+				targets = append(targets, o)
+			}
+		}
+		switch len(targets) {
+		case 0:
+			return fmt.Errorf("%s references unknown dead-letter output %q", output.LogName(), alias)
+		case 1:
+		default:
+			return fmt.Errorf("%s references ambiguous dead-letter output %q used by %d outputs", output.LogName(), alias, len(targets))
+		}
+
+		target := targets[0]
+		if target == output {
+			return fmt.Errorf("%s uses itself as dead-letter output", output.LogName())
+		}
+		if target.Config.DeadLetterOutput != "" {
+			return fmt.Errorf("dead-letter output %s must not use a dead-letter output itself", target.LogName())
+		}
+		for _, group := range c.OutputGroups {
+			if slices.Contains(group.Outputs, alias) {
+				return fmt.Errorf("dead-letter output %s must not be member of output group %q", target.LogName(), group.Name)
+			}
+		}
+	}
+	return nil
+}
+
+// addSettingID records the ID of a non-plugin table to be able to detect
+// changes of the table when diffing configurations
+func (c *Config) addSettingID(prefix string, table *ast.Table) error {
+	id, err := generatePluginID(prefix, table)
+	if err != nil {
+		return fmt.Errorf("generating ID for %q failed: %w", prefix, err)
+	}
+	c.settingIDs = append(c.settingIDs, id)
+	return nil
+}
+
 func (c *Config) LinkSecrets() error {
 	for _, s := range unlinkedSecrets {
 		resolvers := make(map[string]telegraf.ResolveFunc)
@@ -1658,6 +1853,8 @@ func (c *Config) buildOutput(name, source string, tbl *ast.Table) (*models.Outpu
 		Filter:          filter,
 		BufferStrategy:  c.Agent.BufferStrategy,
 		BufferDirectory: c.Agent.BufferDirectory,
+		BufferMaxBytes:  int64(c.Agent.BufferMaxBytes),
+		BufferMaxAge:    time.Duration(c.Agent.BufferMaxAge),
 	}
 
 	// TODO: support FieldPass/FieldDrop on outputs
@@ -1672,12 +1869,34 @@ func (c *Config) buildOutput(name, source string, tbl *ast.Table) (*models.Outpu
 	oc.NamePrefix = c.getFieldString(tbl, "name_prefix")
 	oc.StartupErrorBehavior = c.getFieldString(tbl, "startup_error_behavior")
 	oc.LogLevel = c.getFieldString(tbl, "log_level")
+	if maxBytes, found := c.getFieldSize(tbl, "buffer_max_bytes"); found {
+		oc.BufferMaxBytes = maxBytes
+	}
+	if maxAge, found := c.getFieldDuration(tbl, "buffer_max_age"); found {
+		oc.BufferMaxAge = maxAge
+	}
+	oc.AdaptiveBatching = c.getFieldBool(tbl, "adaptive_batching")
+	oc.AdaptiveBatchSizeMin = c.getFieldInt(tbl, "adaptive_batch_size_min")
+	oc.AdaptiveBatchSizeMax = c.getFieldInt(tbl, "adaptive_batch_size_max")
+	oc.AdaptiveTargetLatency, _ = c.getFieldDuration(tbl, "adaptive_target_latency")
+	oc.MaxConcurrentWrites = c.getFieldInt(tbl, "max_concurrent_writes")
+	oc.Retry.InitialInterval, _ = c.getFieldDuration(tbl, "retry_initial_interval")
+	oc.Retry.MaxInterval, _ = c.getFieldDuration(tbl, "retry_max_interval")
+	oc.Retry.Multiplier, _ = c.getFieldFloat(tbl, "retry_multiplier")
+	oc.Retry.Jitter = models.DefaultRetryJitter
+	if jitter, found := c.getFieldFloat(tbl, "retry_jitter"); found {
+		oc.Retry.Jitter = jitter
+	}
+	oc.Retry.MaxAttempts = c.getFieldInt(tbl, "retry_max_attempts")
+	oc.Retry.CircuitBreakerThreshold = c.getFieldInt(tbl, "circuit_breaker_threshold")
+	oc.Retry.CircuitBreakerTimeout, _ = c.getFieldDuration(tbl, "circuit_breaker_timeout")
+	oc.DeadLetterOutput = c.getFieldString(tbl, "dead_letter_output")
 
 	if c.hasErrs() {
 		return nil, c.firstErr()
 	}
 
-	if oc.BufferStrategy == "disk" {
+	if oc.BufferStrategy == "disk" || oc.BufferStrategy == "segmented" {
 		log.Printf("W! Using disk buffer strategy for plugin outputs.%s, this is an experimental feature", name)
 	}
 
@@ -1689,18 +1908,20 @@ func (c *Config) buildOutput(name, source string, tbl *ast.Table) (*models.Outpu
 func (c *Config) missingTomlField(_ reflect.Type, key string) error {
 	switch key {
 	// General options to ignore
-	case "alias", "always_include_local_tags",
-		"buffer_strategy", "buffer_directory",
-		"collection_jitter", "collection_offset",
-		"data_format", "delay", "drop", "drop_original",
+	case "adaptive_batching", "adaptive_batch_size_max", "adaptive_batch_size_min", "adaptive_target_latency",
+		"alias", "always_include_local_tags",
+		"buffer_strategy", "buffer_directory", "buffer_max_age", "buffer_max_bytes",
+		"circuit_breaker_threshold", "circuit_breaker_timeout", "collection_jitter", "collection_offset",
+		"data_format", "dead_letter_output", "delay", "drop", "drop_original",
 		"fielddrop", "fieldexclude", "fieldinclude", "fieldpass", "flush_interval", "flush_jitter",
 		"grace",
 		"interval",
 		"log_level", "lvm", // What is this used for?
-		"metric_batch_size", "metric_buffer_limit", "metricpass",
+		"max_concurrent_writes", "metric_batch_size", "metric_buffer_limit", "metricpass",
 		"name_override", "name_prefix", "name_suffix", "namedrop", "namedrop_separator", "namepass", "namepass_separator",
 		"order",
 		"pass", "period", "precision",
+		"retry_initial_interval", "retry_jitter", "retry_max_attempts", "retry_max_interval", "retry_multiplier",
 		"tagdrop", "tagexclude", "taginclude", "tagpass", "tags", "startup_error_behavior":
 
 	// Secret-store options to ignore
@@ -1780,6 +2001,32 @@ func (c *Config) getFieldDuration(tbl *ast.Table, fieldName string) (time.Durati
 	return 0, false
 }
 
+func (c *Config) getFieldSize(tbl *ast.Table, fieldName string) (int64, bool) {
+	if node, ok := tbl.Fields[fieldName]; ok {
+		if kv, ok := node.(*ast.KeyValue); ok {
+			var raw string
+			switch v := kv.Value.(type) {
+			case *ast.String:
+				raw = v.Value
+			case *ast.Integer:
+				raw = v.Value
+			default:
+				c.addError(tbl, fmt.Errorf("found unexpected format while parsing %q, expecting size", fieldName))
+				return 0, false
+			}
+
+			var size Size
+			if err := size.UnmarshalText([]byte(raw)); err != nil {
+				c.addError(tbl, fmt.Errorf("error parsing size: %w", err))
+				return 0, false
+			}
+			return int64(size), true
+		}
+	}
+
+	return 0, false
+}
+
 func (c *Config) getFieldBool(tbl *ast.Table, fieldName string) bool {
 	if node, ok := tbl.Fields[fieldName]; ok {
 		if kv, ok := node.(*ast.KeyValue); ok {
@@ -1808,6 +2055,34 @@ func (c *Config) getFieldBool(tbl *ast.Table, fieldName string) bool {
 	return false
 }
 
+func (c *Config) getFieldFloat(tbl *ast.Table, fieldName string) (float64, bool) {
+	if node, ok := tbl.Fields[fieldName]; ok {
+		if kv, ok := node.(*ast.KeyValue); ok {
+			switch v := kv.Value.(type) {
+			case *ast.Float:
+				f, err := v.Float()
+				if err != nil {
+					c.addError(tbl, fmt.Errorf("unexpected float type %q, expecting float", v.Value))
+					return 0, false
+				}
+				return f, true
+			case *ast.Integer:
+				i, err := v.Int()
+				if err != nil {
+					c.addError(tbl, fmt.Errorf("unexpected int type %q, expecting float", v.Value))
+					return 0, false
+				}
+				return float64(i), true
+			default:
+				c.addError(tbl, fmt.Errorf("found unexpected format while parsing %q, expecting float", fieldName))
+				return 0, false
+			}
+		}
+	}
+
+	return 0, false
+}
+
 func (c *Config) getFieldInt(tbl *ast.Table, fieldName string) int {
 	if node, ok := tbl.Fields[fieldName]; ok {
 		if kv, ok := node.(*ast.KeyValue); ok {
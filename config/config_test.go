@@ -1091,6 +1091,382 @@ func TestConfigPluginIDsSame(t *testing.T) {
 	}
 }
 
+func TestConfigDiff(t *testing.T) {
+	previous := []byte(`
+[[inputs.memcached]]
+  servers = ["localhost"]
+[[inputs.memcached]]
+  servers = ["remote"]
+[[processors.processor]]
+  option = "foo"
+[[outputs.http]]
+  url = "http://localhost"
+[[outputs.http]]
+  url = "http://remote"
+`)
+	current := []byte(`
+[[inputs.memcached]]
+  servers = ["localhost"]
+[[inputs.memcached]]
+  servers = ["other"]
+[[processors.processor]]
+  option = "foo"
+[[outputs.http]]
+  url = "http://localhost"
+`)
+
+	old := config.NewConfig()
+	require.NoError(t, old.LoadConfigData(previous, config.EmptySourcePath))
+	c := config.NewConfig()
+	require.NoError(t, c.LoadConfigData(current, config.EmptySourcePath))
+
+	diff := c.Diff(old)
+	require.False(t, diff.Empty())
+	require.False(t, diff.SettingsChanged)
+	require.False(t, diff.ProcessingChanged)
+	require.Equal(t, []*models.RunningInput{c.Inputs[1]}, diff.Added.Inputs)
+	require.Equal(t, []*models.RunningInput{old.Inputs[1]}, diff.Removed.Inputs)
+	require.Empty(t, diff.Added.Outputs)
+	require.Equal(t, []*models.RunningOutput{old.Outputs[1]}, diff.Removed.Outputs)
+
+	// Unchanged plugins must be taken from the old config
+	c.ReuseUnchanged(old)
+	require.Same(t, old.Inputs[0], c.Inputs[0])
+	require.NotSame(t, old.Inputs[1], c.Inputs[1])
+	require.Same(t, old.Processors[0], c.Processors[0])
+	require.Same(t, old.AggProcessors[0], c.AggProcessors[0])
+	require.Same(t, old.Outputs[0], c.Outputs[0])
+
+	// Comparing with itself should not result in any difference
+	require.True(t, c.Diff(c).Empty())
+}
+
+func TestConfigDiffProcessing(t *testing.T) {
+	previous := []byte(`
+[[inputs.memcached]]
+[[processors.processor]]
+  option = "foo"
+[[processors.processor]]
+  option = "bar"
+[[outputs.http]]
+`)
+	current := []byte(`
+[[inputs.memcached]]
+[[processors.processor]]
+  option = "bar"
+[[processors.processor]]
+  option = "foo"
+[[outputs.http]]
+`)
+
+	old := config.NewConfig()
+	require.NoError(t, old.LoadConfigData(previous, config.EmptySourcePath))
+	c := config.NewConfig()
+	require.NoError(t, c.LoadConfigData(current, config.EmptySourcePath))
+
+	// Reordering the processors must restart the processors
+	diff := c.Diff(old)
+	require.True(t, diff.ProcessingChanged)
+	require.True(t, diff.Added.Empty())
+	require.True(t, diff.Removed.Empty())
+}
+
+func TestConfigDiffSettings(t *testing.T) {
+	previous := []byte(`
+[agent]
+  interval = "10s"
+[[inputs.memcached]]
+[[outputs.http]]
+`)
+	current := []byte(`
+[agent]
+  interval = "20s"
+[[inputs.memcached]]
+[[outputs.http]]
+`)
+
+	old := config.NewConfig()
+	require.NoError(t, old.LoadConfigData(previous, config.EmptySourcePath))
+	c := config.NewConfig()
+	require.NoError(t, c.LoadConfigData(current, config.EmptySourcePath))
+
+	diff := c.Diff(old)
+	require.True(t, diff.SettingsChanged)
+	require.True(t, diff.Added.Empty())
+	require.True(t, diff.Removed.Empty())
+}
+
+func TestConfig_OutputGroups(t *testing.T) {
+	cfg := []byte(`
+[[outputs.http]]
+  alias = "primary"
+[[outputs.http]]
+  alias = "secondary"
+[[outputs.http]]
+  alias = "shard1"
+[[outputs.http]]
+  alias = "shard2"
+
+[[output_groups]]
+  name = "failover"
+  mode = "failover"
+  outputs = ["primary", "secondary"]
+
+[[output_groups]]
+  name = "shards"
+  mode = "hash"
+  hash_tag = "host"
+  outputs = ["shard1", "shard2"]
+`)
+	fn := filepath.Join(t.TempDir(), "telegraf.conf")
+	require.NoError(t, os.WriteFile(fn, cfg, 0600))
+
+	c := config.NewConfig()
+	require.NoError(t, c.LoadAll(fn))
+
+	expected := []*models.OutputGroupConfig{
+		{Name: "failover", Mode: "failover", Outputs: []string{"primary", "secondary"}},
+		{Name: "shards", Mode: "hash", Outputs: []string{"shard1", "shard2"}, HashTag: "host"},
+	}
+	require.Equal(t, expected, c.OutputGroups)
+}
+
+func TestConfig_OutputGroupsInvalid(t *testing.T) {
+	tests := []struct {
+		name     string
+		cfg      string
+		expected string
+	}{
+		{
+			name: "unknown output",
+			cfg: `
+[[outputs.http]]
+  alias = "primary"
+[[output_groups]]
+  name = "group"
+  mode = "failover"
+  outputs = ["primary", "secondary"]
+`,
+			expected: `output group "group" references unknown output "secondary"`,
+		},
+		{
+			name: "ambiguous output",
+			cfg: `
+[[outputs.http]]
+  alias = "primary"
+[[outputs.http]]
+  alias = "primary"
+[[output_groups]]
+  name = "group"
+  mode = "round_robin"
+  outputs = ["primary"]
+`,
+			expected: `output group "group" references ambiguous output "primary" used by 2 outputs`,
+		},
+		{
+			name: "output in multiple groups",
+			cfg: `
+[[outputs.http]]
+  alias = "primary"
+[[output_groups]]
+  name = "a"
+  mode = "failover"
+  outputs = ["primary"]
+[[output_groups]]
+  name = "b"
+  mode = "failover"
+  outputs = ["primary"]
+`,
+			expected: `output "primary" is member of output groups "a" and "b"`,
+		},
+		{
+			name: "duplicate group",
+			cfg: `
+[[output_groups]]
+  name = "a"
+  mode = "failover"
+  outputs = ["primary"]
+[[output_groups]]
+  name = "a"
+  mode = "failover"
+  outputs = ["secondary"]
+`,
+			expected: `duplicate output group "a"`,
+		},
+		{
+			name: "missing hash tag",
+			cfg: `
+[[output_groups]]
+  name = "a"
+  mode = "hash"
+  outputs = ["primary"]
+`,
+			expected: `'hash_tag' required in mode "hash"`,
+		},
+		{
+			name: "unknown field",
+			cfg: `
+[[output_groups]]
+  name = "a"
+  mode = "failover"
+  outputs = ["primary"]
+  foo = "bar"
+`,
+			expected: `configuration specified the fields ["foo"], but they were not used`,
+		},
+		{
+			name: "no array",
+			cfg: `
+[output_groups]
+  name = "a"
+`,
+			expected: "expected array of tables",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fn := filepath.Join(t.TempDir(), "telegraf.conf")
+			require.NoError(t, os.WriteFile(fn, []byte(tt.cfg), 0600))
+
+			c := config.NewConfig()
+			require.ErrorContains(t, c.LoadAll(fn), tt.expected)
+		})
+	}
+}
+
+func TestConfig_OutputRetryPolicy(t *testing.T) {
+	cfg := []byte(`
+[[outputs.http]]
+  retry_initial_interval = "1s"
+  retry_max_interval = "1m"
+  retry_multiplier = 1.5
+  retry_max_attempts = 10
+  circuit_breaker_threshold = 5
+  circuit_breaker_timeout = "10m"
+  dead_letter_output = "dlq"
+
+[[outputs.http]]
+  alias = "dlq"
+  retry_jitter = 0
+`)
+	fn := filepath.Join(t.TempDir(), "telegraf.conf")
+	require.NoError(t, os.WriteFile(fn, cfg, 0600))
+
+	c := config.NewConfig()
+	require.NoError(t, c.LoadAll(fn))
+	require.Len(t, c.Outputs, 2)
+
+	expected := models.RetryConfig{
+		InitialInterval:         time.Second,
+		MaxInterval:             time.Minute,
+		Multiplier:              1.5,
+		Jitter:                  models.DefaultRetryJitter,
+		MaxAttempts:             10,
+		CircuitBreakerThreshold: 5,
+		CircuitBreakerTimeout:   10 * time.Minute,
+	}
+	require.Equal(t, expected, c.Outputs[0].Config.Retry)
+	require.Equal(t, "dlq", c.Outputs[0].Config.DeadLetterOutput)
+	require.Equal(t, models.RetryConfig{}, c.Outputs[1].Config.Retry)
+}
+
+func TestConfig_DeadLetterOutputInvalid(t *testing.T) {
+	tests := []struct {
+		name     string
+		cfg      string
+		expected string
+	}{
+		{
+			name: "unknown output",
+			cfg: `
+[[outputs.http]]
+  dead_letter_output = "dlq"
+`,
+			expected: `outputs.http references unknown dead-letter output "dlq"`,
+		},
+		{
+			name: "self reference",
+			cfg: `
+[[outputs.http]]
+  alias = "dlq"
+  dead_letter_output = "dlq"
+`,
+			expected: "outputs.http::dlq uses itself as dead-letter output",
+		},
+		{
+			name: "chained",
+			cfg: `
+[[outputs.http]]
+  dead_letter_output = "dlq"
+[[outputs.http]]
+  alias = "dlq"
+  dead_letter_output = "other"
+[[outputs.http]]
+  alias = "other"
+`,
+			expected: "dead-letter output outputs.http::dlq must not use a dead-letter output itself",
+		},
+		{
+			name: "group member",
+			cfg: `
+[[outputs.http]]
+  dead_letter_output = "dlq"
+[[outputs.http]]
+  alias = "dlq"
+[[output_groups]]
+  name = "group"
+  mode = "round_robin"
+  outputs = ["dlq"]
+`,
+			expected: `dead-letter output outputs.http::dlq must not be member of output group "group"`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fn := filepath.Join(t.TempDir(), "telegraf.conf")
+			require.NoError(t, os.WriteFile(fn, []byte(tt.cfg), 0600))
+
+			c := config.NewConfig()
+			require.ErrorContains(t, c.LoadAll(fn), tt.expected)
+		})
+	}
+}
+
+func TestConfigDiffOutputGroups(t *testing.T) {
+	previous := []byte(`
+[[outputs.http]]
+  alias = "primary"
+[[outputs.http]]
+  alias = "secondary"
+[[output_groups]]
+  name = "group"
+  mode = "failover"
+  outputs = ["primary", "secondary"]
+`)
+	current := []byte(`
+[[outputs.http]]
+  alias = "primary"
+[[outputs.http]]
+  alias = "secondary"
+[[output_groups]]
+  name = "group"
+  mode = "round_robin"
+  outputs = ["primary", "secondary"]
+`)
+
+	old := config.NewConfig()
+	require.NoError(t, old.LoadConfigData(previous, config.EmptySourcePath))
+	c := config.NewConfig()
+	require.NoError(t, c.LoadConfigData(current, config.EmptySourcePath))
+
+	diff := c.Diff(old)
+	require.True(t, diff.SettingsChanged)
+	require.True(t, diff.Added.Empty())
+	require.True(t, diff.Removed.Empty())
+}
+
 func TestPersisterInputStoreLoad(t *testing.T) {
 	// Reserve a temporary state file
 	file, err := os.CreateTemp(t.TempDir(), "telegraf_state-*.json")
@@ -0,0 +1,152 @@
+package config
+
+import (
+	"slices"
+
+	"github.com/influxdata/telegraf/models"
+)
+
+// PluginSet is a collection of plugin instances of all plugin types.
+type PluginSet struct {
+	Inputs        []*models.RunningInput
+	Processors    models.RunningProcessors
+	AggProcessors models.RunningProcessors
+	Aggregators   []*models.RunningAggregator
+	Outputs       []*models.RunningOutput
+}
+
+// Empty returns true if the set does not contain any plugin.
+func (s *PluginSet) Empty() bool {
+	return len(s.Inputs) == 0 &&
+		len(s.Processors) == 0 &&
+		len(s.AggProcessors) == 0 &&
+		len(s.Aggregators) == 0 &&
+		len(s.Outputs) == 0
+}
+
+// Diff describes the changes between two configurations. Plugins are matched
+// by their plugin ID which is derived from the plugin's configuration table,
+// so a plugin with modified settings shows up as removed and added plugin.
+type Diff struct {
+	// SettingsChanged is set if the agent settings, the global tags, the
+	// secret-stores or the output groups differ which requires restarting all
+	// plugins.
+	SettingsChanged bool
+
+	// ProcessingChanged is set if processors or aggregators were added,
+	// removed or changed their order.
+	ProcessingChanged bool
+
+	// Added contains the plugins only existing in the new configuration
+	Added PluginSet
+
+	// Removed contains the plugins only existing in the old configuration
+	Removed PluginSet
+}
+
+// Empty returns true if both configurations are equivalent.
+func (d *Diff) Empty() bool {
+	return !d.SettingsChanged && !d.ProcessingChanged && d.Added.Empty() && d.Removed.Empty()
+}
+
+// Diff compares the configuration with the given, previously loaded
+// configuration.
+func (c *Config) Diff(old *Config) *Diff {
+	d := &Diff{
+		SettingsChanged: !equalSettings(c.settingIDs, old.settingIDs),
+	}
+
+	d.Added.Inputs, d.Removed.Inputs, _ = diffPlugins(old.Inputs, c.Inputs, inputID)
+	d.Added.Outputs, d.Removed.Outputs, _ = diffPlugins(old.Outputs, c.Outputs, outputID)
+
+	var reordered [3]bool
+	d.Added.Processors, d.Removed.Processors, reordered[0] = diffPlugins(old.Processors, c.Processors, processorID)
+	d.Added.AggProcessors, d.Removed.AggProcessors, reordered[1] = diffPlugins(old.AggProcessors, c.AggProcessors, processorID)
+	d.Added.Aggregators, d.Removed.Aggregators, reordered[2] = diffPlugins(old.Aggregators, c.Aggregators, aggregatorID)
+	d.ProcessingChanged = slices.Contains(reordered[:], true) ||
+		len(d.Added.Processors) > 0 || len(d.Removed.Processors) > 0 ||
+		len(d.Added.AggProcessors) > 0 || len(d.Removed.AggProcessors) > 0 ||
+		len(d.Added.Aggregators) > 0 || len(d.Removed.Aggregators) > 0
+
+	return d
+}
+
+// ReuseUnchanged replaces the plugin instances of the configuration with the
+// identically configured instances of the given, previously loaded
+// configuration. This way, running plugins keep their internal state such as
+// buffered metrics.
+func (c *Config) ReuseUnchanged(old *Config) {
+	reusePlugins(old.Inputs, c.Inputs, inputID)
+	reusePlugins(old.Processors, c.Processors, processorID)
+	reusePlugins(old.AggProcessors, c.AggProcessors, processorID)
+	reusePlugins(old.Aggregators, c.Aggregators, aggregatorID)
+	reusePlugins(old.Outputs, c.Outputs, outputID)
+}
+
+func inputID(p *models.RunningInput) string           { return p.Config.ID }
+func processorID(p *models.RunningProcessor) string   { return p.Config.ID }
+func aggregatorID(p *models.RunningAggregator) string { return p.Config.ID }
+func outputID(p *models.RunningOutput) string         { return p.Config.ID }
+
+func equalSettings(a, b []string) bool {
+	a = slices.Clone(a)
+	b = slices.Clone(b)
+	slices.Sort(a)
+	slices.Sort(b)
+	return slices.Equal(a, b)
+}
+
+// matchPlugins pairs the plugins of the new configuration with identically
+// configured plugins of the old configuration. The returned slice contains
+// the index of the matching old plugin, or -1 if there is no match, for each
+// new plugin. Plugins with the same ID are paired in order of appearance.
+func matchPlugins[T any](oldPlugins, newPlugins []T, id func(T) string) []int {
+	available := make(map[string][]int, len(oldPlugins))
+	for i, p := range oldPlugins {
+		available[id(p)] = append(available[id(p)], i)
+	}
+
+	matches := make([]int, 0, len(newPlugins))
+	for _, p := range newPlugins {
+		candidates := available[id(p)]
+		if len(candidates) == 0 {
+			matches = append(matches, -1)
+			continue
+		}
+		matches = append(matches, candidates[0])
+		available[id(p)] = candidates[1:]
+	}
+	return matches
+}
+
+func diffPlugins[T any](oldPlugins, newPlugins []T, id func(T) string) (added, removed []T, reordered bool) {
+	matched := make([]bool, len(oldPlugins))
+	last := -1
+	for i, idx := range matchPlugins(oldPlugins, newPlugins, id) {
+		if idx < 0 {
+			added = append(added, newPlugins[i])
+			continue
+		}
+		matched[idx] = true
+		if idx < last {
+			reordered = true
+		}
+		last = idx
+	}
+
+	for i, p := range oldPlugins {
+		if !matched[i] {
+			removed = append(removed, p)
+		}
+	}
+
+	return added, removed, reordered
+}
+
+func reusePlugins[T any](oldPlugins, newPlugins []T, id func(T) string) {
+	for i, idx := range matchPlugins(oldPlugins, newPlugins, id) {
+		if idx >= 0 {
+			newPlugins[i] = oldPlugins[idx]
+		}
+	}
+}
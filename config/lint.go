@@ -0,0 +1,368 @@
+package config
+
+import (
+	"fmt"
+	"reflect"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/filter"
+	"github.com/influxdata/telegraf/metric"
+	"github.com/influxdata/telegraf/models"
+	"github.com/influxdata/telegraf/plugins/processors"
+)
+
+// Severity levels of lint findings
+const (
+	LintLevelError   = "error"
+	LintLevelWarning = "warning"
+)
+
+// Identifiers of the lint rules
+const (
+	LintRuleUnusedPattern      = "unused-filter-pattern"
+	LintRuleOrderCollision     = "processor-order-collision"
+	LintRuleAggregatorPeriod   = "aggregator-period"
+	LintRuleUnknownSecretStore = "unknown-secret-store"
+	LintRuleDeprecatedPlugin   = "deprecated-plugin"
+	LintRuleDeprecatedOption   = "deprecated-option"
+)
+
+// LintRules contains a short description for each of the lint rules
+var LintRules = map[string]string{
+	LintRuleUnusedPattern:      "namepass or tagpass pattern that never selects a metric",
+	LintRuleOrderCollision:     "processors sharing the same order",
+	LintRuleAggregatorPeriod:   "aggregator period shorter than the interval of an input",
+	LintRuleUnknownSecretStore: "secret referencing an unknown secret-store",
+	LintRuleDeprecatedPlugin:   "deprecated plugin",
+	LintRuleDeprecatedOption:   "deprecated plugin option",
+}
+
+// LintDeprecation contains the deprecation data of a plugin or option
+type LintDeprecation struct {
+	Since     string `json:"since"`
+	RemovalIn string `json:"removal_in,omitempty"`
+	Notice    string `json:"notice,omitempty"`
+}
+
+// LintFinding describes a single issue found in the configuration
+type LintFinding struct {
+	Rule        string           `json:"rule"`
+	Level       string           `json:"level"`
+	Plugin      string           `json:"plugin"`
+	Option      string           `json:"option,omitempty"`
+	Source      string           `json:"source,omitempty"`
+	Message     string           `json:"message"`
+	Deprecation *LintDeprecation `json:"deprecation,omitempty"`
+}
+
+// Lint loads the given configuration files and checks the configuration for
+// issues that do not prevent Telegraf from starting but are likely mistakes.
+// In contrast to LoadAll, references to unknown secret-stores are reported as
+// finding instead of failing the loading.
+func (c *Config) Lint(configFiles ...string) ([]LintFinding, error) {
+	// The secrets of the linted configuration are never linked, so forget
+	// about them to not break linking of subsequently loaded configurations.
+	count := len(unlinkedSecrets)
+	defer func() { unlinkedSecrets = unlinkedSecrets[:count] }()
+
+	if err := c.loadAll(configFiles...); err != nil {
+		return nil, err
+	}
+
+	findings := make([]LintFinding, 0)
+	for _, input := range c.Inputs {
+		findings = append(findings, lintFilter(input.LogName(), input.Config.Source, &input.Config.Filter)...)
+		findings = append(findings, c.lintPlugin("inputs", input.Config.Name, input.LogName(), input.Config.Source, input.Input)...)
+	}
+	for _, processor := range c.Processors {
+		var plugin interface{} = processor.Processor
+		if p, ok := processor.Processor.(processors.HasUnwrap); ok {
+			plugin = p.Unwrap()
+		}
+		findings = append(findings, lintFilter(processor.LogName(), processor.Config.Source, &processor.Config.Filter)...)
+		findings = append(findings, c.lintPlugin("processors", processor.Config.Name, processor.LogName(), processor.Config.Source, plugin)...)
+	}
+	for _, aggregator := range c.Aggregators {
+		findings = append(findings, lintFilter(aggregator.LogName(), aggregator.Config.Source, &aggregator.Config.Filter)...)
+		findings = append(findings, c.lintPlugin("aggregators", aggregator.Config.Name, aggregator.LogName(), aggregator.Config.Source, aggregator.Aggregator)...)
+	}
+	for _, output := range c.Outputs {
+		findings = append(findings, lintFilter(output.LogName(), output.Config.Source, &output.Config.Filter)...)
+		findings = append(findings, c.lintPlugin("outputs", output.Config.Name, output.LogName(), output.Config.Source, output.Output)...)
+	}
+	findings = append(findings, c.lintProcessorOrder()...)
+	findings = append(findings, c.lintAggregatorPeriods()...)
+
+	return findings, nil
+}
+
+// lintPlugin checks the plugin instance for deprecated settings and for
+// secrets referencing unknown secret-stores.
+func (c *Config) lintPlugin(category, name, logName, source string, plugin interface{}) []LintFinding {
+	var findings []LintFinding
+
+	// Collect the deprecation information using a scratch configuration to
+	// not count the deprecations twice.
+	scratch := &Config{Deprecations: make(map[string][]int64)}
+	info := scratch.collectDeprecationInfo(category, name, plugin, false)
+	if info.logLevel != telegraf.None {
+		findings = append(findings, LintFinding{
+			Rule:        LintRuleDeprecatedPlugin,
+			Level:       deprecationLintLevel(info.logLevel),
+			Plugin:      logName,
+			Source:      source,
+			Message:     deprecationLintMessage("plugin "+info.Name, info.info),
+			Deprecation: newLintDeprecation(info.info),
+		})
+	}
+	for _, option := range info.Options {
+		if option.logLevel == telegraf.None {
+			continue
+		}
+		findings = append(findings, LintFinding{
+			Rule:        LintRuleDeprecatedOption,
+			Level:       deprecationLintLevel(option.logLevel),
+			Plugin:      logName,
+			Option:      option.Name,
+			Source:      source,
+			Message:     deprecationLintMessage(fmt.Sprintf("option %q", option.Name), option.info),
+			Deprecation: newLintDeprecation(option.info),
+		})
+	}
+
+	// Check all secrets for references to unknown stores
+	reported := make(map[string]bool)
+	walkPluginStruct(reflect.ValueOf(plugin), func(field reflect.StructField, value reflect.Value) {
+		var refs []string
+		switch s := value.Interface().(type) {
+		case Secret:
+			refs = s.GetUnlinked()
+		case *Secret:
+			if s != nil {
+				refs = s.GetUnlinked()
+			}
+		default:
+			return
+		}
+
+		option := field.Tag.Get("toml")
+		if option == "" {
+			option = field.Name
+		}
+		for _, ref := range refs {
+			storeID, _ := splitLink(ref)
+			if _, found := c.SecretStores[storeID]; found || reported[ref] {
+				continue
+			}
+			reported[ref] = true
+			findings = append(findings, LintFinding{
+				Rule:    LintRuleUnknownSecretStore,
+				Level:   LintLevelError,
+				Plugin:  logName,
+				Option:  option,
+				Source:  source,
+				Message: fmt.Sprintf("secret %q references unknown secret-store %q", ref, storeID),
+			})
+		}
+	})
+
+	return findings
+}
+
+// lintProcessorOrder reports processors sharing an explicitly set order as
+// their relative order then depends on the loading order of the files.
+func (c *Config) lintProcessorOrder() []LintFinding {
+	var findings []LintFinding
+
+	seen := make(map[int64]*models.RunningProcessor)
+	for _, processor := range c.Processors {
+		order := processor.Config.Order
+		if order == 0 {
+			continue
+		}
+		first, found := seen[order]
+		if !found {
+			seen[order] = processor
+			continue
+		}
+		findings = append(findings, LintFinding{
+			Rule:    LintRuleOrderCollision,
+			Level:   LintLevelWarning,
+			Plugin:  processor.LogName(),
+			Option:  "order",
+			Source:  processor.Config.Source,
+			Message: fmt.Sprintf("order %d is also used by %s", order, first.LogName()),
+		})
+	}
+
+	return findings
+}
+
+// lintAggregatorPeriods reports aggregators with a period shorter than the
+// interval of inputs as those aggregation windows will be empty or contain
+// a single metric. For aggregators selecting metrics, only inputs with a known
+// measurement name selected by the aggregator are checked to avoid reporting
+// inputs not contributing to the aggregation.
+func (c *Config) lintAggregatorPeriods() []LintFinding {
+	var findings []LintFinding
+
+	for _, aggregator := range c.Aggregators {
+		f := &aggregator.Config.Filter
+		// Tags and the metric content are unknown before gathering
+		if len(f.TagPassFilters) > 0 || len(f.TagDropFilters) > 0 || f.MetricPass != "" {
+			continue
+		}
+		filtered := len(f.NamePass) > 0 || len(f.NameDrop) > 0
+
+		period := aggregator.Config.Period
+		var offending []string
+		for _, input := range c.Inputs {
+			if filtered && !aggregatorSelectsInput(f, input) {
+				continue
+			}
+			interval := input.Config.Interval
+			if interval == 0 {
+				interval = time.Duration(c.Agent.Interval)
+			}
+			if period < interval {
+				offending = append(offending, fmt.Sprintf("%s (%s)", input.LogName(), interval))
+			}
+		}
+		if len(offending) == 0 {
+			continue
+		}
+		findings = append(findings, LintFinding{
+			Rule:   LintRuleAggregatorPeriod,
+			Level:  LintLevelWarning,
+			Plugin: aggregator.LogName(),
+			Option: "period",
+			Source: aggregator.Config.Source,
+			Message: fmt.Sprintf("period %s is shorter than the interval of %s",
+				period, strings.Join(offending, ", ")),
+		})
+	}
+
+	return findings
+}
+
+// aggregatorSelectsInput returns true if the input overrides the measurement
+// name and the resulting name passes the name filters of the aggregator.
+// Inputs producing measurements not known in advance are never selected.
+func aggregatorSelectsInput(f *models.Filter, input *models.RunningInput) bool {
+	if input.Config.NameOverride == "" {
+		return false
+	}
+	name := input.Config.MeasurementPrefix + input.Config.NameOverride + input.Config.MeasurementSuffix
+	m := metric.New(name, nil, map[string]interface{}{"value": 1}, time.Time{})
+	selected, err := f.Select(m)
+	return err == nil && selected
+}
+
+// lintFilter reports namepass and tagpass patterns never selecting a metric
+// because they are duplicates, are covered by another pattern of the same
+// list or are dropped by the corresponding drop filter.
+func lintFilter(logName, source string, f *models.Filter) []LintFinding {
+	var findings []LintFinding
+
+	for _, issue := range unusedPatterns(f.NamePass, f.NamePassSeparators, f.NameDrop, f.NameDropSeparators, "namedrop") {
+		findings = append(findings, LintFinding{
+			Rule:    LintRuleUnusedPattern,
+			Level:   LintLevelWarning,
+			Plugin:  logName,
+			Option:  "namepass",
+			Source:  source,
+			Message: issue,
+		})
+	}
+
+	for _, pass := range f.TagPassFilters {
+		var drop []string
+		for _, tf := range f.TagDropFilters {
+			if tf.Name == pass.Name {
+				drop = append(drop, tf.Values...)
+			}
+		}
+		for _, issue := range unusedPatterns(pass.Values, "", drop, "", "tagdrop") {
+			findings = append(findings, LintFinding{
+				Rule:    LintRuleUnusedPattern,
+				Level:   LintLevelWarning,
+				Plugin:  logName,
+				Option:  "tagpass." + pass.Name,
+				Source:  source,
+				Message: issue,
+			})
+		}
+	}
+
+	return findings
+}
+
+func unusedPatterns(pass []string, passSeparators string, drop []string, dropSeparators, dropOption string) []string {
+	var issues []string
+
+	for i, p := range pass {
+		if slices.Contains(pass[:i], p) {
+			issues = append(issues, fmt.Sprintf("pattern %q is listed multiple times", p))
+			continue
+		}
+		if idx := slices.IndexFunc(pass, func(q string) bool {
+			return q != p && patternCovers(q, p, passSeparators)
+		}); idx >= 0 {
+			issues = append(issues, fmt.Sprintf("pattern %q is already matched by pattern %q", p, pass[idx]))
+			continue
+		}
+		if idx := slices.IndexFunc(drop, func(q string) bool {
+			return patternCovers(q, p, dropSeparators)
+		}); idx >= 0 {
+			issues = append(issues, fmt.Sprintf("pattern %q is dropped by %s pattern %q", p, dropOption, drop[idx]))
+		}
+	}
+
+	return issues
+}
+
+// patternCovers returns true if every string matched by pattern p is also
+// matched by pattern q. The check is conservative and only detects simple
+// cases such as identical patterns, a catch-all pattern or a literal p.
+func patternCovers(q, p, separators string) bool {
+	if q == p || (q == "*" && separators == "") {
+		return true
+	}
+	if strings.ContainsAny(p, `*?[]{}\`) {
+		return false
+	}
+	f, err := filter.Compile([]string{q}, []rune(separators)...)
+	if err != nil || f == nil {
+		return false
+	}
+	return f.Match(p)
+}
+
+func deprecationLintLevel(level telegraf.LogLevel) string {
+	if level == telegraf.Error {
+		return LintLevelError
+	}
+	return LintLevelWarning
+}
+
+func deprecationLintMessage(subject string, info telegraf.DeprecationInfo) string {
+	msg := fmt.Sprintf("%s is deprecated since version %s", subject, info.Since)
+	if info.RemovalIn != "" {
+		msg += " and will be removed in version " + info.RemovalIn
+	}
+	if info.Notice != "" {
+		msg += ": " + info.Notice
+	}
+	return msg
+}
+
+func newLintDeprecation(info telegraf.DeprecationInfo) *LintDeprecation {
+	return &LintDeprecation{
+		Since:     info.Since,
+		RemovalIn: info.RemovalIn,
+		Notice:    info.Notice,
+	}
+}
@@ -0,0 +1,275 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/coreos/go-semver/semver"
+	"github.com/stretchr/testify/require"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/plugins/aggregators"
+	"github.com/influxdata/telegraf/plugins/inputs"
+	"github.com/influxdata/telegraf/plugins/processors"
+)
+
+func TestLint(t *testing.T) {
+	// Fake telegraf's version
+	defer func(v *semver.Version) { telegrafVersion = v }(telegrafVersion)
+	telegrafVersion = semver.New("1.31.0")
+
+	cfg := []byte(`
+[agent]
+  interval = "10s"
+
+[[secretstores.mockup]]
+  id = "known"
+
+[[inputs.linttest]]
+  password = "@{known:password}"
+  namepass = ["cpu", "cpu", "mem*", "mem_free", "disk"]
+  namedrop = ["disk"]
+  [inputs.linttest.tagpass]
+    host = ["*", "a"]
+
+[[inputs.linttest]]
+  alias = "fast"
+  interval = "1s"
+  password = "@{unknown:password}"
+  old_setting = "foo"
+
+[[processors.linttest]]
+  order = 1
+
+[[processors.linttest]]
+  alias = "second"
+  order = 1
+
+[[processors.linttest]]
+  alias = "third"
+  order = 2
+
+[[aggregators.linttest]]
+  period = "5s"
+`)
+	fn := filepath.Join(t.TempDir(), "telegraf.conf")
+	require.NoError(t, os.WriteFile(fn, cfg, 0600))
+
+	count := len(unlinkedSecrets)
+	c := NewConfig()
+	findings, err := c.Lint(fn)
+	require.NoError(t, err)
+
+	expected := []LintFinding{
+		{
+			Rule:    LintRuleUnusedPattern,
+			Level:   LintLevelWarning,
+			Plugin:  "inputs.linttest",
+			Option:  "namepass",
+			Source:  fn,
+			Message: `pattern "cpu" is listed multiple times`,
+		},
+		{
+			Rule:    LintRuleUnusedPattern,
+			Level:   LintLevelWarning,
+			Plugin:  "inputs.linttest",
+			Option:  "namepass",
+			Source:  fn,
+			Message: `pattern "mem_free" is already matched by pattern "mem*"`,
+		},
+		{
+			Rule:    LintRuleUnusedPattern,
+			Level:   LintLevelWarning,
+			Plugin:  "inputs.linttest",
+			Option:  "namepass",
+			Source:  fn,
+			Message: `pattern "disk" is dropped by namedrop pattern "disk"`,
+		},
+		{
+			Rule:    LintRuleUnusedPattern,
+			Level:   LintLevelWarning,
+			Plugin:  "inputs.linttest",
+			Option:  "tagpass.host",
+			Source:  fn,
+			Message: `pattern "a" is already matched by pattern "*"`,
+		},
+		{
+			Rule:    LintRuleDeprecatedOption,
+			Level:   LintLevelWarning,
+			Plugin:  "inputs.linttest::fast",
+			Option:  "old_setting",
+			Source:  fn,
+			Message: `option "old_setting" is deprecated since version 1.30.0 and will be removed in version 1.99.0: use 'new_setting' instead`,
+			Deprecation: &LintDeprecation{
+				Since:     "1.30.0",
+				RemovalIn: "1.99.0",
+				Notice:    "use 'new_setting' instead",
+			},
+		},
+		{
+			Rule:    LintRuleUnknownSecretStore,
+			Level:   LintLevelError,
+			Plugin:  "inputs.linttest::fast",
+			Option:  "password",
+			Source:  fn,
+			Message: `secret "@{unknown:password}" references unknown secret-store "unknown"`,
+		},
+		{
+			Rule:    LintRuleOrderCollision,
+			Level:   LintLevelWarning,
+			Plugin:  "processors.linttest::second",
+			Option:  "order",
+			Source:  fn,
+			Message: "order 1 is also used by processors.linttest",
+		},
+		{
+			Rule:    LintRuleAggregatorPeriod,
+			Level:   LintLevelWarning,
+			Plugin:  "aggregators.linttest",
+			Option:  "period",
+			Source:  fn,
+			Message: "period 5s is shorter than the interval of inputs.linttest (10s)",
+		},
+	}
+	require.Equal(t, expected, findings)
+
+	// The secrets of the linted configuration must not be linked later on
+	require.Len(t, unlinkedSecrets, count)
+}
+
+func TestLintNoFindings(t *testing.T) {
+	cfg := []byte(`
+[[inputs.linttest]]
+  namepass = ["cpu", "mem*"]
+  namedrop = ["mem_free"]
+
+[[processors.linttest]]
+  order = 1
+
+[[processors.linttest]]
+  order = 2
+`)
+	fn := filepath.Join(t.TempDir(), "telegraf.conf")
+	require.NoError(t, os.WriteFile(fn, cfg, 0600))
+
+	c := NewConfig()
+	findings, err := c.Lint(fn)
+	require.NoError(t, err)
+	require.Empty(t, findings)
+}
+
+func TestLintAggregatorPeriodFilters(t *testing.T) {
+	cfg := []byte(`
+[agent]
+  interval = "10s"
+
+[[inputs.linttest]]
+  alias = "slow"
+  interval = "1m"
+  name_override = "slow"
+
+[[inputs.linttest]]
+  alias = "fast"
+  interval = "1s"
+  name_override = "fast"
+
+[[inputs.linttest]]
+  alias = "unknown"
+  interval = "1m"
+
+[[aggregators.linttest]]
+  alias = "selected"
+  period = "30s"
+  namepass = ["fast"]
+
+[[aggregators.linttest]]
+  alias = "dropped"
+  period = "30s"
+  namedrop = ["slow"]
+
+[[aggregators.linttest]]
+  alias = "tags"
+  period = "30s"
+  [aggregators.linttest.tagpass]
+    host = ["a"]
+
+[[aggregators.linttest]]
+  alias = "offending"
+  period = "30s"
+  namepass = ["s*"]
+`)
+	fn := filepath.Join(t.TempDir(), "telegraf.conf")
+	require.NoError(t, os.WriteFile(fn, cfg, 0600))
+
+	c := NewConfig()
+	findings, err := c.Lint(fn)
+	require.NoError(t, err)
+
+	// Only the inputs selected by the aggregator filters must be reported
+	expected := []LintFinding{
+		{
+			Rule:    LintRuleAggregatorPeriod,
+			Level:   LintLevelWarning,
+			Plugin:  "aggregators.linttest::offending",
+			Option:  "period",
+			Source:  fn,
+			Message: "period 30s is shorter than the interval of inputs.linttest::slow (1m0s)",
+		},
+	}
+	require.Equal(t, expected, findings)
+}
+
+func TestPatternCovers(t *testing.T) {
+	tests := []struct {
+		name       string
+		covering   string
+		covered    string
+		separators string
+		expected   bool
+	}{
+		{name: "identical", covering: "cpu*", covered: "cpu*", expected: true},
+		{name: "catch-all", covering: "*", covered: "cpu*", expected: true},
+		{name: "catch-all with separators", covering: "*", covered: "cpu.usage", separators: ".", expected: false},
+		{name: "literal", covering: "cpu*", covered: "cpu_usage", expected: true},
+		{name: "literal mismatch", covering: "cpu*", covered: "mem", expected: false},
+		{name: "glob", covering: "cpu*", covered: "cpu_*", expected: false},
+		{name: "single character", covering: "?", covered: "*", expected: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require.Equal(t, tt.expected, patternCovers(tt.covering, tt.covered, tt.separators))
+		})
+	}
+}
+
+type MockupLintInput struct {
+	Password   Secret `toml:"password"`
+	OldSetting string `toml:"old_setting" deprecated:"1.30.0;1.99.0;use 'new_setting' instead"`
+	NewSetting string `toml:"new_setting"`
+}
+
+func (*MockupLintInput) SampleConfig() string                { return "Mockup lint input" }
+func (*MockupLintInput) Gather(_ telegraf.Accumulator) error { return nil }
+
+type MockupLintProcessor struct{}
+
+func (*MockupLintProcessor) SampleConfig() string { return "Mockup lint processor" }
+func (*MockupLintProcessor) Apply(in ...telegraf.Metric) []telegraf.Metric {
+	return in
+}
+
+type MockupLintAggregator struct{}
+
+func (*MockupLintAggregator) SampleConfig() string      { return "Mockup lint aggregator" }
+func (*MockupLintAggregator) Add(telegraf.Metric)       {}
+func (*MockupLintAggregator) Push(telegraf.Accumulator) {}
+func (*MockupLintAggregator) Reset()                    {}
+
+// Register the mockup plugins on loading
+func init() {
+	inputs.Add("linttest", func() telegraf.Input { return &MockupLintInput{} })
+	processors.Add("linttest", func() telegraf.Processor { return &MockupLintProcessor{} })
+	aggregators.Add("linttest", func() telegraf.Aggregator { return &MockupLintAggregator{} })
+}
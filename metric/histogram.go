@@ -0,0 +1,141 @@
+package metric
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Histogram is a field value holding a histogram with explicit bucket
+// boundaries such as Prometheus classic histograms or OpenTelemetry
+// histograms. Bucket counts are cumulative and the buckets are sorted by
+// their upper bound. The implicit +Inf bucket contains all observations and
+// is therefore equal to Count.
+// Histogram values are shared between metric copies and must not be
+// modified after being added to a metric.
+type Histogram struct {
+	Count   float64
+	Sum     float64
+	Buckets []Bucket
+}
+
+// Bucket is a bucket of a histogram with explicit boundaries
+type Bucket struct {
+	UpperBound float64
+	Count      float64
+}
+
+// ExponentialHistogram is a field value holding a histogram with
+// exponentially growing bucket boundaries such as Prometheus native
+// histograms or OpenTelemetry exponential histograms. The bucket with index i
+// covers the range (base^i, base^(i+1)] with base = 2^(2^-Scale) following
+// the OpenTelemetry convention. Note that Prometheus uses a bucket index
+// shifted by one.
+// ExponentialHistogram values are shared between metric copies and must not
+// be modified after being added to a metric.
+type ExponentialHistogram struct {
+	Count         float64
+	Sum           float64
+	Scale         int32
+	ZeroThreshold float64
+	ZeroCount     float64
+	Positive      ExponentialBuckets
+	Negative      ExponentialBuckets
+}
+
+// ExponentialBuckets holds the counts of consecutive buckets of an
+// exponential histogram starting at the bucket index Offset
+type ExponentialBuckets struct {
+	Offset int32
+	Counts []float64
+}
+
+// IsHistogram returns true if the given field value is a native histogram.
+// Serializers without support for histograms should skip those fields.
+func IsHistogram(v interface{}) bool {
+	switch v.(type) {
+	case Histogram, ExponentialHistogram:
+		return true
+	}
+	return false
+}
+
+// Merge returns the histogram holding the observations of both histograms.
+// Both histograms must have the same bucket boundaries.
+func (h Histogram) Merge(other Histogram) (Histogram, error) {
+	if len(h.Buckets) != len(other.Buckets) {
+		return Histogram{}, errors.New("histograms have different bucket boundaries")
+	}
+
+	merged := Histogram{
+		Count:   h.Count + other.Count,
+		Sum:     h.Sum + other.Sum,
+		Buckets: make([]Bucket, 0, len(h.Buckets)),
+	}
+	for i, b := range h.Buckets {
+		if b.UpperBound != other.Buckets[i].UpperBound {
+			return Histogram{}, errors.New("histograms have different bucket boundaries")
+		}
+		merged.Buckets = append(merged.Buckets, Bucket{
+			UpperBound: b.UpperBound,
+			Count:      b.Count + other.Buckets[i].Count,
+		})
+	}
+	return merged, nil
+}
+
+// Merge returns the histogram holding the observations of both histograms.
+// Histograms with different scales are merged at the lower scale. Both
+// histograms must have the same zero threshold.
+func (h ExponentialHistogram) Merge(other ExponentialHistogram) (ExponentialHistogram, error) {
+	if h.ZeroThreshold != other.ZeroThreshold {
+		return ExponentialHistogram{}, fmt.Errorf("histograms have different zero thresholds %v and %v", h.ZeroThreshold, other.ZeroThreshold)
+	}
+
+	scale := min(h.Scale, other.Scale)
+	return ExponentialHistogram{
+		Count:         h.Count + other.Count,
+		Sum:           h.Sum + other.Sum,
+		Scale:         scale,
+		ZeroThreshold: h.ZeroThreshold,
+		ZeroCount:     h.ZeroCount + other.ZeroCount,
+		Positive:      h.Positive.downscale(h.Scale - scale).merge(other.Positive.downscale(other.Scale - scale)),
+		Negative:      h.Negative.downscale(h.Scale - scale).merge(other.Negative.downscale(other.Scale - scale)),
+	}, nil
+}
+
+// downscale reduces the resolution of the buckets by the given number of
+// scale steps, each step merging two neighboring buckets
+func (b ExponentialBuckets) downscale(by int32) ExponentialBuckets {
+	if by == 0 || len(b.Counts) == 0 {
+		return b
+	}
+
+	offset := b.Offset >> by
+	last := (b.Offset + int32(len(b.Counts)) - 1) >> by //nolint:gosec // G115: bucket counts are far below the int32 limit
+	counts := make([]float64, last-offset+1)
+	for i, c := range b.Counts {
+		counts[((b.Offset+int32(i))>>by)-offset] += c //nolint:gosec // G115: bucket counts are far below the int32 limit
+	}
+	return ExponentialBuckets{Offset: offset, Counts: counts}
+}
+
+// merge adds up the counts of buckets at the same scale
+func (b ExponentialBuckets) merge(other ExponentialBuckets) ExponentialBuckets {
+	if len(b.Counts) == 0 {
+		return other
+	}
+	if len(other.Counts) == 0 {
+		return b
+	}
+
+	offset := min(b.Offset, other.Offset)
+	end := max(b.Offset+int32(len(b.Counts)), other.Offset+int32(len(other.Counts))) //nolint:gosec // G115: bucket counts are far below the int32 limit
+	counts := make([]float64, end-offset)
+	for i, c := range b.Counts {
+		counts[b.Offset-offset+int32(i)] += c //nolint:gosec // G115: bucket counts are far below the int32 limit
+	}
+	for i, c := range other.Counts {
+		counts[other.Offset-offset+int32(i)] += c //nolint:gosec // G115: bucket counts are far below the int32 limit
+	}
+	return ExponentialBuckets{Offset: offset, Counts: counts}
+}
@@ -0,0 +1,113 @@
+package metric
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestHistogramField(t *testing.T) {
+	h := Histogram{
+		Count:   3,
+		Sum:     4.5,
+		Buckets: []Bucket{{UpperBound: 1, Count: 1}, {UpperBound: 2, Count: 2}},
+	}
+	m := New("test", map[string]string{}, map[string]interface{}{"latency": h, "ptr": &h}, time.Unix(0, 0))
+
+	v, found := m.GetField("latency")
+	require.True(t, found)
+	require.Equal(t, h, v)
+
+	v, found = m.GetField("ptr")
+	require.True(t, found)
+	require.Equal(t, h, v)
+}
+
+func TestHistogramMerge(t *testing.T) {
+	a := Histogram{
+		Count:   3,
+		Sum:     4.5,
+		Buckets: []Bucket{{UpperBound: 1, Count: 1}, {UpperBound: 2, Count: 2}},
+	}
+	b := Histogram{
+		Count:   2,
+		Sum:     1.5,
+		Buckets: []Bucket{{UpperBound: 1, Count: 2}, {UpperBound: 2, Count: 2}},
+	}
+
+	merged, err := a.Merge(b)
+	require.NoError(t, err)
+	require.Equal(t, Histogram{
+		Count:   5,
+		Sum:     6,
+		Buckets: []Bucket{{UpperBound: 1, Count: 3}, {UpperBound: 2, Count: 4}},
+	}, merged)
+
+	_, err = a.Merge(Histogram{Buckets: []Bucket{{UpperBound: 1}, {UpperBound: 5}}})
+	require.ErrorContains(t, err, "different bucket boundaries")
+	_, err = a.Merge(Histogram{Buckets: []Bucket{{UpperBound: 1}}})
+	require.ErrorContains(t, err, "different bucket boundaries")
+}
+
+func TestExponentialHistogramMerge(t *testing.T) {
+	a := ExponentialHistogram{
+		Count:     6,
+		Sum:       10,
+		Scale:     1,
+		ZeroCount: 1,
+		Positive:  ExponentialBuckets{Offset: -1, Counts: []float64{1, 1, 1, 1}},
+		Negative:  ExponentialBuckets{Offset: 0, Counts: []float64{1}},
+	}
+	b := ExponentialHistogram{
+		Count:     3,
+		Sum:       5,
+		Scale:     0,
+		ZeroCount: 1,
+		Positive:  ExponentialBuckets{Offset: 1, Counts: []float64{2}},
+	}
+
+	merged, err := a.Merge(b)
+	require.NoError(t, err)
+	require.Equal(t, ExponentialHistogram{
+		Count:     9,
+		Sum:       15,
+		Scale:     0,
+		ZeroCount: 2,
+		// Indices -1..2 at scale 1 map to -1, 0, 0, 1 at scale 0
+		Positive: ExponentialBuckets{Offset: -1, Counts: []float64{1, 2, 3}},
+		Negative: ExponentialBuckets{Offset: 0, Counts: []float64{1}},
+	}, merged)
+
+	_, err = a.Merge(ExponentialHistogram{ZeroThreshold: 0.1})
+	require.ErrorContains(t, err, "different zero thresholds")
+}
+
+func TestHistogramSerialization(t *testing.T) {
+	Init()
+
+	m := New(
+		"test",
+		map[string]string{"host": "a"},
+		map[string]interface{}{
+			"explicit": Histogram{
+				Count:   3,
+				Sum:     4.5,
+				Buckets: []Bucket{{UpperBound: 1, Count: 1}, {UpperBound: 2, Count: 2}},
+			},
+			"exponential": ExponentialHistogram{
+				Count:    2,
+				Sum:      3,
+				Scale:    3,
+				Positive: ExponentialBuckets{Offset: 4, Counts: []float64{1, 1}},
+			},
+		},
+		time.Unix(0, 0),
+	)
+
+	buf, err := ToBytes(m)
+	require.NoError(t, err)
+	actual, err := FromBytes(buf)
+	require.NoError(t, err)
+	require.Equal(t, m.Fields(), actual.Fields())
+}
@@ -4,4 +4,6 @@ import "encoding/gob"
 
 func Init() {
 	gob.RegisterName("metric.metric", &metric{})
+	gob.RegisterName("metric.Histogram", Histogram{})
+	gob.RegisterName("metric.ExponentialHistogram", ExponentialHistogram{})
 }
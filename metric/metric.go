@@ -380,6 +380,18 @@ func convertField(v interface{}) interface{} {
 		if v != nil {
 			return float64(*v)
 		}
+	case Histogram:
+		return v
+	case ExponentialHistogram:
+		return v
+	case *Histogram:
+		if v != nil {
+			return *v
+		}
+	case *ExponentialHistogram:
+		if v != nil {
+			return *v
+		}
 	default:
 		return nil
 	}
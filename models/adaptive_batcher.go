@@ -0,0 +1,90 @@
+package models
+
+import (
+	"sync"
+	"time"
+)
+
+const (
+	// Weight of the latest write for the moving averages of latency and
+	// error rate
+	adaptiveSmoothing = 0.3
+
+	// Error rate below which the batch size and concurrency may grow
+	adaptiveErrorThreshold = 0.05
+
+	// Default write latency to aim for
+	DefaultAdaptiveTargetLatency = time.Second
+)
+
+// adaptiveBatcher adjusts the batch size and the number of concurrent writes
+// of an output based on the measured write latency and error rate. On errors
+// or latencies above the target it backs off by halving batch size and
+// concurrency. If writes are fast and reliable, it probes for more throughput
+// by first growing the batch size up to the upper bound and then adding
+// concurrent writes.
+type adaptiveBatcher struct {
+	minSize        int
+	maxSize        int
+	maxConcurrency int
+	target         time.Duration
+
+	sync.Mutex
+	size        int
+	concurrency int
+	latency     float64
+	errorRate   float64
+}
+
+func newAdaptiveBatcher(size, minSize, maxSize, maxConcurrency int, target time.Duration) *adaptiveBatcher {
+	return &adaptiveBatcher{
+		minSize:        minSize,
+		maxSize:        maxSize,
+		maxConcurrency: maxConcurrency,
+		target:         target,
+		size:           min(max(size, minSize), maxSize),
+		concurrency:    1,
+	}
+}
+
+// limits returns the current batch size and number of concurrent writes
+func (b *adaptiveBatcher) limits() (size, concurrency int) {
+	b.Lock()
+	defer b.Unlock()
+
+	return b.size, b.concurrency
+}
+
+// update adjusts the limits based on the outcome of a single write
+func (b *adaptiveBatcher) update(elapsed time.Duration, err error) {
+	b.Lock()
+	defer b.Unlock()
+
+	var failed float64
+	if err != nil {
+		failed = 1
+	}
+	b.errorRate = adaptiveSmoothing*failed + (1-adaptiveSmoothing)*b.errorRate
+	if b.latency > 0 {
+		b.latency = adaptiveSmoothing*float64(elapsed) + (1-adaptiveSmoothing)*b.latency
+	} else {
+		b.latency = float64(elapsed)
+	}
+
+	switch {
+	case err != nil || b.latency > float64(b.target):
+		b.size = max(b.minSize, b.size/2)
+		b.concurrency = max(1, b.concurrency/2)
+
+		// Restart measuring the latency as the previous writes used larger
+		// batches, otherwise we would back off multiple times for the same
+		// slow writes.
+		b.latency = 0
+	case b.errorRate < adaptiveErrorThreshold && b.latency < float64(b.target)/2:
+		if b.size < b.maxSize {
+			b.size = min(b.maxSize, b.size+max(1, b.size/4))
+		} else if b.concurrency < b.maxConcurrency {
+			b.concurrency++
+		}
+	}
+}
@@ -0,0 +1,87 @@
+package models
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestAdaptiveBatcherGrow(t *testing.T) {
+	b := newAdaptiveBatcher(100, 10, 200, 3, time.Second)
+
+	size, concurrency := b.limits()
+	require.Equal(t, 100, size)
+	require.Equal(t, 1, concurrency)
+
+	// Fast writes first grow the batch size up to the maximum
+	for range 3 {
+		b.update(10*time.Millisecond, nil)
+	}
+	size, concurrency = b.limits()
+	require.Equal(t, 195, size)
+	require.Equal(t, 1, concurrency)
+
+	// ...and then add concurrent writes up to the maximum
+	for range 4 {
+		b.update(10*time.Millisecond, nil)
+	}
+	size, concurrency = b.limits()
+	require.Equal(t, 200, size)
+	require.Equal(t, 3, concurrency)
+}
+
+func TestAdaptiveBatcherBackoff(t *testing.T) {
+	b := newAdaptiveBatcher(100, 10, 200, 4, time.Second)
+	b.size = 200
+	b.concurrency = 4
+
+	// Errors halve batch size and concurrency
+	b.update(10*time.Millisecond, errors.New("failed"))
+	size, concurrency := b.limits()
+	require.Equal(t, 100, size)
+	require.Equal(t, 2, concurrency)
+
+	// No growth while the error rate is high
+	b.update(10*time.Millisecond, nil)
+	size, concurrency = b.limits()
+	require.Equal(t, 100, size)
+	require.Equal(t, 2, concurrency)
+
+	// Slow writes halve batch size and concurrency once the average latency
+	// exceeds the target
+	b.update(2*time.Second, nil)
+	size, concurrency = b.limits()
+	require.Equal(t, 100, size)
+	require.Equal(t, 2, concurrency)
+	b.update(2*time.Second, nil)
+	size, concurrency = b.limits()
+	require.Equal(t, 50, size)
+	require.Equal(t, 1, concurrency)
+
+	// Keep within bounds
+	for range 5 {
+		b.update(2*time.Second, nil)
+	}
+	size, concurrency = b.limits()
+	require.Equal(t, 10, size)
+	require.Equal(t, 1, concurrency)
+
+	// Recover after the error rate decayed
+	for range 10 {
+		b.update(10*time.Millisecond, nil)
+	}
+	size, _ = b.limits()
+	require.Greater(t, size, 10)
+}
+
+func TestAdaptiveBatcherInitialSize(t *testing.T) {
+	b := newAdaptiveBatcher(1000, 10, 200, 1, time.Second)
+	size, _ := b.limits()
+	require.Equal(t, 200, size)
+
+	b = newAdaptiveBatcher(1, 10, 200, 1, time.Second)
+	size, _ = b.limits()
+	require.Equal(t, 10, size)
+}
@@ -3,6 +3,7 @@ package models
 import (
 	"fmt"
 	"sync"
+	"time"
 
 	"github.com/influxdata/telegraf"
 	"github.com/influxdata/telegraf/metric"
@@ -94,17 +95,35 @@ type BufferStats struct {
 	BufferLimit     selfstat.Stat
 }
 
+// BufferLimits holds the size and age limits of disk-based buffers.
+type BufferLimits struct {
+	// MaxBytes is the maximum size of all buffer files, zero means unlimited
+	MaxBytes int64
+	// MaxAge is the maximum age of a buffered metric, zero means unlimited
+	MaxAge time.Duration
+}
+
 // NewBuffer returns a new empty Buffer with the given capacity.
 func NewBuffer(name, id, alias string, capacity int, strategy, path string) (Buffer, error) {
-	registerGob()
+	return NewBufferWithLimits(name, id, alias, capacity, strategy, path, BufferLimits{})
+}
 
-	bs := NewBufferStats(name, alias, capacity)
+// NewBufferWithLimits returns a new empty Buffer with the given capacity and
+// limits. The limits are only used by the "segmented" strategy.
+func NewBufferWithLimits(name, id, alias string, capacity int, strategy, path string, limits BufferLimits) (Buffer, error) {
+	return newBuffer(name, id, capacity, strategy, path, limits, NewBufferStats(name, alias, capacity))
+}
+
+func newBuffer(name, id string, capacity int, strategy, path string, limits BufferLimits, bs BufferStats) (Buffer, error) {
+	registerGob()
 
 	switch strategy {
 	case "", "memory":
 		return NewMemoryBuffer(capacity, bs)
 	case "disk":
 		return NewDiskBuffer(name, id, path, bs)
+	case "segmented":
+		return NewSegmentedDiskBuffer(name, id, path, limits.MaxBytes, limits.MaxAge, bs)
 	}
 	return nil, fmt.Errorf("invalid buffer strategy %q", strategy)
 }
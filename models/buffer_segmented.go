@@ -0,0 +1,667 @@
+package models
+
+import (
+	"cmp"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/metric"
+)
+
+const (
+	// defaultSegmentSize is the maximum size of a single segment file if no
+	// byte-limit is configured for the buffer.
+	defaultSegmentSize = 16 * 1024 * 1024
+
+	// segmentHeaderSize is the size of the record header consisting of the
+	// payload length, the checksum and the timestamp the record was added.
+	segmentHeaderSize = 16
+
+	segmentFileSuffix = ".seg"
+)
+
+var segmentChecksumTable = crc32.MakeTable(crc32.Castagnoli)
+
+// SegmentedDiskBuffer stores metrics in a write-ahead log split into multiple
+// segment files. The total size of all segments and the age of the stored
+// metrics can be limited. If the limits are exceeded the oldest segment or
+// metrics are dropped. Acknowledged metrics are removed from the segments by
+// a background compaction.
+//
+// Acknowledgements are only persisted by compaction, so metrics written
+// shortly before a restart might be sent again, i.e. the buffer provides
+// at-least-once delivery.
+type SegmentedDiskBuffer struct {
+	BufferStats
+	sync.Mutex
+
+	path string
+
+	maxBytes    int64
+	maxAge      time.Duration
+	segmentSize int64
+
+	// Segments ordered from oldest to newest, the last segment is the one
+	// currently written to
+	segments []*walSegment
+	active   *os.File
+	nextID   uint64
+
+	size int64 // Total size of all segment files in bytes
+	live int   // Number of metrics not yet removed from the buffer
+
+	compact chan struct{}
+	done    chan struct{}
+	wg      sync.WaitGroup
+}
+
+type walSegment struct {
+	id      uint64
+	path    string
+	size    int64
+	entries []*walEntry
+	removed int
+}
+
+type walEntry struct {
+	segment *walSegment
+	offset  int64
+	length  int64
+	added   int64
+
+	// Entry was read from disk on startup and is left over from a previous
+	// instance of telegraf
+	restored bool
+	// Entry was accepted, rejected or dropped and is scheduled for removal
+	removed bool
+	// Entry is part of a running transaction
+	inflight bool
+	// Entry was dropped due to the buffer limits while being part of a
+	// running transaction
+	evicted bool
+}
+
+func NewSegmentedDiskBuffer(name, id, path string, maxBytes int64, maxAge time.Duration, stats BufferStats) (*SegmentedDiskBuffer, error) {
+	dir := filepath.Join(path, id)
+	if err := os.MkdirAll(dir, 0750); err != nil {
+		return nil, fmt.Errorf("creating buffer directory failed: %w", err)
+	}
+
+	segmentSize := int64(defaultSegmentSize)
+	if maxBytes > 0 {
+		segmentSize = min(segmentSize, max(maxBytes/4, 1))
+	}
+
+	buf := &SegmentedDiskBuffer{
+		BufferStats: stats,
+		path:        dir,
+		maxBytes:    maxBytes,
+		maxAge:      maxAge,
+		segmentSize: segmentSize,
+		nextID:      1,
+		compact:     make(chan struct{}, 1),
+		done:        make(chan struct{}),
+	}
+
+	if err := buf.restore(); err != nil {
+		return nil, err
+	}
+	if len(buf.segments) == 0 {
+		log.Printf("I! Buffer segments not found for plugin outputs.%s (%s), "+
+			"this can safely be ignored if you added this plugin instance for the first time", name, id)
+	}
+
+	// Reuse the last segment if there is still room otherwise start a new one
+	if n := len(buf.segments); n > 0 && buf.segments[n-1].size < buf.segmentSize {
+		f, err := os.OpenFile(buf.segments[n-1].path, os.O_WRONLY|os.O_APPEND, 0640)
+		if err != nil {
+			return nil, fmt.Errorf("opening segment failed: %w", err)
+		}
+		buf.active = f
+	} else if err := buf.rotate(); err != nil {
+		return nil, err
+	}
+	buf.BufferSize.Set(int64(buf.live))
+
+	buf.wg.Add(1)
+	go func() {
+		defer buf.wg.Done()
+		buf.compactor()
+	}()
+
+	return buf, nil
+}
+
+func (b *SegmentedDiskBuffer) Len() int {
+	b.Lock()
+	defer b.Unlock()
+
+	return b.live
+}
+
+func (b *SegmentedDiskBuffer) Add(metrics ...telegraf.Metric) int {
+	b.Lock()
+	defer b.Unlock()
+
+	dropped := 0
+	for _, m := range metrics {
+		if err := b.addMetric(m); err != nil {
+			log.Printf("E! Adding metric to buffer segment failed: %v", err)
+			b.metricDropped(m)
+			dropped++
+		}
+	}
+	dropped += b.enforceLimits()
+
+	b.BufferSize.Set(int64(b.live))
+	return dropped
+}
+
+func (b *SegmentedDiskBuffer) BeginTransaction(batchSize int) *Transaction {
+	b.Lock()
+	defer b.Unlock()
+
+	b.enforceLimits()
+	if b.live == 0 || batchSize <= 0 {
+		return &Transaction{}
+	}
+
+	metrics := make([]telegraf.Metric, 0, batchSize)
+	entries := make([]*walEntry, 0, batchSize)
+	for _, seg := range b.segments {
+		if len(metrics) >= batchSize {
+			break
+		}
+		if seg.removed == len(seg.entries) {
+			continue
+		}
+
+		f, err := os.Open(seg.path)
+		if err != nil {
+			log.Printf("E! Opening buffer segment %q failed: %v", seg.path, err)
+			continue
+		}
+		for _, e := range seg.entries {
+			if len(metrics) >= batchSize {
+				break
+			}
+			if e.removed {
+				continue
+			}
+
+			m, err := readEntry(f, e)
+			if err != nil {
+				// Tracking information cannot be found for metrics of older
+				// instances, so the metric is skipped and removed.
+				if !errors.Is(err, metric.ErrSkipTracking) {
+					log.Printf("E! Reading metric from buffer segment %q failed: %v", seg.path, err)
+				}
+				b.remove(e)
+				continue
+			}
+			// Tracking metrics left over from a previous instance must be
+			// skipped as their tracking information cannot be valid.
+			if _, ok := m.(telegraf.TrackingMetric); ok && e.restored {
+				b.remove(e)
+				continue
+			}
+
+			e.inflight = true
+			metrics = append(metrics, m)
+			entries = append(entries, e)
+		}
+		f.Close()
+	}
+	b.BufferSize.Set(int64(b.live))
+
+	if len(metrics) == 0 {
+		return &Transaction{}
+	}
+	return &Transaction{Batch: metrics, valid: true, state: entries}
+}
+
+func (b *SegmentedDiskBuffer) EndTransaction(tx *Transaction) {
+	if len(tx.Batch) == 0 {
+		return
+	}
+
+	// Ignore invalid transactions and make sure they can only be finished once
+	if !tx.valid {
+		return
+	}
+	tx.valid = false
+
+	entries := tx.state.([]*walEntry)
+
+	b.Lock()
+	defer b.Unlock()
+
+	for _, idx := range tx.Accept {
+		b.metricWritten(tx.Batch[idx])
+		b.remove(entries[idx])
+	}
+	for _, idx := range tx.Reject {
+		b.metricRejected(tx.Batch[idx])
+		b.remove(entries[idx])
+	}
+
+	// Metrics kept for a later transaction but dropped due to the buffer
+	// limits in the meantime are now finally dropped.
+	for _, idx := range tx.InferKeep() {
+		if entries[idx].evicted {
+			b.metricDropped(tx.Batch[idx])
+		}
+	}
+	for _, e := range entries {
+		e.inflight = false
+	}
+	b.BufferSize.Set(int64(b.live))
+
+	// Trigger the background compaction without blocking
+	select {
+	case b.compact <- struct{}{}:
+	default:
+	}
+}
+
+func (b *SegmentedDiskBuffer) Stats() BufferStats {
+	return b.BufferStats
+}
+
+func (b *SegmentedDiskBuffer) Close() error {
+	close(b.done)
+	b.wg.Wait()
+
+	b.Lock()
+	defer b.Unlock()
+
+	if err := b.active.Sync(); err != nil {
+		b.active.Close()
+		return err
+	}
+	return b.active.Close()
+}
+
+// restore reads existing segments from disk and rebuilds the index.
+func (b *SegmentedDiskBuffer) restore() error {
+	files, err := os.ReadDir(b.path)
+	if err != nil {
+		return fmt.Errorf("reading buffer directory failed: %w", err)
+	}
+
+	for _, f := range files {
+		name := f.Name()
+		if f.IsDir() || !strings.HasSuffix(name, segmentFileSuffix) {
+			continue
+		}
+		id, err := strconv.ParseUint(strings.TrimSuffix(name, segmentFileSuffix), 10, 64)
+		if err != nil {
+			continue
+		}
+
+		seg := &walSegment{id: id, path: filepath.Join(b.path, name)}
+		if err := b.scanSegment(seg); err != nil {
+			return err
+		}
+		b.segments = append(b.segments, seg)
+		b.nextID = max(b.nextID, id+1)
+	}
+	slices.SortFunc(b.segments, func(x, y *walSegment) int {
+		return cmp.Compare(x.id, y.id)
+	})
+
+	return nil
+}
+
+// scanSegment reads all records of the given segment and truncates the file
+// at the first incomplete or corrupted record.
+func (b *SegmentedDiskBuffer) scanSegment(seg *walSegment) error {
+	data, err := os.ReadFile(seg.path)
+	if err != nil {
+		return fmt.Errorf("reading segment failed: %w", err)
+	}
+
+	var offset int64
+	for offset < int64(len(data)) {
+		payload, added, err := decodeRecord(data[offset:])
+		if err != nil {
+			log.Printf("W! Truncating buffer segment %q at offset %d: %v", seg.path, offset, err)
+			if err := os.Truncate(seg.path, offset); err != nil {
+				return fmt.Errorf("truncating segment failed: %w", err)
+			}
+			break
+		}
+
+		length := int64(segmentHeaderSize + len(payload))
+		seg.entries = append(seg.entries, &walEntry{
+			segment:  seg,
+			offset:   offset,
+			length:   length,
+			added:    added,
+			restored: true,
+		})
+		offset += length
+	}
+	seg.size = offset
+	b.size += offset
+	b.live += len(seg.entries)
+
+	return nil
+}
+
+// rotate closes the active segment and starts a new one.
+func (b *SegmentedDiskBuffer) rotate() error {
+	if b.active != nil {
+		if err := b.active.Sync(); err != nil {
+			return fmt.Errorf("syncing segment failed: %w", err)
+		}
+		if err := b.active.Close(); err != nil {
+			return fmt.Errorf("closing segment failed: %w", err)
+		}
+		b.active = nil
+	}
+
+	seg := &walSegment{
+		id:   b.nextID,
+		path: filepath.Join(b.path, fmt.Sprintf("%020d%s", b.nextID, segmentFileSuffix)),
+	}
+	f, err := os.OpenFile(seg.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND|os.O_TRUNC, 0640)
+	if err != nil {
+		return fmt.Errorf("creating segment failed: %w", err)
+	}
+	b.active = f
+	b.segments = append(b.segments, seg)
+	b.nextID++
+
+	return nil
+}
+
+func (b *SegmentedDiskBuffer) addMetric(m telegraf.Metric) error {
+	data, err := metric.ToBytes(m)
+	if err != nil {
+		return err
+	}
+	added := time.Now().UnixNano()
+	record := encodeRecord(data, added)
+
+	seg := b.segments[len(b.segments)-1]
+	if len(seg.entries) > 0 && seg.size+int64(len(record)) > b.segmentSize {
+		if err := b.rotate(); err != nil {
+			return err
+		}
+		seg = b.segments[len(b.segments)-1]
+	}
+
+	if _, err := b.active.Write(record); err != nil {
+		return err
+	}
+	seg.entries = append(seg.entries, &walEntry{
+		segment: seg,
+		offset:  seg.size,
+		length:  int64(len(record)),
+		added:   added,
+	})
+	seg.size += int64(len(record))
+	b.size += int64(len(record))
+	b.live++
+	b.metricAdded()
+
+	return nil
+}
+
+// enforceLimits drops expired metrics and evicts the oldest segments until
+// the buffer is within its limits again. The number of dropped metrics is
+// returned.
+func (b *SegmentedDiskBuffer) enforceLimits() int {
+	var dropped int
+
+	if b.maxAge > 0 {
+		cutoff := time.Now().Add(-b.maxAge).UnixNano()
+		for _, seg := range b.segments {
+			var expired []*walEntry
+			var done bool
+			for _, e := range seg.entries {
+				if e.added >= cutoff {
+					done = true
+					break
+				}
+				if !e.removed {
+					expired = append(expired, e)
+				}
+			}
+			dropped += b.drop(seg, expired)
+			if done {
+				break
+			}
+		}
+	}
+
+	for b.maxBytes > 0 && b.size > b.maxBytes {
+		// Always keep a segment to write to
+		if len(b.segments) == 1 {
+			if err := b.rotate(); err != nil {
+				log.Printf("E! Rotating buffer segment failed: %v", err)
+				break
+			}
+		}
+		seg := b.segments[0]
+		dropped += b.drop(seg, slices.DeleteFunc(slices.Clone(seg.entries), func(e *walEntry) bool { return e.removed }))
+		b.deleteSegment(seg)
+	}
+
+	return dropped
+}
+
+// drop removes the given entries of a segment from the buffer and accounts
+// them as dropped. Entries currently being part of a transaction are only
+// marked and accounted for when the transaction ends.
+func (b *SegmentedDiskBuffer) drop(seg *walSegment, entries []*walEntry) int {
+	if len(entries) == 0 {
+		return 0
+	}
+
+	f, err := os.Open(seg.path)
+	if err != nil {
+		log.Printf("E! Opening buffer segment %q failed: %v", seg.path, err)
+		f = nil
+	} else {
+		defer f.Close()
+	}
+
+	var dropped int
+	for _, e := range entries {
+		b.remove(e)
+		if e.inflight {
+			e.evicted = true
+			continue
+		}
+		dropped++
+
+		// Use the metric if possible to correctly handle tracking metrics
+		// otherwise only account for the drop.
+		if f != nil {
+			if m, err := readEntry(f, e); err == nil {
+				b.metricDropped(m)
+				continue
+			}
+		}
+		AgentMetricsDropped.Incr(1)
+		b.MetricsDropped.Incr(1)
+	}
+	return dropped
+}
+
+func (b *SegmentedDiskBuffer) remove(e *walEntry) {
+	if e.removed {
+		return
+	}
+	e.removed = true
+	e.segment.removed++
+	b.live--
+}
+
+// deleteSegment removes the given segment from disk and from the index.
+func (b *SegmentedDiskBuffer) deleteSegment(seg *walSegment) {
+	if err := os.Remove(seg.path); err != nil && !errors.Is(err, os.ErrNotExist) {
+		log.Printf("E! Removing buffer segment %q failed: %v", seg.path, err)
+	}
+	b.size -= seg.size
+	b.segments = slices.DeleteFunc(b.segments, func(s *walSegment) bool { return s == seg })
+}
+
+func (b *SegmentedDiskBuffer) compactor() {
+	for {
+		select {
+		case <-b.done:
+			return
+		case <-b.compact:
+			b.Lock()
+			if err := b.compactSegments(); err != nil {
+				log.Printf("E! Compacting buffer segments failed: %v", err)
+			}
+			b.Unlock()
+		}
+	}
+}
+
+// compactSegments deletes segments with all metrics removed and rewrites
+// segments with at least half of the metrics removed. Must be called with the
+// lock held.
+func (b *SegmentedDiskBuffer) compactSegments() error {
+	for _, seg := range slices.Clone(b.segments) {
+		if seg.removed == 0 {
+			continue
+		}
+		isActive := seg == b.segments[len(b.segments)-1]
+
+		// Sealed segments can be deleted completely, the active one is
+		// emptied by the rewrite below.
+		if seg.removed == len(seg.entries) && !isActive {
+			b.deleteSegment(seg)
+			continue
+		}
+		if 2*seg.removed < len(seg.entries) {
+			continue
+		}
+		if err := b.rewriteSegment(seg, isActive); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// rewriteSegment writes the remaining entries of the segment to a temporary
+// file and atomically replaces the segment file.
+func (b *SegmentedDiskBuffer) rewriteSegment(seg *walSegment, isActive bool) error {
+	data, err := os.ReadFile(seg.path)
+	if err != nil {
+		return fmt.Errorf("reading segment failed: %w", err)
+	}
+
+	tmpfile := seg.path + ".tmp"
+	f, err := os.OpenFile(tmpfile, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0640)
+	if err != nil {
+		return fmt.Errorf("creating segment failed: %w", err)
+	}
+
+	entries := make([]*walEntry, 0, len(seg.entries)-seg.removed)
+	var offset int64
+	for _, e := range seg.entries {
+		if e.removed {
+			continue
+		}
+		if _, err := f.Write(data[e.offset : e.offset+e.length]); err != nil {
+			f.Close()
+			os.Remove(tmpfile)
+			return fmt.Errorf("writing segment failed: %w", err)
+		}
+		entries = append(entries, e)
+		offset += e.length
+	}
+	if err := f.Sync(); err != nil {
+		f.Close()
+		os.Remove(tmpfile)
+		return fmt.Errorf("syncing segment failed: %w", err)
+	}
+	if err := f.Close(); err != nil {
+		os.Remove(tmpfile)
+		return fmt.Errorf("closing segment failed: %w", err)
+	}
+
+	if isActive {
+		if err := b.active.Close(); err != nil {
+			return fmt.Errorf("closing segment failed: %w", err)
+		}
+	}
+	if err := os.Rename(tmpfile, seg.path); err != nil {
+		return fmt.Errorf("replacing segment failed: %w", err)
+	}
+	if isActive {
+		active, err := os.OpenFile(seg.path, os.O_WRONLY|os.O_APPEND, 0640)
+		if err != nil {
+			return fmt.Errorf("opening segment failed: %w", err)
+		}
+		b.active = active
+	}
+
+	// Update the index to the new offsets
+	offset = 0
+	for _, e := range entries {
+		e.offset = offset
+		offset += e.length
+	}
+	b.size += offset - seg.size
+	seg.size = offset
+	seg.entries = entries
+	seg.removed = 0
+
+	return nil
+}
+
+func encodeRecord(payload []byte, added int64) []byte {
+	record := make([]byte, segmentHeaderSize+len(payload))
+	binary.LittleEndian.PutUint32(record[0:4], uint32(len(payload)))
+	binary.LittleEndian.PutUint64(record[8:16], uint64(added))
+	copy(record[segmentHeaderSize:], payload)
+	binary.LittleEndian.PutUint32(record[4:8], crc32.Checksum(record[8:], segmentChecksumTable))
+	return record
+}
+
+func decodeRecord(data []byte) (payload []byte, added int64, err error) {
+	if len(data) < segmentHeaderSize {
+		return nil, 0, io.ErrUnexpectedEOF
+	}
+	length := int(binary.LittleEndian.Uint32(data[0:4]))
+	if len(data) < segmentHeaderSize+length {
+		return nil, 0, io.ErrUnexpectedEOF
+	}
+	record := data[:segmentHeaderSize+length]
+	if crc32.Checksum(record[8:], segmentChecksumTable) != binary.LittleEndian.Uint32(record[4:8]) {
+		return nil, 0, errors.New("checksum mismatch")
+	}
+	return record[segmentHeaderSize:], int64(binary.LittleEndian.Uint64(record[8:16])), nil
+}
+
+func readEntry(f *os.File, e *walEntry) (telegraf.Metric, error) {
+	record := make([]byte, e.length)
+	if _, err := f.ReadAt(record, e.offset); err != nil {
+		return nil, err
+	}
+	payload, _, err := decodeRecord(record)
+	if err != nil {
+		return nil, err
+	}
+	return metric.FromBytes(payload)
+}
@@ -0,0 +1,180 @@
+package models
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/metric"
+	"github.com/influxdata/telegraf/testutil"
+)
+
+func newTestSegmentedBuffer(t *testing.T, path string, limits BufferLimits) *SegmentedDiskBuffer {
+	t.Helper()
+
+	buf, err := NewBufferWithLimits("test", "123", "", 0, "segmented", path, limits)
+	require.NoError(t, err)
+	buf.Stats().MetricsAdded.Set(0)
+	buf.Stats().MetricsWritten.Set(0)
+	buf.Stats().MetricsRejected.Set(0)
+	buf.Stats().MetricsDropped.Set(0)
+
+	segBuf, ok := buf.(*SegmentedDiskBuffer)
+	require.True(t, ok, "buffer is not a segmented disk buffer")
+	return segBuf
+}
+
+func TestSegmentedDiskBufferRestore(t *testing.T) {
+	path := t.TempDir()
+
+	expected := make([]telegraf.Metric, 0, 5)
+	for i := range 5 {
+		m := metric.New("test", map[string]string{}, map[string]interface{}{"value": i}, time.Unix(int64(i), 0))
+		expected = append(expected, m)
+	}
+
+	buf := newTestSegmentedBuffer(t, path, BufferLimits{})
+	buf.Add(expected...)
+	require.NoError(t, buf.Close())
+
+	// Reopen the buffer and check that all metrics are restored
+	buf = newTestSegmentedBuffer(t, path, BufferLimits{})
+	defer buf.Close()
+	require.Equal(t, 5, buf.Len())
+
+	tx := buf.BeginTransaction(10)
+	testutil.RequireMetricsEqual(t, expected, tx.Batch)
+}
+
+func TestSegmentedDiskBufferTruncatesCorruptRecord(t *testing.T) {
+	path := t.TempDir()
+
+	buf := newTestSegmentedBuffer(t, path, BufferLimits{})
+	m := metric.New("test", map[string]string{}, map[string]interface{}{"value": 42}, time.Unix(0, 0))
+	buf.Add(m, m)
+	require.NoError(t, buf.Close())
+
+	// Simulate a crash while writing by appending an incomplete record
+	files, err := filepath.Glob(filepath.Join(path, "123", "*"+segmentFileSuffix))
+	require.NoError(t, err)
+	require.Len(t, files, 1)
+	f, err := os.OpenFile(files[0], os.O_WRONLY|os.O_APPEND, 0640)
+	require.NoError(t, err)
+	_, err = f.Write([]byte{0x10, 0x00, 0x00})
+	require.NoError(t, err)
+	require.NoError(t, f.Close())
+
+	buf = newTestSegmentedBuffer(t, path, BufferLimits{})
+	defer buf.Close()
+	require.Equal(t, 2, buf.Len())
+	require.Equal(t, buf.segments[0].size, buf.size)
+}
+
+func TestSegmentedDiskBufferMaxBytes(t *testing.T) {
+	registerGob()
+
+	m := metric.New("test", map[string]string{}, map[string]interface{}{"value": 42}, time.Unix(0, 0))
+	data, err := metric.ToBytes(m)
+	require.NoError(t, err)
+	recordSize := int64(segmentHeaderSize + len(data))
+
+	// Allow for eight records with two records per segment
+	buf := newTestSegmentedBuffer(t, t.TempDir(), BufferLimits{MaxBytes: 8 * recordSize})
+	defer buf.Close()
+
+	for range 8 {
+		require.Zero(t, buf.Add(m))
+	}
+	require.Equal(t, 8, buf.Len())
+	require.Len(t, buf.segments, 4)
+
+	// Adding another metric must evict the oldest segment
+	dropped := buf.Add(m)
+	require.Equal(t, 2, dropped)
+	require.Equal(t, 7, buf.Len())
+	require.Equal(t, int64(2), buf.Stats().MetricsDropped.Get())
+	require.LessOrEqual(t, buf.size, 8*recordSize)
+}
+
+func TestSegmentedDiskBufferMaxBytesInflight(t *testing.T) {
+	registerGob()
+
+	m := metric.New("test", map[string]string{}, map[string]interface{}{"value": 42}, time.Unix(0, 0))
+	data, err := metric.ToBytes(m)
+	require.NoError(t, err)
+	recordSize := int64(segmentHeaderSize + len(data))
+
+	buf := newTestSegmentedBuffer(t, t.TempDir(), BufferLimits{MaxBytes: 4 * recordSize})
+	defer buf.Close()
+
+	buf.Add(m, m, m, m)
+	tx := buf.BeginTransaction(2)
+	require.Len(t, tx.Batch, 2)
+
+	// Evict the segment of the running transaction, the drop is only
+	// accounted for when the transaction keeps the metrics
+	buf.Add(m, m)
+	require.Equal(t, int64(0), buf.Stats().MetricsDropped.Get())
+
+	tx.KeepAll()
+	buf.EndTransaction(tx)
+	require.Equal(t, int64(2), buf.Stats().MetricsDropped.Get())
+	require.Equal(t, 4, buf.Len())
+}
+
+func TestSegmentedDiskBufferMaxAge(t *testing.T) {
+	buf := newTestSegmentedBuffer(t, t.TempDir(), BufferLimits{MaxAge: time.Hour})
+	defer buf.Close()
+
+	m := metric.New("test", map[string]string{}, map[string]interface{}{"value": 42}, time.Unix(0, 0))
+	buf.Add(m, m, m)
+
+	// Age the first two metrics
+	old := time.Now().Add(-2 * time.Hour).UnixNano()
+	buf.segments[0].entries[0].added = old
+	buf.segments[0].entries[1].added = old
+
+	tx := buf.BeginTransaction(5)
+	require.Len(t, tx.Batch, 1)
+	require.Equal(t, 1, buf.Len())
+	require.Equal(t, int64(2), buf.Stats().MetricsDropped.Get())
+}
+
+func TestSegmentedDiskBufferCompaction(t *testing.T) {
+	registerGob()
+
+	m := metric.New("test", map[string]string{}, map[string]interface{}{"value": 42}, time.Unix(0, 0))
+	data, err := metric.ToBytes(m)
+	require.NoError(t, err)
+	recordSize := int64(segmentHeaderSize + len(data))
+
+	path := t.TempDir()
+	buf := newTestSegmentedBuffer(t, path, BufferLimits{MaxBytes: 12 * recordSize})
+	for range 9 {
+		buf.Add(m)
+	}
+	require.Len(t, buf.segments, 3)
+
+	// Accept the first five metrics so the first segment is fully
+	// acknowledged and the second one mostly
+	tx := buf.BeginTransaction(5)
+	tx.AcceptAll()
+	buf.EndTransaction(tx)
+
+	buf.Lock()
+	require.NoError(t, buf.compactSegments())
+	require.Len(t, buf.segments, 2)
+	require.Len(t, buf.segments[0].entries, 1)
+	require.Equal(t, 4*recordSize, buf.size)
+	buf.Unlock()
+	require.NoError(t, buf.Close())
+
+	// Acknowledged metrics must not be restored
+	buf = newTestSegmentedBuffer(t, path, BufferLimits{MaxBytes: 12 * recordSize})
+	defer buf.Close()
+	require.Equal(t, 4, buf.Len())
+}
@@ -142,12 +142,7 @@ func (f *Filter) Select(metric telegraf.Metric) (bool, error) {
 	}
 
 	if f.metricFilter != nil {
-		result, _, err := f.metricFilter.Eval(map[string]interface{}{
-			"name":   metric.Name(),
-			"tags":   metric.Tags(),
-			"fields": metric.Fields(),
-			"time":   metric.Time(),
-		})
+		result, _, err := f.metricFilter.Eval(MetricActivation(metric))
 		if err != nil {
 			return true, err
 		}
@@ -246,22 +241,7 @@ func (f *Filter) compileMetricFilter() error {
 	}
 
 	// Declare the computation environment for the filter including custom functions
-	env, err := cel.NewEnv(
-		cel.VariableDecls(
-			decls.NewVariable("name", types.StringType),
-			decls.NewVariable("tags", types.NewMapType(types.StringType, types.StringType)),
-			decls.NewVariable("fields", types.NewMapType(types.StringType, types.DynType)),
-			decls.NewVariable("time", types.TimestampType),
-		),
-		cel.Function(
-			"now",
-			cel.Overload("now", nil, cel.TimestampType),
-			cel.SingletonFunctionBinding(func(_ ...ref.Val) ref.Val { return types.Timestamp{Time: time.Now()} }),
-		),
-		ext.Encoders(),
-		ext.Math(),
-		ext.Strings(),
-	)
+	env, err := NewMetricEnvironment()
 	if err != nil {
 		return fmt.Errorf("creating environment failed: %w", err)
 	}
@@ -284,6 +264,39 @@ func (f *Filter) compileMetricFilter() error {
 	return err
 }
 
+// NewMetricEnvironment creates a CEL environment providing the "name",
+// "tags", "fields" and "time" variables of a metric as well as the custom
+// functions and extensions available to expressions
+func NewMetricEnvironment() (*cel.Env, error) {
+	return cel.NewEnv(
+		cel.VariableDecls(
+			decls.NewVariable("name", types.StringType),
+			decls.NewVariable("tags", types.NewMapType(types.StringType, types.StringType)),
+			decls.NewVariable("fields", types.NewMapType(types.StringType, types.DynType)),
+			decls.NewVariable("time", types.TimestampType),
+		),
+		cel.Function(
+			"now",
+			cel.Overload("now", nil, cel.TimestampType),
+			cel.SingletonFunctionBinding(func(_ ...ref.Val) ref.Val { return types.Timestamp{Time: time.Now()} }),
+		),
+		ext.Encoders(),
+		ext.Math(),
+		ext.Strings(),
+	)
+}
+
+// MetricActivation returns the variables of the given metric for evaluating
+// programs created from a NewMetricEnvironment
+func MetricActivation(metric telegraf.Metric) map[string]interface{} {
+	return map[string]interface{}{
+		"name":   metric.Name(),
+		"tags":   metric.Tags(),
+		"fields": metric.Fields(),
+		"time":   metric.Time(),
+	}
+}
+
 func ShouldPassFilters(include, exclude filter.Filter, key string) bool {
 	if include != nil && exclude != nil {
 		return include.Match(key) && !exclude.Match(key)
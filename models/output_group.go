@@ -0,0 +1,200 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"sort"
+	"strconv"
+	"sync/atomic"
+
+	"github.com/cespare/xxhash/v2"
+
+	"github.com/influxdata/telegraf"
+)
+
+// Number of points of each output on the hash ring
+const outputGroupReplicas = 128
+
+// OutputGroupConfig contains the configuration of an output group
+type OutputGroupConfig struct {
+	Name    string
+	Mode    string
+	Outputs []string
+	HashTag string
+}
+
+// Check verifies the group settings
+func (c *OutputGroupConfig) Check() error {
+	if c.Name == "" {
+		return errors.New("missing name")
+	}
+	if len(c.Outputs) == 0 {
+		return errors.New("no outputs specified")
+	}
+	for i, alias := range c.Outputs {
+		if alias == "" {
+			return errors.New("empty output alias")
+		}
+		if slices.Contains(c.Outputs[:i], alias) {
+			return fmt.Errorf("output %q listed multiple times", alias)
+		}
+	}
+
+	switch c.Mode {
+	case "failover", "round_robin":
+		if c.HashTag != "" {
+			return fmt.Errorf("'hash_tag' is not supported in mode %q", c.Mode)
+		}
+	case "hash":
+		if c.HashTag == "" {
+			return errors.New("'hash_tag' required in mode \"hash\"")
+		}
+	default:
+		return fmt.Errorf("invalid mode %q", c.Mode)
+	}
+	return nil
+}
+
+// OutputGroup routes each metric to exactly one of the outputs of the group
+// instead of sending the metric to all outputs.
+type OutputGroup struct {
+	Config *OutputGroupConfig
+
+	outputs []*RunningOutput
+	next    atomic.Uint64
+	ring    []ringPoint
+}
+
+// ringPoint is a point of an output on the consistent-hashing ring
+type ringPoint struct {
+	hash   uint64
+	output *RunningOutput
+}
+
+// NewOutputGroup creates a group of the given outputs. The outputs are used
+// in the order specified in the group configuration and outputs not
+// contained in the configuration are ignored.
+func NewOutputGroup(config *OutputGroupConfig, outputs []*RunningOutput) *OutputGroup {
+	g := &OutputGroup{Config: config}
+	for _, alias := range config.Outputs {
+		idx := slices.IndexFunc(outputs, func(o *RunningOutput) bool { return o.Config.Alias == alias })
+		if idx < 0 {
+			continue
+		}
+		g.outputs = append(g.outputs, outputs[idx])
+	}
+
+	// Use the alias for placing the outputs on the ring to keep the
+	// assignment of metrics stable across restarts and reordering
+	if config.Mode == "hash" {
+		g.ring = make([]ringPoint, 0, len(g.outputs)*outputGroupReplicas)
+		for _, output := range g.outputs {
+			for i := range outputGroupReplicas {
+				h := hashString(output.Config.Alias + "#" + strconv.Itoa(i))
+				g.ring = append(g.ring, ringPoint{hash: h, output: output})
+			}
+		}
+		sort.Slice(g.ring, func(i, j int) bool { return g.ring[i].hash < g.ring[j].hash })
+	}
+
+	return g
+}
+
+// Outputs returns the outputs of the group in order of the group configuration
+func (g *OutputGroup) Outputs() []*RunningOutput {
+	return g.outputs
+}
+
+// AddMetric adds the metric to the output selected by the group.
+// The given metric will be copied if the output selects the metric.
+func (g *OutputGroup) AddMetric(metric telegraf.Metric) {
+	output := g.selectOutput(metric)
+	if output == nil {
+		g.metricFiltered()
+		return
+	}
+	output.add(metric.Copy())
+}
+
+// AddMetricNoCopy adds the metric to the output selected by the group.
+// Takes ownership of metric regardless of whether an output selects it.
+func (g *OutputGroup) AddMetricNoCopy(metric telegraf.Metric) {
+	output := g.selectOutput(metric)
+	if output == nil {
+		g.metricFiltered()
+		metric.Drop()
+		return
+	}
+	output.add(metric)
+}
+
+// metricFiltered records a metric not selected by any output of the group
+func (g *OutputGroup) metricFiltered() {
+	for _, output := range g.outputs {
+		output.MetricsFiltered.Incr(1)
+	}
+}
+
+// selectOutput returns the output of the group for the metric. Metrics not
+// passing the filters of the output are passed on to the next output of the
+// group. The function returns nil if no output selects the metric.
+func (g *OutputGroup) selectOutput(metric telegraf.Metric) *RunningOutput {
+	if len(g.outputs) == 0 {
+		return nil
+	}
+
+	switch g.Config.Mode {
+	case "failover":
+		// Use the first output not failing to write and fall back to the
+		// failing outputs if none is available. Failing outputs without
+		// buffered metrics are also used to probe if the output recovered.
+		for _, output := range g.outputs {
+			if output.available() && output.selects(metric) {
+				return output
+			}
+		}
+		for _, output := range g.outputs {
+			if !output.available() && output.selects(metric) {
+				return output
+			}
+		}
+	case "round_robin":
+		n := uint64(len(g.outputs))
+		start := g.next.Add(1) - 1
+		for i := range n {
+			if output := g.outputs[(start+i)%n]; output.selects(metric) {
+				return output
+			}
+		}
+	case "hash":
+		// Metrics without the tag are all assigned to the same output
+		value, _ := metric.GetTag(g.Config.HashTag)
+		h := hashString(value)
+		idx := sort.Search(len(g.ring), func(i int) bool { return g.ring[i].hash >= h })
+		if idx == len(g.ring) {
+			idx = 0
+		}
+		if output := g.ring[idx].output; output.selects(metric) {
+			return output
+		}
+
+		// Walk the ring to find the next output selecting the metric
+		checked := map[*RunningOutput]bool{g.ring[idx].output: true}
+		for i := 1; i < len(g.ring) && len(checked) < len(g.outputs); i++ {
+			output := g.ring[(idx+i)%len(g.ring)].output
+			if checked[output] {
+				continue
+			}
+			if output.selects(metric) {
+				return output
+			}
+			checked[output] = true
+		}
+	}
+	return nil
+}
+
+func hashString(s string) uint64 {
+	return xxhash.Sum64String(s)
+}
@@ -0,0 +1,263 @@
+package models
+
+import (
+	"errors"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/internal"
+	"github.com/influxdata/telegraf/metric"
+)
+
+func TestOutputGroupCheck(t *testing.T) {
+	tests := []struct {
+		name     string
+		config   *OutputGroupConfig
+		expected string
+	}{
+		{
+			name:     "no name",
+			config:   &OutputGroupConfig{Mode: "failover", Outputs: []string{"a"}},
+			expected: "missing name",
+		},
+		{
+			name:     "no outputs",
+			config:   &OutputGroupConfig{Name: "g", Mode: "failover"},
+			expected: "no outputs specified",
+		},
+		{
+			name:     "duplicate output",
+			config:   &OutputGroupConfig{Name: "g", Mode: "failover", Outputs: []string{"a", "a"}},
+			expected: `output "a" listed multiple times`,
+		},
+		{
+			name:     "invalid mode",
+			config:   &OutputGroupConfig{Name: "g", Mode: "foo", Outputs: []string{"a"}},
+			expected: `invalid mode "foo"`,
+		},
+		{
+			name:     "hash without tag",
+			config:   &OutputGroupConfig{Name: "g", Mode: "hash", Outputs: []string{"a"}},
+			expected: `'hash_tag' required in mode "hash"`,
+		},
+		{
+			name:     "tag without hash",
+			config:   &OutputGroupConfig{Name: "g", Mode: "round_robin", Outputs: []string{"a"}, HashTag: "host"},
+			expected: `'hash_tag' is not supported in mode "round_robin"`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require.ErrorContains(t, tt.config.Check(), tt.expected)
+		})
+	}
+}
+
+func TestOutputGroupFailover(t *testing.T) {
+	primary := &mockOutput{}
+	secondary := &mockOutput{}
+	outputs := []*RunningOutput{
+		NewRunningOutput(secondary, &OutputConfig{Name: "mock", Alias: "secondary"}, 1000, 10000),
+		NewRunningOutput(primary, &OutputConfig{Name: "mock", Alias: "primary"}, 1000, 10000),
+		NewRunningOutput(&mockOutput{}, &OutputConfig{Name: "mock", Alias: "other"}, 1000, 10000),
+	}
+	cfg := &OutputGroupConfig{Name: "group", Mode: "failover", Outputs: []string{"primary", "secondary"}}
+	group := NewOutputGroup(cfg, outputs)
+	require.Equal(t, []*RunningOutput{outputs[1], outputs[0]}, group.Outputs())
+
+	// Write to the primary output while it succeeds
+	group.AddMetric(testMetric("a"))
+	require.NoError(t, outputs[1].Write())
+	require.Len(t, primary.Metrics(), 1)
+
+	// Spill to the secondary output while the primary fails
+	primary.batchAcceptSize = -1
+	group.AddMetric(testMetric("b"))
+	require.Error(t, outputs[1].Write())
+	group.AddMetric(testMetric("c"))
+	group.AddMetricNoCopy(testMetric("d"))
+	require.NoError(t, outputs[0].Write())
+	require.Len(t, secondary.Metrics(), 2)
+
+	// Switch back to the primary when it recovers and deliver the metrics
+	// buffered in the meantime
+	primary.batchAcceptSize = 0
+	require.NoError(t, outputs[1].Write())
+	group.AddMetric(testMetric("e"))
+	require.NoError(t, outputs[1].Write())
+	require.NoError(t, outputs[0].Write())
+	require.Len(t, primary.Metrics(), 3)
+	require.Len(t, secondary.Metrics(), 2)
+}
+
+func TestOutputGroupFailoverReconnect(t *testing.T) {
+	primary := &mockOutput{
+		startupError:      &internal.StartupError{Err: errors.New("retryable err"), Retry: true},
+		startupErrorCount: 2,
+	}
+	secondary := &mockOutput{}
+	outputs := []*RunningOutput{
+		NewRunningOutput(primary, &OutputConfig{Name: "mock", Alias: "primary", StartupErrorBehavior: "retry"}, 1000, 10000),
+		NewRunningOutput(secondary, &OutputConfig{Name: "mock", Alias: "secondary"}, 1000, 10000),
+	}
+	require.NoError(t, outputs[0].Connect())
+	cfg := &OutputGroupConfig{Name: "group", Mode: "failover", Outputs: []string{"primary", "secondary"}}
+	group := NewOutputGroup(cfg, outputs)
+
+	// Spill to the secondary output while the primary cannot connect
+	group.AddMetric(testMetric("a"))
+	require.ErrorIs(t, outputs[0].Write(), internal.ErrNotConnected)
+	group.AddMetric(testMetric("b"))
+	require.NoError(t, outputs[1].Write())
+	require.Len(t, secondary.Metrics(), 1)
+
+	// Switch back to the primary output after connecting successfully
+	require.NoError(t, outputs[0].Write())
+	require.Len(t, primary.Metrics(), 1)
+	group.AddMetric(testMetric("c"))
+	require.NoError(t, outputs[0].Write())
+	require.Len(t, primary.Metrics(), 2)
+	require.Len(t, secondary.Metrics(), 1)
+}
+
+func TestOutputGroupFailoverProbe(t *testing.T) {
+	primary := &mockOutput{}
+	secondary := &mockOutput{}
+	outputs := []*RunningOutput{
+		NewRunningOutput(primary, &OutputConfig{Name: "mock", Alias: "primary"}, 1000, 10000),
+		NewRunningOutput(secondary, &OutputConfig{Name: "mock", Alias: "secondary"}, 1000, 10000),
+	}
+	cfg := &OutputGroupConfig{Name: "group", Mode: "failover", Outputs: []string{"primary", "secondary"}}
+	group := NewOutputGroup(cfg, outputs)
+
+	// A failing output without buffered metrics receives a single metric to
+	// probe if the output recovered
+	outputs[0].failing.Store(true)
+	group.AddMetric(testMetric("a"))
+	group.AddMetric(testMetric("b"))
+	require.Equal(t, 1, outputs[0].BufferLength())
+	require.Equal(t, 1, outputs[1].BufferLength())
+
+	require.NoError(t, outputs[0].Write())
+	require.False(t, outputs[0].Failing())
+	group.AddMetric(testMetric("c"))
+	require.Equal(t, 1, outputs[0].BufferLength())
+}
+
+func TestOutputGroupFiltered(t *testing.T) {
+	for _, mode := range []string{"failover", "round_robin", "hash"} {
+		t.Run(mode, func(t *testing.T) {
+			filter := Filter{NameDrop: []string{"test"}}
+			require.NoError(t, filter.Compile())
+
+			mocks := []*mockOutput{{}, {}, {}}
+			outputs := []*RunningOutput{
+				NewRunningOutput(mocks[0], &OutputConfig{Name: "mock", Alias: "out0", Filter: filter}, 1000, 10000),
+				NewRunningOutput(mocks[1], &OutputConfig{Name: "mock", Alias: "out1"}, 1000, 10000),
+				NewRunningOutput(mocks[2], &OutputConfig{Name: "mock", Alias: "out2", Filter: filter}, 1000, 10000),
+			}
+			cfg := &OutputGroupConfig{Name: "group", Mode: mode, Outputs: []string{"out0", "out1", "out2"}}
+			if mode == "hash" {
+				cfg.HashTag = "host"
+			}
+			group := NewOutputGroup(cfg, outputs)
+
+			// Metrics rejected by the filters of an output are passed on to
+			// the next output of the group
+			for i := range 10 {
+				group.AddMetric(testMetric(strconv.Itoa(i)))
+				group.AddMetricNoCopy(testMetric(strconv.Itoa(i)))
+			}
+			for _, output := range outputs {
+				require.NoError(t, output.Write())
+			}
+			require.Empty(t, mocks[0].Metrics())
+			require.Len(t, mocks[1].Metrics(), 20)
+			require.Empty(t, mocks[2].Metrics())
+
+			// Metrics rejected by all outputs are dropped
+			outputs[1].Config.Filter = filter
+			group.AddMetric(testMetric("x"))
+			for _, output := range outputs {
+				require.Zero(t, output.BufferLength())
+			}
+		})
+	}
+}
+
+func TestOutputGroupRoundRobin(t *testing.T) {
+	mocks := []*mockOutput{{}, {}, {}}
+	outputs := make([]*RunningOutput, 0, len(mocks))
+	for i, m := range mocks {
+		cfg := &OutputConfig{Name: "mock", Alias: "out" + strconv.Itoa(i)}
+		outputs = append(outputs, NewRunningOutput(m, cfg, 1000, 10000))
+	}
+	cfg := &OutputGroupConfig{Name: "group", Mode: "round_robin", Outputs: []string{"out0", "out1", "out2"}}
+	group := NewOutputGroup(cfg, outputs)
+
+	for i := range 7 {
+		group.AddMetric(testMetric(strconv.Itoa(i)))
+	}
+	for _, output := range outputs {
+		require.NoError(t, output.Write())
+	}
+	require.Len(t, mocks[0].Metrics(), 3)
+	require.Len(t, mocks[1].Metrics(), 2)
+	require.Len(t, mocks[2].Metrics(), 2)
+}
+
+func TestOutputGroupHash(t *testing.T) {
+	mocks := []*mockOutput{{}, {}, {}}
+	outputs := make([]*RunningOutput, 0, len(mocks))
+	for i, m := range mocks {
+		cfg := &OutputConfig{Name: "mock", Alias: "shard" + strconv.Itoa(i)}
+		outputs = append(outputs, NewRunningOutput(m, cfg, 1000, 10000))
+	}
+	cfg := &OutputGroupConfig{Name: "group", Mode: "hash", Outputs: []string{"shard0", "shard1", "shard2"}, HashTag: "host"}
+	group := NewOutputGroup(cfg, outputs)
+
+	// Metrics with the same tag value must end up in the same output
+	for range 3 {
+		for i := range 30 {
+			group.AddMetric(testMetric("host" + strconv.Itoa(i)))
+		}
+	}
+	hosts := make(map[string]int)
+	for idx, output := range outputs {
+		require.NoError(t, output.Write())
+		require.NotEmpty(t, mocks[idx].Metrics())
+		for _, m := range mocks[idx].Metrics() {
+			host, _ := m.GetTag("host")
+			if prev, found := hosts[host]; found {
+				require.Equal(t, prev, idx, "host %q in multiple outputs", host)
+			}
+			hosts[host] = idx
+		}
+	}
+	require.Len(t, hosts, 30)
+
+	// Removing an output must only move the tag values assigned to that
+	// output
+	reduced := NewOutputGroup(cfg, outputs[:2])
+	for i := range 30 {
+		host := "host" + strconv.Itoa(i)
+		selected := reduced.selectOutput(testMetric(host))
+		if idx := hosts[host]; idx < 2 {
+			require.Same(t, outputs[idx], selected)
+		}
+	}
+}
+
+func testMetric(host string) telegraf.Metric {
+	return metric.New(
+		"test",
+		map[string]string{"host": host},
+		map[string]interface{}{"value": 42},
+		time.Unix(0, 0),
+	)
+}
@@ -0,0 +1,130 @@
+package models
+
+import (
+	"math"
+	"math/rand"
+	"sync"
+	"time"
+
+	"github.com/influxdata/telegraf"
+)
+
+const (
+	// Defaults of the retry policy
+	DefaultRetryMaxInterval      = 5 * time.Minute
+	DefaultRetryMultiplier       = 2.0
+	DefaultRetryJitter           = 0.2
+	DefaultCircuitBreakerTimeout = time.Minute
+)
+
+// RetryConfig contains the settings for retrying failed writes of an output
+type RetryConfig struct {
+	// InitialInterval is the delay after the first failed write, zero
+	// disables the exponential backoff
+	InitialInterval time.Duration
+	// MaxInterval limits the delay between writes
+	MaxInterval time.Duration
+	// Multiplier is the factor of the delay growth for each failed write
+	Multiplier float64
+	// Jitter is the fraction the delay is randomly varied by
+	Jitter float64
+	// MaxAttempts is the number of failed writes after which the oldest
+	// batch of metrics is expired, zero means unlimited
+	MaxAttempts int
+	// CircuitBreakerThreshold is the number of consecutive failed writes
+	// opening the circuit, zero disables the circuit breaker
+	CircuitBreakerThreshold int
+	// CircuitBreakerTimeout is the time writes are paused while the circuit
+	// is open
+	CircuitBreakerTimeout time.Duration
+}
+
+// Enabled returns true if any part of the retry policy is enabled
+func (c *RetryConfig) Enabled() bool {
+	return c.InitialInterval > 0 || c.MaxAttempts > 0 || c.CircuitBreakerThreshold > 0
+}
+
+// retryPolicy delays writes after failures using exponential backoff with
+// jitter. After a number of consecutive failures, the circuit breaker opens
+// and pauses writes for a fixed timeout. After the timeout, a single write is
+// attempted to probe the output ("half-open" state) closing the circuit on
+// success and opening it again on failure.
+type retryPolicy struct {
+	cfg RetryConfig
+	log telegraf.Logger
+
+	sync.Mutex
+	failures int
+	attempts int
+	next     time.Time
+	open     bool
+}
+
+func newRetryPolicy(cfg RetryConfig, log telegraf.Logger) *retryPolicy {
+	if cfg.MaxInterval <= 0 {
+		cfg.MaxInterval = DefaultRetryMaxInterval
+	}
+	if cfg.Multiplier <= 0 {
+		cfg.Multiplier = DefaultRetryMultiplier
+	}
+	if cfg.CircuitBreakerTimeout <= 0 {
+		cfg.CircuitBreakerTimeout = DefaultCircuitBreakerTimeout
+	}
+	return &retryPolicy{cfg: cfg, log: log}
+}
+
+// wait returns the remaining time until the next write may be attempted
+func (p *retryPolicy) wait(now time.Time) time.Duration {
+	p.Lock()
+	defer p.Unlock()
+
+	return max(0, p.next.Sub(now))
+}
+
+// success resets the policy after a successful write
+func (p *retryPolicy) success() {
+	p.Lock()
+	defer p.Unlock()
+
+	if p.open {
+		p.log.Infof("Circuit breaker closed after %d failed writes", p.failures)
+	}
+	p.failures = 0
+	p.attempts = 0
+	p.next = time.Time{}
+	p.open = false
+}
+
+// failure records a failed write and determines the time of the next write.
+// The function returns true if the maximum number of attempts is exceeded and
+// the written metrics should be expired.
+func (p *retryPolicy) failure(now time.Time) bool {
+	p.Lock()
+	defer p.Unlock()
+
+	p.failures++
+	p.attempts++
+
+	var delay time.Duration
+	if p.cfg.CircuitBreakerThreshold > 0 && p.failures >= p.cfg.CircuitBreakerThreshold {
+		if !p.open {
+			p.log.Warnf("Circuit breaker opened after %d failed writes, pausing writes for %s", p.failures, p.cfg.CircuitBreakerTimeout)
+		}
+		p.open = true
+		delay = p.cfg.CircuitBreakerTimeout
+	} else if p.cfg.InitialInterval > 0 {
+		d := float64(p.cfg.InitialInterval) * math.Pow(p.cfg.Multiplier, float64(p.failures-1))
+		d = min(d, float64(p.cfg.MaxInterval))
+		if p.cfg.Jitter > 0 {
+			d *= 1 + p.cfg.Jitter*(2*rand.Float64()-1) //nolint:gosec // G404: not security critical
+		}
+		delay = time.Duration(d)
+	}
+	p.next = now.Add(delay)
+
+	if p.cfg.MaxAttempts > 0 && p.attempts >= p.cfg.MaxAttempts {
+		p.attempts = 0
+		return true
+	}
+	return false
+}
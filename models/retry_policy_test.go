@@ -0,0 +1,89 @@
+package models
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/influxdata/telegraf/testutil"
+)
+
+func TestRetryPolicyBackoff(t *testing.T) {
+	cfg := RetryConfig{
+		InitialInterval: time.Second,
+		MaxInterval:     5 * time.Second,
+	}
+	p := newRetryPolicy(cfg, testutil.Logger{})
+
+	now := time.Unix(1700000000, 0)
+	require.Zero(t, p.wait(now))
+
+	for _, expected := range []time.Duration{time.Second, 2 * time.Second, 4 * time.Second, 5 * time.Second, 5 * time.Second} {
+		require.False(t, p.failure(now))
+		require.Equal(t, expected, p.wait(now))
+	}
+
+	p.success()
+	require.Zero(t, p.wait(now))
+}
+
+func TestRetryPolicyJitter(t *testing.T) {
+	cfg := RetryConfig{
+		InitialInterval: 10 * time.Second,
+		Jitter:          0.5,
+	}
+	p := newRetryPolicy(cfg, testutil.Logger{})
+
+	now := time.Unix(1700000000, 0)
+	for range 100 {
+		p.failure(now)
+		wait := p.wait(now)
+		require.GreaterOrEqual(t, wait, 5*time.Second)
+		require.LessOrEqual(t, wait, 15*time.Second)
+		p.success()
+	}
+}
+
+func TestRetryPolicyCircuitBreaker(t *testing.T) {
+	cfg := RetryConfig{
+		InitialInterval:         time.Second,
+		CircuitBreakerThreshold: 3,
+		CircuitBreakerTimeout:   time.Hour,
+	}
+	p := newRetryPolicy(cfg, testutil.Logger{})
+
+	now := time.Unix(1700000000, 0)
+	p.failure(now)
+	p.failure(now)
+	require.Equal(t, 2*time.Second, p.wait(now))
+
+	// Open the circuit
+	p.failure(now)
+	require.Equal(t, time.Hour, p.wait(now))
+
+	// Probe after the timeout and open the circuit again on failure
+	now = now.Add(time.Hour)
+	require.Zero(t, p.wait(now))
+	p.failure(now)
+	require.Equal(t, time.Hour, p.wait(now))
+
+	// Close the circuit on success
+	now = now.Add(time.Hour)
+	p.success()
+	p.failure(now)
+	require.Equal(t, time.Second, p.wait(now))
+}
+
+func TestRetryPolicyMaxAttempts(t *testing.T) {
+	p := newRetryPolicy(RetryConfig{MaxAttempts: 2}, testutil.Logger{})
+
+	now := time.Unix(1700000000, 0)
+	require.False(t, p.failure(now))
+	require.True(t, p.failure(now))
+	require.Zero(t, p.wait(now))
+
+	// The next batch gets the full number of attempts
+	require.False(t, p.failure(now))
+	require.True(t, p.failure(now))
+}
@@ -172,6 +172,13 @@ func (r *RunningAggregator) Add(m telegraf.Metric) bool {
 }
 
 func (r *RunningAggregator) Push(acc telegraf.Accumulator) {
+	r.PushAt(acc, time.Now())
+}
+
+// PushAt pushes the current aggregation and advances the aggregation window
+// using the given time as the current time. This allows to drive the
+// aggregation by a simulated clock, e.g. when replaying recorded metrics.
+func (r *RunningAggregator) PushAt(acc telegraf.Accumulator, now time.Time) {
 	r.Lock()
 	defer r.Unlock()
 
@@ -182,7 +189,7 @@ func (r *RunningAggregator) Push(acc telegraf.Accumulator) {
 	// not be the case if the machine's clock was adjusted or the machine
 	// hibernated as in those cases the clock might be advanced before or
 	// after the initial aggregation window.
-	nowWall := time.Now().Truncate(-1)
+	nowWall := now.Truncate(-1)
 	if nowWall.Before(since.Truncate(-1)) || nowWall.After(until.Truncate(-1)) {
 		since = nowWall.Truncate(r.Config.Period)
 		until = since.Add(r.Config.Period)
@@ -3,6 +3,7 @@ package models
 import (
 	"errors"
 	"fmt"
+	"sync/atomic"
 	"time"
 
 	"github.com/influxdata/telegraf"
@@ -29,6 +30,7 @@ type RunningInput struct {
 	retries     uint64
 	gatherStart time.Time
 	gatherEnd   time.Time
+	paused      atomic.Bool
 
 	MetricsGathered selfstat.Stat
 	GatherTime      selfstat.Stat
@@ -185,6 +187,21 @@ func (r *RunningInput) Stop() {
 	}
 }
 
+// Pause suspends gathering metrics until Resume is called
+func (r *RunningInput) Pause() {
+	r.paused.Store(true)
+}
+
+// Resume continues gathering metrics after Pause was called
+func (r *RunningInput) Resume() {
+	r.paused.Store(false)
+}
+
+// Paused returns if gathering metrics is suspended
+func (r *RunningInput) Paused() bool {
+	return r.paused.Load()
+}
+
 func (r *RunningInput) ID() string {
 	if p, ok := r.Input.(telegraf.PluginWithID); ok {
 		return p.ID()
@@ -1,8 +1,11 @@
 package models
 
 import (
+	"context"
 	"errors"
 	"fmt"
+	"net"
+	"os"
 	"sync"
 	"sync/atomic"
 	"time"
@@ -41,6 +44,17 @@ type OutputConfig struct {
 
 	BufferStrategy  string
 	BufferDirectory string
+	BufferMaxBytes  int64
+	BufferMaxAge    time.Duration
+
+	AdaptiveBatching      bool
+	AdaptiveBatchSizeMin  int
+	AdaptiveBatchSizeMax  int
+	AdaptiveTargetLatency time.Duration
+	MaxConcurrentWrites   int
+
+	Retry            RetryConfig
+	DeadLetterOutput string
 
 	LogLevel string
 }
@@ -60,14 +74,38 @@ type RunningOutput struct {
 	WriteTime       selfstat.Stat
 	StartupErrors   selfstat.Stat
 
+	// Statistics of adaptive batching, only set if enabled
+	BatchSize        selfstat.Stat
+	ConcurrentWrites selfstat.Stat
+
 	BatchReady chan time.Time
 
-	buffer Buffer
-	log    telegraf.Logger
+	// FlushRequested signals a request to flush the output immediately
+	FlushRequested chan struct{}
+
+	// The buffer is created on connecting or first use to avoid opening the
+	// buffer of instances that are never started, e.g. on reload
+	buffer      Buffer
+	bufferStats BufferStats
+	bufferErr   error
+	bufferOnce  sync.Once
+	log         telegraf.Logger
 
 	started bool
 	retries uint64
 
+	// failing is set while writing to the output fails
+	failing atomic.Bool
+
+	// batcher adjusts the batch size in adaptive batching mode
+	batcher *adaptiveBatcher
+
+	// retry delays writes after failures if a retry policy is configured
+	retry *retryPolicy
+
+	// deadLetter receives the rejected and expired metrics if configured
+	deadLetter atomic.Pointer[RunningOutput]
+
 	aggMutex sync.Mutex
 }
 
@@ -100,14 +138,10 @@ func NewRunningOutput(output telegraf.Output, config *OutputConfig, batchSize, b
 		batchSize = DefaultMetricBatchSize
 	}
 
-	b, err := NewBuffer(config.Name, config.ID, config.Alias, bufferLimit, config.BufferStrategy, config.BufferDirectory)
-	if err != nil {
-		panic(err)
-	}
-
 	ro := &RunningOutput{
-		buffer:            b,
+		bufferStats:       NewBufferStats(config.Name, config.Alias, bufferLimit),
 		BatchReady:        make(chan time.Time, 1),
+		FlushRequested:    make(chan struct{}, 1),
 		Output:            output,
 		Config:            config,
 		MetricBufferLimit: bufferLimit,
@@ -130,9 +164,57 @@ func NewRunningOutput(output telegraf.Output, config *OutputConfig, batchSize, b
 		log: logger,
 	}
 
+	if config.AdaptiveBatching {
+		minSize := config.AdaptiveBatchSizeMin
+		if minSize <= 0 {
+			minSize = max(1, batchSize/10)
+		}
+		maxSize := config.AdaptiveBatchSizeMax
+		if maxSize <= 0 {
+			maxSize = min(10*batchSize, bufferLimit)
+		}
+		target := config.AdaptiveTargetLatency
+		if target <= 0 {
+			target = DefaultAdaptiveTargetLatency
+		}
+		ro.batcher = newAdaptiveBatcher(batchSize, minSize, maxSize, max(1, config.MaxConcurrentWrites), target)
+		ro.BatchSize = selfstat.Register("write", "batch_size", tags)
+		ro.ConcurrentWrites = selfstat.Register("write", "concurrent_writes", tags)
+		ro.updateBatchStats()
+	}
+
+	if config.Retry.Enabled() {
+		ro.retry = newRetryPolicy(config.Retry, logger)
+	}
+
 	return ro
 }
 
+// openBuffer creates the buffer of the output if not done before
+func (r *RunningOutput) openBuffer() error {
+	r.bufferOnce.Do(func() {
+		limits := BufferLimits{MaxBytes: r.Config.BufferMaxBytes, MaxAge: r.Config.BufferMaxAge}
+		r.buffer, r.bufferErr = newBuffer(
+			r.Config.Name,
+			r.Config.ID,
+			r.MetricBufferLimit,
+			r.Config.BufferStrategy,
+			r.Config.BufferDirectory,
+			limits,
+			r.bufferStats,
+		)
+	})
+	return r.bufferErr
+}
+
+// buf returns the buffer of the output, creating it if necessary
+func (r *RunningOutput) buf() Buffer {
+	if err := r.openBuffer(); err != nil {
+		panic(err)
+	}
+	return r.buffer
+}
+
 func (r *RunningOutput) LogName() string {
 	return logName("outputs", r.Config.Name, r.Config.Alias)
 }
@@ -156,6 +238,36 @@ func (r *RunningOutput) Init() error {
 		return fmt.Errorf("invalid 'startup_error_behavior' setting %q", r.Config.StartupErrorBehavior)
 	}
 
+	if r.Config.MaxConcurrentWrites < 0 {
+		return errors.New("'max_concurrent_writes' must not be negative")
+	}
+	if r.Config.MaxConcurrentWrites > 1 {
+		p, ok := r.Output.(telegraf.ConcurrentOutput)
+		if !ok || !p.SupportsConcurrentWrites() {
+			return errors.New("output does not support concurrent writes")
+		}
+	}
+	if r.Config.AdaptiveBatchSizeMin < 0 || r.Config.AdaptiveBatchSizeMax < 0 {
+		return errors.New("adaptive batch size bounds must not be negative")
+	}
+	if r.batcher != nil && r.batcher.minSize > r.batcher.maxSize {
+		return fmt.Errorf("adaptive batch size minimum %d exceeds maximum %d", r.batcher.minSize, r.batcher.maxSize)
+	}
+
+	retry := r.Config.Retry
+	if retry.InitialInterval < 0 || retry.MaxInterval < 0 || retry.CircuitBreakerTimeout < 0 {
+		return errors.New("retry intervals must not be negative")
+	}
+	if retry.MaxAttempts < 0 || retry.CircuitBreakerThreshold < 0 {
+		return errors.New("retry attempts and circuit breaker threshold must not be negative")
+	}
+	if retry.Multiplier != 0 && retry.Multiplier < 1 {
+		return fmt.Errorf("retry multiplier %v must not be less than one", retry.Multiplier)
+	}
+	if retry.Jitter < 0 || retry.Jitter > 1 {
+		return fmt.Errorf("retry jitter %v must be between zero and one", retry.Jitter)
+	}
+
 	if p, ok := r.Output.(telegraf.Initializer); ok {
 		err := p.Init()
 		if err != nil {
@@ -166,6 +278,10 @@ func (r *RunningOutput) Init() error {
 }
 
 func (r *RunningOutput) Connect() error {
+	if err := r.openBuffer(); err != nil {
+		return fmt.Errorf("creating buffer failed: %w", err)
+	}
+
 	// Try to connect and exit early on success
 	err := r.Output.Connect()
 	if err == nil {
@@ -201,6 +317,11 @@ func (r *RunningOutput) Close() {
 		r.log.Errorf("Error closing output: %v", err)
 	}
 
+	// Do not create the buffer of never used outputs just for closing it
+	r.bufferOnce.Do(func() {})
+	if r.buffer == nil {
+		return
+	}
 	if err := r.buffer.Close(); err != nil {
 		r.log.Errorf("Error closing output buffer: %v", err)
 	}
@@ -209,10 +330,7 @@ func (r *RunningOutput) Close() {
 // AddMetric adds a metric to the output.
 // The given metric will be copied if the output selects the metric.
 func (r *RunningOutput) AddMetric(metric telegraf.Metric) {
-	ok, err := r.Config.Filter.Select(metric)
-	if err != nil {
-		r.log.Errorf("filtering failed: %v", err)
-	} else if !ok {
+	if !r.selects(metric) {
 		r.MetricsFiltered.Incr(1)
 		return
 	}
@@ -223,10 +341,7 @@ func (r *RunningOutput) AddMetric(metric telegraf.Metric) {
 // AddMetricNoCopy adds a metric to the output.
 // Takes ownership of metric regardless of whether the output selects it for outputting.
 func (r *RunningOutput) AddMetricNoCopy(metric telegraf.Metric) {
-	ok, err := r.Config.Filter.Select(metric)
-	if err != nil {
-		r.log.Errorf("filtering failed: %v", err)
-	} else if !ok {
+	if !r.selects(metric) {
 		r.metricFiltered(metric)
 		return
 	}
@@ -234,6 +349,17 @@ func (r *RunningOutput) AddMetricNoCopy(metric telegraf.Metric) {
 	r.add(metric)
 }
 
+// selects returns true if the metric passes the filters of the output.
+// Metrics are selected if filtering fails.
+func (r *RunningOutput) selects(metric telegraf.Metric) bool {
+	ok, err := r.Config.Filter.Select(metric)
+	if err != nil {
+		r.log.Errorf("filtering failed: %v", err)
+		return true
+	}
+	return ok
+}
+
 func (r *RunningOutput) add(metric telegraf.Metric) {
 	r.Config.Filter.Modify(metric)
 	if len(metric.FieldList()) == 0 {
@@ -260,11 +386,12 @@ func (r *RunningOutput) add(metric telegraf.Metric) {
 		metric.AddSuffix(r.Config.NameSuffix)
 	}
 
-	dropped := r.buffer.Add(metric)
+	dropped := r.buf().Add(metric)
 	atomic.AddInt64(&r.droppedMetrics, int64(dropped))
 
+	size, _ := r.batchLimits()
 	count := atomic.AddInt64(&r.newMetricsCount, 1)
-	if count == int64(r.MetricBatchSize) {
+	if count >= int64(size) {
 		atomic.StoreInt64(&r.newMetricsCount, 0)
 		select {
 		case r.BatchReady <- time.Now():
@@ -276,6 +403,18 @@ func (r *RunningOutput) add(metric telegraf.Metric) {
 // Write writes all metrics to the output, stopping when all have been sent on
 // or error.
 func (r *RunningOutput) Write() error {
+	return r.write(false)
+}
+
+// WriteFinal writes all metrics to the output like Write but ignores the retry
+// policy, so the write is attempted even while backing off after failed writes
+// or while the circuit breaker is open. Use this function for flushing the
+// output one last time on shutdown.
+func (r *RunningOutput) WriteFinal() error {
+	return r.write(true)
+}
+
+func (r *RunningOutput) write(final bool) error {
 	// Try to connect if we are not yet started up
 	if !r.started {
 		r.retries++
@@ -283,11 +422,13 @@ func (r *RunningOutput) Write() error {
 			var serr *internal.StartupError
 			if !errors.As(err, &serr) || !serr.Retry || !serr.Partial {
 				r.StartupErrors.Incr(1)
+				r.failing.Store(true)
 				return internal.ErrNotConnected
 			}
 			r.log.Debugf("Partially connected after %d attempts", r.retries)
 		} else {
 			r.started = true
+			r.failing.Store(false)
 			r.log.Debugf("Successfully connected after %d attempts", r.retries)
 		}
 	}
@@ -295,28 +436,28 @@ func (r *RunningOutput) Write() error {
 	if output, ok := r.Output.(telegraf.AggregatingOutput); ok {
 		r.aggMutex.Lock()
 		metrics := output.Push()
-		r.buffer.Add(metrics...)
+		r.buf().Add(metrics...)
 		output.Reset()
 		r.aggMutex.Unlock()
 	}
 
 	atomic.StoreInt64(&r.newMetricsCount, 0)
 
+	if !final && r.postponeWrite() {
+		return nil
+	}
+
 	// Only process the metrics in the buffer now. Metrics added while we are
 	// writing will be sent on the next call.
-	nBuffer := r.buffer.Len()
-	nBatches := nBuffer/r.MetricBatchSize + 1
-	for i := 0; i < nBatches; i++ {
-		tx := r.buffer.BeginTransaction(r.MetricBatchSize)
-		if len(tx.Batch) == 0 {
-			return nil
-		}
-		err := r.writeMetrics(tx.Batch)
-		r.updateTransaction(tx, err)
-		r.buffer.EndTransaction(tx)
+	for nBuffer := r.buf().Len(); nBuffer > 0; {
+		n, err := r.writeTransaction()
 		if err != nil {
 			return err
 		}
+		if n == 0 {
+			return nil
+		}
+		nBuffer -= n
 	}
 	return nil
 }
@@ -328,23 +469,106 @@ func (r *RunningOutput) WriteBatch() error {
 		r.retries++
 		if err := r.Output.Connect(); err != nil {
 			r.StartupErrors.Incr(1)
+			r.failing.Store(true)
 			return internal.ErrNotConnected
 		}
 		r.started = true
+		r.failing.Store(false)
 		r.log.Debugf("Successfully connected after %d attempts", r.retries)
 	}
 
-	tx := r.buffer.BeginTransaction(r.MetricBatchSize)
-	if len(tx.Batch) == 0 {
+	if r.postponeWrite() {
 		return nil
 	}
-	err := r.writeMetrics(tx.Batch)
-	r.updateTransaction(tx, err)
-	r.buffer.EndTransaction(tx)
 
+	_, err := r.writeTransaction()
 	return err
 }
 
+// postponeWrite returns true if writing should be skipped due to the retry
+// policy after failed writes
+func (r *RunningOutput) postponeWrite() bool {
+	if r.retry == nil {
+		return false
+	}
+	if wait := r.retry.wait(time.Now()); wait > 0 {
+		r.log.Debugf("Postponing write for %s after failed writes", wait)
+		return true
+	}
+	return false
+}
+
+// writeTransaction writes the oldest metrics of the buffer and returns the
+// number of metrics taken from the buffer. With concurrent writes enabled,
+// the transaction contains multiple batches written in parallel.
+func (r *RunningOutput) writeTransaction() (int, error) {
+	size, concurrency := r.batchLimits()
+	tx := r.buf().BeginTransaction(size * concurrency)
+	if len(tx.Batch) == 0 {
+		return 0, nil
+	}
+
+	var err error
+	if len(tx.Batch) > size {
+		err = r.writeConcurrently(tx, size)
+	} else {
+		err = r.writeMetrics(tx.Batch)
+		r.finishTransaction(tx, err)
+	}
+	r.buf().EndTransaction(tx)
+
+	return len(tx.Batch), err
+}
+
+// writeConcurrently splits the transaction into batches of the given size and
+// writes the batches in parallel. The results of the individual writes are
+// merged into the given transaction.
+func (r *RunningOutput) writeConcurrently(tx *Transaction, size int) error {
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	var errs []error
+	for offset := 0; offset < len(tx.Batch); offset += size {
+		batch := tx.Batch[offset:min(offset+size, len(tx.Batch))]
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			sub := &Transaction{Batch: batch}
+			err := r.writeMetrics(batch)
+			r.finishTransaction(sub, err)
+
+			mu.Lock()
+			defer mu.Unlock()
+			for _, idx := range sub.Accept {
+				tx.Accept = append(tx.Accept, offset+idx)
+			}
+			for _, idx := range sub.Reject {
+				tx.Reject = append(tx.Reject, offset+idx)
+			}
+			if err != nil {
+				errs = append(errs, err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	return errors.Join(errs...)
+}
+
+// batchLimits returns the current batch size and number of concurrent writes
+func (r *RunningOutput) batchLimits() (size, concurrency int) {
+	if r.batcher != nil {
+		return r.batcher.limits()
+	}
+	return r.MetricBatchSize, max(1, r.Config.MaxConcurrentWrites)
+}
+
+func (r *RunningOutput) updateBatchStats() {
+	size, concurrency := r.batcher.limits()
+	r.BatchSize.Set(int64(size))
+	r.ConcurrentWrites.Set(int64(concurrency))
+}
+
 func (r *RunningOutput) writeMetrics(metrics []telegraf.Metric) error {
 	dropped := atomic.LoadInt64(&r.droppedMetrics)
 	if dropped > 0 {
@@ -356,6 +580,11 @@ func (r *RunningOutput) writeMetrics(metrics []telegraf.Metric) error {
 	err := r.Output.Write(metrics)
 	elapsed := time.Since(start)
 	r.WriteTime.Incr(elapsed.Nanoseconds())
+	r.failing.Store(err != nil)
+	if r.batcher != nil {
+		r.batcher.update(elapsed, err)
+		r.updateBatchStats()
+	}
 
 	if err == nil {
 		r.log.Debugf("Wrote batch of %d metrics in %s", len(metrics), elapsed)
@@ -363,6 +592,81 @@ func (r *RunningOutput) writeMetrics(metrics []telegraf.Metric) error {
 	return err
 }
 
+// finishTransaction updates the transaction with the result of the write and
+// applies the retry policy. Metrics of the oldest batch are rejected as expired
+// if the maximum number of write attempts is exceeded. Rejected and expired
+// metrics are sent to the dead-letter output if configured.
+func (r *RunningOutput) finishTransaction(tx *Transaction, err error) {
+	r.updateTransaction(tx, err)
+	rejected := len(tx.Reject)
+
+	// A write making no progress at all is considered as failed
+	if r.retry != nil {
+		if err == nil || len(tx.Accept) > 0 || len(tx.Reject) > 0 {
+			r.retry.success()
+		} else if r.retry.failure(time.Now()) {
+			r.log.Warnf("Expiring %d metrics after %d failed write attempts", len(tx.Batch), r.Config.Retry.MaxAttempts)
+			tx.Reject = append(tx.Reject, tx.InferKeep()...)
+		}
+	}
+
+	dl := r.deadLetter.Load()
+	if dl == nil {
+		return
+	}
+	for i, idx := range tx.Reject {
+		reason := "rejected"
+		if i >= rejected {
+			reason = "expired"
+		}
+		m := tx.Batch[idx].Copy()
+		m.AddTag("dead_letter_reason", reason)
+		m.AddTag("dead_letter_output", r.LogName())
+		if err != nil {
+			// Use the error of the individual metric if available
+			merr := err
+			var werr *internal.PartialWriteError
+			if i < rejected && errors.As(err, &werr) && len(werr.MetricsRejectErrors) == len(werr.MetricsReject) {
+				merr = werr.MetricsRejectErrors[i]
+			}
+			m.AddTag("dead_letter_error", deadLetterClass(err))
+			m.AddField("dead_letter_message", merr.Error())
+		}
+		dl.AddMetricNoCopy(m)
+	}
+}
+
+// deadLetterClass returns the class of the given write error. Only a small,
+// fixed set of classes is used to limit the cardinality of the tag.
+func deadLetterClass(err error) string {
+	switch {
+	case errors.Is(err, internal.ErrSerialization):
+		return "serialization"
+	case errors.Is(err, internal.ErrSizeLimitReached):
+		return "size_limit"
+	case errors.Is(err, internal.ErrNotConnected):
+		return "not_connected"
+	case errors.Is(err, context.DeadlineExceeded), errors.Is(err, os.ErrDeadlineExceeded):
+		return "timeout"
+	}
+
+	var nerr net.Error
+	if errors.As(err, &nerr) && nerr.Timeout() {
+		return "timeout"
+	}
+	var werr *internal.PartialWriteError
+	if errors.As(err, &werr) {
+		return "rejected"
+	}
+	return "write"
+}
+
+// SetDeadLetterOutput sets the output receiving the rejected and expired
+// metrics of this output, nil disables forwarding the metrics.
+func (r *RunningOutput) SetDeadLetterOutput(output *RunningOutput) {
+	r.deadLetter.Store(output)
+}
+
 func (*RunningOutput) updateTransaction(tx *Transaction, err error) {
 	// No error indicates all metrics were written successfully
 	if err == nil {
@@ -383,9 +687,21 @@ func (*RunningOutput) updateTransaction(tx *Transaction, err error) {
 	tx.Reject = writeErr.MetricsReject
 }
 
+// Failing returns true if the last attempt to write to the output failed.
+func (r *RunningOutput) Failing() bool {
+	return r.failing.Load()
+}
+
+// available returns true if the output is not failing or if the output is
+// failing without buffered metrics, so new metrics probe if the output
+// recovered.
+func (r *RunningOutput) available() bool {
+	return !r.Failing() || r.BufferLength() == 0
+}
+
 func (r *RunningOutput) LogBufferStatus() {
-	nBuffer := r.buffer.Len()
-	if r.Config.BufferStrategy == "disk" {
+	nBuffer := r.buf().Len()
+	if r.Config.BufferStrategy == "disk" || r.Config.BufferStrategy == "segmented" {
 		r.log.Debugf("Buffer fullness: %d metrics", nBuffer)
 	} else {
 		r.log.Debugf("Buffer fullness: %d / %d metrics", nBuffer, r.MetricBufferLimit)
@@ -397,5 +713,18 @@ func (r *RunningOutput) Log() telegraf.Logger {
 }
 
 func (r *RunningOutput) BufferLength() int {
-	return r.buffer.Len()
+	return r.buf().Len()
+}
+
+func (r *RunningOutput) BufferStats() BufferStats {
+	return r.buf().Stats()
+}
+
+// RequestFlush requests an immediate flush of the output. Requests are
+// coalesced if a flush is already pending.
+func (r *RunningOutput) RequestFlush() {
+	select {
+	case r.FlushRequested <- struct{}{}:
+	default:
+	}
 }
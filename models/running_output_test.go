@@ -1,7 +1,12 @@
 package models
 
 import (
+	"context"
 	"errors"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
 	"sync"
 	"testing"
 	"time"
@@ -412,6 +417,46 @@ func TestRunningOutputWriteFailOrder3(t *testing.T) {
 	require.Equal(t, expected, m.Metrics())
 }
 
+func TestRunningOutputBufferOpenedOnConnect(t *testing.T) {
+	dir := t.TempDir()
+	ro := NewRunningOutput(
+		&mockOutput{},
+		&OutputConfig{
+			Name:            "test",
+			ID:              "test-id",
+			BufferStrategy:  "disk",
+			BufferDirectory: dir,
+		},
+		1000,
+		10000,
+	)
+	require.NoError(t, ro.Init())
+
+	// The buffer must not be opened for instances never started
+	require.NoDirExists(t, filepath.Join(dir, "test-id"))
+
+	require.NoError(t, ro.Connect())
+	require.DirExists(t, filepath.Join(dir, "test-id"))
+	ro.Close()
+}
+
+func TestRunningOutputCloseUnused(t *testing.T) {
+	dir := t.TempDir()
+	ro := NewRunningOutput(
+		&mockOutput{},
+		&OutputConfig{
+			Name:            "test",
+			ID:              "test-id",
+			BufferStrategy:  "disk",
+			BufferDirectory: dir,
+		},
+		1000,
+		10000,
+	)
+	ro.Close()
+	require.NoDirExists(t, filepath.Join(dir, "test-id"))
+}
+
 func TestRunningOutputInternalMetrics(t *testing.T) {
 	_ = NewRunningOutput(
 		&mockOutput{},
@@ -865,6 +910,271 @@ func TestRunningOutputWriteBatchPartialSuccessAndLoss(t *testing.T) {
 }
 
 // Benchmark adding metrics.
+func TestRunningOutputConcurrentWritesUnsupported(t *testing.T) {
+	ro := NewRunningOutput(&mockOutput{}, &OutputConfig{MaxConcurrentWrites: 2}, 5, 10)
+	require.ErrorContains(t, ro.Init(), "output does not support concurrent writes")
+}
+
+func TestRunningOutputConcurrentWrites(t *testing.T) {
+	plugin := &concurrentOutput{}
+	model := NewRunningOutput(plugin, &OutputConfig{MaxConcurrentWrites: 3}, 2, 20)
+	require.NoError(t, model.Init())
+	require.NoError(t, model.Connect())
+	defer model.Close()
+
+	for _, metric := range append(first5, next5...) {
+		model.AddMetric(metric)
+	}
+
+	// Up to three batches of two metrics are written in one transaction
+	require.NoError(t, model.WriteBatch())
+	require.Equal(t, 3, plugin.writes)
+	require.Len(t, plugin.Metrics(), 6)
+	require.Equal(t, 4, model.buffer.Len())
+
+	require.NoError(t, model.Write())
+	require.Equal(t, 5, plugin.writes)
+	testutil.RequireMetricsEqual(t, append(first5, next5...), plugin.Metrics(), testutil.SortMetrics())
+	require.Zero(t, model.buffer.Len())
+}
+
+func TestRunningOutputConcurrentWritesPartialFailure(t *testing.T) {
+	plugin := &concurrentOutput{fail: map[string]bool{"metric3": true}}
+	model := NewRunningOutput(plugin, &OutputConfig{MaxConcurrentWrites: 3}, 2, 20)
+	require.NoError(t, model.Init())
+	require.NoError(t, model.Connect())
+	defer model.Close()
+
+	for _, metric := range first5 {
+		model.AddMetric(metric)
+	}
+
+	// The batch containing the failing metric must be kept in the buffer
+	require.ErrorContains(t, model.WriteBatch(), "failed write")
+	require.Len(t, plugin.Metrics(), 3)
+	require.Equal(t, 2, model.buffer.Len())
+
+	plugin.fail = nil
+	require.NoError(t, model.Write())
+	testutil.RequireMetricsEqual(t, first5, plugin.Metrics(), testutil.SortMetrics())
+}
+
+func TestRunningOutputAdaptiveBatching(t *testing.T) {
+	plugin := &concurrentOutput{}
+	cfg := &OutputConfig{
+		AdaptiveBatching:      true,
+		AdaptiveBatchSizeMin:  1,
+		AdaptiveBatchSizeMax:  4,
+		AdaptiveTargetLatency: time.Minute,
+		MaxConcurrentWrites:   2,
+	}
+	model := NewRunningOutput(plugin, cfg, 2, 100)
+	require.NoError(t, model.Init())
+	require.NoError(t, model.Connect())
+	defer model.Close()
+	require.Equal(t, int64(2), model.BatchSize.Get())
+	require.Equal(t, int64(1), model.ConcurrentWrites.Get())
+
+	for range 5 {
+		for _, metric := range first5 {
+			model.AddMetric(metric)
+		}
+		require.NoError(t, model.Write())
+	}
+	require.Len(t, plugin.Metrics(), 25)
+	require.Equal(t, int64(4), model.BatchSize.Get())
+	require.Equal(t, int64(2), model.ConcurrentWrites.Get())
+
+	// Back off on errors
+	plugin.fail = map[string]bool{"metric1": true}
+	for _, metric := range first5 {
+		model.AddMetric(metric)
+	}
+	require.Error(t, model.Write())
+	require.Equal(t, int64(2), model.BatchSize.Get())
+	require.Equal(t, int64(1), model.ConcurrentWrites.Get())
+}
+
+func TestRunningOutputAdaptiveBatchingInvalidBounds(t *testing.T) {
+	cfg := &OutputConfig{
+		AdaptiveBatching:     true,
+		AdaptiveBatchSizeMin: 100,
+		AdaptiveBatchSizeMax: 10,
+	}
+	ro := NewRunningOutput(&mockOutput{}, cfg, 5, 10)
+	require.ErrorContains(t, ro.Init(), "adaptive batch size minimum 100 exceeds maximum 10")
+}
+
+func TestRunningOutputRetryPostpone(t *testing.T) {
+	plugin := &mockOutput{batchAcceptSize: -1}
+	cfg := &OutputConfig{Retry: RetryConfig{InitialInterval: time.Hour}}
+	model := NewRunningOutput(plugin, cfg, 5, 10)
+	require.NoError(t, model.Init())
+	require.NoError(t, model.Connect())
+	defer model.Close()
+
+	for _, metric := range first5 {
+		model.AddMetric(metric)
+	}
+	require.Error(t, model.Write())
+	require.Equal(t, 1, plugin.writes)
+
+	// Further writes are postponed until the backoff elapsed
+	plugin.batchAcceptSize = 0
+	require.NoError(t, model.Write())
+	require.NoError(t, model.WriteBatch())
+	require.Equal(t, 1, plugin.writes)
+	require.Equal(t, 5, model.buffer.Len())
+
+	model.retry.next = time.Time{}
+	require.NoError(t, model.Write())
+	require.Equal(t, 2, plugin.writes)
+	require.Zero(t, model.buffer.Len())
+}
+
+func TestRunningOutputRetryFinalWrite(t *testing.T) {
+	plugin := &mockOutput{batchAcceptSize: -1}
+	cfg := &OutputConfig{Retry: RetryConfig{CircuitBreakerThreshold: 1, CircuitBreakerTimeout: time.Hour}}
+	model := NewRunningOutput(plugin, cfg, 5, 10)
+	require.NoError(t, model.Init())
+	require.NoError(t, model.Connect())
+	defer model.Close()
+
+	for _, metric := range first5 {
+		model.AddMetric(metric)
+	}
+	require.Error(t, model.Write())
+	require.Equal(t, 1, plugin.writes)
+
+	// The final write must be attempted even if the circuit is open
+	plugin.batchAcceptSize = 0
+	require.NoError(t, model.Write())
+	require.Equal(t, 1, plugin.writes)
+	require.NoError(t, model.WriteFinal())
+	require.Equal(t, 2, plugin.writes)
+	require.Zero(t, model.buffer.Len())
+}
+
+func TestRunningOutputDeadLetterRejected(t *testing.T) {
+	lost := 1
+	plugin := &mockOutput{batchAcceptSize: 4, metricFatalIndex: &lost}
+	model := NewRunningOutput(plugin, &OutputConfig{Name: "mock"}, 5, 10)
+	require.NoError(t, model.Init())
+	require.NoError(t, model.Connect())
+	defer model.Close()
+
+	dlPlugin := &mockOutput{}
+	dl := NewRunningOutput(dlPlugin, &OutputConfig{Name: "dlq"}, 5, 10)
+	model.SetDeadLetterOutput(dl)
+
+	for _, metric := range first5 {
+		model.AddMetric(metric)
+	}
+	require.ErrorIs(t, model.WriteBatch(), internal.ErrSizeLimitReached)
+	require.NoError(t, dl.Write())
+
+	expected := []telegraf.Metric{
+		testutil.MustMetric(
+			"metric2",
+			map[string]string{
+				"tag1":               "value1",
+				"dead_letter_reason": "rejected",
+				"dead_letter_output": "outputs.mock",
+				"dead_letter_error":  "size_limit",
+			},
+			map[string]interface{}{
+				"value":               101,
+				"dead_letter_message": internal.ErrSizeLimitReached.Error(),
+			},
+			time.Unix(0, 0),
+		),
+	}
+	testutil.RequireMetricsEqual(t, expected, dlPlugin.Metrics(), testutil.IgnoreTime())
+}
+
+func TestRunningOutputDeadLetterExpired(t *testing.T) {
+	plugin := &mockOutput{batchAcceptSize: -1}
+	cfg := &OutputConfig{Name: "mock", Alias: "primary", Retry: RetryConfig{MaxAttempts: 2}}
+	model := NewRunningOutput(plugin, cfg, 3, 10)
+	require.NoError(t, model.Init())
+	require.NoError(t, model.Connect())
+	defer model.Close()
+
+	dlPlugin := &mockOutput{}
+	dl := NewRunningOutput(dlPlugin, &OutputConfig{Name: "dlq"}, 5, 10)
+	model.SetDeadLetterOutput(dl)
+
+	for _, metric := range first5 {
+		model.AddMetric(metric)
+	}
+
+	// The oldest batch is expired after the second failed attempt
+	require.Error(t, model.WriteBatch())
+	require.Equal(t, 5, model.buffer.Len())
+	require.Error(t, model.WriteBatch())
+	require.Equal(t, 2, model.buffer.Len())
+
+	require.NoError(t, dl.Write())
+	require.Len(t, dlPlugin.Metrics(), 3)
+	for i, m := range dlPlugin.Metrics() {
+		require.Equal(t, first5[i].Name(), m.Name())
+		require.Equal(t, map[string]string{
+			"tag1":               "value1",
+			"dead_letter_reason": "expired",
+			"dead_letter_output": "outputs.mock::primary",
+			"dead_letter_error":  "write",
+		}, m.Tags())
+		msg, found := m.GetField("dead_letter_message")
+		require.True(t, found)
+		require.Equal(t, "failed write", msg)
+	}
+}
+
+func TestRunningOutputDeadLetterClass(t *testing.T) {
+	tests := []struct {
+		name     string
+		err      error
+		expected string
+	}{
+		{
+			name:     "serialization",
+			err:      &internal.PartialWriteError{Err: internal.ErrSerialization},
+			expected: "serialization",
+		},
+		{
+			name:     "size limit",
+			err:      &internal.PartialWriteError{Err: internal.ErrSizeLimitReached},
+			expected: "size_limit",
+		},
+		{
+			name:     "rejected",
+			err:      &internal.PartialWriteError{Err: errors.New("invalid field")},
+			expected: "rejected",
+		},
+		{
+			name:     "deadline",
+			err:      fmt.Errorf("sending failed: %w", context.DeadlineExceeded),
+			expected: "timeout",
+		},
+		{
+			name:     "network timeout",
+			err:      &net.OpError{Op: "dial", Err: os.ErrDeadlineExceeded},
+			expected: "timeout",
+		},
+		{
+			name:     "other",
+			err:      errors.New("server returned 500 for request 1234"),
+			expected: "write",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require.Equal(t, tt.expected, deadLetterClass(tt.err))
+		})
+	}
+}
+
 func BenchmarkRunningOutputAddWrite(b *testing.B) {
 	conf := &OutputConfig{
 		Filter: Filter{},
@@ -940,11 +1250,11 @@ func (*mockOutput) SampleConfig() string {
 }
 
 func (m *mockOutput) Write(metrics []telegraf.Metric) error {
-	m.writes++
-
 	m.Lock()
 	defer m.Unlock()
 
+	m.writes++
+
 	// Simulate a failed write
 	if m.batchAcceptSize < 0 {
 		return errors.New("failed write")
@@ -975,6 +1285,52 @@ func (m *mockOutput) Metrics() []telegraf.Metric {
 	return m.metrics
 }
 
+// concurrentOutput is an output supporting concurrent writes failing to
+// write batches containing one of the given metric names
+type concurrentOutput struct {
+	sync.Mutex
+
+	metrics []telegraf.Metric
+	writes  int
+	fail    map[string]bool
+}
+
+func (*concurrentOutput) Connect() error {
+	return nil
+}
+
+func (*concurrentOutput) Close() error {
+	return nil
+}
+
+func (*concurrentOutput) SampleConfig() string {
+	return ""
+}
+
+func (*concurrentOutput) SupportsConcurrentWrites() bool {
+	return true
+}
+
+func (m *concurrentOutput) Write(metrics []telegraf.Metric) error {
+	m.Lock()
+	defer m.Unlock()
+
+	m.writes++
+	for _, metric := range metrics {
+		if m.fail[metric.Name()] {
+			return errors.New("failed write")
+		}
+	}
+	m.metrics = append(m.metrics, metrics...)
+	return nil
+}
+
+func (m *concurrentOutput) Metrics() []telegraf.Metric {
+	m.Lock()
+	defer m.Unlock()
+	return m.metrics
+}
+
 type perfOutput struct {
 	// if true, mock write failure
 	failWrite bool
@@ -1,6 +1,7 @@
 package models
 
 import (
+	"io"
 	"time"
 
 	"github.com/influxdata/telegraf"
@@ -93,6 +94,46 @@ func (r *RunningParser) ParseLine(line string) (telegraf.Metric, error) {
 	return m, err
 }
 
+// ParseStream parses the data of the given reader and calls the function for
+// each metric. If the parser does not support streaming, the whole data is
+// read and parsed at once.
+func (r *RunningParser) ParseStream(reader io.Reader, fn func(telegraf.Metric) error) error {
+	sp, ok := r.Parser.(telegraf.StreamingParser)
+	if !ok {
+		buf, err := io.ReadAll(reader)
+		if err != nil {
+			return err
+		}
+		metrics, err := r.Parse(buf)
+		if err != nil {
+			return err
+		}
+		for _, m := range metrics {
+			if err := fn(m); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	// Exclude the time spent in the callback from the parse-time as it
+	// might block e.g. when adding metrics to the accumulator
+	var count int64
+	var blocked time.Duration
+	start := time.Now()
+	err := sp.ParseStream(reader, func(m telegraf.Metric) error {
+		count++
+		before := time.Now()
+		err := fn(m)
+		blocked += time.Since(before)
+		return err
+	})
+	r.ParseTime.Incr((time.Since(start) - blocked).Nanoseconds())
+	r.MetricsParsed.Incr(count)
+
+	return err
+}
+
 func (r *RunningParser) SetDefaultTags(tags map[string]string) {
 	r.Parser.SetDefaultTags(tags)
 }
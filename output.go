@@ -27,3 +27,14 @@ type AggregatingOutput interface {
 	// Reset signals that the aggregator period is completed.
 	Reset()
 }
+
+// ConcurrentOutput is an Output declaring whether it is safe to call Write
+// concurrently. Only outputs supporting concurrent writes may be configured
+// to have multiple batches in flight at the same time.
+type ConcurrentOutput interface {
+	Output
+
+	// SupportsConcurrentWrites returns true if Write may be called
+	// concurrently with the current settings of the output.
+	SupportsConcurrentWrites() bool
+}
@@ -1,5 +1,7 @@
 package telegraf
 
+import "io"
+
 // Parser is an interface defining functions that a parser plugin must satisfy.
 type Parser interface {
 	// Parse takes a byte buffer separated by newlines
@@ -22,6 +24,17 @@ type Parser interface {
 	SetDefaultTags(tags map[string]string)
 }
 
+// StreamingParser is an optional interface for parsers able to process the
+// data incrementally instead of requiring the whole data in memory.
+type StreamingParser interface {
+	Parser
+
+	// ParseStream reads the data from the given reader and calls the given
+	// function for each parsed metric as soon as it is available. Parsing
+	// stops at the first error returned by the function.
+	ParseStream(r io.Reader, fn func(Metric) error) error
+}
+
 // ParserFunc is a function to create a new instance of a parser
 type ParserFunc func() (Parser, error)
 
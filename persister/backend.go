@@ -0,0 +1,72 @@
+package persister
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// Backend stores and retrieves the serialized states of all plugins
+type Backend interface {
+	// Read returns the serialized states indexed by the plugin ID. If no
+	// states were stored before, an error wrapping os.ErrNotExist must be
+	// returned.
+	Read() (map[string][]byte, error)
+
+	// Write persists the serialized states indexed by the plugin ID
+	Write(states map[string][]byte) error
+}
+
+// NewBackend returns the backend of the given type storing data at path
+func NewBackend(backendType, path string) (Backend, error) {
+	switch backendType {
+	case "", "file":
+		return &FileBackend{Filename: path}, nil
+	case "boltdb":
+		return &BoltBackend{Filename: path}, nil
+	case "directory":
+		return &DirectoryBackend{Path: path}, nil
+	}
+	return nil, fmt.Errorf("invalid state backend %q", backendType)
+}
+
+// writeFileAtomic writes the data to a temporary file and renames the file
+// to the given filename after syncing it to disk. This way the file either
+// contains the old or the new data even if the process crashes.
+func writeFileAtomic(filename string, data []byte) error {
+	dir := filepath.Dir(filename)
+	f, err := os.CreateTemp(dir, filepath.Base(filename)+".tmp-*")
+	if err != nil {
+		return fmt.Errorf("creating temporary file failed: %w", err)
+	}
+	tmpfile := f.Name()
+
+	if _, err := f.Write(data); err != nil {
+		f.Close()
+		os.Remove(tmpfile)
+		return fmt.Errorf("writing temporary file failed: %w", err)
+	}
+	if err := f.Sync(); err != nil {
+		f.Close()
+		os.Remove(tmpfile)
+		return fmt.Errorf("syncing temporary file failed: %w", err)
+	}
+	if err := f.Close(); err != nil {
+		os.Remove(tmpfile)
+		return fmt.Errorf("closing temporary file failed: %w", err)
+	}
+	if err := os.Rename(tmpfile, filename); err != nil {
+		os.Remove(tmpfile)
+		return fmt.Errorf("renaming temporary file failed: %w", err)
+	}
+
+	// Sync the directory to persist the rename. Some platforms do not support
+	// syncing directories so ignore errors here.
+	if d, err := os.Open(dir); err == nil {
+		//nolint:errcheck // best effort, not supported on all platforms
+		d.Sync()
+		d.Close()
+	}
+
+	return nil
+}
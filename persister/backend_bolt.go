@@ -0,0 +1,85 @@
+package persister
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+var boltBucket = []byte("states")
+
+// BoltBackend stores the states of all plugins in an embedded key-value
+// database file
+type BoltBackend struct {
+	Filename string
+}
+
+func (b *BoltBackend) Read() (map[string][]byte, error) {
+	// Do not implicitly create the database when reading
+	if _, err := os.Stat(b.Filename); err != nil {
+		return nil, fmt.Errorf("reading states database failed: %w", err)
+	}
+
+	db, err := b.open()
+	if err != nil {
+		return nil, err
+	}
+	defer db.Close()
+
+	states := make(map[string][]byte)
+	err = db.View(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket(boltBucket)
+		if bucket == nil {
+			return nil
+		}
+		return bucket.ForEach(func(k, v []byte) error {
+			// Values are only valid during the transaction so copy them
+			states[string(k)] = append([]byte(nil), v...)
+			return nil
+		})
+	})
+	if err != nil {
+		return nil, fmt.Errorf("reading states database failed: %w", err)
+	}
+	return states, nil
+}
+
+func (b *BoltBackend) Write(states map[string][]byte) error {
+	db, err := b.open()
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	// Replace all states within a single transaction
+	err = db.Update(func(tx *bolt.Tx) error {
+		if err := tx.DeleteBucket(boltBucket); err != nil && !errors.Is(err, bolt.ErrBucketNotFound) {
+			return err
+		}
+		bucket, err := tx.CreateBucket(boltBucket)
+		if err != nil {
+			return err
+		}
+		for id, state := range states {
+			if err := bucket.Put([]byte(id), state); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("writing states database failed: %w", err)
+	}
+	return nil
+}
+
+func (b *BoltBackend) open() (*bolt.DB, error) {
+	db, err := bolt.Open(b.Filename, 0640, &bolt.Options{Timeout: 5 * time.Second})
+	if err != nil {
+		return nil, fmt.Errorf("opening states database %q failed: %w", b.Filename, err)
+	}
+	return db, nil
+}
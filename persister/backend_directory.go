@@ -0,0 +1,55 @@
+package persister
+
+import (
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// DirectoryBackend stores the state of each plugin in a separate file within
+// the given directory. States of plugins not registered anymore are kept.
+type DirectoryBackend struct {
+	Path string
+}
+
+func (b *DirectoryBackend) Read() (map[string][]byte, error) {
+	entries, err := os.ReadDir(b.Path)
+	if err != nil {
+		return nil, fmt.Errorf("reading states directory failed: %w", err)
+	}
+
+	states := make(map[string][]byte, len(entries))
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || !strings.HasSuffix(name, ".json") {
+			continue
+		}
+		id, err := url.PathUnescape(strings.TrimSuffix(name, ".json"))
+		if err != nil {
+			continue
+		}
+
+		data, err := os.ReadFile(filepath.Join(b.Path, name))
+		if err != nil {
+			return nil, fmt.Errorf("reading state file %q failed: %w", name, err)
+		}
+		states[id] = data
+	}
+	return states, nil
+}
+
+func (b *DirectoryBackend) Write(states map[string][]byte) error {
+	if err := os.MkdirAll(b.Path, 0750); err != nil {
+		return fmt.Errorf("creating states directory failed: %w", err)
+	}
+
+	for id, state := range states {
+		filename := filepath.Join(b.Path, url.PathEscape(id)+".json")
+		if err := writeFileAtomic(filename, state); err != nil {
+			return fmt.Errorf("writing state of %q failed: %w", id, err)
+		}
+	}
+	return nil
+}
@@ -3,25 +3,46 @@ package persister
 import (
 	"encoding/json"
 	"fmt"
-	"os"
+	"log"
 	"reflect"
+	"sync"
 
 	"github.com/influxdata/telegraf"
 )
 
+// entry wraps the serialized state of a plugin together with the version of
+// the state schema. States persisted by previous versions of Telegraf are
+// not wrapped and are treated as version zero.
+type entry struct {
+	Version int             `json:"__version__"`
+	State   json.RawMessage `json:"__state__"`
+}
+
 type Persister struct {
-	Filename string
+	Filename    string
+	BackendType string
 
+	backend  Backend
 	register map[string]telegraf.StatefulPlugin
+	sync.Mutex
 }
 
 func (p *Persister) Init() error {
 	p.register = make(map[string]telegraf.StatefulPlugin)
 
+	backend, err := NewBackend(p.BackendType, p.Filename)
+	if err != nil {
+		return err
+	}
+	p.backend = backend
+
 	return nil
 }
 
 func (p *Persister) Register(id string, plugin telegraf.StatefulPlugin) error {
+	p.Lock()
+	defer p.Unlock()
+
 	if _, found := p.register[id]; found {
 		return fmt.Errorf("plugin with ID %q already registered", id)
 	}
@@ -30,20 +51,24 @@ func (p *Persister) Register(id string, plugin telegraf.StatefulPlugin) error {
 	return nil
 }
 
+// Unregister removes the plugin with the given ID, e.g. when the plugin is
+// removed on a configuration reload.
+func (p *Persister) Unregister(id string) {
+	p.Lock()
+	defer p.Unlock()
+
+	delete(p.register, id)
+}
+
 func (p *Persister) Load() error {
-	// Read the states from disk
-	in, err := os.ReadFile(p.Filename)
+	states, err := p.backend.Read()
 	if err != nil {
-		return fmt.Errorf("reading states file failed: %w", err)
-	}
-
-	// Unmarshal the id to serialized states map
-	var states map[string][]byte
-	if err := json.Unmarshal(in, &states); err != nil {
-		return fmt.Errorf("unmarshalling states failed: %w", err)
+		return err
 	}
 
-	// Get the initialized state as blueprint for unmarshalling
+	// Restore the states of all registered plugins. A state that cannot be
+	// restored should not prevent Telegraf from starting, so the plugin
+	// starts without state in this case.
 	for id, serialized := range states {
 		// Check if we have a plugin with that ID
 		plugin, found := p.register[id]
@@ -51,18 +76,8 @@ func (p *Persister) Load() error {
 			continue
 		}
 
-		// Create a new empty state of the "state"-type. As we need a pointer
-		// of the state, we cannot dereference it here due to the unknown
-		// nature of the state-type.
-		nstate := reflect.New(reflect.TypeOf(plugin.GetState())).Interface()
-		if err := json.Unmarshal(serialized, &nstate); err != nil {
-			return fmt.Errorf("unmarshalling state for %q failed: %w", id, err)
-		}
-		state := reflect.ValueOf(nstate).Elem().Interface()
-
-		// Set the state in the plugin
-		if err := plugin.SetState(state); err != nil {
-			return fmt.Errorf("setting state of %q failed: %w", id, err)
+		if err := restore(plugin, serialized); err != nil {
+			log.Printf("W! Restoring state of plugin with ID %q failed, starting without state: %v", id, err)
 		}
 	}
 
@@ -70,6 +85,9 @@ func (p *Persister) Load() error {
 }
 
 func (p *Persister) Store() error {
+	p.Lock()
+	defer p.Unlock()
+
 	states := make(map[string][]byte)
 
 	// Collect the states and serialize the individual data chunks
@@ -79,24 +97,59 @@ func (p *Persister) Store() error {
 		if err != nil {
 			return fmt.Errorf("marshalling state for id %q failed: %w", id, err)
 		}
-		states[id] = state
+
+		var version int
+		if vp, ok := plugin.(telegraf.VersionedStatefulPlugin); ok {
+			version = vp.StateVersion()
+		}
+		serialized, err := json.Marshal(&entry{Version: version, State: state})
+		if err != nil {
+			return fmt.Errorf("marshalling state entry for id %q failed: %w", id, err)
+		}
+		states[id] = serialized
 	}
 
-	// Serialize the states
-	serialized, err := json.Marshal(states)
-	if err != nil {
-		return fmt.Errorf("marshalling states failed: %w", err)
+	return p.backend.Write(states)
+}
+
+// restore sets the state of the plugin after migrating the serialized state
+// to the version supported by the plugin.
+func restore(plugin telegraf.StatefulPlugin, serialized []byte) error {
+	// Unwrap the versioned state falling back to the raw state of older
+	// Telegraf versions
+	var e entry
+	if err := json.Unmarshal(serialized, &e); err != nil || e.State == nil {
+		e = entry{State: serialized}
 	}
 
-	// Write the states to disk
-	f, err := os.Create(p.Filename)
-	if err != nil {
-		return fmt.Errorf("creating states file %q failed: %w", p.Filename, err)
+	var current int
+	vp, versioned := plugin.(telegraf.VersionedStatefulPlugin)
+	if versioned {
+		current = vp.StateVersion()
+	}
+	switch {
+	case e.Version > current:
+		return fmt.Errorf("state version %d is newer than supported version %d", e.Version, current)
+	case e.Version < current:
+		migrated, err := vp.MigrateState(e.Version, e.State)
+		if err != nil {
+			return fmt.Errorf("migrating state from version %d to %d failed: %w", e.Version, current, err)
+		}
+		e.State = migrated
+	}
+
+	// Create a new empty state of the "state"-type. As we need a pointer
+	// of the state, we cannot dereference it here due to the unknown
+	// nature of the state-type.
+	nstate := reflect.New(reflect.TypeOf(plugin.GetState())).Interface()
+	if err := json.Unmarshal(e.State, &nstate); err != nil {
+		return fmt.Errorf("unmarshalling state failed: %w", err)
 	}
-	defer f.Close()
+	state := reflect.ValueOf(nstate).Elem().Interface()
 
-	if _, err := f.Write(serialized); err != nil {
-		return fmt.Errorf("writing states failed: %w", err)
+	// Set the state in the plugin
+	if err := plugin.SetState(state); err != nil {
+		return fmt.Errorf("setting state failed: %w", err)
 	}
 
 	return nil
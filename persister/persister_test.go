@@ -0,0 +1,194 @@
+package persister
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+type mockState struct {
+	Name   string `json:"name"`
+	Offset int    `json:"offset"`
+}
+
+type mockPlugin struct {
+	state mockState
+}
+
+func (m *mockPlugin) GetState() interface{} {
+	return m.state
+}
+
+func (m *mockPlugin) SetState(state interface{}) error {
+	s, ok := state.(mockState)
+	if !ok {
+		return errors.New("invalid state type")
+	}
+	m.state = s
+	return nil
+}
+
+type mockVersionedPlugin struct {
+	mockPlugin
+}
+
+func (*mockVersionedPlugin) StateVersion() int {
+	return 2
+}
+
+func (*mockVersionedPlugin) MigrateState(version int, state []byte) ([]byte, error) {
+	if version != 0 {
+		return nil, errors.New("unexpected version")
+	}
+	// The unversioned state was a plain offset
+	var offset int
+	if err := json.Unmarshal(state, &offset); err != nil {
+		return nil, err
+	}
+	return json.Marshal(mockState{Name: "migrated", Offset: offset})
+}
+
+func TestBackendsStoreLoad(t *testing.T) {
+	for _, backend := range []string{"file", "boltdb", "directory"} {
+		t.Run(backend, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "state")
+
+			store := &Persister{Filename: path, BackendType: backend}
+			require.NoError(t, store.Init())
+			plugins := map[string]*mockPlugin{
+				"a":    {state: mockState{Name: "foo", Offset: 1}},
+				"b/c":  {state: mockState{Name: "bar", Offset: 2}},
+				"d e#": {state: mockState{Name: "baz", Offset: 3}},
+			}
+			for id, p := range plugins {
+				require.NoError(t, store.Register(id, p))
+			}
+			require.NoError(t, store.Store())
+
+			// Store a second time to check overwriting
+			plugins["a"].state.Offset = 42
+			require.NoError(t, store.Store())
+
+			load := &Persister{Filename: path, BackendType: backend}
+			require.NoError(t, load.Init())
+			restored := make(map[string]*mockPlugin, len(plugins))
+			for id := range plugins {
+				restored[id] = &mockPlugin{}
+				require.NoError(t, load.Register(id, restored[id]))
+			}
+			require.NoError(t, load.Load())
+
+			for id, p := range plugins {
+				require.Equal(t, p.state, restored[id].state, id)
+			}
+		})
+	}
+}
+
+func TestBackendsNotExist(t *testing.T) {
+	for _, backend := range []string{"file", "boltdb", "directory"} {
+		t.Run(backend, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "state")
+
+			p := &Persister{Filename: path, BackendType: backend}
+			require.NoError(t, p.Init())
+			require.ErrorIs(t, p.Load(), os.ErrNotExist)
+
+			// Reading must not create the state
+			_, err := os.Stat(path)
+			require.ErrorIs(t, err, os.ErrNotExist)
+		})
+	}
+}
+
+func TestInvalidBackend(t *testing.T) {
+	p := &Persister{Filename: "foo", BackendType: "unknown"}
+	require.ErrorContains(t, p.Init(), "invalid state backend")
+}
+
+func TestFileBackendAtomicWrite(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "state.json")
+
+	p := &Persister{Filename: path}
+	require.NoError(t, p.Init())
+	require.NoError(t, p.Register("a", &mockPlugin{state: mockState{Name: "foo"}}))
+	require.NoError(t, p.Store())
+
+	// No temporary files should be left behind
+	entries, err := os.ReadDir(dir)
+	require.NoError(t, err)
+	require.Len(t, entries, 1)
+	require.Equal(t, "state.json", entries[0].Name())
+}
+
+func TestLoadLegacyState(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "state.json")
+
+	// State file as written by previous versions without version information
+	legacy := map[string][]byte{"a": []byte(`{"name":"foo","offset":23}`)}
+	data, err := json.Marshal(legacy)
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(path, data, 0600))
+
+	p := &Persister{Filename: path}
+	require.NoError(t, p.Init())
+	plugin := &mockPlugin{}
+	require.NoError(t, p.Register("a", plugin))
+	require.NoError(t, p.Load())
+	require.Equal(t, mockState{Name: "foo", Offset: 23}, plugin.state)
+}
+
+func TestLoadMigratesState(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "state.json")
+
+	legacy := map[string][]byte{"a": []byte(`23`)}
+	data, err := json.Marshal(legacy)
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(path, data, 0600))
+
+	p := &Persister{Filename: path}
+	require.NoError(t, p.Init())
+	plugin := &mockVersionedPlugin{}
+	require.NoError(t, p.Register("a", plugin))
+	require.NoError(t, p.Load())
+	require.Equal(t, mockState{Name: "migrated", Offset: 23}, plugin.state)
+
+	// The stored state must contain the version
+	require.NoError(t, p.Store())
+	data, err = os.ReadFile(path)
+	require.NoError(t, err)
+	var states map[string][]byte
+	require.NoError(t, json.Unmarshal(data, &states))
+	require.True(t, strings.HasPrefix(string(states["a"]), `{"__version__":2,`))
+}
+
+func TestLoadSkipsNewerState(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "state.json")
+
+	states := map[string][]byte{
+		"a": []byte(`{"__version__":3,"__state__":{"name":"foo","offset":1}}`),
+		"b": []byte(`{"__version__":0,"__state__":{"name":"bar","offset":2}}`),
+	}
+	data, err := json.Marshal(states)
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(path, data, 0600))
+
+	p := &Persister{Filename: path}
+	require.NoError(t, p.Init())
+	pa := &mockVersionedPlugin{}
+	pb := &mockPlugin{}
+	require.NoError(t, p.Register("a", pa))
+	require.NoError(t, p.Register("b", pb))
+
+	// The state of a newer version cannot be restored, but this must not
+	// prevent restoring other plugins
+	require.NoError(t, p.Load())
+	require.Equal(t, mockState{}, pa.state)
+	require.Equal(t, mockState{Name: "bar", Offset: 2}, pb.state)
+}
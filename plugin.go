@@ -57,6 +57,23 @@ type StatefulPlugin interface {
 	SetState(state interface{}) error
 }
 
+// VersionedStatefulPlugin contains the functions that stateful plugins must
+// implement to version their state and migrate states persisted by older
+// versions of the plugin.
+type VersionedStatefulPlugin interface {
+	StatefulPlugin
+
+	// StateVersion returns the version of the state returned by GetState.
+	// The version must be increased whenever the layout of the state changes.
+	StateVersion() int
+
+	// MigrateState is called by the Persister with the JSON serialized state
+	// of an older version and must return the JSON serialized state in the
+	// layout of the current version. Version zero denotes states persisted
+	// without version information.
+	MigrateState(version int, state []byte) ([]byte, error)
+}
+
 // ProbePlugin is an interface that all input/output plugins need to
 // implement in order to support the `probe` value of `startup_error_behavior`
 type ProbePlugin interface {
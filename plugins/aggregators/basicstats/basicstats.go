@@ -3,10 +3,12 @@ package basicstats
 
 import (
 	_ "embed"
+	"errors"
 	"math"
 	"time"
 
 	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/metric"
 	"github.com/influxdata/telegraf/plugins/aggregators"
 )
 
@@ -46,9 +48,10 @@ func NewBasicStats() *BasicStats {
 }
 
 type aggregate struct {
-	fields map[string]basicstats
-	name   string
-	tags   map[string]string
+	fields     map[string]basicstats
+	histograms map[string]histogramstats
+	name       string
+	tags       map[string]string
 }
 
 type basicstats struct {
@@ -67,6 +70,15 @@ type basicstats struct {
 	TIME     time.Time // intermediate value for rate
 }
 
+// histogramstats holds the statistics of native histogram fields
+type histogramstats struct {
+	count     float64
+	sum       interface{} // merged histogram, nil if not mergeable
+	first     interface{}
+	last      interface{}
+	mergeable bool
+}
+
 func (*BasicStats) SampleConfig() string {
 	return sampleConfig
 }
@@ -76,11 +88,22 @@ func (b *BasicStats) Add(in telegraf.Metric) {
 	if _, ok := b.cache[id]; !ok {
 		// hit an uncached metric, create caches for first time:
 		a := aggregate{
-			name:   in.Name(),
-			tags:   in.Tags(),
-			fields: make(map[string]basicstats),
+			name:       in.Name(),
+			tags:       in.Tags(),
+			fields:     make(map[string]basicstats),
+			histograms: make(map[string]histogramstats),
 		}
 		for _, field := range in.FieldList() {
+			if metric.IsHistogram(field.Value) {
+				a.histograms[field.Key] = histogramstats{
+					count:     1,
+					sum:       field.Value,
+					first:     field.Value,
+					last:      field.Value,
+					mergeable: true,
+				}
+				continue
+			}
 			if fv, ok := convert(field.Value); ok {
 				a.fields[field.Key] = basicstats{
 					count:    1,
@@ -101,7 +124,19 @@ func (b *BasicStats) Add(in telegraf.Metric) {
 		b.cache[id] = a
 	} else {
 		for _, field := range in.FieldList() {
+			if metric.IsHistogram(field.Value) {
+				if _, found := b.cache[id].fields[field.Key]; found {
+					b.Log.Warnf("Skipping histogram value of numeric field %q of %q", field.Key, in.Name())
+					continue
+				}
+				b.addHistogram(b.cache[id], field)
+				continue
+			}
 			if fv, ok := convert(field.Value); ok {
+				if _, found := b.cache[id].histograms[field.Key]; found {
+					b.Log.Warnf("Skipping numeric value of histogram field %q of %q", field.Key, in.Name())
+					continue
+				}
 				if _, ok := b.cache[id].fields[field.Key]; !ok {
 					// hit an uncached field of a cached metric
 					b.cache[id].fields[field.Key] = basicstats{
@@ -163,9 +198,52 @@ func (b *BasicStats) Add(in telegraf.Metric) {
 	}
 }
 
+// addHistogram updates the statistics of a native histogram field
+func (b *BasicStats) addHistogram(a aggregate, field *telegraf.Field) {
+	tmp, found := a.histograms[field.Key]
+	if !found {
+		a.histograms[field.Key] = histogramstats{
+			count:     1,
+			sum:       field.Value,
+			first:     field.Value,
+			last:      field.Value,
+			mergeable: true,
+		}
+		return
+	}
+
+	tmp.count++
+	tmp.last = field.Value
+	if tmp.mergeable {
+		merged, err := mergeHistograms(tmp.sum, field.Value)
+		if err != nil {
+			b.Log.Warnf("Cannot compute sum of histogram field %q of %q: %v", field.Key, a.name, err)
+			tmp.sum = nil
+			tmp.mergeable = false
+		} else {
+			tmp.sum = merged
+		}
+	}
+	a.histograms[field.Key] = tmp
+}
+
 func (b *BasicStats) Push(acc telegraf.Accumulator) {
 	for _, aggregate := range b.cache {
 		fields := make(map[string]interface{})
+		for k, v := range aggregate.histograms {
+			if b.statsConfig.count {
+				fields[k+"_count"] = v.count
+			}
+			if b.statsConfig.sum && v.mergeable {
+				fields[k+"_sum"] = v.sum
+			}
+			if b.statsConfig.last {
+				fields[k+"_last"] = v.last
+			}
+			if b.statsConfig.first {
+				fields[k+"_first"] = v.first
+			}
+		}
 		for k, v := range aggregate.fields {
 			if b.statsConfig.count {
 				fields[k+"_count"] = v.count
@@ -312,6 +390,22 @@ func convert(in interface{}) (float64, bool) {
 	}
 }
 
+// mergeHistograms returns the histogram holding the observations of both
+// native histogram values
+func mergeHistograms(a, b interface{}) (interface{}, error) {
+	switch av := a.(type) {
+	case metric.Histogram:
+		if bv, ok := b.(metric.Histogram); ok {
+			return av.Merge(bv)
+		}
+	case metric.ExponentialHistogram:
+		if bv, ok := b.(metric.ExponentialHistogram); ok {
+			return av.Merge(bv)
+		}
+	}
+	return nil, errors.New("histograms are of different kinds")
+}
+
 func (b *BasicStats) Init() error {
 	b.initConfiguredStats()
 
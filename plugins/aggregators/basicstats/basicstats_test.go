@@ -824,3 +824,114 @@ func TestBasicStatsWithOnlyFirst(t *testing.T) {
 	}
 	acc.AssertContainsTaggedFields(t, "m1", expectedFields, expectedTags)
 }
+
+func TestBasicStatsWithHistograms(t *testing.T) {
+	aggregator := NewBasicStats()
+	aggregator.Stats = []string{"count", "sum", "min", "first", "last"}
+	aggregator.Log = testutil.Logger{}
+	aggregator.initConfiguredStats()
+
+	h1 := metric.Histogram{
+		Count:   3,
+		Sum:     4.5,
+		Buckets: []metric.Bucket{{UpperBound: 1, Count: 1}, {UpperBound: 2, Count: 2}},
+	}
+	h2 := metric.Histogram{
+		Count:   2,
+		Sum:     1.5,
+		Buckets: []metric.Bucket{{UpperBound: 1, Count: 2}, {UpperBound: 2, Count: 2}},
+	}
+	e1 := metric.ExponentialHistogram{
+		Count:    1,
+		Sum:      2,
+		Positive: metric.ExponentialBuckets{Offset: 0, Counts: []float64{1}},
+	}
+	e2 := metric.ExponentialHistogram{
+		Count:         1,
+		Sum:           2,
+		ZeroThreshold: 0.1,
+		Positive:      metric.ExponentialBuckets{Offset: 0, Counts: []float64{1}},
+	}
+
+	aggregator.Add(metric.New("m1",
+		map[string]string{"foo": "bar"},
+		map[string]interface{}{"latency": h1, "size": e1, "value": int64(1)},
+		time.Date(2000, 1, 1, 0, 0, 0, 0, time.UTC),
+	))
+	aggregator.Add(metric.New("m1",
+		map[string]string{"foo": "bar"},
+		map[string]interface{}{"latency": h2, "size": e2, "value": int64(3)},
+		time.Date(2000, 1, 1, 0, 0, 1, 0, time.UTC),
+	))
+
+	acc := testutil.Accumulator{}
+	aggregator.Push(&acc)
+
+	expectedFields := map[string]interface{}{
+		"latency_count": float64(2),
+		"latency_sum": metric.Histogram{
+			Count:   5,
+			Sum:     6,
+			Buckets: []metric.Bucket{{UpperBound: 1, Count: 3}, {UpperBound: 2, Count: 4}},
+		},
+		"latency_first": h1,
+		"latency_last":  h2,
+		// Different zero thresholds cannot be merged
+		"size_count":  float64(2),
+		"size_first":  e1,
+		"size_last":   e2,
+		"value_count": float64(2),
+		"value_sum":   float64(4),
+		"value_min":   float64(1),
+		"value_first": float64(1),
+		"value_last":  float64(3),
+	}
+	expectedTags := map[string]string{
+		"foo": "bar",
+	}
+	acc.AssertContainsTaggedFields(t, "m1", expectedFields, expectedTags)
+}
+
+func TestBasicStatsWithMixedHistograms(t *testing.T) {
+	aggregator := NewBasicStats()
+	aggregator.Stats = []string{"count", "sum", "first", "last"}
+	aggregator.Log = testutil.Logger{}
+	aggregator.initConfiguredStats()
+
+	h := metric.Histogram{
+		Count:   3,
+		Sum:     4.5,
+		Buckets: []metric.Bucket{{UpperBound: 1, Count: 1}, {UpperBound: 2, Count: 2}},
+	}
+
+	// The kind of the first value of a field decides the statistics, values
+	// of the other kind must be skipped
+	aggregator.Add(metric.New("m1",
+		map[string]string{"foo": "bar"},
+		map[string]interface{}{"latency": h, "value": int64(1)},
+		time.Date(2000, 1, 1, 0, 0, 0, 0, time.UTC),
+	))
+	aggregator.Add(metric.New("m1",
+		map[string]string{"foo": "bar"},
+		map[string]interface{}{"latency": 2.0, "value": h},
+		time.Date(2000, 1, 1, 0, 0, 1, 0, time.UTC),
+	))
+
+	acc := testutil.Accumulator{}
+	aggregator.Push(&acc)
+
+	expectedFields := map[string]interface{}{
+		"latency_count": float64(1),
+		"latency_sum":   h,
+		"latency_first": h,
+		"latency_last":  h,
+		"value_count":   float64(1),
+		"value_sum":     float64(1),
+		"value_first":   float64(1),
+		"value_last":    float64(1),
+	}
+	expectedTags := map[string]string{
+		"foo": "bar",
+	}
+	acc.AssertContainsTaggedFields(t, "m1", expectedFields, expectedTags)
+}
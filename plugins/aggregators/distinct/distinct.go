@@ -0,0 +1,176 @@
+//go:generate ../../../tools/readme_config_includer/generator
+package distinct
+
+import (
+	_ "embed"
+	"errors"
+	"fmt"
+	"math"
+	"slices"
+	"strconv"
+
+	"github.com/cespare/xxhash/v2"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/plugins/aggregators"
+)
+
+//go:embed sample.conf
+var sampleConfig string
+
+const defaultPrecision = 14
+
+type Distinct struct {
+	Fields     []string        `toml:"fields"`
+	Tags       []string        `toml:"tags"`
+	Precision  uint8           `toml:"precision"`
+	EmitSketch bool            `toml:"emit_sketch"`
+	Log        telegraf.Logger `toml:"-"`
+
+	cache map[uint64]*aggregate
+}
+
+type aggregate struct {
+	name     string
+	tags     map[string]string
+	sketches map[string]*sketch
+}
+
+func (*Distinct) SampleConfig() string {
+	return sampleConfig
+}
+
+func (d *Distinct) Init() error {
+	if len(d.Fields) == 0 && len(d.Tags) == 0 {
+		return errors.New("no fields or tags configured")
+	}
+
+	if d.Precision == 0 {
+		d.Precision = defaultPrecision
+	}
+	if d.Precision < minPrecision || d.Precision > maxPrecision {
+		return fmt.Errorf("precision %d out of range [%d,%d]", d.Precision, minPrecision, maxPrecision)
+	}
+
+	d.Reset()
+	return nil
+}
+
+func (d *Distinct) Add(in telegraf.Metric) {
+	var agg *aggregate
+	get := func() *aggregate {
+		if agg != nil {
+			return agg
+		}
+
+		// Group by metric name and tags excluding the counted tags
+		tags := make(map[string]string)
+		h := xxhash.New()
+		h.WriteString(in.Name())
+		for _, tag := range in.TagList() {
+			if slices.Contains(d.Tags, tag.Key) {
+				continue
+			}
+			h.WriteString("\n" + tag.Key + "=" + tag.Value)
+			tags[tag.Key] = tag.Value
+		}
+		id := h.Sum64()
+
+		var found bool
+		if agg, found = d.cache[id]; !found {
+			agg = &aggregate{
+				name:     in.Name(),
+				tags:     tags,
+				sketches: make(map[string]*sketch),
+			}
+			d.cache[id] = agg
+		}
+		return agg
+	}
+
+	for _, key := range d.Fields {
+		if v, found := in.GetField(key); found {
+			get().sketch(key, d.Precision).insert(hashValue(v))
+		}
+		d.mergeSketch(in, key, get)
+	}
+	for _, key := range d.Tags {
+		if v, found := in.GetTag(key); found {
+			get().sketch(key, d.Precision).insert(xxhash.Sum64String(v))
+		}
+		d.mergeSketch(in, key, get)
+	}
+}
+
+// mergeSketch merges a serialized sketch emitted by another instance
+func (d *Distinct) mergeSketch(in telegraf.Metric, key string, get func() *aggregate) {
+	raw, found := in.GetField(key + "_sketch")
+	if !found {
+		return
+	}
+	encoded, ok := raw.(string)
+	if !ok {
+		d.Log.Errorf("Invalid type %T for sketch of %q", raw, key)
+		return
+	}
+
+	other, err := decodeSketch(encoded)
+	if err != nil {
+		d.Log.Errorf("Decoding sketch of %q failed: %v", key, err)
+		return
+	}
+	if err := get().sketch(key, d.Precision).merge(other); err != nil {
+		d.Log.Errorf("Merging sketch of %q failed: %v", key, err)
+	}
+}
+
+func (d *Distinct) Push(acc telegraf.Accumulator) {
+	for _, agg := range d.cache {
+		fields := make(map[string]interface{}, len(agg.sketches))
+		for key, s := range agg.sketches {
+			fields[key+"_distinct"] = int64(math.Round(s.estimate()))
+			if d.EmitSketch {
+				fields[key+"_sketch"] = s.encode()
+			}
+		}
+		acc.AddFields(agg.name, fields, agg.tags)
+	}
+}
+
+func (d *Distinct) Reset() {
+	d.cache = make(map[uint64]*aggregate)
+}
+
+// sketch returns the sketch for the given key creating it if necessary
+func (a *aggregate) sketch(key string, precision uint8) *sketch {
+	s, found := a.sketches[key]
+	if !found {
+		s = newSketch(precision)
+		a.sketches[key] = s
+	}
+	return s
+}
+
+// hashValue hashes the string representation of the value so values of
+// different types but the same representation are considered equal
+func hashValue(v interface{}) uint64 {
+	switch v := v.(type) {
+	case string:
+		return xxhash.Sum64String(v)
+	case int64:
+		return xxhash.Sum64String(strconv.FormatInt(v, 10))
+	case uint64:
+		return xxhash.Sum64String(strconv.FormatUint(v, 10))
+	case float64:
+		return xxhash.Sum64String(strconv.FormatFloat(v, 'g', -1, 64))
+	case bool:
+		return xxhash.Sum64String(strconv.FormatBool(v))
+	}
+	return xxhash.Sum64String(fmt.Sprint(v))
+}
+
+func init() {
+	aggregators.Add("distinct", func() telegraf.Aggregator {
+		return &Distinct{}
+	})
+}
@@ -0,0 +1,244 @@
+package distinct
+
+import (
+	"encoding/base64"
+	"fmt"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/metric"
+	"github.com/influxdata/telegraf/testutil"
+)
+
+func TestInitFail(t *testing.T) {
+	plugin := &Distinct{}
+	require.ErrorContains(t, plugin.Init(), "no fields or tags configured")
+
+	plugin = &Distinct{Fields: []string{"user"}, Precision: 20}
+	require.ErrorContains(t, plugin.Init(), "precision 20 out of range [4,18]")
+}
+
+func TestSmallCardinality(t *testing.T) {
+	plugin := &Distinct{
+		Fields: []string{"user", "code"},
+		Tags:   []string{"ip"},
+		Log:    testutil.Logger{},
+	}
+	require.NoError(t, plugin.Init())
+
+	ips := []string{"10.0.0.1", "10.0.0.2", "10.0.0.1"}
+	for i := range 30 {
+		plugin.Add(metric.New(
+			"access",
+			map[string]string{"host": "a", "ip": ips[i%len(ips)]},
+			map[string]interface{}{
+				"user": fmt.Sprintf("user%d", i%7),
+				"code": int64(200 + i%3),
+			},
+			time.Unix(0, 0),
+		))
+	}
+	// Different series
+	plugin.Add(metric.New(
+		"access",
+		map[string]string{"host": "b"},
+		map[string]interface{}{"user": "user1", "other": 42},
+		time.Unix(0, 0),
+	))
+	// Metrics without any of the fields or tags are ignored
+	plugin.Add(metric.New("cpu", map[string]string{}, map[string]interface{}{"usage": 42.0}, time.Unix(0, 0)))
+
+	var acc testutil.Accumulator
+	plugin.Push(&acc)
+
+	expected := []telegraf.Metric{
+		metric.New(
+			"access",
+			map[string]string{"host": "a"},
+			map[string]interface{}{
+				"user_distinct": int64(7),
+				"code_distinct": int64(3),
+				"ip_distinct":   int64(2),
+			},
+			time.Unix(0, 0),
+		),
+		metric.New(
+			"access",
+			map[string]string{"host": "b"},
+			map[string]interface{}{"user_distinct": int64(1)},
+			time.Unix(0, 0),
+		),
+	}
+	testutil.RequireMetricsEqual(t, expected, acc.GetTelegrafMetrics(), testutil.IgnoreTime(), testutil.SortMetrics())
+
+	// Make sure the state is reset after push
+	plugin.Reset()
+	acc.ClearMetrics()
+	plugin.Push(&acc)
+	require.Empty(t, acc.GetTelegrafMetrics())
+}
+
+func TestLargeCardinality(t *testing.T) {
+	for _, precision := range []uint8{10, 14} {
+		t.Run(fmt.Sprintf("precision %d", precision), func(t *testing.T) {
+			plugin := &Distinct{
+				Fields:    []string{"user"},
+				Precision: precision,
+				Log:       testutil.Logger{},
+			}
+			require.NoError(t, plugin.Init())
+
+			n := 200000
+			for i := range n {
+				plugin.Add(metric.New(
+					"access",
+					map[string]string{},
+					map[string]interface{}{"user": int64(i)},
+					time.Unix(0, 0),
+				))
+			}
+
+			var acc testutil.Accumulator
+			plugin.Push(&acc)
+			actual := acc.GetTelegrafMetrics()
+			require.Len(t, actual, 1)
+
+			estimate, found := actual[0].GetField("user_distinct")
+			require.True(t, found)
+
+			// Allow for four times the standard error
+			stderr := 1.04 / math.Sqrt(float64(uint64(1)<<precision))
+			require.InEpsilon(t, float64(n), float64(estimate.(int64)), 4*stderr)
+		})
+	}
+}
+
+func TestMergeSketches(t *testing.T) {
+	// Simulate two edge instances with overlapping users
+	var edges []telegraf.Metric
+	for _, offset := range []int{0, 500} {
+		edge := &Distinct{
+			Fields:     []string{"user"},
+			EmitSketch: true,
+			Log:        testutil.Logger{},
+		}
+		require.NoError(t, edge.Init())
+
+		for i := range 1000 {
+			edge.Add(metric.New(
+				"access",
+				map[string]string{"site": "x"},
+				map[string]interface{}{"user": fmt.Sprintf("user%d", offset+i)},
+				time.Unix(0, 0),
+			))
+		}
+		var acc testutil.Accumulator
+		edge.Push(&acc)
+		edges = append(edges, acc.GetTelegrafMetrics()...)
+	}
+	require.Len(t, edges, 2)
+
+	// Merge the results centrally
+	central := &Distinct{
+		Fields: []string{"user"},
+		Log:    testutil.Logger{},
+	}
+	require.NoError(t, central.Init())
+	for _, m := range edges {
+		central.Add(m)
+	}
+
+	var acc testutil.Accumulator
+	central.Push(&acc)
+	actual := acc.GetTelegrafMetrics()
+	require.Len(t, actual, 1)
+
+	estimate, found := actual[0].GetField("user_distinct")
+	require.True(t, found)
+	require.InEpsilon(t, 1500, float64(estimate.(int64)), 0.03)
+}
+
+func TestMergeInvalidSketch(t *testing.T) {
+	logger := &testutil.CaptureLogger{}
+	plugin := &Distinct{
+		Fields: []string{"user"},
+		Log:    logger,
+	}
+	require.NoError(t, plugin.Init())
+
+	plugin.Add(metric.New(
+		"access",
+		map[string]string{},
+		map[string]interface{}{"user_sketch": "not a sketch"},
+		time.Unix(0, 0),
+	))
+	require.Len(t, logger.Errors(), 1)
+	require.Contains(t, logger.Errors()[0], `Decoding sketch of "user" failed`)
+}
+
+func TestSketchEncoding(t *testing.T) {
+	for _, n := range []int{0, 10, 100000} {
+		t.Run(fmt.Sprintf("%d values", n), func(t *testing.T) {
+			s := newSketch(12)
+			for i := range n {
+				s.insert(hashValue(int64(i)))
+			}
+
+			decoded, err := decodeSketch(s.encode())
+			require.NoError(t, err)
+			require.Equal(t, s.precision, decoded.precision)
+			require.InDelta(t, s.estimate(), decoded.estimate(), 1e-9)
+			require.Equal(t, s.encode(), decoded.encode())
+		})
+	}
+}
+
+func TestSketchDecodingInvalid(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    []byte
+		expected string
+	}{
+		{
+			name:     "too short",
+			input:    []byte{1, 12},
+			expected: "sketch too short",
+		},
+		{
+			name:     "unknown version",
+			input:    []byte{2, 12, 0, 0},
+			expected: "unsupported sketch version 2",
+		},
+		{
+			name:     "invalid precision",
+			input:    []byte{1, 30, 0, 0},
+			expected: "invalid precision 30",
+		},
+		{
+			name:     "dense too short",
+			input:    []byte{1, 4, 1, 0, 0},
+			expected: "invalid number of registers 2",
+		},
+		{
+			name:     "sparse truncated",
+			input:    []byte{1, 4, 0, 2, 1, 3},
+			expected: "sketch truncated",
+		},
+		{
+			name:     "sparse invalid rank",
+			input:    []byte{1, 4, 0, 1, 1, 99},
+			expected: "invalid register",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := decodeSketch(base64.StdEncoding.EncodeToString(tt.input))
+			require.EqualError(t, err, tt.expected)
+		})
+	}
+}
@@ -0,0 +1,234 @@
+package distinct
+
+import (
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+	"math/bits"
+	"slices"
+)
+
+const (
+	minPrecision = 4
+	maxPrecision = 18
+
+	sketchVersion  = 1
+	encodingSparse = 0
+	encodingDense  = 1
+)
+
+// sketch is a HyperLogLog++ cardinality estimator using 64-bit hashes and
+// a sparse representation for small cardinalities. The estimate is computed
+// using the improved estimator by Otmar Ertl (https://arxiv.org/abs/1702.01284)
+// which does not require empirical bias correction.
+type sketch struct {
+	precision uint8
+	dense     []uint8
+	sparse    map[uint32]uint8
+}
+
+func newSketch(precision uint8) *sketch {
+	return &sketch{
+		precision: precision,
+		sparse:    make(map[uint32]uint8),
+	}
+}
+
+// insert adds the given hash value to the sketch
+func (s *sketch) insert(hash uint64) {
+	idx := uint32(hash >> (64 - s.precision))
+	// Add a guard bit to limit the rank to the remaining bits
+	w := hash<<s.precision | 1<<(s.precision-1)
+	s.set(idx, uint8(bits.LeadingZeros64(w))+1) //nolint:gosec // G115: the rank is at most 64
+}
+
+func (s *sketch) set(idx uint32, rank uint8) {
+	if s.dense != nil {
+		s.dense[idx] = max(s.dense[idx], rank)
+		return
+	}
+
+	if rank > s.sparse[idx] {
+		s.sparse[idx] = rank
+	}
+
+	// Switch to the dense representation once the sparse one gets too large
+	if len(s.sparse) > (1<<s.precision)/8 {
+		s.dense = make([]uint8, 1<<s.precision)
+		for i, r := range s.sparse {
+			s.dense[i] = r
+		}
+		s.sparse = nil
+	}
+}
+
+// merge adds all values of the other sketch
+func (s *sketch) merge(other *sketch) error {
+	if s.precision != other.precision {
+		return fmt.Errorf("precision mismatch %d != %d", s.precision, other.precision)
+	}
+
+	if other.dense != nil {
+		for i, r := range other.dense {
+			if r > 0 {
+				s.set(uint32(i), r) //nolint:gosec // G115: the index is limited by the precision
+			}
+		}
+		return nil
+	}
+	for i, r := range other.sparse {
+		s.set(i, r)
+	}
+	return nil
+}
+
+// estimate returns the estimated number of distinct values
+func (s *sketch) estimate() float64 {
+	m := float64(uint64(1) << s.precision)
+	q := 64 - int(s.precision)
+
+	// Histogram of the register values
+	counts := make([]float64, q+2)
+	if s.dense != nil {
+		for _, r := range s.dense {
+			counts[r]++
+		}
+	} else {
+		counts[0] = m - float64(len(s.sparse))
+		for _, r := range s.sparse {
+			counts[r]++
+		}
+	}
+
+	z := m * tau(1-counts[q+1]/m)
+	for k := q; k >= 1; k-- {
+		z = 0.5 * (z + counts[k])
+	}
+	z += m * sigma(counts[0]/m)
+
+	return m * m / (2 * math.Ln2 * z)
+}
+
+func sigma(x float64) float64 {
+	if x == 1 {
+		return math.Inf(1)
+	}
+	y := 1.0
+	z := x
+	for {
+		x *= x
+		prev := z
+		z += x * y
+		y += y
+		if z == prev {
+			return z
+		}
+	}
+}
+
+func tau(x float64) float64 {
+	if x == 0 || x == 1 {
+		return 0
+	}
+	y := 1.0
+	z := 1 - x
+	for {
+		x = math.Sqrt(x)
+		prev := z
+		y *= 0.5
+		z -= math.Pow(1-x, 2) * y
+		if z == prev {
+			return z / 3
+		}
+	}
+}
+
+// encode serializes the sketch to a base64 string
+func (s *sketch) encode() string {
+	buf := []byte{sketchVersion, s.precision}
+	if s.dense != nil {
+		buf = append(buf, encodingDense)
+		buf = append(buf, s.dense...)
+		return base64.StdEncoding.EncodeToString(buf)
+	}
+
+	// Encode the sorted register indices as deltas
+	indices := make([]uint32, 0, len(s.sparse))
+	for i := range s.sparse {
+		indices = append(indices, i)
+	}
+	slices.Sort(indices)
+
+	buf = append(buf, encodingSparse)
+	buf = binary.AppendUvarint(buf, uint64(len(indices)))
+	var last uint32
+	for _, i := range indices {
+		buf = binary.AppendUvarint(buf, uint64(i-last))
+		buf = append(buf, s.sparse[i])
+		last = i
+	}
+	return base64.StdEncoding.EncodeToString(buf)
+}
+
+// decodeSketch deserializes a sketch from a base64 string
+func decodeSketch(encoded string) (*sketch, error) {
+	buf, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, fmt.Errorf("decoding base64 failed: %w", err)
+	}
+	if len(buf) < 3 {
+		return nil, errors.New("sketch too short")
+	}
+	if buf[0] != sketchVersion {
+		return nil, fmt.Errorf("unsupported sketch version %d", buf[0])
+	}
+	precision := buf[1]
+	if precision < minPrecision || precision > maxPrecision {
+		return nil, fmt.Errorf("invalid precision %d", precision)
+	}
+	m := uint64(1) << precision
+	maxRank := 65 - precision
+
+	s := newSketch(precision)
+	data := buf[3:]
+	switch buf[2] {
+	case encodingDense:
+		if uint64(len(data)) != m {
+			return nil, fmt.Errorf("invalid number of registers %d", len(data))
+		}
+		if slices.Max(data) > maxRank {
+			return nil, errors.New("invalid register value")
+		}
+		s.dense = slices.Clone(data)
+		s.sparse = nil
+	case encodingSparse:
+		n, l := binary.Uvarint(data)
+		if l <= 0 || n > m {
+			return nil, errors.New("invalid number of entries")
+		}
+		data = data[l:]
+		var idx uint64
+		for range n {
+			delta, l := binary.Uvarint(data)
+			if l <= 0 || len(data) < l+1 {
+				return nil, errors.New("sketch truncated")
+			}
+			idx += delta
+			rank := data[l]
+			if idx >= m || rank == 0 || rank > maxRank {
+				return nil, errors.New("invalid register")
+			}
+			s.set(uint32(idx), rank)
+			data = data[l+1:]
+		}
+		if len(data) != 0 {
+			return nil, errors.New("trailing data")
+		}
+	default:
+		return nil, fmt.Errorf("unknown encoding %d", buf[2])
+	}
+
+	return s, nil
+}
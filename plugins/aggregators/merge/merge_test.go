@@ -372,3 +372,57 @@ func BenchmarkMergeTwo(b *testing.B) {
 		merger.Push(&acc)
 	}
 }
+
+func TestHistograms(t *testing.T) {
+	plugin := &Merge{}
+	require.NoError(t, plugin.Init())
+
+	latency := metric.Histogram{
+		Count:   3,
+		Sum:     4.5,
+		Buckets: []metric.Bucket{{UpperBound: 1, Count: 1}, {UpperBound: 2, Count: 2}},
+	}
+	size := metric.ExponentialHistogram{
+		Count:    2,
+		Sum:      3,
+		Scale:    3,
+		Positive: metric.ExponentialBuckets{Offset: 4, Counts: []float64{1, 1}},
+	}
+
+	plugin.Add(
+		testutil.MustMetric(
+			"prometheus",
+			map[string]string{"host": "a"},
+			map[string]interface{}{"latency": latency},
+			time.Unix(0, 0),
+			telegraf.Histogram,
+		),
+	)
+	plugin.Add(
+		testutil.MustMetric(
+			"prometheus",
+			map[string]string{"host": "a"},
+			map[string]interface{}{"size": size},
+			time.Unix(0, 0),
+			telegraf.Histogram,
+		),
+	)
+
+	var acc testutil.Accumulator
+	plugin.Push(&acc)
+
+	expected := []telegraf.Metric{
+		testutil.MustMetric(
+			"prometheus",
+			map[string]string{"host": "a"},
+			map[string]interface{}{
+				"latency": latency,
+				"size":    size,
+			},
+			time.Unix(0, 0),
+			telegraf.Histogram,
+		),
+	}
+
+	testutil.RequireMetricsEqual(t, expected, acc.GetTelegrafMetrics())
+}
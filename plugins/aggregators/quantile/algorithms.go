@@ -1,6 +1,7 @@
 package quantile
 
 import (
+	"bytes"
 	"math"
 	"sort"
 
@@ -12,8 +13,35 @@ type algorithm interface {
 	Quantile(q float64) float64
 }
 
+// mergeable is implemented by algorithms supporting serialization of their
+// state and merging serialized states of other instances
+type mergeable interface {
+	encode() ([]byte, error)
+	merge(buf []byte) error
+}
+
+type tdigestAlgorithm struct {
+	*tdigest.TDigest
+}
+
 func newTDigest(compression float64) (algorithm, error) {
-	return tdigest.New(tdigest.Compression(compression))
+	t, err := tdigest.New(tdigest.Compression(compression))
+	if err != nil {
+		return nil, err
+	}
+	return &tdigestAlgorithm{t}, nil
+}
+
+func (t *tdigestAlgorithm) encode() ([]byte, error) {
+	return t.AsBytes()
+}
+
+func (t *tdigestAlgorithm) merge(buf []byte) error {
+	other, err := tdigest.FromBytes(bytes.NewReader(buf))
+	if err != nil {
+		return err
+	}
+	return t.Merge(other)
 }
 
 type exactAlgorithmR7 struct {
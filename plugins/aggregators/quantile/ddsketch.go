@@ -0,0 +1,239 @@
+package quantile
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+)
+
+// ddSketch is a DDSketch approximation with relative-error guarantees using a
+// logarithmic index mapping and stores collapsing the lowest bins if the
+// maximum number of bins is exceeded.
+// See Masson, Rim & Lee; DDSketch: A Fast and Fully-Mergeable Quantile Sketch
+// with Relative-Error Guarantees; PVLDB vol 12; pp 2195-2205; 2019
+type ddSketch struct {
+	gamma    float64
+	logGamma float64
+	maxBins  int
+
+	positive ddStore
+	negative ddStore
+	zero     uint64
+}
+
+type ddStore struct {
+	bins   []uint64
+	offset int
+}
+
+func newDDSketch(relativeAccuracy float64, maxBins int) (*ddSketch, error) {
+	if relativeAccuracy <= 0 || relativeAccuracy >= 1 {
+		return nil, fmt.Errorf("relative accuracy %v out of range (0,1)", relativeAccuracy)
+	}
+	if maxBins < 1 {
+		return nil, fmt.Errorf("invalid maximum number of bins %d", maxBins)
+	}
+
+	gamma := (1 + relativeAccuracy) / (1 - relativeAccuracy)
+	return &ddSketch{
+		gamma:    gamma,
+		logGamma: math.Log(gamma),
+		maxBins:  maxBins,
+	}, nil
+}
+
+func (s *ddSketch) Add(value float64) error {
+	if math.IsNaN(value) || math.IsInf(value, 0) {
+		return fmt.Errorf("cannot add value %v", value)
+	}
+
+	switch {
+	case value > 0:
+		s.positive.add(s.index(value), 1, s.maxBins)
+	case value < 0:
+		s.negative.add(s.index(-value), 1, s.maxBins)
+	default:
+		s.zero++
+	}
+	return nil
+}
+
+func (s *ddSketch) Quantile(q float64) float64 {
+	count := s.negative.count() + s.zero + s.positive.count()
+	if count == 0 {
+		return math.NaN()
+	}
+
+	// Walk the bins in ascending order of the values they represent
+	rank := q * float64(count-1)
+	var n float64
+	for i := len(s.negative.bins) - 1; i >= 0; i-- {
+		n += float64(s.negative.bins[i])
+		if n > rank {
+			return -s.value(s.negative.offset + i)
+		}
+	}
+	n += float64(s.zero)
+	if n > rank {
+		return 0
+	}
+	for i, c := range s.positive.bins {
+		n += float64(c)
+		if n > rank {
+			return s.value(s.positive.offset + i)
+		}
+	}
+
+	// Only reachable due to rounding issues for the maximum quantile
+	if len(s.positive.bins) > 0 {
+		return s.value(s.positive.offset + len(s.positive.bins) - 1)
+	}
+	return 0
+}
+
+func (s *ddSketch) index(value float64) int {
+	return int(math.Ceil(math.Log(value) / s.logGamma))
+}
+
+// value returns the value representing the bin with the given index with the
+// relative error being bounded for all values within the bin
+func (s *ddSketch) value(index int) float64 {
+	return 2 * math.Pow(s.gamma, float64(index)) / (s.gamma + 1)
+}
+
+func (s *ddSketch) encode() ([]byte, error) {
+	buf := make([]byte, 8, 64)
+	binary.LittleEndian.PutUint64(buf, math.Float64bits(s.gamma))
+	buf = binary.AppendUvarint(buf, uint64(s.maxBins))
+	buf = binary.AppendUvarint(buf, s.zero)
+	buf = s.positive.encode(buf)
+	buf = s.negative.encode(buf)
+	return buf, nil
+}
+
+func (s *ddSketch) merge(buf []byte) error {
+	if len(buf) < 8 {
+		return errors.New("sketch too short")
+	}
+	gamma := math.Float64frombits(binary.LittleEndian.Uint64(buf))
+	if gamma != s.gamma {
+		return fmt.Errorf("relative accuracy mismatch, gamma %v != %v", gamma, s.gamma)
+	}
+	buf = buf[8:]
+
+	// The number of bins of the other sketch is informational only
+	_, n := binary.Uvarint(buf)
+	if n <= 0 {
+		return errors.New("invalid maximum number of bins")
+	}
+	buf = buf[n:]
+
+	zero, n := binary.Uvarint(buf)
+	if n <= 0 {
+		return errors.New("invalid zero count")
+	}
+	buf = buf[n:]
+
+	var positive, negative ddStore
+	var err error
+	if buf, err = positive.decode(buf); err != nil {
+		return fmt.Errorf("decoding positive store failed: %w", err)
+	}
+	if buf, err = negative.decode(buf); err != nil {
+		return fmt.Errorf("decoding negative store failed: %w", err)
+	}
+	if len(buf) != 0 {
+		return errors.New("trailing data")
+	}
+
+	s.zero += zero
+	s.positive.merge(&positive, s.maxBins)
+	s.negative.merge(&negative, s.maxBins)
+	return nil
+}
+
+func (s *ddStore) count() uint64 {
+	var n uint64
+	for _, c := range s.bins {
+		n += c
+	}
+	return n
+}
+
+// add increments the bin with the given index, collapsing the lowest bins
+// into one if the number of bins exceeds the given maximum
+func (s *ddStore) add(index int, n uint64, maxBins int) {
+	if len(s.bins) == 0 {
+		s.bins = []uint64{n}
+		s.offset = index
+		return
+	}
+
+	lo := min(s.offset, index)
+	hi := max(s.offset+len(s.bins)-1, index)
+	if hi-lo+1 > maxBins {
+		lo = hi - maxBins + 1
+	}
+	s.resize(lo, hi)
+
+	s.bins[max(index, lo)-s.offset] += n
+}
+
+// resize extends the store to cover the given index range, adding the counts
+// of all bins below the range to the lowest bin
+func (s *ddStore) resize(lo, hi int) {
+	if lo == s.offset && hi == s.offset+len(s.bins)-1 {
+		return
+	}
+
+	bins := make([]uint64, hi-lo+1)
+	for i, c := range s.bins {
+		bins[max(s.offset+i, lo)-lo] += c
+	}
+	s.bins = bins
+	s.offset = lo
+}
+
+func (s *ddStore) merge(other *ddStore, maxBins int) {
+	for i, c := range other.bins {
+		if c > 0 {
+			s.add(other.offset+i, c, maxBins)
+		}
+	}
+}
+
+func (s *ddStore) encode(buf []byte) []byte {
+	buf = binary.AppendVarint(buf, int64(s.offset))
+	buf = binary.AppendUvarint(buf, uint64(len(s.bins)))
+	for _, c := range s.bins {
+		buf = binary.AppendUvarint(buf, c)
+	}
+	return buf
+}
+
+func (s *ddStore) decode(buf []byte) ([]byte, error) {
+	offset, n := binary.Varint(buf)
+	if n <= 0 {
+		return nil, errors.New("invalid offset")
+	}
+	buf = buf[n:]
+
+	length, n := binary.Uvarint(buf)
+	if n <= 0 || length > uint64(len(buf)) {
+		return nil, errors.New("invalid number of bins")
+	}
+	buf = buf[n:]
+
+	s.offset = int(offset)
+	s.bins = make([]uint64, 0, length)
+	for range length {
+		c, n := binary.Uvarint(buf)
+		if n <= 0 {
+			return nil, errors.New("store truncated")
+		}
+		s.bins = append(s.bins, c)
+		buf = buf[n:]
+	}
+	return buf, nil
+}
@@ -3,7 +3,9 @@ package quantile
 
 import (
 	_ "embed"
+	"encoding/base64"
 	"fmt"
+	"strings"
 
 	"github.com/influxdata/telegraf"
 	"github.com/influxdata/telegraf/plugins/aggregators"
@@ -12,12 +14,22 @@ import (
 //go:embed sample.conf
 var sampleConfig string
 
+const (
+	sketchTDigest  byte = 1
+	sketchDDSketch byte = 2
+)
+
 type Quantile struct {
-	Quantiles     []float64 `toml:"quantiles"`
-	Compression   float64   `toml:"compression"`
-	AlgorithmType string    `toml:"algorithm"`
+	Quantiles        []float64 `toml:"quantiles"`
+	Compression      float64   `toml:"compression"`
+	RelativeAccuracy float64   `toml:"relative_accuracy"`
+	MaxBins          int       `toml:"max_bins"`
+	AlgorithmType    string    `toml:"algorithm"`
+	EmitSketch       bool      `toml:"emit_sketch"`
+	MergeSketches    bool      `toml:"merge_sketches"`
 
 	newAlgorithm newAlgorithmFunc
+	sketchType   byte
 
 	cache    map[uint64]aggregate
 	suffixes []string
@@ -38,6 +50,11 @@ func (*Quantile) SampleConfig() string {
 }
 
 func (q *Quantile) Add(in telegraf.Metric) {
+	if q.MergeSketches {
+		q.addSketches(in)
+		return
+	}
+
 	id := in.HashID()
 	if cached, ok := q.cache[id]; ok {
 		fields := in.Fields()
@@ -76,6 +93,53 @@ func (q *Quantile) Add(in telegraf.Metric) {
 	q.cache[id] = a
 }
 
+// addSketches merges the serialized sketches contained in the metric's
+// "<field>_sketch" fields, all other fields are ignored
+func (q *Quantile) addSketches(in telegraf.Metric) {
+	id := in.HashID()
+	for _, field := range in.FieldList() {
+		k, found := strings.CutSuffix(field.Key, "_sketch")
+		if !found {
+			continue
+		}
+		encoded, ok := field.Value.(string)
+		if !ok {
+			q.Log.Errorf("invalid type %T for sketch %s", field.Value, field.Key)
+			continue
+		}
+		buf, err := base64.StdEncoding.DecodeString(encoded)
+		if err != nil {
+			q.Log.Errorf("decoding sketch %s: %v", field.Key, err)
+			continue
+		}
+		if len(buf) == 0 || buf[0] != q.sketchType {
+			q.Log.Errorf("sketch %s does not match algorithm %q", field.Key, q.AlgorithmType)
+			continue
+		}
+
+		a, found := q.cache[id]
+		if !found {
+			a = aggregate{
+				name:   in.Name(),
+				tags:   in.Tags(),
+				fields: make(map[string]algorithm),
+			}
+			q.cache[id] = a
+		}
+		algo, found := a.fields[k]
+		if !found {
+			if algo, err = q.newAlgorithm(q.Compression); err != nil {
+				q.Log.Errorf("generating algorithm %s: %v", k, err)
+				continue
+			}
+			a.fields[k] = algo
+		}
+		if err := algo.(mergeable).merge(buf[1:]); err != nil {
+			q.Log.Errorf("merging sketch %s: %v", field.Key, err)
+		}
+	}
+}
+
 func (q *Quantile) Push(acc telegraf.Accumulator) {
 	for _, aggregate := range q.cache {
 		fields := make(map[string]interface{}, len(aggregate.fields)*len(q.Quantiles))
@@ -83,6 +147,14 @@ func (q *Quantile) Push(acc telegraf.Accumulator) {
 			for i, qtl := range q.Quantiles {
 				fields[k+q.suffixes[i]] = algo.Quantile(qtl)
 			}
+			if q.EmitSketch {
+				buf, err := algo.(mergeable).encode()
+				if err != nil {
+					q.Log.Errorf("encoding sketch %s: %v", k, err)
+					continue
+				}
+				fields[k+"_sketch"] = base64.StdEncoding.EncodeToString(append([]byte{q.sketchType}, buf...))
+			}
 		}
 		acc.AddFields(aggregate.name, fields, aggregate.tags)
 	}
@@ -109,6 +181,18 @@ func (q *Quantile) Init() error {
 	switch q.AlgorithmType {
 	case "t-digest", "":
 		q.newAlgorithm = newTDigest
+		q.sketchType = sketchTDigest
+	case "ddsketch":
+		if q.RelativeAccuracy == 0 {
+			q.RelativeAccuracy = 0.01
+		}
+		if q.MaxBins == 0 {
+			q.MaxBins = 2048
+		}
+		q.newAlgorithm = func(float64) (algorithm, error) {
+			return newDDSketch(q.RelativeAccuracy, q.MaxBins)
+		}
+		q.sketchType = sketchDDSketch
 	case "exact R7":
 		q.newAlgorithm = newExactR7
 	case "exact R8":
@@ -119,6 +203,9 @@ func (q *Quantile) Init() error {
 	if _, err := q.newAlgorithm(q.Compression); err != nil {
 		return fmt.Errorf("cannot create %q algorithm: %w", q.AlgorithmType, err)
 	}
+	if (q.EmitSketch || q.MergeSketches) && q.sketchType == 0 {
+		return fmt.Errorf("algorithm %q does not support sketches", q.AlgorithmType)
+	}
 
 	if len(q.Quantiles) == 0 {
 		q.Quantiles = []float64{0.25, 0.5, 0.75}
@@ -1,6 +1,7 @@
 package quantile
 
 import (
+	"math"
 	"math/rand"
 	"testing"
 	"time"
@@ -669,3 +670,151 @@ func BenchmarkDefaultExactR8100Q(b *testing.B) {
 		q.Push(&acc)
 	}
 }
+
+func TestConfigSketchesUnsupported(t *testing.T) {
+	q := Quantile{AlgorithmType: "exact R7", EmitSketch: true}
+	require.ErrorContains(t, q.Init(), `algorithm "exact R7" does not support sketches`)
+
+	q = Quantile{AlgorithmType: "exact R8", MergeSketches: true}
+	require.ErrorContains(t, q.Init(), `algorithm "exact R8" does not support sketches`)
+}
+
+func TestConfigInvalidRelativeAccuracy(t *testing.T) {
+	q := Quantile{AlgorithmType: "ddsketch", RelativeAccuracy: 1.5}
+	require.ErrorContains(t, q.Init(), `cannot create "ddsketch" algorithm`)
+}
+
+func TestDDSketchAccuracy(t *testing.T) {
+	sketch, err := newDDSketch(0.01, 2048)
+	require.NoError(t, err)
+
+	// Values from -1000 to 9000 including zero
+	values := make([]float64, 0, 10001)
+	for i := -1000; i <= 9000; i++ {
+		values = append(values, float64(i))
+		require.NoError(t, sketch.Add(float64(i)))
+	}
+
+	exact, err := newExactR7(0)
+	require.NoError(t, err)
+	for _, v := range values {
+		require.NoError(t, exact.Add(v))
+	}
+
+	for _, qtl := range []float64{0, 0.01, 0.05, 0.1, 0.25, 0.5, 0.75, 0.9, 0.99} {
+		expected := exact.Quantile(qtl)
+		actual := sketch.Quantile(qtl)
+		require.InDeltaf(t, expected, actual, math.Abs(expected)*0.01+1, "quantile %v", qtl)
+	}
+	require.InEpsilon(t, 9000.0, sketch.Quantile(1.0), 0.01)
+	require.Error(t, sketch.Add(math.NaN()))
+}
+
+func TestDDSketchCollapsing(t *testing.T) {
+	sketch, err := newDDSketch(0.01, 100)
+	require.NoError(t, err)
+	for i := 1; i <= 100000; i++ {
+		require.NoError(t, sketch.Add(float64(i)))
+	}
+	require.LessOrEqual(t, len(sketch.positive.bins), 100)
+
+	// The upper quantiles are still accurate
+	require.InEpsilon(t, 99000.0, sketch.Quantile(0.99), 0.01)
+	require.InEpsilon(t, 100000.0, sketch.Quantile(1.0), 0.01)
+}
+
+func TestMergeSketches(t *testing.T) {
+	for _, algorithm := range []string{"t-digest", "ddsketch"} {
+		t.Run(algorithm, func(t *testing.T) {
+			rng := rand.New(rand.NewSource(42))
+			values := make([]float64, 0, 20000)
+			for range cap(values) {
+				values = append(values, rng.ExpFloat64()*100)
+			}
+
+			// Aggregate all values in a single instance as reference
+			reference := &Quantile{
+				Compression:   100,
+				AlgorithmType: algorithm,
+				Quantiles:     []float64{0.5, 0.9, 0.99},
+				Log:           testutil.Logger{},
+			}
+			require.NoError(t, reference.Init())
+			for _, v := range values {
+				reference.Add(testutil.MustMetric("test", map[string]string{}, map[string]interface{}{"latency": v}, time.Unix(0, 0)))
+			}
+			var racc testutil.Accumulator
+			reference.Push(&racc)
+			require.Len(t, racc.GetTelegrafMetrics(), 1)
+			expected := racc.GetTelegrafMetrics()[0]
+
+			// Split the values across multiple edge instances emitting sketches
+			var edges []telegraf.Metric
+			for i := range 4 {
+				edge := &Quantile{
+					Compression:   100,
+					AlgorithmType: algorithm,
+					Quantiles:     []float64{0.5, 0.9, 0.99},
+					EmitSketch:    true,
+					Log:           testutil.Logger{},
+				}
+				require.NoError(t, edge.Init())
+				for _, v := range values[i*5000 : (i+1)*5000] {
+					edge.Add(testutil.MustMetric("test", map[string]string{}, map[string]interface{}{"latency": v}, time.Unix(0, 0)))
+				}
+				var acc testutil.Accumulator
+				edge.Push(&acc)
+				edges = append(edges, acc.GetTelegrafMetrics()...)
+			}
+			require.Len(t, edges, 4)
+			for _, m := range edges {
+				require.True(t, m.HasField("latency_sketch"))
+				require.True(t, m.HasField("latency_050"))
+			}
+
+			// Merge the sketches in a central instance
+			central := &Quantile{
+				Compression:   100,
+				AlgorithmType: algorithm,
+				Quantiles:     []float64{0.5, 0.9, 0.99},
+				MergeSketches: true,
+				Log:           testutil.Logger{},
+			}
+			require.NoError(t, central.Init())
+			for _, m := range edges {
+				central.Add(m)
+			}
+			var acc testutil.Accumulator
+			central.Push(&acc)
+			actual := acc.GetTelegrafMetrics()
+			require.Len(t, actual, 1)
+
+			// Quantiles of other fields must not be aggregated
+			require.Len(t, actual[0].FieldList(), 3)
+			for _, suffix := range []string{"_050", "_090", "_099"} {
+				e, found := expected.GetField("latency" + suffix)
+				require.True(t, found)
+				a, found := actual[0].GetField("latency" + suffix)
+				require.True(t, found)
+				require.InEpsilon(t, e, a, 0.02, "quantile %s", suffix)
+			}
+		})
+	}
+}
+
+func TestMergeSketchesMismatch(t *testing.T) {
+	edge := &Quantile{Compression: 100, EmitSketch: true, Log: testutil.Logger{}}
+	require.NoError(t, edge.Init())
+	edge.Add(testutil.MustMetric("test", map[string]string{}, map[string]interface{}{"value": 1.0}, time.Unix(0, 0)))
+	var acc testutil.Accumulator
+	edge.Push(&acc)
+
+	logger := &testutil.CaptureLogger{}
+	central := &Quantile{AlgorithmType: "ddsketch", MergeSketches: true, Log: logger}
+	require.NoError(t, central.Init())
+	for _, m := range acc.GetTelegrafMetrics() {
+		central.Add(m)
+	}
+	require.Len(t, logger.Errors(), 1)
+	require.Contains(t, logger.Errors()[0], `sketch value_sketch does not match algorithm "ddsketch"`)
+}
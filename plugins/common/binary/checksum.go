@@ -0,0 +1,56 @@
+package binary
+
+import "hash/crc32"
+
+var castagnoliTable = crc32.MakeTable(crc32.Castagnoli)
+
+// crc16Modbus computes the CRC-16/MODBUS checksum with the reflected
+// polynomial 0xA001 and initial value 0xFFFF
+func crc16Modbus(data []byte) uint64 {
+	crc := uint16(0xffff)
+	for _, b := range data {
+		crc ^= uint16(b)
+		for range 8 {
+			if crc&0x0001 != 0 {
+				crc = (crc >> 1) ^ 0xa001
+			} else {
+				crc >>= 1
+			}
+		}
+	}
+	return uint64(crc)
+}
+
+// crc16CCITT computes the CRC-16/CCITT-FALSE checksum with polynomial 0x1021
+// and initial value 0xFFFF
+func crc16CCITT(data []byte) uint64 {
+	return crc16(data, 0xffff)
+}
+
+// crc16XModem computes the CRC-16/XMODEM checksum with polynomial 0x1021 and
+// initial value 0x0000
+func crc16XModem(data []byte) uint64 {
+	return crc16(data, 0x0000)
+}
+
+func crc16(data []byte, crc uint16) uint64 {
+	for _, b := range data {
+		crc ^= uint16(b) << 8
+		for range 8 {
+			if crc&0x8000 != 0 {
+				crc = (crc << 1) ^ 0x1021
+			} else {
+				crc <<= 1
+			}
+		}
+	}
+	return uint64(crc)
+}
+
+func crc32IEEE(data []byte) uint64 {
+	return uint64(crc32.ChecksumIEEE(data))
+}
+
+func crc32Castagnoli(data []byte) uint64 {
+	return uint64(crc32.Checksum(data, castagnoliTable))
+}
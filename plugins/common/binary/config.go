@@ -0,0 +1,323 @@
+// Package binary contains the layout definitions shared by the binary parser
+// and serializer to decode and encode metrics from and to binary frames.
+package binary
+
+import (
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/internal"
+	"github.com/influxdata/telegraf/metric"
+)
+
+type BinaryPart struct {
+	Offset uint64 `toml:"offset"`
+	Bits   uint64 `toml:"bits"`
+	Match  string `toml:"match"`
+
+	val []byte
+}
+
+type Filter struct {
+	Selection []BinaryPart `toml:"selection"`
+	LengthMin uint64       `toml:"length_min"`
+	Length    uint64       `toml:"length"`
+}
+
+// Config is the layout of a binary frame
+type Config struct {
+	MetricName string  `toml:"metric_name"`
+	Endianness string  `toml:"endianness"`
+	Filter     *Filter `toml:"filter"`
+	Entries    []Entry `toml:"entries"`
+
+	order binary.ByteOrder
+	// Array entries referencing the count entry with the given name
+	arrays map[string]*Entry
+}
+
+// HasMeasurement returns true if the layout contains an entry for the metric
+// name
+func (c *Config) HasMeasurement() bool {
+	for _, e := range c.Entries {
+		if !e.Omit && strings.EqualFold(e.Assignment, "measurement") {
+			return true
+		}
+	}
+	return false
+}
+
+// Preprocess checks the layout and sets up the internal state
+func (c *Config) Preprocess() error {
+	if c.Endianness != "" {
+		order, err := ByteOrder(c.Endianness)
+		if err != nil {
+			return err
+		}
+		c.order = order
+	}
+
+	// Preprocess filter part
+	if c.Filter != nil {
+		if c.Filter.Length != 0 && c.Filter.LengthMin != 0 {
+			return errors.New("length and length_min cannot be used together")
+		}
+
+		var length uint64
+		for i, s := range c.Filter.Selection {
+			end := (s.Offset + s.Bits) / 8
+			if (s.Offset+s.Bits)%8 != 0 {
+				end++
+			}
+			if end > length {
+				length = end
+			}
+			var err error
+			s.val, err = hex.DecodeString(strings.TrimPrefix(s.Match, "0x"))
+			if err != nil {
+				return fmt.Errorf("decoding match %d failed: %w", i, err)
+			}
+			c.Filter.Selection[i] = s
+		}
+
+		if c.Filter.Length != 0 && length > c.Filter.Length {
+			return fmt.Errorf("filter length (%d) larger than constraint (%d)", length, c.Filter.Length)
+		}
+
+		if c.Filter.Length == 0 && length > c.Filter.LengthMin {
+			c.Filter.LengthMin = length
+		}
+	}
+
+	// Preprocess entries part
+	var hasField bool
+	defined := make(map[string]bool)
+	known := make(map[string]bool)
+	c.arrays = make(map[string]*Entry)
+	for i, e := range c.Entries {
+		if err := e.check(); err != nil {
+			return fmt.Errorf("entry %q (%d): %w", e.Name, i, err)
+		}
+		// Store the normalized entry
+		c.Entries[i] = e
+
+		if e.countRef != "" {
+			if !known[e.countRef] {
+				return fmt.Errorf("entry %q (%d): count references unknown entry %q", e.Name, i, e.countRef)
+			}
+			c.arrays[e.countRef] = &c.Entries[i]
+		}
+		if e.Condition != nil && !known[e.Condition.Name] {
+			return fmt.Errorf("entry %q (%d): condition references unknown entry %q", e.Name, i, e.Condition.Name)
+		}
+		if e.Name != "" {
+			known[e.Name] = true
+		}
+
+		if e.Omit || e.Assignment == "checksum" {
+			continue
+		}
+
+		// Check for duplicate entries, entries with conditions might
+		// replace each other
+		key := e.Assignment + "_" + e.Name
+		if defined[key] && e.Condition == nil {
+			return fmt.Errorf("multiple definitions of %q", e.Name)
+		}
+		defined[key] = true
+		hasField = hasField || e.Assignment == "field"
+	}
+
+	if !hasField {
+		return errors.New("no field defined")
+	}
+
+	return nil
+}
+
+// Matches checks if the binary data matches the filter of the layout
+func (c *Config) Matches(in []byte) bool {
+	// If no filter is given, just match everything
+	if c.Filter == nil {
+		return true
+	}
+
+	// Checking length constraints
+	length := uint64(len(in))
+	if c.Filter.Length != 0 && length != c.Filter.Length {
+		return false
+	}
+	if c.Filter.LengthMin != 0 && length < c.Filter.LengthMin {
+		return false
+	}
+
+	// Matching elements
+	for _, s := range c.Filter.Selection {
+		data, err := extractPart(in, s.Offset, s.Bits)
+		if err != nil {
+			return false
+		}
+		if len(data) != len(s.val) {
+			return false
+		}
+		for i, v := range data {
+			if v != s.val[i] {
+				return false
+			}
+		}
+	}
+
+	return true
+}
+
+// MatchesMetric checks if the layout can be used to serialize the given
+// metric, i.e. if the metric name matches the layout's name
+func (c *Config) MatchesMetric(m telegraf.Metric) bool {
+	return c.MetricName == "" || c.HasMeasurement() || c.MetricName == m.Name()
+}
+
+// Collect decodes the binary data into a metric using the given byte-order
+// unless the layout or entries specify their own
+func (c *Config) Collect(in []byte, order binary.ByteOrder, defaultTime time.Time) (telegraf.Metric, error) {
+	if c.order != nil {
+		order = c.order
+	}
+
+	t := defaultTime
+	name := c.MetricName
+	tags := make(map[string]string)
+	fields := make(map[string]interface{})
+
+	// Keep all decoded values for evaluating conditions and counts
+	values := make(map[string]interface{})
+	lookup := func(key string) (interface{}, bool) {
+		v, found := values[key]
+		return v, found
+	}
+
+	var offset uint64
+	for _, e := range c.Entries {
+		if e.Condition != nil && !e.Condition.holds(lookup) {
+			continue
+		}
+		eorder := e.byteOrder(order)
+
+		if e.Assignment == "checksum" {
+			n, err := e.verifyChecksum(in, offset, eorder)
+			if err != nil {
+				return nil, err
+			}
+			offset += n
+			continue
+		}
+
+		count := e.countFixed
+		if e.countRef != "" {
+			v, found := values[e.countRef]
+			if !found {
+				return nil, fmt.Errorf("count %q for %q not found", e.countRef, e.Name)
+			}
+			n, err := internal.ToUint64(v)
+			if err != nil {
+				return nil, fmt.Errorf("count %q for %q invalid: %w", e.countRef, e.Name, err)
+			}
+			count = n
+		}
+
+		for i := uint64(0); i < count; i++ {
+			data, n, err := e.extract(in, offset)
+			if err != nil {
+				return nil, err
+			}
+			offset += n
+
+			key := e.key(i, e.isArray())
+			if e.Omit {
+				// Keep omitted values for conditions and counts
+				if e.Name != "" && e.Type != "" && !e.isArray() {
+					if v, err := e.convertType(data, eorder); err == nil {
+						values[key] = v
+					}
+				}
+				continue
+			}
+
+			switch e.Assignment {
+			case "measurement":
+				name, err = e.convertStringType(data)
+				if err != nil {
+					return nil, fmt.Errorf("measurement failed: %w", err)
+				}
+			case "field":
+				v, err := e.convertType(data, eorder)
+				if err != nil {
+					return nil, fmt.Errorf("field %q failed: %w", key, err)
+				}
+				fields[key] = v
+				values[key] = v
+			case "tag":
+				raw, err := e.convertType(data, eorder)
+				if err != nil {
+					return nil, fmt.Errorf("tag %q failed: %w", key, err)
+				}
+				v, err := internal.ToString(raw)
+				if err != nil {
+					return nil, fmt.Errorf("tag %q failed: %w", key, err)
+				}
+				tags[key] = v
+				values[key] = raw
+			case "time":
+				t, err = e.convertTimeType(data, eorder)
+				if err != nil {
+					return nil, fmt.Errorf("time failed: %w", err)
+				}
+			}
+		}
+	}
+
+	return metric.New(name, tags, fields, t), nil
+}
+
+// verifyChecksum checks the checksum at the given bit offset against the
+// checksum computed for the data preceding it
+func (e *Entry) verifyChecksum(in []byte, offset uint64, order binary.ByteOrder) (uint64, error) {
+	if offset%8 != 0 {
+		return 0, fmt.Errorf("checksum %q not aligned to byte boundaries", e.Name)
+	}
+	end := offset / 8
+	if e.ChecksumStart > end {
+		return 0, fmt.Errorf("checksum %q starts after its position", e.Name)
+	}
+
+	data, n, err := e.extract(in, offset)
+	if err != nil {
+		return 0, err
+	}
+	raw, err := convertNumericType(data, e.Type, order)
+	if err != nil {
+		return 0, fmt.Errorf("checksum %q failed: %w", e.Name, err)
+	}
+	actual, err := internal.ToUint64(raw)
+	if err != nil {
+		return 0, fmt.Errorf("checksum %q failed: %w", e.Name, err)
+	}
+
+	expected := e.checksum(in[e.ChecksumStart:end]) & e.mask()
+	if actual&e.mask() != expected {
+		return 0, fmt.Errorf("checksum %q mismatch: expected 0x%x but got 0x%x", e.Name, expected, actual)
+	}
+	return n, nil
+}
+
+// mask returns the bit-mask for the entry's size
+func (e *Entry) mask() uint64 {
+	if e.Bits >= 64 {
+		return ^uint64(0)
+	}
+	return (uint64(1) << e.Bits) - 1
+}
@@ -0,0 +1,248 @@
+package binary
+
+import (
+	"encoding/binary"
+	"encoding/hex"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/metric"
+	"github.com/influxdata/telegraf/testutil"
+)
+
+func TestRoundtrip(t *testing.T) {
+	tests := []struct {
+		name     string
+		layout   Config
+		input    telegraf.Metric
+		expected string
+		parsed   telegraf.Metric
+	}{
+		{
+			name: "bit fields and endianness",
+			layout: Config{
+				MetricName: "test",
+				Endianness: "be",
+				Entries: []Entry{
+					{Name: "flags", Type: "uint8", Bits: 3},
+					{Name: "mode", Type: "uint8", Bits: 5},
+					{Name: "value", Type: "uint16", Endianness: "le"},
+				},
+			},
+			input: metric.New(
+				"test",
+				map[string]string{},
+				map[string]interface{}{"flags": uint8(5), "mode": uint8(17), "value": uint16(0x1234)},
+				time.Unix(0, 0),
+			),
+			expected: "b13412",
+		},
+		{
+			name: "array with count",
+			layout: Config{
+				MetricName: "test",
+				Endianness: "be",
+				Entries: []Entry{
+					{Name: "n", Type: "uint8"},
+					{Name: "sample", Type: "int16", Count: "n"},
+					{Name: "fixed", Type: "uint8", Count: "2"},
+				},
+			},
+			input: metric.New(
+				"test",
+				map[string]string{},
+				map[string]interface{}{
+					"sample_0": int16(1),
+					"sample_1": int16(-1),
+					"fixed_0":  uint8(3),
+					"fixed_1":  uint8(4),
+				},
+				time.Unix(0, 0),
+			),
+			expected: "020001ffff0304",
+			parsed: metric.New(
+				"test",
+				map[string]string{},
+				map[string]interface{}{
+					"n":        uint8(2),
+					"sample_0": int16(1),
+					"sample_1": int16(-1),
+					"fixed_0":  uint8(3),
+					"fixed_1":  uint8(4),
+				},
+				time.Unix(0, 0),
+			),
+		},
+		{
+			name: "conditional sections",
+			layout: Config{
+				MetricName: "test",
+				Endianness: "be",
+				Entries: []Entry{
+					{Name: "type", Type: "uint8"},
+					{Name: "temperature", Type: "float32", Condition: &Condition{Name: "type", Equals: "1"}},
+					{Name: "humidity", Type: "uint16", Condition: &Condition{Name: "type", Equals: "2"}},
+					{Name: "alarm", Type: "bool", Bits: 8, Condition: &Condition{Name: "type", Mask: 0x02}},
+				},
+			},
+			input: metric.New(
+				"test",
+				map[string]string{},
+				map[string]interface{}{"type": uint8(2), "humidity": uint16(55), "alarm": true},
+				time.Unix(0, 0),
+			),
+			expected: "02003701",
+		},
+		{
+			name: "string encodings",
+			layout: Config{
+				MetricName: "test",
+				Entries: []Entry{
+					{Name: "label", Type: "string", Encoding: "utf-16le", Terminator: "null", Assignment: "tag"},
+					{Name: "value", Type: "uint8"},
+					{Name: "city", Type: "string", Encoding: "latin1", Bits: 40},
+				},
+			},
+			input: metric.New(
+				"test",
+				map[string]string{"label": "Aé"},
+				map[string]interface{}{"value": uint8(7), "city": "Malmö"},
+				time.Unix(0, 0),
+			),
+			expected: "4100e9000000074d616c6df6",
+		},
+		{
+			name: "checksum and filter",
+			layout: Config{
+				MetricName: "test",
+				Endianness: "be",
+				Filter: &Filter{
+					Selection: []BinaryPart{{Offset: 0, Bits: 8, Match: "0x7e"}},
+				},
+				Entries: []Entry{
+					{Bits: 8, Omit: true},
+					{Name: "value", Type: "uint16"},
+					{Assignment: "checksum", Algorithm: "crc16-modbus", Endianness: "le"},
+				},
+			},
+			input: metric.New(
+				"test",
+				map[string]string{},
+				map[string]interface{}{"value": uint16(42)},
+				time.Unix(0, 0),
+			),
+			expected: "7e002a9007",
+		},
+		{
+			name: "measurement and time",
+			layout: Config{
+				Endianness: "be",
+				Entries: []Entry{
+					{Assignment: "measurement", Terminator: "null"},
+					{Assignment: "time", Type: "unix_ms"},
+					{Name: "value", Type: "int32"},
+				},
+			},
+			input: metric.New(
+				"cpu",
+				map[string]string{},
+				map[string]interface{}{"value": int32(-2)},
+				time.Unix(1, 500*int64(time.Millisecond)),
+			),
+			expected: "6370750000000000000005dcfffffffe",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require.NoError(t, tt.layout.Preprocess())
+			require.True(t, tt.layout.MatchesMetric(tt.input))
+
+			actual, err := tt.layout.Serialize(tt.input, binary.LittleEndian)
+			require.NoError(t, err)
+			require.Equal(t, tt.expected, hex.EncodeToString(actual))
+
+			require.True(t, tt.layout.Matches(actual))
+			parsed, err := tt.layout.Collect(actual, binary.LittleEndian, time.Unix(0, 0))
+			require.NoError(t, err)
+
+			expected := tt.parsed
+			if expected == nil {
+				expected = tt.input
+			}
+			testutil.RequireMetricEqual(t, expected, parsed)
+		})
+	}
+}
+
+func TestChecksumMismatch(t *testing.T) {
+	layout := Config{
+		MetricName: "test",
+		Entries: []Entry{
+			{Name: "value", Type: "uint16"},
+			{Assignment: "checksum", Algorithm: "crc32"},
+		},
+	}
+	require.NoError(t, layout.Preprocess())
+
+	_, err := layout.Collect([]byte{0x00, 0x2a, 0x00, 0x00, 0x00, 0x00}, binary.BigEndian, time.Unix(0, 0))
+	require.ErrorContains(t, err, `checksum "checksum" mismatch`)
+}
+
+func TestChecksumAlgorithms(t *testing.T) {
+	data := []byte("123456789")
+	require.Equal(t, uint64(0x4b37), crc16Modbus(data))
+	require.Equal(t, uint64(0x29b1), crc16CCITT(data))
+	require.Equal(t, uint64(0x31c3), crc16XModem(data))
+	require.Equal(t, uint64(0xcbf43926), crc32IEEE(data))
+	require.Equal(t, uint64(0xe3069283), crc32Castagnoli(data))
+}
+
+func TestPreprocessInvalid(t *testing.T) {
+	tests := []struct {
+		name     string
+		entries  []Entry
+		expected string
+	}{
+		{
+			name:     "unknown count reference",
+			entries:  []Entry{{Name: "sample", Type: "int16", Count: "n"}},
+			expected: `entry "sample" (0): count references unknown entry "n"`,
+		},
+		{
+			name: "unknown condition reference",
+			entries: []Entry{
+				{Name: "value", Type: "int16", Condition: &Condition{Name: "type", Equals: "1"}},
+			},
+			expected: `entry "value" (0): condition references unknown entry "type"`,
+		},
+		{
+			name: "checksum without algorithm",
+			entries: []Entry{
+				{Name: "value", Type: "int16"},
+				{Assignment: "checksum"},
+			},
+			expected: `entry "checksum" (1): missing algorithm for checksum "checksum"`,
+		},
+		{
+			name:     "unknown string encoding",
+			entries:  []Entry{{Name: "value", Type: "string", Bits: 8, Encoding: "ebcdic"}},
+			expected: `entry "value" (0): unknown encoding "ebcdic" for "value"`,
+		},
+		{
+			name:     "unknown endianness",
+			entries:  []Entry{{Name: "value", Type: "int16", Endianness: "middle"}},
+			expected: `entry "value" (0): unknown endianness "middle"`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			layout := Config{MetricName: "test", Entries: tt.entries}
+			require.EqualError(t, layout.Preprocess(), tt.expected)
+		})
+	}
+}
@@ -0,0 +1,338 @@
+package binary
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/internal"
+)
+
+// bitWriter appends data at bit granularity
+type bitWriter struct {
+	buf  []byte
+	bits uint64
+}
+
+// write appends the trailing n bits of the given big-endian data, padding
+// with leading zero bits if the data is shorter
+func (w *bitWriter) write(data []byte, n uint64) {
+	total := uint64(len(data)) * 8
+
+	// Fast path for byte-aligned data
+	if w.bits%8 == 0 && n%8 == 0 && n <= total {
+		w.buf = append(w.buf, data[uint64(len(data))-n/8:]...)
+		w.bits += n
+		return
+	}
+
+	for ; n > total; n-- {
+		w.writeBit(0)
+	}
+	for i := total - n; i < total; i++ {
+		w.writeBit((data[i/8] >> (7 - i%8)) & 0x01)
+	}
+}
+
+func (w *bitWriter) writeBit(bit byte) {
+	if w.bits%8 == 0 {
+		w.buf = append(w.buf, 0)
+	}
+	w.buf[len(w.buf)-1] |= bit << (7 - w.bits%8)
+	w.bits++
+}
+
+// setPart overwrites the given number of bits at the bit offset with the
+// trailing bits of the big-endian data
+func setPart(buf []byte, offset, bits uint64, data []byte) {
+	total := uint64(len(data)) * 8
+	for i := uint64(0); i < bits; i++ {
+		var bit byte
+		if bits-i <= total {
+			idx := total - (bits - i)
+			bit = (data[idx/8] >> (7 - idx%8)) & 0x01
+		}
+		pos := offset + i
+		mask := byte(1) << (7 - pos%8)
+		if bit != 0 {
+			buf[pos/8] |= mask
+		} else {
+			buf[pos/8] &^= mask
+		}
+	}
+}
+
+// checksumPosition records a checksum for recomputation
+type checksumPosition struct {
+	entry  *Entry
+	offset uint64
+	order  binary.ByteOrder
+}
+
+// Serialize encodes the metric according to the layout using the given
+// byte-order unless the layout or entries specify their own
+func (c *Config) Serialize(m telegraf.Metric, order binary.ByteOrder) ([]byte, error) {
+	if c.order != nil {
+		order = c.order
+	}
+
+	lookup := func(key string) (interface{}, bool) {
+		if v, found := m.GetField(key); found {
+			return v, true
+		}
+		if v, found := m.GetTag(key); found {
+			return v, true
+		}
+		// Derive counts from the number of array elements
+		if e, found := c.arrays[key]; found {
+			return c.arrayLength(e, m), true
+		}
+		return nil, false
+	}
+
+	var w bitWriter
+	var checksums []checksumPosition
+	for i := range c.Entries {
+		e := &c.Entries[i]
+		if e.Condition != nil && !e.Condition.holds(lookup) {
+			continue
+		}
+		eorder := e.byteOrder(order)
+
+		if e.Assignment == "checksum" {
+			if w.bits%8 != 0 {
+				return nil, fmt.Errorf("checksum %q not aligned to byte boundaries", e.Name)
+			}
+			checksums = append(checksums, checksumPosition{entry: e, offset: w.bits, order: eorder})
+			// Reserve the space and fill in the checksum at the end
+			w.write(nil, e.Bits)
+			continue
+		}
+
+		count := e.countFixed
+		if e.countRef != "" {
+			v, found := lookup(e.countRef)
+			if !found {
+				return nil, fmt.Errorf("count %q for %q not found", e.countRef, e.Name)
+			}
+			n, err := internal.ToUint64(v)
+			if err != nil {
+				return nil, fmt.Errorf("count %q for %q invalid: %w", e.countRef, e.Name, err)
+			}
+			count = n
+		}
+
+		for idx := uint64(0); idx < count; idx++ {
+			key := e.key(idx, e.isArray())
+
+			var v interface{}
+			var found bool
+			switch e.Assignment {
+			case "measurement":
+				v, found = m.Name(), true
+			case "time":
+				v, found = m.Time(), true
+			case "tag":
+				v, found = m.GetTag(key)
+			default:
+				v, found = lookup(key)
+			}
+
+			if e.Omit {
+				// Fill omitted entries with the value if any or zeros
+				if !found || e.Type == "" {
+					w.write(nil, e.Bits)
+					continue
+				}
+			} else if !found {
+				return nil, fmt.Errorf("%s %q not found", e.Assignment, key)
+			}
+
+			data, bits, err := e.encode(v, eorder)
+			if err != nil {
+				return nil, fmt.Errorf("encoding %q failed: %w", key, err)
+			}
+			w.write(data, bits)
+		}
+	}
+
+	// Apply the filter constraints
+	buf := w.buf
+	if c.Filter != nil {
+		length := c.Filter.LengthMin
+		if c.Filter.Length != 0 {
+			length = c.Filter.Length
+			if uint64(len(buf)) > length {
+				return nil, fmt.Errorf("frame length %d exceeds the required length %d", len(buf), length)
+			}
+		}
+		for uint64(len(buf)) < length {
+			buf = append(buf, 0)
+		}
+		for _, s := range c.Filter.Selection {
+			setPart(buf, s.Offset, s.Bits, s.val)
+		}
+	}
+
+	// Compute the checksums
+	for _, pos := range checksums {
+		e := pos.entry
+		end := pos.offset / 8
+		if e.ChecksumStart > end {
+			return nil, fmt.Errorf("checksum %q starts after its position", e.Name)
+		}
+		data, bits, err := e.encode(e.checksum(buf[e.ChecksumStart:end])&e.mask(), pos.order)
+		if err != nil {
+			return nil, fmt.Errorf("encoding checksum %q failed: %w", e.Name, err)
+		}
+		setPart(buf, pos.offset, bits, data)
+	}
+
+	return buf, nil
+}
+
+// arrayLength returns the number of consecutive array elements of the entry
+// in the metric
+func (c *Config) arrayLength(e *Entry, m telegraf.Metric) uint64 {
+	var n uint64
+	for {
+		key := e.key(n, true)
+		var found bool
+		if e.Assignment == "tag" {
+			_, found = m.GetTag(key)
+		} else {
+			_, found = m.GetField(key)
+		}
+		if !found {
+			return n
+		}
+		n++
+	}
+}
+
+// encode converts the value to big-endian data and returns the number of
+// trailing bits to write
+func (e *Entry) encode(v interface{}, order binary.ByteOrder) ([]byte, uint64, error) {
+	if e.Assignment == "time" {
+		t, ok := v.(time.Time)
+		if !ok {
+			return nil, 0, fmt.Errorf("time expected but got %T", v)
+		}
+		switch e.Type {
+		case "unix":
+			v = t.Unix()
+		case "unix_ms":
+			v = t.UnixMilli()
+		case "unix_us":
+			v = t.UnixMicro()
+		case "unix_ns":
+			v = t.UnixNano()
+		default:
+			// Format-specification string
+			return []byte(t.In(e.location).Format(e.Type)), e.Bits, nil
+		}
+		data, err := convertToNumeric(v, "int64", order)
+		return data, e.Bits, err
+	}
+
+	switch e.Type {
+	case "uint8", "int8", "uint16", "int16", "uint32", "int32", "float32", "uint64", "int64", "float64":
+		data, err := convertToNumeric(v, e.Type, order)
+		return data, e.Bits, err
+	case "bool":
+		b, err := internal.ToBool(v)
+		if err != nil {
+			return nil, 0, err
+		}
+		if b {
+			return []byte{1}, e.Bits, nil
+		}
+		return []byte{0}, e.Bits, nil
+	case "string":
+		data, err := e.convertToString(v)
+		return data, uint64(len(data)) * 8, err
+	}
+	return nil, 0, fmt.Errorf("cannot handle type %q", e.Type)
+}
+
+// convertToString encodes the string and applies the length constraints
+func (e *Entry) convertToString(v interface{}) ([]byte, error) {
+	s, err := internal.ToString(v)
+	if err != nil {
+		return nil, err
+	}
+
+	data := []byte(s)
+	if e.encoding != nil {
+		data, err = e.encoding.NewEncoder().Bytes(data)
+		if err != nil {
+			return nil, fmt.Errorf("encoding %q failed: %w", e.Encoding, err)
+		}
+	}
+
+	if e.Terminator != "fixed" {
+		return append(data, e.termination...), nil
+	}
+
+	// Truncate or pad fixed-length strings
+	length := int(e.Bits / 8) //nolint:gosec // G115: string lengths are far below the int limit
+	if len(data) > length {
+		return data[:length], nil
+	}
+	return append(data, make([]byte, length-len(data))...), nil
+}
+
+// convertToNumeric encodes the value as the given numeric type
+func convertToNumeric(v interface{}, t string, order binary.ByteOrder) ([]byte, error) {
+	switch t {
+	case "uint8":
+		x, err := internal.ToUint8(v)
+		return []byte{x}, err
+	case "int8":
+		x, err := internal.ToInt8(v)
+		return []byte{uint8(x)}, err
+	case "uint16":
+		x, err := internal.ToUint16(v)
+		buf := make([]byte, 2)
+		order.PutUint16(buf, x)
+		return buf, err
+	case "int16":
+		x, err := internal.ToInt16(v)
+		buf := make([]byte, 2)
+		order.PutUint16(buf, uint16(x))
+		return buf, err
+	case "uint32":
+		x, err := internal.ToUint32(v)
+		buf := make([]byte, 4)
+		order.PutUint32(buf, x)
+		return buf, err
+	case "int32":
+		x, err := internal.ToInt32(v)
+		buf := make([]byte, 4)
+		order.PutUint32(buf, uint32(x))
+		return buf, err
+	case "uint64":
+		x, err := internal.ToUint64(v)
+		buf := make([]byte, 8)
+		order.PutUint64(buf, x)
+		return buf, err
+	case "int64":
+		x, err := internal.ToInt64(v)
+		buf := make([]byte, 8)
+		order.PutUint64(buf, uint64(x))
+		return buf, err
+	case "float32":
+		x, err := internal.ToFloat32(v)
+		buf := make([]byte, 4)
+		order.PutUint32(buf, math.Float32bits(x))
+		return buf, err
+	case "float64":
+		x, err := internal.ToFloat64(v)
+		buf := make([]byte, 8)
+		order.PutUint64(buf, math.Float64bits(x))
+		return buf, err
+	}
+	return nil, fmt.Errorf("no numeric type %q", t)
+}
@@ -0,0 +1,563 @@
+package binary
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"golang.org/x/text/encoding"
+	"golang.org/x/text/encoding/charmap"
+	"golang.org/x/text/encoding/unicode"
+
+	"github.com/influxdata/telegraf/internal"
+)
+
+// Entry describes an element of the binary layout
+type Entry struct {
+	Name          string     `toml:"name"`
+	Type          string     `toml:"type"`
+	Bits          uint64     `toml:"bits"`
+	Omit          bool       `toml:"omit"`
+	Terminator    string     `toml:"terminator"`
+	Encoding      string     `toml:"encoding"`
+	Timezone      string     `toml:"timezone"`
+	Assignment    string     `toml:"assignment"`
+	Endianness    string     `toml:"endianness"`
+	Count         string     `toml:"count"`
+	Condition     *Condition `toml:"condition"`
+	Algorithm     string     `toml:"algorithm"`
+	ChecksumStart uint64     `toml:"checksum_start"`
+
+	termination []byte
+	encoding    encoding.Encoding
+	unitSize    int
+	location    *time.Location
+	order       binary.ByteOrder
+	countFixed  uint64
+	countRef    string
+	checksum    func([]byte) uint64
+}
+
+// Condition restricts an entry to layouts where a previously defined entry
+// has the given value or, if a mask is given, any of the masked bits set
+type Condition struct {
+	Name   string `toml:"name"`
+	Equals string `toml:"equals"`
+	Mask   uint64 `toml:"mask"`
+}
+
+// holds evaluates the condition using the given value lookup
+func (c *Condition) holds(lookup func(string) (interface{}, bool)) bool {
+	v, found := lookup(c.Name)
+	if !found {
+		return false
+	}
+
+	if c.Mask != 0 {
+		iv, err := internal.ToUint64(v)
+		return err == nil && iv&c.Mask != 0
+	}
+	sv, err := internal.ToString(v)
+	return err == nil && sv == c.Equals
+}
+
+// ByteOrder returns the byte-order for the given endianness setting, with
+// an empty setting referring to the host endianness
+func ByteOrder(endianness string) (binary.ByteOrder, error) {
+	switch endianness {
+	case "le", "little":
+		return binary.LittleEndian, nil
+	case "be", "big":
+		return binary.BigEndian, nil
+	case "", "host":
+		return internal.HostEndianness, nil
+	}
+	return nil, fmt.Errorf("unknown endianness %q", endianness)
+}
+
+func (e *Entry) check() error {
+	// Normalize cases
+	e.Assignment = strings.ToLower(e.Assignment)
+	e.Terminator = strings.ToLower(e.Terminator)
+	e.Encoding = strings.ToLower(e.Encoding)
+	if e.Assignment != "time" {
+		e.Type = strings.ToLower(e.Type)
+	}
+
+	if e.Endianness != "" {
+		order, err := ByteOrder(e.Endianness)
+		if err != nil {
+			return err
+		}
+		e.order = order
+	}
+
+	if e.Condition != nil && e.Condition.Name == "" {
+		return errors.New("missing name for condition")
+	}
+
+	if err := e.checkCount(); err != nil {
+		return err
+	}
+
+	// Handle omitted fields
+	if e.Omit {
+		if e.Bits == 0 && e.Type == "" {
+			return errors.New("neither type nor bits given")
+		}
+		if e.Bits == 0 {
+			bits, err := bitsForType(e.Type)
+			if err != nil {
+				return err
+			}
+			e.Bits = bits
+		}
+		return nil
+	}
+
+	// Set name for global options
+	if e.Assignment == "measurement" || e.Assignment == "time" {
+		e.Name = e.Assignment
+	}
+	if e.Assignment == "checksum" && e.Name == "" {
+		e.Name = "checksum"
+	}
+
+	// Check the name
+	if e.Name == "" {
+		return errors.New("missing name")
+	}
+
+	// Check the assignment
+	var defaultType string
+	switch e.Assignment {
+	case "measurement":
+		defaultType = "string"
+		if e.Type != "string" && e.Type != "" {
+			return errors.New("'measurement' type has to be 'string'")
+		}
+	case "time":
+		bits := uint64(64)
+
+		switch e.Type {
+		// Make 'unix' the default
+		case "":
+			defaultType = "unix"
+		// Special plugin specific names
+		case "unix", "unix_ms", "unix_us", "unix_ns":
+		// Format-specification string formats
+		default:
+			bits = uint64(len(e.Type) * 8)
+		}
+		if e.Bits == 0 {
+			e.Bits = bits
+		}
+
+		switch e.Timezone {
+		case "", "utc":
+			// Make UTC the default
+			e.location = time.UTC
+		case "local":
+			e.location = time.Local
+		default:
+			var err error
+			e.location, err = time.LoadLocation(e.Timezone)
+			if err != nil {
+				return err
+			}
+		}
+	case "checksum":
+		var err error
+		defaultType, err = e.checkChecksum()
+		if err != nil {
+			return err
+		}
+	case "tag":
+		defaultType = "string"
+	case "", "field":
+		e.Assignment = "field"
+	default:
+		return fmt.Errorf("no assignment for %q", e.Name)
+	}
+
+	if e.countRef != "" || e.countFixed != 1 {
+		switch e.Assignment {
+		case "measurement", "time", "checksum":
+			return fmt.Errorf("cannot use 'count' for %q assignment", e.Assignment)
+		}
+	}
+
+	// Check type (special type for "time")
+	switch e.Type {
+	case "uint8", "int8", "uint16", "int16", "uint32", "int32", "uint64", "int64":
+		fallthrough
+	case "float32", "float64":
+		bits, err := bitsForType(e.Type)
+		if err != nil {
+			return err
+		}
+		if e.Bits == 0 {
+			e.Bits = bits
+		}
+		if bits < e.Bits {
+			return fmt.Errorf("type overflow for %q", e.Name)
+		}
+	case "bool":
+		if e.Bits == 0 {
+			e.Bits = 1
+		}
+	case "string":
+		if err := e.checkString(); err != nil {
+			return err
+		}
+	case "":
+		if defaultType == "" {
+			return fmt.Errorf("no type for %q", e.Name)
+		}
+		e.Type = defaultType
+		if e.Type == "string" {
+			return e.checkString()
+		}
+		if e.Bits == 0 {
+			bits, err := bitsForType(e.Type)
+			if err == nil {
+				e.Bits = bits
+			}
+		}
+	default:
+		if e.Assignment != "time" {
+			return fmt.Errorf("unknown type for %q", e.Name)
+		}
+	}
+
+	if e.Assignment == "checksum" && (e.Type == "float32" || e.Type == "float64" || e.Type == "bool") {
+		return fmt.Errorf("checksum %q requires an integer type", e.Name)
+	}
+
+	return nil
+}
+
+// checkCount sets up the number of array elements either using a fixed
+// number or a reference to a previous entry
+func (e *Entry) checkCount() error {
+	e.countFixed = 1
+	if e.Count == "" {
+		return nil
+	}
+	n, err := strconv.ParseUint(e.Count, 10, 64)
+	if err != nil {
+		e.countFixed = 0
+		e.countRef = e.Count
+		return nil
+	}
+	if n == 0 {
+		return errors.New("count has to be greater than zero")
+	}
+	e.countFixed = n
+	return nil
+}
+
+// checkChecksum sets up the checksum algorithm and returns the default type
+// for the checksum value
+func (e *Entry) checkChecksum() (string, error) {
+	switch strings.ToLower(e.Algorithm) {
+	case "crc16", "crc16-modbus":
+		e.checksum = crc16Modbus
+		return "uint16", nil
+	case "crc16-ccitt":
+		e.checksum = crc16CCITT
+		return "uint16", nil
+	case "crc16-xmodem":
+		e.checksum = crc16XModem
+		return "uint16", nil
+	case "crc32", "crc32-ieee":
+		e.checksum = crc32IEEE
+		return "uint32", nil
+	case "crc32c", "crc32-castagnoli":
+		e.checksum = crc32Castagnoli
+		return "uint32", nil
+	case "":
+		return "", fmt.Errorf("missing algorithm for checksum %q", e.Name)
+	}
+	return "", fmt.Errorf("unknown checksum algorithm %q", e.Algorithm)
+}
+
+// checkString sets up the string encoding and termination
+func (e *Entry) checkString() error {
+	e.unitSize = 1
+	switch e.Encoding {
+	case "", "utf-8", "utf8", "ascii":
+	case "latin1", "iso-8859-1":
+		e.encoding = charmap.ISO8859_1
+	case "utf-16le":
+		e.encoding = unicode.UTF16(unicode.LittleEndian, unicode.IgnoreBOM)
+		e.unitSize = 2
+	case "utf-16be":
+		e.encoding = unicode.UTF16(unicode.BigEndian, unicode.IgnoreBOM)
+		e.unitSize = 2
+	default:
+		return fmt.Errorf("unknown encoding %q for %q", e.Encoding, e.Name)
+	}
+
+	// Check termination
+	switch e.Terminator {
+	case "", "fixed":
+		e.Terminator = "fixed"
+		if e.Bits == 0 {
+			return fmt.Errorf("require 'bits' for fixed-length string for %q", e.Name)
+		}
+	case "null":
+		e.termination = make([]byte, e.unitSize)
+		if e.Bits != 0 {
+			return fmt.Errorf("cannot use 'bits' and 'null' terminator together for %q", e.Name)
+		}
+	default:
+		if e.Bits != 0 {
+			return fmt.Errorf("cannot use 'bits' and terminator together for %q", e.Name)
+		}
+		var err error
+		e.termination, err = hex.DecodeString(strings.TrimPrefix(e.Terminator, "0x"))
+		if err != nil {
+			return fmt.Errorf("decoding terminator failed for %q: %w", e.Name, err)
+		}
+		if len(e.termination)%e.unitSize != 0 {
+			return fmt.Errorf("terminator not matching encoding for %q", e.Name)
+		}
+	}
+
+	// We can only handle strings that adhere to the character bounds
+	if e.Bits%uint64(8*e.unitSize) != 0 {
+		return fmt.Errorf("non-byte length for string field %q", e.Name)
+	}
+	return nil
+}
+
+// byteOrder returns the entry specific byte-order falling back to the given
+// default
+func (e *Entry) byteOrder(order binary.ByteOrder) binary.ByteOrder {
+	if e.order != nil {
+		return e.order
+	}
+	return order
+}
+
+// key returns the field or tag name for the i-th element of the entry
+func (e *Entry) key(i uint64, array bool) string {
+	if !array {
+		return e.Name
+	}
+	return e.Name + "_" + strconv.FormatUint(i, 10)
+}
+
+func (e *Entry) isArray() bool {
+	return e.countRef != "" || e.countFixed > 1
+}
+
+func (e *Entry) extract(in []byte, offset uint64) ([]byte, uint64, error) {
+	if e.Bits > 0 {
+		data, err := extractPart(in, offset, e.Bits)
+		return data, e.Bits, err
+	}
+
+	if e.Type != "string" || len(e.termination) == 0 {
+		return nil, 0, fmt.Errorf("unexpected entry %q without length", e.Name)
+	}
+
+	inbits := uint64(len(in)) * 8
+	unitBits := uint64(e.unitSize) * 8
+
+	// Read up to the termination
+	var data []byte
+	var n uint64
+	for offset+n+unitBits <= inbits {
+		buf, err := extractPart(in, offset+n, unitBits)
+		if err != nil {
+			return nil, 0, err
+		}
+		if len(buf) != e.unitSize {
+			return nil, 0, fmt.Errorf("unexpected length %d", len(buf))
+		}
+		data = append(data, buf...)
+		n += unitBits
+
+		// Check for terminator
+		if bytes.HasSuffix(data, e.termination) {
+			// Strip the terminator
+			return data[:len(data)-len(e.termination)], n, nil
+		}
+	}
+	return nil, n, fmt.Errorf("terminator not found for %q", e.Name)
+}
+
+func (e *Entry) convertType(in []byte, order binary.ByteOrder) (interface{}, error) {
+	switch e.Type {
+	case "uint8", "int8", "uint16", "int16", "uint32", "int32", "float32", "uint64", "int64", "float64":
+		return convertNumericType(in, e.Type, order)
+	case "bool":
+		return convertBoolType(in), nil
+	case "string":
+		return e.convertStringType(in)
+	}
+
+	return nil, fmt.Errorf("cannot handle type %q", e.Type)
+}
+
+func (e *Entry) convertTimeType(in []byte, order binary.ByteOrder) (time.Time, error) {
+	factor := int64(1)
+
+	switch e.Type {
+	case "unix":
+		factor *= 1000
+		fallthrough
+	case "unix_ms":
+		factor *= 1000
+		fallthrough
+	case "unix_us":
+		factor *= 1000
+		fallthrough
+	case "unix_ns":
+		raw, err := convertNumericType(in, "int64", order)
+		if err != nil {
+			return time.Unix(0, 0), err
+		}
+		v := raw.(int64)
+		return time.Unix(0, v*factor).In(e.location), nil
+	}
+	// We have a format specification (hopefully)
+	return internal.ParseTimestamp(e.Type, string(in), e.location)
+}
+
+func (e *Entry) convertStringType(in []byte) (string, error) {
+	if e.encoding == nil {
+		return string(in), nil
+	}
+	out, err := e.encoding.NewDecoder().Bytes(in)
+	if err != nil {
+		return "", fmt.Errorf("decoding %q failed: %w", e.Encoding, err)
+	}
+	return string(out), nil
+}
+
+func convertNumericType(in []byte, t string, order binary.ByteOrder) (interface{}, error) {
+	bits, err := bitsForType(t)
+	if err != nil {
+		return nil, err
+	}
+
+	inlen := uint64(len(in))
+	expected := bits / 8
+	if inlen > expected {
+		// Should never happen
+		return 0, fmt.Errorf("too many bytes %d vs %d", len(in), expected)
+	}
+
+	// Pad the data if shorter than the datatype length
+	buf := make([]byte, expected-inlen, expected)
+	buf = append(buf, in...)
+
+	switch t {
+	case "uint8":
+		return buf[0], nil
+	case "int8":
+		return int8(buf[0]), nil
+	case "uint16":
+		return order.Uint16(buf), nil
+	case "int16":
+		v := order.Uint16(buf)
+		return int16(v), nil
+	case "uint32":
+		return order.Uint32(buf), nil
+	case "int32":
+		v := order.Uint32(buf)
+		return int32(v), nil
+	case "uint64":
+		return order.Uint64(buf), nil
+	case "int64":
+		v := order.Uint64(buf)
+		return int64(v), nil
+	case "float32":
+		v := order.Uint32(buf)
+		return math.Float32frombits(v), nil
+	case "float64":
+		v := order.Uint64(buf)
+		return math.Float64frombits(v), nil
+	}
+	return nil, fmt.Errorf("no numeric type %q", t)
+}
+
+func convertBoolType(in []byte) bool {
+	for _, x := range in {
+		if x != 0 {
+			return true
+		}
+	}
+	return false
+}
+
+func extractPart(in []byte, offset, bits uint64) ([]byte, error) {
+	inLen := uint64(len(in))
+
+	start := offset / 8
+	bitend := offset%8 + bits
+	length := bitend / 8
+	if bitend%8 != 0 {
+		length++
+	}
+
+	if start+length > inLen {
+		return nil, fmt.Errorf("out-of-bounds @%d with %d bits", offset, bits)
+	}
+
+	var out []byte
+	out = append(out, in[start:start+length]...)
+
+	if offset%8 != 0 {
+		// Mask the start-byte with the non-aligned bit-mask
+		startmask := (byte(1) << (8 - offset%8)) - 1
+		out[0] = out[0] & startmask
+	}
+
+	if bitend%8 == 0 {
+		// The end is aligned to byte-boundaries
+		return out, nil
+	}
+
+	shift := 8 - bitend%8
+	carryshift := bitend % 8
+
+	// We need to shift right in case of not ending at a byte boundary
+	// to make the bits right aligned.
+	// Carry over the bits from the byte left to fill in...
+	var carry byte
+	for i, x := range out {
+		out[i] = (x >> shift) | carry
+		carry = x << carryshift
+	}
+
+	if bits%8 == 0 {
+		// Avoid an empty leading byte
+		return out[1:], nil
+	}
+
+	return out, nil
+}
+
+func bitsForType(t string) (uint64, error) {
+	switch t {
+	case "uint8", "int8":
+		return 8, nil
+	case "uint16", "int16":
+		return 16, nil
+	case "uint32", "int32", "float32":
+		return 32, nil
+	case "uint64", "int64", "float64":
+		return 64, nil
+	}
+	return 0, fmt.Errorf("cannot determine length for type %q", t)
+}
@@ -14,7 +14,7 @@ func TestEntryExtract(t *testing.T) {
 
 	e := &Entry{Type: "uint64"}
 	_, _, err := e.extract(testdata, 0)
-	require.EqualError(t, err, `unexpected entry: &{ uint64 0 false    [] <nil>}`)
+	require.EqualError(t, err, `unexpected entry "" without length`)
 }
 
 func TestEntryConvertType(t *testing.T) {
@@ -0,0 +1,298 @@
+// Package columnar converts Telegraf metrics to Apache Arrow records for the
+// columnar serializers.
+package columnar
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+
+	"github.com/apache/arrow-go/v18/arrow"
+	"github.com/apache/arrow-go/v18/arrow/array"
+	"github.com/apache/arrow-go/v18/arrow/memory"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/internal"
+	"github.com/influxdata/telegraf/metric"
+)
+
+const (
+	// MeasurementKey is the schema metadata key holding the measurement name
+	MeasurementKey = "measurement"
+
+	// MeasurementColumnKey is the schema metadata key holding the name of the
+	// column containing the measurement names of a union schema
+	MeasurementColumnKey = "measurement_column"
+)
+
+// Group contains the metrics of a single measurement
+type Group struct {
+	Name    string
+	Metrics []telegraf.Metric
+}
+
+// GroupByMeasurement splits the metrics by measurement keeping the order of
+// first occurrence of the measurements and the order of the metrics
+func GroupByMeasurement(metrics []telegraf.Metric) []*Group {
+	groups := make([]*Group, 0)
+	index := make(map[string]*Group)
+	for _, m := range metrics {
+		g, found := index[m.Name()]
+		if !found {
+			g = &Group{Name: m.Name()}
+			index[m.Name()] = g
+			groups = append(groups, g)
+		}
+		g.Metrics = append(g.Metrics, m)
+	}
+	return groups
+}
+
+type kind int
+
+const (
+	kindBool kind = iota
+	kindInt
+	kindUint
+	kindFloat
+	kindString
+)
+
+type column struct {
+	kind  kind
+	isTag bool
+
+	// Set if any unsigned value exceeds the range of a signed integer
+	uintOverflow bool
+}
+
+func (c *column) widen(k kind) {
+	if c.kind == k {
+		return
+	}
+	switch {
+	case c.kind == kindString || k == kindString:
+		c.kind = kindString
+	case c.kind == kindBool || k == kindBool:
+		// Booleans cannot be represented as numbers without losing the
+		// information about the type
+		c.kind = kindString
+	case c.kind == kindFloat || k == kindFloat:
+		c.kind = kindFloat
+	default:
+		// Mix of signed and unsigned integers
+		c.kind = kindInt
+	}
+}
+
+func (c *column) dataType() arrow.DataType {
+	switch c.kind {
+	case kindBool:
+		return arrow.FixedWidthTypes.Boolean
+	case kindInt:
+		if c.uintOverflow {
+			return arrow.PrimitiveTypes.Float64
+		}
+		return arrow.PrimitiveTypes.Int64
+	case kindUint:
+		return arrow.PrimitiveTypes.Uint64
+	case kindFloat:
+		return arrow.PrimitiveTypes.Float64
+	}
+	return arrow.BinaryTypes.String
+}
+
+func valueKind(value interface{}) (kind, error) {
+	switch value.(type) {
+	case bool:
+		return kindBool, nil
+	case int64:
+		return kindInt, nil
+	case uint64:
+		return kindUint, nil
+	case float64:
+		return kindFloat, nil
+	case string:
+		return kindString, nil
+	}
+	return kindString, fmt.Errorf("unsupported type %T", value)
+}
+
+// InferSchema derives the schema of the given metrics of a single measurement.
+// The schema contains a non-nullable timestamp column followed by a nullable
+// column for each tag and field sorted by name. Columns with different value
+// types across the metrics are widened to a common type, i.e. integers mixed
+// with floats become floats, signed mixed with unsigned integers become
+// signed integers (or floats if the unsigned values exceed the signed range)
+// and all other combinations become strings. Native histogram fields are
+// skipped.
+func InferSchema(metrics []telegraf.Metric, timestampColumn string) (*arrow.Schema, error) {
+	return inferSchema(metrics, timestampColumn, "")
+}
+
+// InferUnionSchema derives a common schema for metrics of multiple
+// measurements. In addition to the columns of InferSchema, the schema contains
+// a non-nullable string column holding the measurement name right after the
+// timestamp column.
+func InferUnionSchema(metrics []telegraf.Metric, timestampColumn, measurementColumn string) (*arrow.Schema, error) {
+	if measurementColumn == "" {
+		return nil, errors.New("no measurement column")
+	}
+	return inferSchema(metrics, timestampColumn, measurementColumn)
+}
+
+func inferSchema(metrics []telegraf.Metric, timestampColumn, measurementColumn string) (*arrow.Schema, error) {
+	if len(metrics) == 0 {
+		return nil, errors.New("no metrics")
+	}
+
+	columns := make(map[string]*column)
+	for _, m := range metrics {
+		for _, tag := range m.TagList() {
+			if c, found := columns[tag.Key]; found {
+				c.widen(kindString)
+				continue
+			}
+			columns[tag.Key] = &column{kind: kindString, isTag: true}
+		}
+		for _, field := range m.FieldList() {
+			// Native histograms are not supported
+			if metric.IsHistogram(field.Value) {
+				continue
+			}
+			k, err := valueKind(field.Value)
+			if err != nil {
+				return nil, fmt.Errorf("field %q: %w", field.Key, err)
+			}
+			c, found := columns[field.Key]
+			if !found {
+				c = &column{kind: k}
+				columns[field.Key] = c
+			} else {
+				c.widen(k)
+			}
+			if v, ok := field.Value.(uint64); ok && v > math.MaxInt64 {
+				c.uintOverflow = true
+			}
+		}
+	}
+	if _, found := columns[timestampColumn]; found {
+		return nil, fmt.Errorf("timestamp column %q collides with a tag or field", timestampColumn)
+	}
+	if _, found := columns[measurementColumn]; found && measurementColumn != "" {
+		return nil, fmt.Errorf("measurement column %q collides with a tag or field", measurementColumn)
+	}
+
+	names := make([]string, 0, len(columns))
+	for name := range columns {
+		names = append(names, name)
+	}
+	sort.Slice(names, func(i, j int) bool {
+		ci, cj := columns[names[i]], columns[names[j]]
+		if ci.isTag != cj.isTag {
+			return ci.isTag
+		}
+		return names[i] < names[j]
+	})
+
+	fields := make([]arrow.Field, 0, len(names)+2)
+	fields = append(fields, arrow.Field{
+		Name: timestampColumn,
+		Type: &arrow.TimestampType{Unit: arrow.Nanosecond, TimeZone: "UTC"},
+	})
+	if measurementColumn != "" {
+		fields = append(fields, arrow.Field{Name: measurementColumn, Type: arrow.BinaryTypes.String})
+	}
+	for _, name := range names {
+		fields = append(fields, arrow.Field{Name: name, Type: columns[name].dataType(), Nullable: true})
+	}
+
+	metadata := arrow.NewMetadata([]string{MeasurementKey}, []string{metrics[0].Name()})
+	if measurementColumn != "" {
+		metadata = arrow.NewMetadata([]string{MeasurementColumnKey}, []string{measurementColumn})
+	}
+
+	return arrow.NewSchema(fields, &metadata), nil
+}
+
+// BuildRecord creates a record with the given schema from the metrics. The
+// values of each column are taken from the field with the column name or, if
+// not present, from the tag and are converted to the column type. For union
+// schemata the measurement column is filled with the metric names.
+func BuildRecord(mem memory.Allocator, schema *arrow.Schema, metrics []telegraf.Metric) (arrow.Record, error) {
+	builder := array.NewRecordBuilder(mem, schema)
+	defer builder.Release()
+	builder.Reserve(len(metrics))
+
+	measurementColumn, _ := schema.Metadata().GetValue(MeasurementColumnKey)
+
+	for i, col := range schema.Fields() {
+		for _, m := range metrics {
+			if i == 0 {
+				ts, err := arrow.TimestampFromTime(m.Time(), arrow.Nanosecond)
+				if err != nil {
+					return nil, err
+				}
+				builder.Field(i).(*array.TimestampBuilder).Append(ts)
+				continue
+			}
+			if i == 1 && col.Name == measurementColumn {
+				builder.Field(i).(*array.StringBuilder).Append(m.Name())
+				continue
+			}
+
+			value, found := m.GetField(col.Name)
+			if !found || metric.IsHistogram(value) {
+				value, found = m.GetTag(col.Name)
+			}
+			if !found {
+				builder.Field(i).AppendNull()
+				continue
+			}
+			if err := appendValue(builder.Field(i), value); err != nil {
+				return nil, fmt.Errorf("column %q: %w", col.Name, err)
+			}
+		}
+	}
+
+	return builder.NewRecord(), nil
+}
+
+func appendValue(b array.Builder, value interface{}) error {
+	switch b := b.(type) {
+	case *array.BooleanBuilder:
+		v, ok := value.(bool)
+		if !ok {
+			return fmt.Errorf("cannot convert %v (%T) to boolean", value, value)
+		}
+		b.Append(v)
+	case *array.Int64Builder:
+		v, err := internal.ToInt64(value)
+		if err != nil {
+			return err
+		}
+		b.Append(v)
+	case *array.Uint64Builder:
+		v, err := internal.ToUint64(value)
+		if err != nil {
+			return err
+		}
+		b.Append(v)
+	case *array.Float64Builder:
+		v, err := internal.ToFloat64(value)
+		if err != nil {
+			return err
+		}
+		b.Append(v)
+	case *array.StringBuilder:
+		v, err := internal.ToString(value)
+		if err != nil {
+			return err
+		}
+		b.Append(v)
+	default:
+		return fmt.Errorf("unsupported column type %s", b.Type())
+	}
+	return nil
+}
@@ -0,0 +1,181 @@
+package columnar
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/apache/arrow-go/v18/arrow"
+	"github.com/apache/arrow-go/v18/arrow/array"
+	"github.com/apache/arrow-go/v18/arrow/memory"
+	"github.com/stretchr/testify/require"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/metric"
+)
+
+func TestInferSchemaWidening(t *testing.T) {
+	metrics := []telegraf.Metric{
+		metric.New(
+			"test",
+			map[string]string{"host": "a"},
+			map[string]interface{}{
+				"int_float":   int64(1),
+				"int_uint":    int64(-1),
+				"uint_large":  uint64(1),
+				"bool_int":    true,
+				"int_string":  int64(5),
+				"tag_field":   1.5,
+				"stable_bool": true,
+			},
+			time.Unix(0, 0),
+		),
+		metric.New(
+			"test",
+			map[string]string{"host": "b", "tag_field": "x"},
+			map[string]interface{}{
+				"int_float":   2.5,
+				"int_uint":    uint64(2),
+				"uint_large":  int64(2),
+				"bool_int":    int64(1),
+				"int_string":  "five",
+				"stable_bool": false,
+			},
+			time.Unix(1, 0),
+		),
+		metric.New(
+			"test",
+			map[string]string{},
+			map[string]interface{}{"uint_large": uint64(math.MaxUint64)},
+			time.Unix(2, 0),
+		),
+	}
+
+	schema, err := InferSchema(metrics, "time")
+	require.NoError(t, err)
+
+	expected := map[string]arrow.DataType{
+		"time":        &arrow.TimestampType{Unit: arrow.Nanosecond, TimeZone: "UTC"},
+		"host":        arrow.BinaryTypes.String,
+		"tag_field":   arrow.BinaryTypes.String,
+		"bool_int":    arrow.BinaryTypes.String,
+		"int_float":   arrow.PrimitiveTypes.Float64,
+		"int_string":  arrow.BinaryTypes.String,
+		"int_uint":    arrow.PrimitiveTypes.Int64,
+		"stable_bool": arrow.FixedWidthTypes.Boolean,
+		"uint_large":  arrow.PrimitiveTypes.Float64,
+	}
+	actual := make(map[string]arrow.DataType, len(schema.Fields()))
+	for _, f := range schema.Fields() {
+		actual[f.Name] = f.Type
+	}
+	require.Equal(t, expected, actual)
+
+	// Timestamp first, then tags and fields sorted by name
+	names := make([]string, 0, len(schema.Fields()))
+	for _, f := range schema.Fields() {
+		names = append(names, f.Name)
+	}
+	require.Equal(t, []string{"time", "host", "bool_int", "int_float", "int_string", "int_uint", "stable_bool", "tag_field", "uint_large"}, names)
+
+	v, found := schema.Metadata().GetValue(MeasurementKey)
+	require.True(t, found)
+	require.Equal(t, "test", v)
+
+	record, err := BuildRecord(memory.DefaultAllocator, schema, metrics)
+	require.NoError(t, err)
+	defer record.Release()
+	require.Equal(t, int64(3), record.NumRows())
+
+	col := record.Column(schema.FieldIndices("int_string")[0]).(*array.String)
+	require.Equal(t, "5", col.Value(0))
+	require.Equal(t, "five", col.Value(1))
+	require.True(t, col.IsNull(2))
+
+	tagField := record.Column(schema.FieldIndices("tag_field")[0]).(*array.String)
+	require.Equal(t, "1.5", tagField.Value(0))
+	require.Equal(t, "x", tagField.Value(1))
+
+	large := record.Column(schema.FieldIndices("uint_large")[0]).(*array.Float64)
+	require.InDelta(t, float64(math.MaxUint64), large.Value(2), 1)
+}
+
+func TestInferSchemaTimestampCollision(t *testing.T) {
+	metrics := []telegraf.Metric{
+		metric.New("test", map[string]string{}, map[string]interface{}{"timestamp": 1.0}, time.Unix(0, 0)),
+	}
+	_, err := InferSchema(metrics, "timestamp")
+	require.ErrorContains(t, err, `timestamp column "timestamp" collides with a tag or field`)
+}
+
+func TestInferUnionSchema(t *testing.T) {
+	metrics := []telegraf.Metric{
+		metric.New("cpu", map[string]string{"host": "a"}, map[string]interface{}{"usage": 1.0}, time.Unix(0, 0)),
+		metric.New("mem", map[string]string{"host": "a"}, map[string]interface{}{"free": int64(2)}, time.Unix(0, 0)),
+	}
+
+	schema, err := InferUnionSchema(metrics, "time", "name")
+	require.NoError(t, err)
+
+	names := make([]string, 0, len(schema.Fields()))
+	for _, f := range schema.Fields() {
+		names = append(names, f.Name)
+	}
+	require.Equal(t, []string{"time", "name", "host", "free", "usage"}, names)
+	require.False(t, schema.Field(1).Nullable)
+
+	v, found := schema.Metadata().GetValue(MeasurementColumnKey)
+	require.True(t, found)
+	require.Equal(t, "name", v)
+
+	record, err := BuildRecord(memory.DefaultAllocator, schema, metrics)
+	require.NoError(t, err)
+	defer record.Release()
+
+	measurement := record.Column(1).(*array.String)
+	require.Equal(t, "cpu", measurement.Value(0))
+	require.Equal(t, "mem", measurement.Value(1))
+	require.True(t, record.Column(3).IsNull(0))
+	require.True(t, record.Column(4).IsNull(1))
+
+	_, err = InferUnionSchema(metrics, "time", "host")
+	require.ErrorContains(t, err, `measurement column "host" collides with a tag or field`)
+}
+
+func TestInferSchemaSkipHistograms(t *testing.T) {
+	metrics := []telegraf.Metric{
+		metric.New(
+			"test",
+			map[string]string{},
+			map[string]interface{}{
+				"value":   1.0,
+				"latency": metric.Histogram{Count: 1, Sum: 1},
+				"size":    metric.ExponentialHistogram{Count: 1, Sum: 1},
+			},
+			time.Unix(0, 0),
+		),
+	}
+	schema, err := InferSchema(metrics, "timestamp")
+	require.NoError(t, err)
+	require.Equal(t, 2, schema.NumFields())
+	require.Equal(t, "value", schema.Field(1).Name)
+
+	record, err := BuildRecord(memory.DefaultAllocator, schema, metrics)
+	require.NoError(t, err)
+	defer record.Release()
+	require.Equal(t, int64(1), record.NumRows())
+}
+
+func TestGroupByMeasurement(t *testing.T) {
+	metrics := []telegraf.Metric{
+		metric.New("b", map[string]string{}, map[string]interface{}{"v": 1.0}, time.Unix(0, 0)),
+		metric.New("a", map[string]string{}, map[string]interface{}{"v": 2.0}, time.Unix(0, 0)),
+		metric.New("b", map[string]string{}, map[string]interface{}{"v": 3.0}, time.Unix(0, 0)),
+	}
+	groups := GroupByMeasurement(metrics)
+	require.Len(t, groups, 2)
+	require.Equal(t, "b", groups[0].Name)
+	require.Equal(t, []telegraf.Metric{metrics[0], metrics[2]}, groups[0].Metrics)
+	require.Equal(t, "a", groups[1].Name)
+	require.Equal(t, []telegraf.Metric{metrics[1]}, groups[1].Metrics)
+}
@@ -0,0 +1,200 @@
+package opentelemetry
+
+import (
+	"maps"
+	"math"
+	"time"
+
+	"github.com/influxdata/influxdb-observability/otel2influx"
+	"go.opentelemetry.io/collector/pdata/pcommon"
+	"go.opentelemetry.io/collector/pdata/pmetric"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/metric"
+)
+
+// HistogramFromDataPoint converts the data point of an OpenTelemetry histogram
+// to a histogram field value
+func HistogramFromDataPoint(dp pmetric.HistogramDataPoint) metric.Histogram {
+	bounds := dp.ExplicitBounds()
+	counts := dp.BucketCounts()
+
+	h := metric.Histogram{
+		Count:   float64(dp.Count()),
+		Sum:     dp.Sum(),
+		Buckets: make([]metric.Bucket, 0, bounds.Len()),
+	}
+
+	// OpenTelemetry uses per-bucket counts while the field is cumulative
+	var cumulative float64
+	for i := 0; i < bounds.Len(); i++ {
+		if i < counts.Len() {
+			cumulative += float64(counts.At(i))
+		}
+		h.Buckets = append(h.Buckets, metric.Bucket{UpperBound: bounds.At(i), Count: cumulative})
+	}
+	return h
+}
+
+// ExponentialHistogramFromDataPoint converts the data point of an
+// OpenTelemetry exponential histogram to an exponential histogram field value
+func ExponentialHistogramFromDataPoint(dp pmetric.ExponentialHistogramDataPoint) metric.ExponentialHistogram {
+	return metric.ExponentialHistogram{
+		Count:         float64(dp.Count()),
+		Sum:           dp.Sum(),
+		Scale:         dp.Scale(),
+		ZeroThreshold: dp.ZeroThreshold(),
+		ZeroCount:     float64(dp.ZeroCount()),
+		Positive:      exponentialBucketsFromDataPoint(dp.Positive()),
+		Negative:      exponentialBucketsFromDataPoint(dp.Negative()),
+	}
+}
+
+func exponentialBucketsFromDataPoint(b pmetric.ExponentialHistogramDataPointBuckets) metric.ExponentialBuckets {
+	counts := make([]float64, 0, b.BucketCounts().Len())
+	for _, c := range b.BucketCounts().AsRaw() {
+		counts = append(counts, float64(c))
+	}
+	return metric.ExponentialBuckets{Offset: b.Offset(), Counts: counts}
+}
+
+// ExtractHistograms removes all histograms and exponential histograms from
+// the given OpenTelemetry metrics and returns them as Telegraf metrics with
+// native histogram fields. The measurement and field names follow the given
+// metrics schema.
+func ExtractHistograms(md pmetric.Metrics, schema string) []telegraf.Metric {
+	var metrics []telegraf.Metric
+
+	rms := md.ResourceMetrics()
+	for i := 0; i < rms.Len(); i++ {
+		rm := rms.At(i)
+		resourceTags := otel2influx.ResourceToTags(rm.Resource(), make(map[string]string))
+
+		sms := rm.ScopeMetrics()
+		for j := 0; j < sms.Len(); j++ {
+			sm := sms.At(j)
+			scopeTags := otel2influx.InstrumentationScopeToTags(sm.Scope(), maps.Clone(resourceTags))
+
+			sm.Metrics().RemoveIf(func(m pmetric.Metric) bool {
+				switch m.Type() {
+				case pmetric.MetricTypeHistogram:
+					dps := m.Histogram().DataPoints()
+					for k := 0; k < dps.Len(); k++ {
+						dp := dps.At(k)
+						h := HistogramFromDataPoint(dp)
+						metrics = append(metrics, newHistogramMetric(schema, m.Name(), scopeTags, dp.Attributes(), h, dp.Timestamp().AsTime()))
+					}
+					return true
+				case pmetric.MetricTypeExponentialHistogram:
+					dps := m.ExponentialHistogram().DataPoints()
+					for k := 0; k < dps.Len(); k++ {
+						dp := dps.At(k)
+						h := ExponentialHistogramFromDataPoint(dp)
+						metrics = append(metrics, newHistogramMetric(schema, m.Name(), scopeTags, dp.Attributes(), h, dp.Timestamp().AsTime()))
+					}
+					return true
+				}
+				return false
+			})
+		}
+	}
+
+	return metrics
+}
+
+func newHistogramMetric(schema, name string, tags map[string]string, attributes pcommon.Map, value interface{}, t time.Time) telegraf.Metric {
+	mtags := maps.Clone(tags)
+	attributes.Range(func(k string, v pcommon.Value) bool {
+		if k != "" {
+			mtags[k] = v.AsString()
+		}
+		return true
+	})
+
+	if schema == "prometheus-v2" {
+		return metric.New("prometheus", mtags, map[string]interface{}{name: value}, t, telegraf.Histogram)
+	}
+	return metric.New(name, mtags, map[string]interface{}{"histogram": value}, t, telegraf.Histogram)
+}
+
+// AppendHistograms adds the native histogram fields of the given metric as
+// cumulative OpenTelemetry histograms to the metric slice. The tags of the
+// metric are used as data point attributes. The function returns the
+// remaining fields not being native histograms.
+func AppendHistograms(ms pmetric.MetricSlice, m telegraf.Metric) map[string]interface{} {
+	remaining := make(map[string]interface{}, len(m.FieldList()))
+	for _, field := range m.FieldList() {
+		switch v := field.Value.(type) {
+		case metric.Histogram:
+			om := ms.AppendEmpty()
+			om.SetName(HistogramName(m.Name(), field.Key))
+			h := om.SetEmptyHistogram()
+			h.SetAggregationTemporality(pmetric.AggregationTemporalityCumulative)
+			dp := h.DataPoints().AppendEmpty()
+			setDataPointAttributes(dp.Attributes(), m)
+			dp.SetTimestamp(pcommon.NewTimestampFromTime(m.Time()))
+			dp.SetCount(toCount(v.Count))
+			dp.SetSum(v.Sum)
+
+			// OpenTelemetry uses per-bucket counts including the +Inf bucket
+			var previous float64
+			for _, b := range v.Buckets {
+				dp.ExplicitBounds().Append(b.UpperBound)
+				dp.BucketCounts().Append(toCount(b.Count - previous))
+				previous = b.Count
+			}
+			dp.BucketCounts().Append(toCount(v.Count - previous))
+		case metric.ExponentialHistogram:
+			om := ms.AppendEmpty()
+			om.SetName(HistogramName(m.Name(), field.Key))
+			h := om.SetEmptyExponentialHistogram()
+			h.SetAggregationTemporality(pmetric.AggregationTemporalityCumulative)
+			dp := h.DataPoints().AppendEmpty()
+			setDataPointAttributes(dp.Attributes(), m)
+			dp.SetTimestamp(pcommon.NewTimestampFromTime(m.Time()))
+			dp.SetCount(toCount(v.Count))
+			dp.SetSum(v.Sum)
+			dp.SetScale(v.Scale)
+			dp.SetZeroThreshold(v.ZeroThreshold)
+			dp.SetZeroCount(toCount(v.ZeroCount))
+			dp.Positive().SetOffset(v.Positive.Offset)
+			for _, c := range v.Positive.Counts {
+				dp.Positive().BucketCounts().Append(toCount(c))
+			}
+			dp.Negative().SetOffset(v.Negative.Offset)
+			for _, c := range v.Negative.Counts {
+				dp.Negative().BucketCounts().Append(toCount(c))
+			}
+		default:
+			remaining[field.Key] = field.Value
+		}
+	}
+	return remaining
+}
+
+// HistogramName returns the name of the histogram stored in the given field
+// using the prometheus naming conventions of the metrics schemas
+func HistogramName(measurement, field string) string {
+	switch {
+	case measurement == "prometheus":
+		return field
+	case field == "histogram":
+		return measurement
+	}
+	return measurement + "_" + field
+}
+
+func setDataPointAttributes(attributes pcommon.Map, m telegraf.Metric) {
+	for _, tag := range m.TagList() {
+		attributes.PutStr(tag.Key, tag.Value)
+	}
+}
+
+// toCount converts a histogram count to the integer representation of
+// OpenTelemetry
+func toCount(v float64) uint64 {
+	if v <= 0 {
+		return 0
+	}
+	return uint64(math.Round(v))
+}
@@ -0,0 +1,70 @@
+// Package opentelemetry contains the conversion settings shared by the
+// OpenTelemetry plugins and the OTLP parser and serializer.
+package opentelemetry
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/influxdata/influxdb-observability/common"
+
+	"github.com/influxdata/telegraf"
+)
+
+// DefaultMetricsSchema is the schema used for converting OpenTelemetry metrics
+// if none is specified
+const DefaultMetricsSchema = "prometheus-v1"
+
+// MetricsSchemata contains the supported schemas for converting OpenTelemetry
+// metrics to Telegraf metrics
+var MetricsSchemata = map[string]common.MetricsSchema{
+	"prometheus-v1": common.MetricsSchemaTelegrafPrometheusV1,
+	"prometheus-v2": common.MetricsSchemaTelegrafPrometheusV2,
+}
+
+// Logger adapts a Telegraf logger to the OpenTelemetry conversion libraries
+type Logger struct {
+	telegraf.Logger
+}
+
+// Debug logs a debug message with key-value pairs
+func (l Logger) Debug(msg string, kv ...interface{}) {
+	format := msg + strings.Repeat(" %s=%q", len(kv)/2)
+	l.Logger.Debugf(format, kv...)
+}
+
+// ValueType returns the value type of the conversion libraries for the given
+// Telegraf metric type
+func ValueType(t telegraf.ValueType) (common.InfluxMetricValueType, error) {
+	switch t {
+	case telegraf.Untyped:
+		return common.InfluxMetricValueTypeUntyped, nil
+	case telegraf.Gauge:
+		return common.InfluxMetricValueTypeGauge, nil
+	case telegraf.Counter:
+		return common.InfluxMetricValueTypeSum, nil
+	case telegraf.Histogram:
+		return common.InfluxMetricValueTypeHistogram, nil
+	case telegraf.Summary:
+		return common.InfluxMetricValueTypeSummary, nil
+	}
+	return common.InfluxMetricValueTypeUntyped, fmt.Errorf("unrecognized metric type %v", t)
+}
+
+// MetricType returns the Telegraf metric type for the given value type of the
+// conversion libraries
+func MetricType(vType common.InfluxMetricValueType) (telegraf.ValueType, error) {
+	switch vType {
+	case common.InfluxMetricValueTypeUntyped:
+		return telegraf.Untyped, nil
+	case common.InfluxMetricValueTypeGauge:
+		return telegraf.Gauge, nil
+	case common.InfluxMetricValueTypeSum:
+		return telegraf.Counter, nil
+	case common.InfluxMetricValueTypeHistogram:
+		return telegraf.Histogram, nil
+	case common.InfluxMetricValueTypeSummary:
+		return telegraf.Summary, nil
+	}
+	return telegraf.Untyped, fmt.Errorf("unrecognized InfluxMetricValueType %q", vType)
+}
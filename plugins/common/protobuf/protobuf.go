@@ -0,0 +1,41 @@
+// Package protobuf contains helpers for working with user-supplied
+// protocol-buffer definitions.
+package protobuf
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/bufbuild/protocompile"
+	"google.golang.org/protobuf/reflect/protoregistry"
+)
+
+// Compile parses the given protocol-buffer definition files, resolving
+// imports using the given import paths and the standard imports, and returns
+// a registry containing all resulting file descriptors
+func Compile(files, importPaths []string) (*protoregistry.Files, error) {
+	if len(files) == 0 {
+		return nil, errors.New("protocol-buffer files not set")
+	}
+
+	resolver := &protocompile.SourceResolver{ImportPaths: importPaths}
+	compiler := &protocompile.Compiler{
+		Resolver: protocompile.WithStandardImports(resolver),
+	}
+	compiled, err := compiler.Compile(context.Background(), files...)
+	if err != nil {
+		return nil, fmt.Errorf("parsing protocol-buffer definition failed: %w", err)
+	}
+	if len(compiled) < 1 {
+		return nil, errors.New("files do not contain a file descriptor")
+	}
+
+	var registry protoregistry.Files
+	for _, f := range compiled {
+		if err := registry.RegisterFile(f); err != nil {
+			return nil, fmt.Errorf("adding file %q to registry failed: %w", f.Path(), err)
+		}
+	}
+	return &registry, nil
+}
@@ -26,6 +26,25 @@ func (r *RateLimiter) Remaining(t time.Time) int64 {
 	r.Lock()
 	defer r.Unlock()
 
+	return r.remainingAt(t)
+}
+
+// Consume calls the given function with the remaining limit and accepts the
+// used amount returned by the function in one step. This prevents concurrent
+// callers from exceeding the limit as the limit cannot change between checking
+// and accepting the usage.
+func (r *RateLimiter) Consume(t time.Time, fn func(limit int64) (int64, error)) error {
+	r.Lock()
+	defer r.Unlock()
+
+	used, err := fn(r.remainingAt(t))
+	if used > 0 {
+		r.acceptAt(t, used)
+	}
+	return err
+}
+
+func (r *RateLimiter) remainingAt(t time.Time) int64 {
 	if r.limit == 0 {
 		return math.MaxInt64
 	}
@@ -62,6 +81,10 @@ func (r *RateLimiter) Accept(t time.Time, used int64) {
 	r.Lock()
 	defer r.Unlock()
 
+	r.acceptAt(t, used)
+}
+
+func (r *RateLimiter) acceptAt(t time.Time, used int64) {
 	if r.limit == 0 || r.periodStart.After(t) {
 		return
 	}
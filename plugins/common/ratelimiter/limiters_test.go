@@ -2,6 +2,8 @@ package ratelimiter
 
 import (
 	"math"
+	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 
@@ -187,3 +189,34 @@ func TestUndo(t *testing.T) {
 		})
 	}
 }
+
+func TestConsumeConcurrent(t *testing.T) {
+	cfg := &RateLimitConfig{
+		Limit:  config.Size(1000),
+		Period: config.Duration(time.Hour),
+	}
+	limiter, err := cfg.CreateRateLimiter()
+	require.NoError(t, err)
+
+	// Each writer takes up to 100 units, so concurrent writers must never
+	// be granted more than the limit in total
+	start := time.Now()
+	var wg sync.WaitGroup
+	var granted atomic.Int64
+	for i := range 50 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			ts := start.Add(time.Duration(i) * time.Millisecond)
+			require.NoError(t, limiter.Consume(ts, func(limit int64) (int64, error) {
+				used := min(limit, 100)
+				granted.Add(used)
+				return used, nil
+			}))
+		}()
+	}
+	wg.Wait()
+
+	require.Positive(t, granted.Load())
+	require.LessOrEqual(t, granted.Load(), int64(1000))
+}
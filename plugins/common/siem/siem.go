@@ -0,0 +1,117 @@
+// Package siem contains the handling of the pipe-separated security event
+// formats shared by the CEF and LEEF parsers.
+package siem
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/influxdata/telegraf"
+)
+
+var ErrNoMetric = errors.New("no metric in line")
+
+// TimeLayouts are the layouts of event times besides milliseconds since epoch
+// defined by the CEF and LEEF specifications
+var TimeLayouts = []string{
+	"Jan 02 2006 15:04:05.000 MST",
+	"Jan 02 2006 15:04:05.000",
+	"Jan 02 2006 15:04:05 MST",
+	"Jan 02 2006 15:04:05",
+}
+
+// ParseLines parses each non-empty line of the buffer into a metric using the
+// given function
+func ParseLines(buf []byte, parse func(line string) (telegraf.Metric, error)) ([]telegraf.Metric, error) {
+	metrics := make([]telegraf.Metric, 0)
+	scanner := bufio.NewScanner(bytes.NewReader(buf))
+	for scanner.Scan() {
+		line := strings.TrimRight(scanner.Text(), "\r")
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		m, err := parse(line)
+		if err != nil {
+			return nil, err
+		}
+		metrics = append(metrics, m)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return metrics, nil
+}
+
+// ParseLine parses the line into a single metric using the given function
+func ParseLine(line string, parse func(line string) (telegraf.Metric, error)) (telegraf.Metric, error) {
+	metrics, err := ParseLines([]byte(line), parse)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(metrics) < 1 {
+		return nil, ErrNoMetric
+	}
+	return metrics[0], nil
+}
+
+// SplitHeader splits the given number of pipe-separated header fields while
+// unescaping backslash-escaped pipes and backslashes and returns the
+// remaining string
+func SplitHeader(s string, n int) ([]string, string, error) {
+	headers := make([]string, 0, n)
+	var current strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case c == '\\' && i+1 < len(s) && (s[i+1] == '|' || s[i+1] == '\\'):
+			i++
+			current.WriteByte(s[i])
+		case c == '|':
+			headers = append(headers, strings.TrimSpace(current.String()))
+			current.Reset()
+			if len(headers) == n {
+				return headers, s[i+1:], nil
+			}
+		default:
+			current.WriteByte(c)
+		}
+	}
+	return nil, "", fmt.Errorf("expected %d header fields but found %d", n, len(headers))
+}
+
+// Tags returns the non-empty header fields as tags using the given names
+// complemented by the default tags
+func Tags(names, headers []string, defaultTags map[string]string) map[string]string {
+	tags := make(map[string]string, len(headers)+len(defaultTags))
+	for i, name := range names {
+		if headers[i] != "" {
+			tags[name] = headers[i]
+		}
+	}
+	for k, v := range defaultTags {
+		if _, found := tags[k]; !found {
+			tags[k] = v
+		}
+	}
+	return tags
+}
+
+// ParseTime parses the time in milliseconds since epoch or one of the
+// default layouts
+func ParseTime(s string) (time.Time, error) {
+	if ms, err := strconv.ParseInt(s, 10, 64); err == nil {
+		return time.UnixMilli(ms), nil
+	}
+	for _, layout := range TimeLayouts {
+		if t, err := time.Parse(layout, s); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("unknown time format %q", s)
+}
@@ -0,0 +1,76 @@
+package siem
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/metric"
+)
+
+func TestSplitHeader(t *testing.T) {
+	headers, remainder, err := SplitHeader(`0| Ven\|dor |Pro\\duct|rest|of\|line`, 3)
+	require.NoError(t, err)
+	require.Equal(t, []string{"0", "Ven|dor", `Pro\duct`}, headers)
+	require.Equal(t, `rest|of\|line`, remainder)
+
+	_, _, err = SplitHeader("0|a", 3)
+	require.EqualError(t, err, "expected 3 header fields but found 1")
+}
+
+func TestTags(t *testing.T) {
+	tags := Tags(
+		[]string{"version", "vendor", "product"},
+		[]string{"1", "", "Product"},
+		map[string]string{"vendor": "default", "product": "default", "host": "localhost"},
+	)
+	require.Equal(t, map[string]string{
+		"version": "1",
+		"vendor":  "default",
+		"product": "Product",
+		"host":    "localhost",
+	}, tags)
+}
+
+func TestParseTime(t *testing.T) {
+	for input, expected := range map[string]time.Time{
+		"1700000000123":                  time.UnixMilli(1700000000123),
+		"Nov 14 2023 22:13:20.123 UTC":   time.Date(2023, time.November, 14, 22, 13, 20, 123000000, time.UTC),
+		"Nov 14 2023 22:13:20.123":       time.Date(2023, time.November, 14, 22, 13, 20, 123000000, time.UTC),
+		"Nov 14 2023 22:13:20":           time.Date(2023, time.November, 14, 22, 13, 20, 0, time.UTC),
+		"Nov 14 2023 22:13:20 UTC":       time.Date(2023, time.November, 14, 22, 13, 20, 0, time.UTC),
+		"  Nov 14 2023 22:13:20 invalid": {},
+	} {
+		actual, err := ParseTime(input)
+		if expected.IsZero() {
+			require.ErrorContains(t, err, "unknown time format", input)
+			continue
+		}
+		require.NoError(t, err, input)
+		require.True(t, expected.Equal(actual), input)
+	}
+}
+
+func TestParseLines(t *testing.T) {
+	parse := func(line string) (telegraf.Metric, error) {
+		if line == "invalid" {
+			return nil, errors.New("invalid line")
+		}
+		return metric.New("test", nil, map[string]interface{}{"line": line}, time.Unix(0, 0)), nil
+	}
+
+	metrics, err := ParseLines([]byte("a\r\n\n  \nb\n"), parse)
+	require.NoError(t, err)
+	require.Len(t, metrics, 2)
+	require.Equal(t, map[string]interface{}{"line": "a"}, metrics[0].Fields())
+	require.Equal(t, map[string]interface{}{"line": "b"}, metrics[1].Fields())
+
+	_, err = ParseLines([]byte("a\ninvalid\n"), parse)
+	require.EqualError(t, err, "invalid line")
+
+	_, err = ParseLine("  ", parse)
+	require.ErrorIs(t, err, ErrNoMetric)
+}
@@ -0,0 +1,106 @@
+// Package syslog contains the conversion of syslog messages to metrics shared
+// by the syslog input and parser.
+package syslog
+
+import (
+	"strings"
+	"unicode"
+
+	"github.com/leodido/go-syslog/v4"
+	"github.com/leodido/go-syslog/v4/rfc3164"
+	"github.com/leodido/go-syslog/v4/rfc5424"
+)
+
+// Tags returns the tags of the given syslog message, the source is only
+// added if not empty
+func Tags(msg syslog.Message, src string) map[string]string {
+	// Extract message information
+	tags := map[string]string{
+		"severity": *msg.SeverityShortLevel(),
+		"facility": *msg.FacilityLevel(),
+	}
+
+	if src != "" {
+		tags["source"] = src
+	}
+
+	switch msg := msg.(type) {
+	case *rfc5424.SyslogMessage:
+		if msg.Hostname != nil {
+			tags["hostname"] = *msg.Hostname
+		}
+		if msg.Appname != nil {
+			tags["appname"] = *msg.Appname
+		}
+	case *rfc3164.SyslogMessage:
+		if msg.Hostname != nil {
+			tags["hostname"] = *msg.Hostname
+		}
+		if msg.Appname != nil {
+			tags["appname"] = *msg.Appname
+		}
+	}
+
+	return tags
+}
+
+// Fields returns the fields of the given syslog message with the structured
+// data parameters being joined to their element ID using the separator
+func Fields(msg syslog.Message, separator string) map[string]interface{} {
+	var fields map[string]interface{}
+	switch msg := msg.(type) {
+	case *rfc5424.SyslogMessage:
+		fields = map[string]interface{}{
+			"facility_code": int(*msg.Facility),
+			"severity_code": int(*msg.Severity),
+			"version":       msg.Version,
+		}
+		if msg.Timestamp != nil {
+			fields["timestamp"] = (*msg.Timestamp).UnixNano()
+		}
+		if msg.ProcID != nil {
+			fields["procid"] = *msg.ProcID
+		}
+		if msg.MsgID != nil {
+			fields["msgid"] = *msg.MsgID
+		}
+		if msg.Message != nil {
+			fields["message"] = strings.TrimRightFunc(*msg.Message, func(r rune) bool {
+				return unicode.IsSpace(r)
+			})
+		}
+		if msg.StructuredData != nil {
+			for sdid, sdparams := range *msg.StructuredData {
+				if len(sdparams) == 0 {
+					// When SD-ID does not have params we indicate its presence with a bool
+					fields[sdid] = true
+					continue
+				}
+				for k, v := range sdparams {
+					fields[sdid+separator+k] = v
+				}
+			}
+		}
+	case *rfc3164.SyslogMessage:
+		fields = map[string]interface{}{
+			"facility_code": int(*msg.Facility),
+			"severity_code": int(*msg.Severity),
+		}
+		if msg.Timestamp != nil {
+			fields["timestamp"] = (*msg.Timestamp).UnixNano()
+		}
+		if msg.ProcID != nil {
+			fields["procid"] = *msg.ProcID
+		}
+		if msg.MsgID != nil {
+			fields["msgid"] = *msg.MsgID
+		}
+		if msg.Message != nil {
+			fields["message"] = strings.TrimRightFunc(*msg.Message, func(r rune) bool {
+				return unicode.IsSpace(r)
+			})
+		}
+	}
+
+	return fields
+}
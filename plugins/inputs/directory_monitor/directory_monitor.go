@@ -354,6 +354,12 @@ func (monitor *DirectoryMonitor) parseFile(parser telegraf.Parser, reader io.Rea
 }
 
 func (monitor *DirectoryMonitor) parseAtOnce(parser telegraf.Parser, reader io.Reader, fileName string) error {
+	// Parse the file incrementally if possible to avoid loading huge files
+	// into memory
+	if sp, ok := parser.(telegraf.StreamingParser); ok {
+		return monitor.parseStream(sp, reader, fileName)
+	}
+
 	bytes, err := io.ReadAll(reader)
 	if err != nil {
 		return err
@@ -367,6 +373,27 @@ func (monitor *DirectoryMonitor) parseAtOnce(parser telegraf.Parser, reader io.R
 	return monitor.sendMetrics(metrics)
 }
 
+func (monitor *DirectoryMonitor) parseStream(parser telegraf.StreamingParser, reader io.Reader, fileName string) error {
+	var count int
+	err := parser.ParseStream(reader, func(m telegraf.Metric) error {
+		count++
+		if monitor.FileTag != "" {
+			m.AddTag(monitor.FileTag, filepath.Base(fileName))
+		}
+		return monitor.sendMetrics([]telegraf.Metric{m})
+	})
+	if err != nil && !errors.Is(err, parsers.ErrEOF) {
+		return err
+	}
+
+	if count == 0 {
+		once.Do(func() {
+			monitor.Log.Debug(internal.NoMetricsCreatedMsg)
+		})
+	}
+	return nil
+}
+
 func (monitor *DirectoryMonitor) parseMetrics(parser telegraf.Parser, line []byte, fileName string) (metrics []telegraf.Metric, err error) {
 	metrics, err = parser.Parse(line)
 	if err != nil {
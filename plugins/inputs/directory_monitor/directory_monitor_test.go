@@ -13,6 +13,7 @@ import (
 	"github.com/influxdata/telegraf"
 	"github.com/influxdata/telegraf/plugins/inputs"
 	"github.com/influxdata/telegraf/plugins/parsers/csv"
+	"github.com/influxdata/telegraf/plugins/parsers/influx"
 	"github.com/influxdata/telegraf/plugins/parsers/json"
 	"github.com/influxdata/telegraf/testutil"
 )
@@ -271,6 +272,45 @@ func TestFileTag(t *testing.T) {
 	}
 }
 
+func TestParseStreamError(t *testing.T) {
+	acc := testutil.Accumulator{}
+
+	// Establish process, finished and error directory.
+	finishedDirectory := t.TempDir()
+	processDirectory := t.TempDir()
+	errorDirectory := t.TempDir()
+
+	// Init plugin.
+	r := DirectoryMonitor{
+		Directory:          processDirectory,
+		FinishedDirectory:  finishedDirectory,
+		ErrorDirectory:     errorDirectory,
+		MaxBufferedMetrics: defaultMaxBufferedMetrics,
+		FileQueueSize:      defaultFileQueueSize,
+		ParseMethod:        defaultParseMethod,
+		Log:                testutil.Logger{},
+	}
+	require.NoError(t, r.Init())
+	r.SetParserFunc(func() (telegraf.Parser, error) {
+		parser := &influx.Parser{}
+		err := parser.Init()
+		return parser, err
+	})
+
+	// The metrics before the invalid line are sent while streaming
+	content := "cpu value=1 1000\ncpu value=2 2000\ncpu value=\n"
+	require.NoError(t, os.WriteFile(filepath.Join(processDirectory, "test.influx"), []byte(content), 0640))
+
+	require.NoError(t, r.Start(&acc))
+	require.NoError(t, r.Gather(&acc))
+	acc.Wait(2)
+	r.Stop()
+
+	require.Len(t, acc.Metrics, 2)
+	require.FileExists(t, filepath.Join(errorDirectory, "test.influx"))
+	require.NoFileExists(t, filepath.Join(finishedDirectory, "test.influx"))
+}
+
 func TestCSVNoSkipRows(t *testing.T) {
 	acc := testutil.Accumulator{}
 	testCsvFile := "test.csv"
@@ -156,6 +156,19 @@ func (d *DockerLogs) SetState(state interface{}) error {
 	return nil
 }
 
+func (*DockerLogs) StateVersion() int {
+	return 1
+}
+
+func (*DockerLogs) MigrateState(version int, state []byte) ([]byte, error) {
+	// Initial, unversioned states contain the same container to last record
+	// timestamp map
+	if version == 0 {
+		return state, nil
+	}
+	return nil, fmt.Errorf("unsupported state version %d", version)
+}
+
 func (d *DockerLogs) Gather(acc telegraf.Accumulator) error {
 	ctx := context.Background()
 	acc.SetPrecision(time.Nanosecond)
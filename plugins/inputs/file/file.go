@@ -55,22 +55,9 @@ func (f *File) Gather(acc telegraf.Accumulator) error {
 		return err
 	}
 	for _, k := range f.filenames {
-		metrics, err := f.readMetric(k)
-		if err != nil {
+		if err := f.readMetrics(acc, k); err != nil {
 			return err
 		}
-
-		for _, m := range metrics {
-			if f.FileTag != "" {
-				m.AddTag(f.FileTag, filepath.Base(k))
-			}
-			if f.FilePathTag != "" {
-				if absPath, err := filepath.Abs(k); err == nil {
-					m.AddTag(f.FilePathTag, absPath)
-				}
-			}
-			acc.AddMetric(m)
-		}
 	}
 	return nil
 }
@@ -93,33 +80,65 @@ func (f *File) refreshFilePaths() error {
 	return nil
 }
 
-func (f *File) readMetric(filename string) ([]telegraf.Metric, error) {
+func (f *File) readMetrics(acc telegraf.Accumulator, filename string) error {
 	file, err := os.Open(filename)
 	if err != nil {
-		return nil, err
+		return err
 	}
 	defer file.Close()
 
-	r, _ := utfbom.Skip(f.decoder.Reader(file))
-	fileContents, err := io.ReadAll(r)
-	if err != nil {
-		return nil, fmt.Errorf("could not read %q: %w", filename, err)
-	}
 	parser, err := f.parserFunc()
 	if err != nil {
-		return nil, fmt.Errorf("could not instantiate parser: %w", err)
+		return fmt.Errorf("could not instantiate parser: %w", err)
 	}
-	metrics, err := parser.Parse(fileContents)
-	if err != nil {
-		return metrics, fmt.Errorf("could not parse %q: %w", filename, err)
+
+	r, _ := utfbom.Skip(f.decoder.Reader(file))
+
+	// Parse the file incrementally if possible to avoid loading huge files
+	// into memory
+	var count int
+	if sp, ok := parser.(telegraf.StreamingParser); ok {
+		err := sp.ParseStream(r, func(m telegraf.Metric) error {
+			f.addMetric(acc, filename, m)
+			count++
+			return nil
+		})
+		if err != nil {
+			return fmt.Errorf("could not parse %q: %w", filename, err)
+		}
+	} else {
+		fileContents, err := io.ReadAll(r)
+		if err != nil {
+			return fmt.Errorf("could not read %q: %w", filename, err)
+		}
+		metrics, err := parser.Parse(fileContents)
+		if err != nil {
+			return fmt.Errorf("could not parse %q: %w", filename, err)
+		}
+		for _, m := range metrics {
+			f.addMetric(acc, filename, m)
+		}
+		count = len(metrics)
 	}
 
-	if len(metrics) == 0 {
+	if count == 0 {
 		once.Do(func() {
 			f.Log.Debug(internal.NoMetricsCreatedMsg)
 		})
 	}
-	return metrics, err
+	return nil
+}
+
+func (f *File) addMetric(acc telegraf.Accumulator, filename string, m telegraf.Metric) {
+	if f.FileTag != "" {
+		m.AddTag(f.FileTag, filepath.Base(filename))
+	}
+	if f.FilePathTag != "" {
+		if absPath, err := filepath.Abs(filename); err == nil {
+			m.AddTag(f.FilePathTag, absPath)
+		}
+	}
+	acc.AddMetric(m)
 }
 
 func init() {
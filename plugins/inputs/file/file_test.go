@@ -18,6 +18,7 @@ import (
 	"github.com/influxdata/telegraf/metric"
 	"github.com/influxdata/telegraf/plugins/parsers/csv"
 	"github.com/influxdata/telegraf/plugins/parsers/grok"
+	"github.com/influxdata/telegraf/plugins/parsers/influx"
 	"github.com/influxdata/telegraf/plugins/parsers/json"
 	"github.com/influxdata/telegraf/testutil"
 )
@@ -67,6 +68,35 @@ func TestFileTag(t *testing.T) {
 	}
 }
 
+func TestStreamingParser(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "metrics.influx")
+	content := "cpu value=1 1000\ncpu value=2 2000\ncpu value=\ncpu value=4 4000\n"
+	require.NoError(t, os.WriteFile(filename, []byte(content), 0640))
+
+	plugin := &File{
+		Files:   []string{filename},
+		FileTag: "filename",
+		Log:     testutil.Logger{},
+	}
+	plugin.SetParserFunc(func() (telegraf.Parser, error) {
+		parser := &influx.Parser{}
+		err := parser.Init()
+		return parser, err
+	})
+	require.NoError(t, plugin.Init())
+
+	// Metrics are added as they are parsed so the metrics before the invalid
+	// line are available
+	expected := []telegraf.Metric{
+		metric.New("cpu", map[string]string{"filename": "metrics.influx"}, map[string]interface{}{"value": 1.0}, time.Unix(0, 1000)),
+		metric.New("cpu", map[string]string{"filename": "metrics.influx"}, map[string]interface{}{"value": 2.0}, time.Unix(0, 2000)),
+	}
+
+	var acc testutil.Accumulator
+	require.ErrorContains(t, plugin.Gather(&acc), "could not parse")
+	testutil.RequireMetricsEqual(t, expected, acc.GetTelegrafMetrics())
+}
+
 func TestJSONParserCompile(t *testing.T) {
 	var acc testutil.Accumulator
 	wd, err := os.Getwd()
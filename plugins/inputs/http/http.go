@@ -191,37 +191,55 @@ func (h *HTTP) gatherURL(acc telegraf.Accumulator, url string) error {
 			h.SuccessStatusCodes)
 	}
 
-	b, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return fmt.Errorf("reading body failed: %w", err)
-	}
-
 	// Instantiate a new parser for the new data to avoid trouble with stateful parsers
 	parser, err := h.parserFunc()
 	if err != nil {
 		return fmt.Errorf("instantiating parser failed: %w", err)
 	}
-	metrics, err := parser.Parse(b)
-	if err != nil {
-		return fmt.Errorf("parsing metrics failed: %w", err)
+
+	// Parse the body incrementally if possible to avoid loading huge
+	// responses into memory
+	var count int
+	if sp, ok := parser.(telegraf.StreamingParser); ok {
+		err := sp.ParseStream(resp.Body, func(m telegraf.Metric) error {
+			addMetric(acc, url, m)
+			count++
+			return nil
+		})
+		if err != nil {
+			return fmt.Errorf("parsing metrics failed: %w", err)
+		}
+	} else {
+		b, err := io.ReadAll(resp.Body)
+		if err != nil {
+			return fmt.Errorf("reading body failed: %w", err)
+		}
+		metrics, err := parser.Parse(b)
+		if err != nil {
+			return fmt.Errorf("parsing metrics failed: %w", err)
+		}
+		for _, m := range metrics {
+			addMetric(acc, url, m)
+		}
+		count = len(metrics)
 	}
 
-	if len(metrics) == 0 {
+	if count == 0 {
 		once.Do(func() {
 			h.Log.Debug(internal.NoMetricsCreatedMsg)
 		})
 	}
 
-	for _, metric := range metrics {
-		if !metric.HasTag("url") {
-			metric.AddTag("url", url)
-		}
-		acc.AddFields(metric.Name(), metric.Fields(), metric.Tags(), metric.Time())
-	}
-
 	return nil
 }
 
+func addMetric(acc telegraf.Accumulator, url string, m telegraf.Metric) {
+	if !m.HasTag("url") {
+		m.AddTag("url", url)
+	}
+	acc.AddFields(m.Name(), m.Fields(), m.Tags(), m.Time())
+}
+
 func (h *HTTP) setRequestAuth(request *http.Request) error {
 	if h.Username.Empty() && h.Password.Empty() {
 		return nil
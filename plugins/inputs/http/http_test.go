@@ -11,6 +11,7 @@ import (
 	"net/url"
 	"os"
 	"path/filepath"
+	"strconv"
 	"strings"
 	"testing"
 	"time"
@@ -70,6 +71,41 @@ func TestHTTPWithJSONFormat(t *testing.T) {
 	require.Equal(t, acc.Metrics[0].Tags["url"], address)
 }
 
+func TestHTTPStreamingParser(t *testing.T) {
+	fakeServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		// Send the metrics in chunks
+		for i := range 100 {
+			if _, err := fmt.Fprintf(w, "cpu,core=%d value=%d 1000\n", i, i); err != nil {
+				t.Error(err)
+				return
+			}
+			if i%10 == 0 {
+				w.(http.Flusher).Flush()
+			}
+		}
+	}))
+	defer fakeServer.Close()
+
+	plugin := &httpplugin.HTTP{
+		URLs: []string{fakeServer.URL},
+		Log:  testutil.Logger{},
+	}
+	plugin.SetParserFunc(func() (telegraf.Parser, error) {
+		parser := &influx.Parser{}
+		err := parser.Init()
+		return parser, err
+	})
+	require.NoError(t, plugin.Init())
+
+	var acc testutil.Accumulator
+	require.NoError(t, acc.GatherError(plugin.Gather))
+	require.Len(t, acc.Metrics, 100)
+	for i, m := range acc.Metrics {
+		require.Equal(t, strconv.Itoa(i), m.Tags["core"])
+		require.Equal(t, fakeServer.URL, m.Tags["url"])
+	}
+}
+
 func TestHTTPHeaders(t *testing.T) {
 	header := "X-Special-Header"
 	headerValue := "Special-Value"
@@ -7,8 +7,12 @@ import (
 	"github.com/influxdata/influxdb-observability/common"
 	"github.com/influxdata/influxdb-observability/otel2influx"
 	"go.opentelemetry.io/collector/pdata/plog/plogotlp"
+	"go.opentelemetry.io/collector/pdata/pmetric"
 	"go.opentelemetry.io/collector/pdata/pmetric/pmetricotlp"
 	"go.opentelemetry.io/collector/pdata/ptrace/ptraceotlp"
+
+	"github.com/influxdata/telegraf"
+	common_otel "github.com/influxdata/telegraf/plugins/common/opentelemetry"
 )
 
 type traceService struct {
@@ -41,17 +45,17 @@ func (s *traceService) Export(ctx context.Context, req ptraceotlp.ExportRequest)
 type metricsService struct {
 	pmetricotlp.UnimplementedGRPCServer
 	exporter *otel2influx.OtelMetricsToLineProtocol
+
+	// Accumulator for histograms kept as native field values, nil if
+	// histograms should be flattened
+	histogramAcc telegraf.Accumulator
+	schema       string
 }
 
 var _ pmetricotlp.GRPCServer = (*metricsService)(nil)
 
-var metricsSchemata = map[string]common.MetricsSchema{
-	"prometheus-v1": common.MetricsSchemaTelegrafPrometheusV1,
-	"prometheus-v2": common.MetricsSchemaTelegrafPrometheusV2,
-}
-
-func newMetricsService(logger common.Logger, writer *writeToAccumulator, schema string) (*metricsService, error) {
-	ms, found := metricsSchemata[schema]
+func newMetricsService(logger common.Logger, writer *writeToAccumulator, schema string, nativeHistograms bool) (*metricsService, error) {
+	ms, found := common_otel.MetricsSchemata[schema]
 	if !found {
 		return nil, fmt.Errorf("schema %q not recognized", schema)
 	}
@@ -64,14 +68,28 @@ func newMetricsService(logger common.Logger, writer *writeToAccumulator, schema
 	if err != nil {
 		return nil, err
 	}
-	return &metricsService{
+	svc := &metricsService{
 		exporter: exp,
-	}, nil
+		schema:   schema,
+	}
+	if nativeHistograms {
+		svc.histogramAcc = writer.accumulator
+	}
+	return svc, nil
 }
 
 // Export processes and exports the metrics data received in the request.
 func (s *metricsService) Export(ctx context.Context, req pmetricotlp.ExportRequest) (pmetricotlp.ExportResponse, error) {
-	err := s.exporter.WriteMetrics(ctx, req.Metrics())
+	md := req.Metrics()
+	if s.histogramAcc != nil {
+		// Copy the metrics as the request data must not be modified
+		md = pmetric.NewMetrics()
+		req.Metrics().CopyTo(md)
+		for _, m := range common_otel.ExtractHistograms(md, s.schema) {
+			s.histogramAcc.AddMetric(m)
+		}
+	}
+	err := s.exporter.WriteMetrics(ctx, md)
 	return pmetricotlp.NewExportResponse(), err
 }
 
@@ -18,6 +18,7 @@ import (
 
 	"github.com/influxdata/telegraf"
 	"github.com/influxdata/telegraf/config"
+	common_otel "github.com/influxdata/telegraf/plugins/common/opentelemetry"
 	"github.com/influxdata/telegraf/plugins/common/tls"
 	"github.com/influxdata/telegraf/plugins/inputs"
 )
@@ -31,6 +32,7 @@ type OpenTelemetry struct {
 	LogRecordDimensions []string        `toml:"log_record_dimensions"`
 	ProfileDimensions   []string        `toml:"profile_dimensions"`
 	MetricsSchema       string          `toml:"metrics_schema"`
+	NativeHistograms    bool            `toml:"native_histograms"`
 	MaxMsgSize          config.Size     `toml:"max_msg_size"`
 	Timeout             config.Duration `toml:"timeout"`
 	Log                 telegraf.Logger `toml:"-"`
@@ -50,11 +52,10 @@ func (o *OpenTelemetry) Init() error {
 	if o.ServiceAddress == "" {
 		o.ServiceAddress = "0.0.0.0:4317"
 	}
-	switch o.MetricsSchema {
-	case "": // Set default
-		o.MetricsSchema = "prometheus-v1"
-	case "prometheus-v1", "prometheus-v2": // Valid values
-	default:
+	if o.MetricsSchema == "" {
+		o.MetricsSchema = common_otel.DefaultMetricsSchema
+	}
+	if _, found := common_otel.MetricsSchemata[o.MetricsSchema]; !found {
 		return fmt.Errorf("invalid metric schema %q", o.MetricsSchema)
 	}
 
@@ -75,7 +76,7 @@ func (o *OpenTelemetry) Start(acc telegraf.Accumulator) error {
 		grpcOptions = append(grpcOptions, grpc.MaxRecvMsgSize(int(o.MaxMsgSize)))
 	}
 
-	logger := &otelLogger{o.Log}
+	logger := &common_otel.Logger{Logger: o.Log}
 	influxWriter := &writeToAccumulator{acc}
 	o.grpcServer = grpc.NewServer(grpcOptions...)
 
@@ -85,7 +86,7 @@ func (o *OpenTelemetry) Start(acc telegraf.Accumulator) error {
 	}
 	ptraceotlp.RegisterGRPCServer(o.grpcServer, traceSvc)
 
-	metricsSvc, err := newMetricsService(logger, influxWriter, o.MetricsSchema)
+	metricsSvc, err := newMetricsService(logger, influxWriter, o.MetricsSchema, o.NativeHistograms)
 	if err != nil {
 		return err
 	}
@@ -14,6 +14,9 @@ import (
 	"github.com/google/go-cmp/cmp"
 	"github.com/influxdata/influxdb-observability/otel2influx"
 	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/collector/pdata/pcommon"
+	"go.opentelemetry.io/collector/pdata/pmetric"
+	"go.opentelemetry.io/collector/pdata/pmetric/pmetricotlp"
 	"go.opentelemetry.io/otel/exporters/otlp/otlpmetric/otlpmetricgrpc"
 	"go.opentelemetry.io/otel/sdk/metric"
 	"go.opentelemetry.io/otel/sdk/metric/metricdata"
@@ -27,6 +30,8 @@ import (
 
 	"github.com/influxdata/telegraf"
 	"github.com/influxdata/telegraf/config"
+	telegraf_metric "github.com/influxdata/telegraf/metric"
+	common_otel "github.com/influxdata/telegraf/plugins/common/opentelemetry"
 	"github.com/influxdata/telegraf/plugins/inputs"
 	"github.com/influxdata/telegraf/plugins/parsers/influx"
 	"github.com/influxdata/telegraf/testutil"
@@ -254,3 +259,92 @@ func TestCases(t *testing.T) {
 		})
 	}
 }
+
+func TestNativeHistograms(t *testing.T) {
+	md := pmetric.NewMetrics()
+	rm := md.ResourceMetrics().AppendEmpty()
+	rm.Resource().Attributes().PutStr("service.name", "test")
+	sm := rm.ScopeMetrics().AppendEmpty()
+	sm.Scope().SetName("library-name")
+
+	m := sm.Metrics().AppendEmpty()
+	m.SetName("latency")
+	dp := m.SetEmptyHistogram().DataPoints().AppendEmpty()
+	dp.Attributes().PutStr("host", "a")
+	dp.SetTimestamp(pcommon.NewTimestampFromTime(time.Unix(10, 0)))
+	dp.SetCount(6)
+	dp.SetSum(12.5)
+	dp.ExplicitBounds().FromRaw([]float64{1, 5})
+	dp.BucketCounts().FromRaw([]uint64{1, 3, 2})
+
+	m = sm.Metrics().AppendEmpty()
+	m.SetName("size")
+	edp := m.SetEmptyExponentialHistogram().DataPoints().AppendEmpty()
+	edp.SetTimestamp(pcommon.NewTimestampFromTime(time.Unix(10, 0)))
+	edp.SetCount(4)
+	edp.SetSum(9)
+	edp.SetScale(2)
+	edp.SetZeroCount(1)
+	edp.Positive().SetOffset(3)
+	edp.Positive().BucketCounts().FromRaw([]uint64{2, 1})
+
+	m = sm.Metrics().AppendEmpty()
+	m.SetName("requests")
+	m.SetEmptyGauge().DataPoints().AppendEmpty().SetIntValue(3)
+
+	tags := map[string]string{"service.name": "test", "otel.library.name": "library-name"}
+	expected := []telegraf.Metric{
+		testutil.MustMetric(
+			"latency",
+			map[string]string{"service.name": "test", "otel.library.name": "library-name", "host": "a"},
+			map[string]interface{}{
+				"histogram": telegraf_metric.Histogram{
+					Count:   6,
+					Sum:     12.5,
+					Buckets: []telegraf_metric.Bucket{{UpperBound: 1, Count: 1}, {UpperBound: 5, Count: 4}},
+				},
+			},
+			time.Unix(10, 0),
+			telegraf.Histogram,
+		),
+		testutil.MustMetric(
+			"size",
+			tags,
+			map[string]interface{}{
+				"histogram": telegraf_metric.ExponentialHistogram{
+					Count:     4,
+					Sum:       9,
+					Scale:     2,
+					ZeroCount: 1,
+					Positive:  telegraf_metric.ExponentialBuckets{Offset: 3, Counts: []float64{2, 1}},
+					Negative:  telegraf_metric.ExponentialBuckets{Counts: []float64{}},
+				},
+			},
+			time.Unix(10, 0),
+			telegraf.Histogram,
+		),
+		testutil.MustMetric(
+			"requests",
+			tags,
+			map[string]interface{}{"gauge": int64(3)},
+			time.Unix(0, 0),
+			telegraf.Gauge,
+		),
+	}
+
+	var acc testutil.Accumulator
+	logger := &common_otel.Logger{Logger: testutil.Logger{}}
+	svc, err := newMetricsService(logger, &writeToAccumulator{&acc}, "prometheus-v1", true)
+	require.NoError(t, err)
+	_, err = svc.Export(t.Context(), pmetricotlp.NewExportRequestFromMetrics(md))
+	require.NoError(t, err)
+
+	// The request must not be modified
+	require.Equal(t, 3, md.MetricCount())
+
+	options := []cmp.Option{
+		testutil.IgnoreFields("start_time_unix_nano"),
+		testutil.IgnoreTime(),
+	}
+	testutil.RequireMetricsEqual(t, expected, acc.GetTelegrafMetrics(), options...)
+}
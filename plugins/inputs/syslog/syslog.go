@@ -11,7 +11,6 @@ import (
 	"strings"
 	"sync"
 	"time"
-	"unicode"
 
 	"github.com/leodido/go-syslog/v4"
 	"github.com/leodido/go-syslog/v4/nontransparent"
@@ -21,6 +20,7 @@ import (
 
 	"github.com/influxdata/telegraf"
 	"github.com/influxdata/telegraf/plugins/common/socket"
+	common_syslog "github.com/influxdata/telegraf/plugins/common/syslog"
 	"github.com/influxdata/telegraf/plugins/inputs"
 )
 
@@ -191,7 +191,7 @@ func (s *Syslog) createStreamDataHandler(acc telegraf.Accumulator) socket.Callba
 			}
 
 			// Extract message information
-			acc.AddFields("syslog", fields(r.Message, s.Separator), tags(r.Message, addr))
+			acc.AddFields("syslog", common_syslog.Fields(r.Message, s.Separator), common_syslog.Tags(r.Message, addr))
 		})
 		parser.Parse(reader)
 	}
@@ -230,100 +230,10 @@ func (s *Syslog) createDatagramDataHandler(acc telegraf.Accumulator) socket.Call
 				addr = src.String()
 			}
 		}
-		acc.AddFields("syslog", fields(message, s.Separator), tags(message, addr))
+		acc.AddFields("syslog", common_syslog.Fields(message, s.Separator), common_syslog.Tags(message, addr))
 	}
 }
 
-func tags(msg syslog.Message, src string) map[string]string {
-	// Extract message information
-	tags := map[string]string{
-		"severity": *msg.SeverityShortLevel(),
-		"facility": *msg.FacilityLevel(),
-	}
-
-	if src != "" {
-		tags["source"] = src
-	}
-
-	switch msg := msg.(type) {
-	case *rfc5424.SyslogMessage:
-		if msg.Hostname != nil {
-			tags["hostname"] = *msg.Hostname
-		}
-		if msg.Appname != nil {
-			tags["appname"] = *msg.Appname
-		}
-	case *rfc3164.SyslogMessage:
-		if msg.Hostname != nil {
-			tags["hostname"] = *msg.Hostname
-		}
-		if msg.Appname != nil {
-			tags["appname"] = *msg.Appname
-		}
-	}
-
-	return tags
-}
-
-func fields(msg syslog.Message, separator string) map[string]interface{} {
-	var fields map[string]interface{}
-	switch msg := msg.(type) {
-	case *rfc5424.SyslogMessage:
-		fields = map[string]interface{}{
-			"facility_code": int(*msg.Facility),
-			"severity_code": int(*msg.Severity),
-			"version":       msg.Version,
-		}
-		if msg.Timestamp != nil {
-			fields["timestamp"] = (*msg.Timestamp).UnixNano()
-		}
-		if msg.ProcID != nil {
-			fields["procid"] = *msg.ProcID
-		}
-		if msg.MsgID != nil {
-			fields["msgid"] = *msg.MsgID
-		}
-		if msg.Message != nil {
-			fields["message"] = strings.TrimRightFunc(*msg.Message, func(r rune) bool {
-				return unicode.IsSpace(r)
-			})
-		}
-		if msg.StructuredData != nil {
-			for sdid, sdparams := range *msg.StructuredData {
-				if len(sdparams) == 0 {
-					// When SD-ID does not have params we indicate its presence with a bool
-					fields[sdid] = true
-					continue
-				}
-				for k, v := range sdparams {
-					fields[sdid+separator+k] = v
-				}
-			}
-		}
-	case *rfc3164.SyslogMessage:
-		fields = map[string]interface{}{
-			"facility_code": int(*msg.Facility),
-			"severity_code": int(*msg.Severity),
-		}
-		if msg.Timestamp != nil {
-			fields["timestamp"] = (*msg.Timestamp).UnixNano()
-		}
-		if msg.ProcID != nil {
-			fields["procid"] = *msg.ProcID
-		}
-		if msg.MsgID != nil {
-			fields["msgid"] = *msg.MsgID
-		}
-		if msg.Message != nil {
-			fields["message"] = strings.TrimRightFunc(*msg.Message, func(r rune) bool {
-				return unicode.IsSpace(r)
-			})
-		}
-	}
-
-	return fields
-}
-
 func init() {
 	inputs.Add("syslog", func() telegraf.Input {
 		return &Syslog{
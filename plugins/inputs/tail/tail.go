@@ -190,7 +190,23 @@ func (t *Tail) getSeekInfo(file string) (*tail.SeekInfo, error) {
 }
 
 func (t *Tail) GetState() interface{} {
-	return t.offsets
+	t.tailersMutex.RLock()
+	defer t.tailersMutex.RUnlock()
+
+	// Include the current offsets of running tailers to allow persisting the
+	// state while Telegraf is running
+	state := make(map[string]int64, len(t.offsets)+len(t.tailers))
+	for k, v := range t.offsets {
+		state[k] = v
+	}
+	if !t.Pipe {
+		for _, tailer := range t.tailers {
+			if offset, err := tailer.Tell(); err == nil {
+				state[tailer.Filename] = offset
+			}
+		}
+	}
+	return state
 }
 
 func (t *Tail) SetState(state interface{}) error {
@@ -204,6 +220,18 @@ func (t *Tail) SetState(state interface{}) error {
 	return nil
 }
 
+func (*Tail) StateVersion() int {
+	return 1
+}
+
+func (*Tail) MigrateState(version int, state []byte) ([]byte, error) {
+	// Offsets stored without version use the same file to offset mapping
+	if version == 0 {
+		return state, nil
+	}
+	return nil, fmt.Errorf("unsupported state version %d", version)
+}
+
 func (t *Tail) Gather(_ telegraf.Accumulator) error {
 	return t.tailNewFiles()
 }
@@ -13,6 +13,7 @@ import (
 	"math"
 	"reflect"
 	"strings"
+	"sync"
 	"syscall"
 	"time"
 
@@ -50,6 +51,7 @@ type WinEventLog struct {
 	subscription     evtHandle
 	subscriptionFlag evtSubscribeFlag
 	bookmark         evtHandle
+	bookmarkLock     sync.Mutex
 	tagFilter        filter.Filter
 	fieldFilter      filter.Filter
 	fieldEmptyFilter filter.Filter
@@ -114,6 +116,10 @@ func (w *WinEventLog) Start(telegraf.Accumulator) error {
 }
 
 func (w *WinEventLog) GetState() interface{} {
+	// The state is checkpointed concurrently to gathering
+	w.bookmarkLock.Lock()
+	defer w.bookmarkLock.Unlock()
+
 	bookmarkXML, err := w.renderBookmark()
 	if err != nil {
 		w.Log.Errorf("State-persistence failed, cannot render bookmark: %v", err)
@@ -378,7 +384,10 @@ func (w *WinEventLog) fetchEvents(subsHandle evtHandle) ([]event, error) {
 			events = append(events, event)
 		}
 
-		if err := evtUpdateBookmark(w.bookmark, eventHandle); err != nil {
+		w.bookmarkLock.Lock()
+		err := evtUpdateBookmark(w.bookmark, eventHandle)
+		w.bookmarkLock.Unlock()
+		if err != nil {
 			w.Log.Errorf("Updateing bookmark failed: %v", err)
 			if evterr == nil {
 				evterr = err
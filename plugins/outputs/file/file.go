@@ -3,6 +3,7 @@ package file
 
 import (
 	_ "embed"
+	"errors"
 	"fmt"
 	"io"
 	"os"
@@ -96,10 +97,26 @@ func (f *File) Close() error {
 func (f *File) Write(metrics []telegraf.Metric) error {
 	var writeErr error
 
+	// Reject metrics failing to serialize instead of retrying them forever
+	werr := &internal.PartialWriteError{
+		MetricsAccept: make([]int, 0, len(metrics)),
+	}
+
 	if f.UseBatchFormat {
 		octets, err := f.serializer.SerializeBatch(metrics)
 		if err != nil {
 			f.Log.Errorf("Could not serialize metric: %v", err)
+
+			// Serializers may reject individual metrics of the batch while
+			// still returning the remaining ones
+			var serr *internal.PartialWriteError
+			if !errors.As(err, &serr) {
+				return nil
+			}
+			werr = serr
+			if len(werr.MetricsAccept) == 0 {
+				return werr
+			}
 		}
 
 		octets, err = f.encoder.Encode(octets)
@@ -112,11 +129,16 @@ func (f *File) Write(metrics []telegraf.Metric) error {
 			f.Log.Errorf("Error writing to file: %v", err)
 		}
 	} else {
-		for _, metric := range metrics {
+		for i, metric := range metrics {
 			b, err := f.serializer.Serialize(metric)
 			if err != nil {
 				f.Log.Debugf("Could not serialize metric: %v", err)
+				werr.Err = internal.ErrSerialization
+				werr.MetricsReject = append(werr.MetricsReject, i)
+				werr.MetricsRejectErrors = append(werr.MetricsRejectErrors, err)
+				continue
 			}
+			werr.MetricsAccept = append(werr.MetricsAccept, i)
 
 			b, err = f.encoder.Encode(b)
 			if err != nil {
@@ -130,7 +152,13 @@ func (f *File) Write(metrics []telegraf.Metric) error {
 		}
 	}
 
-	return writeErr
+	if writeErr != nil {
+		return writeErr
+	}
+	if werr.Err != nil {
+		return werr
+	}
+	return nil
 }
 
 func init() {
@@ -2,14 +2,20 @@ package file
 
 import (
 	"bytes"
+	"fmt"
 	"io"
 	"os"
+	"path/filepath"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/require"
 
+	"github.com/influxdata/telegraf"
 	"github.com/influxdata/telegraf/internal"
+	"github.com/influxdata/telegraf/metric"
 	"github.com/influxdata/telegraf/plugins/serializers/influx"
+	"github.com/influxdata/telegraf/plugins/serializers/json"
 	"github.com/influxdata/telegraf/testutil"
 )
 
@@ -291,6 +297,53 @@ func TestFileStdout(t *testing.T) {
 	require.Equal(t, expNewFile, out.str)
 }
 
+func TestFileRejectSerializationErrors(t *testing.T) {
+	schema := filepath.Join(t.TempDir(), "schema.json")
+	require.NoError(t, os.WriteFile(schema, []byte(`{
+		"type": "object",
+		"properties": {"host": {"type": "string"}, "value": {"type": "number"}},
+		"required": ["host", "value"]
+	}`), 0600))
+
+	metrics := []telegraf.Metric{
+		metric.New("test", map[string]string{"host": "a"}, map[string]interface{}{"value": 1.0}, time.Unix(0, 0)),
+		metric.New("test", map[string]string{}, map[string]interface{}{"value": 2.0}, time.Unix(0, 0)),
+		metric.New("test", map[string]string{"host": "c"}, map[string]interface{}{"value": 3.0}, time.Unix(0, 0)),
+	}
+
+	for _, batch := range []bool{false, true} {
+		t.Run(fmt.Sprintf("batch=%v", batch), func(t *testing.T) {
+			s := &json.Serializer{Schemas: map[string]string{"test": schema}}
+			require.NoError(t, s.Init())
+
+			fn := tmpFile(t)
+			f := File{
+				Files:            []string{fn},
+				UseBatchFormat:   batch,
+				CompressionLevel: -1,
+				Log:              testutil.Logger{},
+				serializer:       s,
+			}
+			require.NoError(t, f.Init())
+			require.NoError(t, f.Connect())
+			defer f.Close()
+
+			err := f.Write(metrics)
+			var werr *internal.PartialWriteError
+			require.ErrorAs(t, err, &werr)
+			require.ErrorIs(t, err, internal.ErrSerialization)
+			require.Equal(t, []int{0, 2}, werr.MetricsAccept)
+			require.Equal(t, []int{1}, werr.MetricsReject)
+
+			expected := `{"host":"a","value":1}` + "\n" + `{"host":"c","value":3}` + "\n"
+			if batch {
+				expected = `[{"host":"a","value":1},{"host":"c","value":3}]` + "\n"
+			}
+			validateFile(t, fn, expected)
+		})
+	}
+}
+
 func createFile(t *testing.T) *os.File {
 	f, err := os.CreateTemp(t.TempDir(), "")
 	require.NoError(t, err)
@@ -8,10 +8,12 @@ import (
 	"crypto/sha256"
 	_ "embed"
 	"encoding/hex"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
@@ -57,6 +59,9 @@ type HTTP struct {
 	client     *http.Client
 	serializer telegraf.Serializer
 
+	// Protects the serializer and the token for concurrent writes
+	sync.Mutex
+
 	awsCfg *aws.Config
 	common_aws.CredentialConfig
 
@@ -108,25 +113,58 @@ func (h *HTTP) Close() error {
 	return nil
 }
 
+func (*HTTP) SupportsConcurrentWrites() bool {
+	return true
+}
+
 func (h *HTTP) Write(metrics []telegraf.Metric) error {
 	if h.UseBatchFormat {
+		h.Lock()
 		reqBody, err := h.serializer.SerializeBatch(metrics)
-		if err != nil {
+		h.Unlock()
+
+		// Serializers may reject individual metrics of the batch while
+		// still returning the remaining ones
+		var werr *internal.PartialWriteError
+		if err != nil && !errors.As(err, &werr) {
 			return err
 		}
+		if werr != nil && len(werr.MetricsAccept) == 0 {
+			return werr
+		}
 
-		return h.writeMetric(reqBody)
+		if err := h.writeMetric(reqBody); err != nil {
+			return err
+		}
+		if werr != nil {
+			return werr
+		}
+		return nil
 	}
 
-	for _, metric := range metrics {
+	// Reject metrics failing to serialize instead of retrying them forever
+	werr := &internal.PartialWriteError{
+		MetricsAccept: make([]int, 0, len(metrics)),
+	}
+	for i, metric := range metrics {
+		h.Lock()
 		reqBody, err := h.serializer.Serialize(metric)
+		h.Unlock()
 		if err != nil {
-			return err
+			h.Log.Debugf("Could not serialize metric: %v", err)
+			werr.Err = internal.ErrSerialization
+			werr.MetricsReject = append(werr.MetricsReject, i)
+			werr.MetricsRejectErrors = append(werr.MetricsRejectErrors, err)
+			continue
 		}
 
 		if err := h.writeMetric(reqBody); err != nil {
 			return err
 		}
+		werr.MetricsAccept = append(werr.MetricsAccept, i)
+	}
+	if werr.Err != nil {
+		return werr
 	}
 	return nil
 }
@@ -265,6 +303,9 @@ func init() {
 }
 
 func (h *HTTP) getAccessToken(ctx context.Context, audience string) (*oauth2.Token, error) {
+	h.Lock()
+	defer h.Unlock()
+
 	if h.oauth2Token.Valid() {
 		return h.oauth2Token, nil
 	}
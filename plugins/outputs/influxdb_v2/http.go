@@ -15,6 +15,7 @@ import (
 	"path"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
 
 	"golang.org/x/net/http2"
@@ -68,6 +69,10 @@ type httpClient struct {
 	retryTime        time.Time
 	retryCount       int
 	log              telegraf.Logger
+
+	// Protects the serializer, the encoder and the retry state for
+	// concurrent writes
+	sync.Mutex
 }
 
 func (c *httpClient) Init() error {
@@ -154,7 +159,10 @@ func (g genericRespError) Error() string {
 }
 
 func (c *httpClient) Write(ctx context.Context, metrics []telegraf.Metric) error {
-	if c.retryTime.After(time.Now()) {
+	c.Lock()
+	retryTime := c.retryTime
+	c.Unlock()
+	if retryTime.After(time.Now()) {
 		return errors.New("retry time has not elapsed")
 	}
 
@@ -242,29 +250,26 @@ func (c *httpClient) splitAndWriteBatch(ctx context.Context, bucket string, metr
 }
 
 func (c *httpClient) writeBatch(ctx context.Context, bucket string, metrics []telegraf.Metric) error {
-	// Get the current limit for the outbound data
+	// Serialize and encode the metrics with the remaining limit and account
+	// for the used size in one step so concurrent writes cannot exceed the
+	// limit. Exit early if nothing was serialized.
 	ratets := time.Now()
-	limit := c.rateLimiter.Remaining(ratets)
-
-	// Serialize the metrics with the remaining limit, exit early if nothing was serialized
-	body, werr := c.serializer.SerializeBatch(metrics, limit)
+	var body []byte
+	var used int64
+	werr := c.rateLimiter.Consume(ratets, func(limit int64) (int64, error) {
+		var err error
+		body, used, err = c.encodeBatch(metrics, limit)
+		return used, err
+	})
 	if werr != nil && !errors.Is(werr, internal.ErrSizeLimitReached) || len(body) == 0 {
 		return werr
 	}
-	used := int64(len(body))
-
-	// Encode the content if requested
-	if c.encoder != nil {
-		var err error
-		if body, err = c.encoder.Encode(body); err != nil {
-			return fmt.Errorf("encoding failed: %w", err)
-		}
-	}
 
 	// Setup the request
 	address := makeWriteURL(*c.url, c.params, bucket)
 	req, err := http.NewRequest("POST", address, io.NopCloser(bytes.NewBuffer(body)))
 	if err != nil {
+		c.rateLimiter.Undo(ratets, used)
 		return fmt.Errorf("creating request failed: %w", err)
 	}
 	if c.encoder != nil {
@@ -275,17 +280,18 @@ func (c *httpClient) writeBatch(ctx context.Context, bucket string, metrics []te
 	// Set authorization
 	token, err := c.token.Get()
 	if err != nil {
+		c.rateLimiter.Undo(ratets, used)
 		return fmt.Errorf("getting token failed: %w", err)
 	}
 	req.Header.Set("Authorization", "Token "+token.String())
 	token.Destroy()
 
 	if err := c.addHeaders(req); err != nil {
+		c.rateLimiter.Undo(ratets, used)
 		return fmt.Errorf("adding headers failed: %w", err)
 	}
 
 	// Execute the request
-	c.rateLimiter.Accept(ratets, used)
 	resp, err := c.client.Do(req.WithContext(ctx))
 	if err != nil {
 		internal.OnClientError(c.client, err)
@@ -305,7 +311,9 @@ func (c *httpClient) writeBatch(ctx context.Context, bucket string, metrics []te
 		http.StatusPartialContent,
 		http.StatusMultiStatus,
 		http.StatusAlreadyReported:
+		c.Lock()
 		c.retryCount = 0
+		c.Unlock()
 		return werr
 	}
 
@@ -344,9 +352,11 @@ func (c *httpClient) writeBatch(ctx context.Context, bucket string, metrics []te
 		http.StatusBadGateway,
 		http.StatusGatewayTimeout:
 		// ^ these handle the cases where the server is likely overloaded, and may not be able to say so.
+		c.Lock()
 		c.retryCount++
 		retryDuration := c.getRetryDuration(resp.Header)
 		c.retryTime = time.Now().Add(retryDuration)
+		c.Unlock()
 		c.log.Warnf("Failed to write to %s; will retry in %s. (%s)\n", bucket, retryDuration, resp.Status)
 		return fmt.Errorf("waiting %s for server (%s) before sending metric again", retryDuration, bucket)
 	}
@@ -373,6 +383,28 @@ func (c *httpClient) writeBatch(ctx context.Context, bucket string, metrics []te
 	}
 }
 
+// encodeBatch serializes the metrics with the given limit and encodes the
+// result if requested. The number of serialized bytes is returned for
+// accounting the rate-limit.
+func (c *httpClient) encodeBatch(metrics []telegraf.Metric, limit int64) ([]byte, int64, error) {
+	c.Lock()
+	defer c.Unlock()
+
+	body, werr := c.serializer.SerializeBatch(metrics, limit)
+	if werr != nil && !errors.Is(werr, internal.ErrSizeLimitReached) || len(body) == 0 {
+		return body, 0, werr
+	}
+	used := int64(len(body))
+
+	if c.encoder != nil {
+		var err error
+		if body, err = c.encoder.Encode(body); err != nil {
+			return nil, 0, fmt.Errorf("encoding failed: %w", err)
+		}
+	}
+	return body, used, werr
+}
+
 // retryDuration takes the longer of the Retry-After header and our own back-off calculation
 func (c *httpClient) getRetryDuration(headers http.Header) time.Duration {
 	// basic exponential backoff (x^2)/40 (denominator to widen the slope)
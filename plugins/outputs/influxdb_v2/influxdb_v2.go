@@ -191,6 +191,12 @@ func (i *InfluxDB) Close() error {
 	return nil
 }
 
+// SupportsConcurrentWrites signals that the clients are safe for concurrent
+// use so multiple batches can be written in parallel.
+func (*InfluxDB) SupportsConcurrentWrites() bool {
+	return true
+}
+
 // Write sends metrics to one of the configured servers, logging each
 // unsuccessful. If all servers fail, return an error.
 func (i *InfluxDB) Write(metrics []telegraf.Metric) error {
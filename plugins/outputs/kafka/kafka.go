@@ -39,6 +39,7 @@ type Kafka struct {
 	RoutingKey        string          `toml:"routing_key"`
 	ProducerTimestamp string          `toml:"producer_timestamp"`
 	MetricNameHeader  string          `toml:"metric_name_header"`
+	UseBatchFormat    bool            `toml:"use_batch_format"`
 	Log               telegraf.Logger `toml:"-"`
 	proxy.Socks5ProxyConfig
 	kafka.WriteConfig
@@ -194,6 +195,52 @@ func (k *Kafka) routingKey(metric telegraf.Metric) (string, error) {
 }
 
 func (k *Kafka) Write(metrics []telegraf.Metric) error {
+	var msgs []*sarama.ProducerMessage
+	var err error
+	if k.UseBatchFormat {
+		msgs, err = k.batchMessages(metrics)
+	} else {
+		msgs, err = k.messages(metrics)
+	}
+	// Serializers may reject individual metrics of the batch while still
+	// returning the remaining ones
+	var werr *internal.PartialWriteError
+	if err != nil && !errors.As(err, &werr) {
+		return err
+	}
+	if werr != nil && len(werr.MetricsAccept) == 0 {
+		return werr
+	}
+
+	if err := k.producer.SendMessages(msgs); err != nil {
+		// We could have many errors, return only the first encountered.
+		var errs sarama.ProducerErrors
+		if errors.As(err, &errs) && len(errs) > 0 {
+			// Just return the first error encountered
+			firstErr := errs[0]
+			if errors.Is(firstErr.Err, sarama.ErrMessageSizeTooLarge) {
+				k.Log.Error("Message too large, consider increasing `max_message_bytes`; dropping batch")
+				return nil
+			}
+			if errors.Is(firstErr.Err, sarama.ErrInvalidTimestamp) {
+				k.Log.Error(
+					"The timestamp of the message is out of acceptable range, consider increasing broker `message.timestamp.difference.max.ms`; " +
+						"dropping batch",
+				)
+				return nil
+			}
+			return firstErr
+		}
+		return err
+	}
+
+	if werr != nil {
+		return werr
+	}
+	return nil
+}
+
+func (k *Kafka) messages(metrics []telegraf.Metric) ([]*sarama.ProducerMessage, error) {
 	msgs := make([]*sarama.ProducerMessage, 0, len(metrics))
 	for _, metric := range metrics {
 		metric, topic := k.GetTopicName(metric)
@@ -204,60 +251,127 @@ func (k *Kafka) Write(metrics []telegraf.Metric) error {
 			continue
 		}
 
-		m := &sarama.ProducerMessage{
-			Topic: topic,
-			Value: sarama.ByteEncoder(buf),
+		m, err := k.newMessage(topic, metric, buf)
+		if err != nil {
+			return nil, err
 		}
+		msgs = append(msgs, m)
+	}
+	return msgs, nil
+}
 
-		if k.MetricNameHeader != "" {
-			m.Headers = []sarama.RecordHeader{
-				{
-					Key:   []byte(k.MetricNameHeader),
-					Value: []byte(metric.Name()),
-				},
-			}
+// batchMessages serializes the metrics into one message per topic and
+// measurement using the batch format of the serializer. The message key,
+// header and timestamp are determined using the first metric of each batch.
+// Metrics failing to serialize are rejected using a partial write error while
+// the messages for the remaining metrics are still returned.
+func (k *Kafka) batchMessages(metrics []telegraf.Metric) ([]*sarama.ProducerMessage, error) {
+	type batchKey struct {
+		topic string
+		name  string
+	}
+	type batch struct {
+		indices []int
+		metrics []telegraf.Metric
+	}
+	batches := make(map[batchKey]*batch)
+	order := make([]batchKey, 0)
+	for i, metric := range metrics {
+		metric, topic := k.GetTopicName(metric)
+		key := batchKey{topic: topic, name: metric.Name()}
+		b, found := batches[key]
+		if !found {
+			b = &batch{}
+			batches[key] = b
+			order = append(order, key)
 		}
+		b.indices = append(b.indices, i)
+		b.metrics = append(b.metrics, metric)
+	}
 
-		// Negative timestamps are not allowed by the Kafka protocol.
-		if k.ProducerTimestamp == "metric" && !metric.Time().Before(zeroTime) {
-			m.Timestamp = metric.Time()
+	werr := &internal.PartialWriteError{
+		MetricsAccept: make([]int, 0, len(metrics)),
+	}
+	msgs := make([]*sarama.ProducerMessage, 0, len(order))
+	for _, key := range order {
+		b := batches[key]
+		buf, err := k.serializer.SerializeBatch(b.metrics)
+
+		first := b.metrics[0]
+		var serr *internal.PartialWriteError
+		switch {
+		case err == nil:
+			werr.MetricsAccept = append(werr.MetricsAccept, b.indices...)
+		case errors.As(err, &serr):
+			// Map the indices of the batch to the indices of the metrics
+			werr.Err = serr.Err
+			for _, i := range serr.MetricsAccept {
+				werr.MetricsAccept = append(werr.MetricsAccept, b.indices[i])
+			}
+			for j, i := range serr.MetricsReject {
+				rerr := serr.Err
+				if j < len(serr.MetricsRejectErrors) {
+					rerr = serr.MetricsRejectErrors[j]
+				}
+				werr.MetricsReject = append(werr.MetricsReject, b.indices[i])
+				werr.MetricsRejectErrors = append(werr.MetricsRejectErrors, rerr)
+			}
+			if len(serr.MetricsAccept) == 0 {
+				continue
+			}
+			first = b.metrics[serr.MetricsAccept[0]]
+		default:
+			k.Log.Errorf("Could not serialize batch: %v", err)
+			werr.Err = internal.ErrSerialization
+			for _, i := range b.indices {
+				werr.MetricsReject = append(werr.MetricsReject, i)
+				werr.MetricsRejectErrors = append(werr.MetricsRejectErrors, err)
+			}
+			continue
 		}
 
-		key, err := k.routingKey(metric)
+		m, err := k.newMessage(key.topic, first, buf)
 		if err != nil {
-			return fmt.Errorf("could not generate routing key: %w", err)
+			return nil, err
 		}
+		msgs = append(msgs, m)
+	}
 
-		if key != "" {
-			m.Key = sarama.StringEncoder(key)
+	if werr.Err != nil {
+		return msgs, werr
+	}
+	return msgs, nil
+}
+
+func (k *Kafka) newMessage(topic string, metric telegraf.Metric, buf []byte) (*sarama.ProducerMessage, error) {
+	m := &sarama.ProducerMessage{
+		Topic: topic,
+		Value: sarama.ByteEncoder(buf),
+	}
+
+	if k.MetricNameHeader != "" {
+		m.Headers = []sarama.RecordHeader{
+			{
+				Key:   []byte(k.MetricNameHeader),
+				Value: []byte(metric.Name()),
+			},
 		}
-		msgs = append(msgs, m)
 	}
 
-	err := k.producer.SendMessages(msgs)
+	// Negative timestamps are not allowed by the Kafka protocol.
+	if k.ProducerTimestamp == "metric" && !metric.Time().Before(zeroTime) {
+		m.Timestamp = metric.Time()
+	}
+
+	key, err := k.routingKey(metric)
 	if err != nil {
-		// We could have many errors, return only the first encountered.
-		var errs sarama.ProducerErrors
-		if errors.As(err, &errs) && len(errs) > 0 {
-			// Just return the first error encountered
-			firstErr := errs[0]
-			if errors.Is(firstErr.Err, sarama.ErrMessageSizeTooLarge) {
-				k.Log.Error("Message too large, consider increasing `max_message_bytes`; dropping batch")
-				return nil
-			}
-			if errors.Is(firstErr.Err, sarama.ErrInvalidTimestamp) {
-				k.Log.Error(
-					"The timestamp of the message is out of acceptable range, consider increasing broker `message.timestamp.difference.max.ms`; " +
-						"dropping batch",
-				)
-				return nil
-			}
-			return firstErr
-		}
-		return err
+		return nil, fmt.Errorf("could not generate routing key: %w", err)
 	}
 
-	return nil
+	if key != "" {
+		m.Key = sarama.StringEncoder(key)
+	}
+	return m, nil
 }
 
 func init() {
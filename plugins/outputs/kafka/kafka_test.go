@@ -1,6 +1,7 @@
 package kafka
 
 import (
+	"errors"
 	"testing"
 	"time"
 
@@ -9,6 +10,7 @@ import (
 	kafkacontainer "github.com/testcontainers/testcontainers-go/modules/kafka"
 
 	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/internal"
 	"github.com/influxdata/telegraf/metric"
 	"github.com/influxdata/telegraf/plugins/serializers/influx"
 	"github.com/influxdata/telegraf/testutil"
@@ -310,3 +312,129 @@ func TestTopicTag(t *testing.T) {
 		})
 	}
 }
+
+func TestBatchFormat(t *testing.T) {
+	plugin := &Kafka{
+		Brokers:          []string{"127.0.0.1"},
+		Topic:            "telegraf",
+		TopicTag:         "topic",
+		MetricNameHeader: "name",
+		UseBatchFormat:   true,
+		producerFunc:     NewMockProducer,
+		Log:              testutil.Logger{},
+	}
+
+	s := &influx.Serializer{}
+	require.NoError(t, s.Init())
+	plugin.SetSerializer(s)
+	require.NoError(t, plugin.Connect())
+
+	producer := &MockProducer{}
+	plugin.producer = producer
+
+	input := []telegraf.Metric{
+		metric.New("cpu", map[string]string{}, map[string]interface{}{"value": 1.0}, time.Unix(0, 0)),
+		metric.New("mem", map[string]string{}, map[string]interface{}{"value": 2.0}, time.Unix(0, 0)),
+		metric.New("cpu", map[string]string{}, map[string]interface{}{"value": 3.0}, time.Unix(1, 0)),
+		metric.New("cpu", map[string]string{"topic": "other"}, map[string]interface{}{"value": 4.0}, time.Unix(2, 0)),
+	}
+	require.NoError(t, plugin.Write(input))
+
+	expected := []struct {
+		topic string
+		name  string
+		value string
+	}{
+		{topic: "telegraf", name: "cpu", value: "cpu value=1 0\ncpu value=3 1000000000\n"},
+		{topic: "telegraf", name: "mem", value: "mem value=2 0\n"},
+		{topic: "other", name: "cpu", value: "cpu,topic=other value=4 2000000000\n"},
+	}
+	require.Len(t, producer.sent, len(expected))
+	for i, e := range expected {
+		msg := producer.sent[i]
+		require.Equal(t, e.topic, msg.Topic)
+		require.Equal(t, []byte(e.name), msg.Headers[0].Value)
+		encoded, err := msg.Value.Encode()
+		require.NoError(t, err)
+		require.Equal(t, e.value, string(encoded))
+	}
+}
+
+// rejectingSerializer rejects metrics with a "reject" tag and fails on
+// batches of the "broken" measurement
+type rejectingSerializer struct {
+	influx.Serializer
+}
+
+func (s *rejectingSerializer) SerializeBatch(metrics []telegraf.Metric) ([]byte, error) {
+	if metrics[0].Name() == "broken" {
+		return nil, errors.New("broken batch")
+	}
+
+	werr := &internal.PartialWriteError{}
+	accepted := make([]telegraf.Metric, 0, len(metrics))
+	for i, m := range metrics {
+		if m.HasTag("reject") {
+			werr.Err = internal.ErrSerialization
+			werr.MetricsReject = append(werr.MetricsReject, i)
+			werr.MetricsRejectErrors = append(werr.MetricsRejectErrors, errors.New("rejected"))
+			continue
+		}
+		werr.MetricsAccept = append(werr.MetricsAccept, i)
+		accepted = append(accepted, m)
+	}
+	if len(accepted) == 0 {
+		return nil, werr
+	}
+	buf, err := s.Serializer.SerializeBatch(accepted)
+	if err != nil {
+		return nil, err
+	}
+	if werr.Err != nil {
+		return buf, werr
+	}
+	return buf, nil
+}
+
+func TestBatchFormatPartialWrite(t *testing.T) {
+	plugin := &Kafka{
+		Brokers:        []string{"127.0.0.1"},
+		Topic:          "telegraf",
+		UseBatchFormat: true,
+		producerFunc:   NewMockProducer,
+		Log:            testutil.Logger{},
+	}
+
+	s := &rejectingSerializer{}
+	require.NoError(t, s.Init())
+	plugin.SetSerializer(s)
+	require.NoError(t, plugin.Connect())
+
+	producer := &MockProducer{}
+	plugin.producer = producer
+
+	input := []telegraf.Metric{
+		metric.New("cpu", map[string]string{"reject": "true"}, map[string]interface{}{"value": 1.0}, time.Unix(0, 0)),
+		metric.New("broken", map[string]string{}, map[string]interface{}{"value": 2.0}, time.Unix(0, 0)),
+		metric.New("cpu", map[string]string{}, map[string]interface{}{"value": 3.0}, time.Unix(1, 0)),
+		metric.New("mem", map[string]string{"reject": "true"}, map[string]interface{}{"value": 4.0}, time.Unix(2, 0)),
+		metric.New("mem", map[string]string{}, map[string]interface{}{"value": 5.0}, time.Unix(3, 0)),
+	}
+	err := plugin.Write(input)
+
+	// The accepted metrics must be sent and the others rejected with the
+	// indices referring to the input
+	var werr *internal.PartialWriteError
+	require.ErrorAs(t, err, &werr)
+	require.ElementsMatch(t, []int{2, 4}, werr.MetricsAccept)
+	require.ElementsMatch(t, []int{0, 1, 3}, werr.MetricsReject)
+	require.Len(t, werr.MetricsRejectErrors, len(werr.MetricsReject))
+
+	expected := []string{"cpu value=3 1000000000\n", "mem value=5 3000000000\n"}
+	require.Len(t, producer.sent, len(expected))
+	for i, e := range expected {
+		encoded, err := producer.sent[i].Value.Encode()
+		require.NoError(t, err)
+		require.Equal(t, e, string(encoded))
+	}
+}
@@ -8,8 +8,8 @@ import (
 	"sort"
 	"time"
 
-	"github.com/influxdata/influxdb-observability/common"
 	"github.com/influxdata/influxdb-observability/influx2otel"
+	"go.opentelemetry.io/collector/pdata/pmetric"
 	"go.opentelemetry.io/collector/pdata/pmetric/pmetricotlp"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/credentials"
@@ -20,6 +20,7 @@ import (
 	"github.com/influxdata/telegraf"
 	"github.com/influxdata/telegraf/config"
 	"github.com/influxdata/telegraf/internal"
+	common_otel "github.com/influxdata/telegraf/plugins/common/opentelemetry"
 	"github.com/influxdata/telegraf/plugins/common/tls"
 	"github.com/influxdata/telegraf/plugins/outputs"
 )
@@ -58,7 +59,7 @@ func (*OpenTelemetry) SampleConfig() string {
 }
 
 func (o *OpenTelemetry) Connect() error {
-	logger := &otelLogger{o.Log}
+	logger := &common_otel.Logger{Logger: o.Log}
 
 	if o.ServiceAddress == "" {
 		o.ServiceAddress = defaultServiceAddress
@@ -151,31 +152,33 @@ func (o *OpenTelemetry) Write(metrics []telegraf.Metric) error {
 
 func (o *OpenTelemetry) sendBatch(metrics []telegraf.Metric) error {
 	batch := o.metricsConverter.NewBatch()
+
+	// Native histogram fields are sent as OpenTelemetry histograms directly
+	histograms := pmetric.NewMetrics()
+	histogramSlice := histograms.ResourceMetrics().AppendEmpty().ScopeMetrics().AppendEmpty().Metrics()
+
 	for _, metric := range metrics {
-		var vType common.InfluxMetricValueType
-		switch metric.Type() {
-		case telegraf.Gauge:
-			vType = common.InfluxMetricValueTypeGauge
-		case telegraf.Untyped:
-			vType = common.InfluxMetricValueTypeUntyped
-		case telegraf.Counter:
-			vType = common.InfluxMetricValueTypeSum
-		case telegraf.Histogram:
-			vType = common.InfluxMetricValueTypeHistogram
-		case telegraf.Summary:
-			vType = common.InfluxMetricValueTypeSummary
-		default:
-			o.Log.Warnf("Unrecognized metric type %v", metric.Type())
+		vType, err := common_otel.ValueType(metric.Type())
+		if err != nil {
+			o.Log.Warn(err)
 			continue
 		}
-		err := batch.AddPoint(metric.Name(), metric.Tags(), metric.Fields(), metric.Time(), vType)
+		fields := common_otel.AppendHistograms(histogramSlice, metric)
+		if len(fields) == 0 {
+			continue
+		}
+		err = batch.AddPoint(metric.Name(), metric.Tags(), fields, metric.Time(), vType)
 		if err != nil {
 			o.Log.Warnf("Failed to add point: %v", err)
 			continue
 		}
 	}
 
-	md := pmetricotlp.NewExportRequestFromMetrics(batch.GetMetrics())
+	converted := batch.GetMetrics()
+	if histogramSlice.Len() > 0 {
+		histograms.ResourceMetrics().MoveAndAppendTo(converted.ResourceMetrics())
+	}
+	md := pmetricotlp.NewExportRequestFromMetrics(converted)
 	if md.Metrics().ResourceMetrics().Len() == 0 {
 		return nil
 	}
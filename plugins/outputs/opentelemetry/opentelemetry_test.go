@@ -19,6 +19,7 @@ import (
 
 	"github.com/influxdata/telegraf"
 	"github.com/influxdata/telegraf/config"
+	"github.com/influxdata/telegraf/metric"
 	"github.com/influxdata/telegraf/testutil"
 )
 
@@ -81,6 +82,95 @@ func TestOpenTelemetry(t *testing.T) {
 	require.JSONEq(t, string(expectJSON), string(gotJSON))
 }
 
+func TestOpenTelemetryNativeHistograms(t *testing.T) {
+	expect := pmetric.NewMetrics()
+	{
+		rm := expect.ResourceMetrics().AppendEmpty()
+		rm.Resource().Attributes().PutStr("attr-key", "attr-val")
+		ms := rm.ScopeMetrics().AppendEmpty().Metrics()
+
+		m := ms.AppendEmpty()
+		m.SetName("http_latency")
+		m.SetEmptyHistogram().SetAggregationTemporality(pmetric.AggregationTemporalityCumulative)
+		dp := m.Histogram().DataPoints().AppendEmpty()
+		dp.Attributes().PutStr("host", "a")
+		dp.SetTimestamp(pcommon.Timestamp(1622848686000000000))
+		dp.SetCount(6)
+		dp.SetSum(12.5)
+		dp.ExplicitBounds().FromRaw([]float64{1, 5})
+		dp.BucketCounts().FromRaw([]uint64{1, 3, 2})
+
+		m = ms.AppendEmpty()
+		m.SetName("size")
+		m.SetEmptyExponentialHistogram().SetAggregationTemporality(pmetric.AggregationTemporalityCumulative)
+		edp := m.ExponentialHistogram().DataPoints().AppendEmpty()
+		edp.Attributes().PutStr("host", "a")
+		edp.SetTimestamp(pcommon.Timestamp(1622848686000000000))
+		edp.SetCount(4)
+		edp.SetSum(9)
+		edp.SetScale(2)
+		edp.SetZeroCount(1)
+		edp.Positive().SetOffset(3)
+		edp.Positive().BucketCounts().FromRaw([]uint64{2, 1})
+	}
+	m := newMockOtelService(t)
+	t.Cleanup(m.Cleanup)
+
+	metricsConverter, err := influx2otel.NewLineProtocolToOtelMetrics(common.NoopLogger{})
+	require.NoError(t, err)
+	plugin := &OpenTelemetry{
+		ServiceAddress:       m.Address(),
+		Timeout:              config.Duration(time.Second),
+		Headers:              map[string]string{"test": "header1"},
+		Attributes:           map[string]string{"attr-key": "attr-val"},
+		metricsConverter:     metricsConverter,
+		grpcClientConn:       m.GrpcClient(),
+		metricsServiceClient: pmetricotlp.NewGRPCClient(m.GrpcClient()),
+		Log:                  testutil.Logger{},
+	}
+
+	input := []telegraf.Metric{
+		testutil.MustMetric(
+			"http",
+			map[string]string{"host": "a"},
+			map[string]interface{}{
+				"latency": metric.Histogram{
+					Count:   6,
+					Sum:     12.5,
+					Buckets: []metric.Bucket{{UpperBound: 1, Count: 1}, {UpperBound: 5, Count: 4}},
+				},
+			},
+			time.Unix(0, 1622848686000000000),
+			telegraf.Histogram,
+		),
+		testutil.MustMetric(
+			"prometheus",
+			map[string]string{"host": "a"},
+			map[string]interface{}{
+				"size": metric.ExponentialHistogram{
+					Count:     4,
+					Sum:       9,
+					Scale:     2,
+					ZeroCount: 1,
+					Positive:  metric.ExponentialBuckets{Offset: 3, Counts: []float64{2, 1}},
+				},
+			},
+			time.Unix(0, 1622848686000000000),
+			telegraf.Histogram,
+		),
+	}
+	require.NoError(t, plugin.Write(input))
+
+	marshaller := pmetric.JSONMarshaler{}
+	expectJSON, err := marshaller.MarshalMetrics(expect)
+	require.NoError(t, err)
+
+	gotJSON, err := marshaller.MarshalMetrics(m.GotMetrics())
+	require.NoError(t, err)
+
+	require.JSONEq(t, string(expectJSON), string(gotJSON))
+}
+
 var _ pmetricotlp.GRPCServer = (*mockOtelService)(nil)
 
 type mockOtelService struct {
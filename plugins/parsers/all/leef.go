@@ -0,0 +1,5 @@
+//go:build !custom || parsers || parsers.leef
+
+package all
+
+import _ "github.com/influxdata/telegraf/plugins/parsers/leef" // register plugin
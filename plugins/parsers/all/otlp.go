@@ -0,0 +1,5 @@
+//go:build !custom || parsers || parsers.otlp
+
+package all
+
+import _ "github.com/influxdata/telegraf/plugins/parsers/otlp" // register plugin
@@ -0,0 +1,5 @@
+//go:build !custom || parsers || parsers.syslog
+
+package all
+
+import _ "github.com/influxdata/telegraf/plugins/parsers/syslog" // register plugin
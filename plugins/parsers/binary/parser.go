@@ -11,9 +11,19 @@ import (
 
 	"github.com/influxdata/telegraf"
 	"github.com/influxdata/telegraf/internal"
+	common_binary "github.com/influxdata/telegraf/plugins/common/binary"
 	"github.com/influxdata/telegraf/plugins/parsers"
 )
 
+// Layout definitions shared with the binary serializer
+type (
+	Config     = common_binary.Config
+	Filter     = common_binary.Filter
+	BinaryPart = common_binary.BinaryPart
+	Entry      = common_binary.Entry
+	Condition  = common_binary.Condition
+)
+
 type Parser struct {
 	AllowNoMatch bool            `toml:"allow_no_match"`
 	Endianess    string          `toml:"endianess" deprecated:"1.27.4;1.35.0;use 'endianness' instead"`
@@ -62,7 +72,13 @@ func (p *Parser) Init() error {
 		return errors.New("no configuration given")
 	}
 	for i, cfg := range p.Configs {
-		if err := cfg.preprocess(p.metricName); err != nil {
+		if !cfg.HasMeasurement() && cfg.MetricName == "" {
+			if p.metricName == "" {
+				return fmt.Errorf("config %d invalid: no metric name given", i)
+			}
+			cfg.MetricName = p.metricName
+		}
+		if err := cfg.Preprocess(); err != nil {
 			return fmt.Errorf("config %d invalid: %w", i, err)
 		}
 		p.Configs[i] = cfg
@@ -101,14 +117,14 @@ func (p *Parser) Parse(data []byte) ([]telegraf.Metric, error) {
 	metrics := make([]telegraf.Metric, 0)
 	for i, cfg := range p.Configs {
 		// Apply the filter and see if we should match this
-		if !cfg.matches(buf) {
+		if !cfg.Matches(buf) {
 			p.Log.Debugf("ignoring data in config %d", i)
 			continue
 		}
 		matches++
 
 		// Collect the metric
-		m, err := cfg.collect(buf, p.converter, t)
+		m, err := cfg.Collect(buf, p.converter, t)
 		if err != nil {
 			return nil, err
 		}
@@ -149,65 +165,3 @@ func init() {
 		},
 	)
 }
-
-func extractPart(in []byte, offset, bits uint64) ([]byte, error) {
-	inLen := uint64(len(in))
-
-	start := offset / 8
-	bitend := offset%8 + bits
-	length := bitend / 8
-	if bitend%8 != 0 {
-		length++
-	}
-
-	if start+length > inLen {
-		return nil, fmt.Errorf("out-of-bounds @%d with %d bits", offset, bits)
-	}
-
-	var out []byte
-	out = append(out, in[start:start+length]...)
-
-	if offset%8 != 0 {
-		// Mask the start-byte with the non-aligned bit-mask
-		startmask := (byte(1) << (8 - offset%8)) - 1
-		out[0] = out[0] & startmask
-	}
-
-	if bitend%8 == 0 {
-		// The end is aligned to byte-boundaries
-		return out, nil
-	}
-
-	shift := 8 - bitend%8
-	carryshift := bitend % 8
-
-	// We need to shift right in case of not ending at a byte boundary
-	// to make the bits right aligned.
-	// Carry over the bits from the byte left to fill in...
-	var carry byte
-	for i, x := range out {
-		out[i] = (x >> shift) | carry
-		carry = x << carryshift
-	}
-
-	if bits%8 == 0 {
-		// Avoid an empty leading byte
-		return out[1:], nil
-	}
-
-	return out, nil
-}
-
-func bitsForType(t string) (uint64, error) {
-	switch t {
-	case "uint8", "int8":
-		return 8, nil
-	case "uint16", "int16":
-		return 16, nil
-	case "uint32", "int32", "float32":
-		return 32, nil
-	case "uint64", "int64", "float64":
-		return 64, nil
-	}
-	return 0, fmt.Errorf("cannot determine length for type %q", t)
-}
@@ -0,0 +1,157 @@
+package cef
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/metric"
+	"github.com/influxdata/telegraf/plugins/common/siem"
+	"github.com/influxdata/telegraf/plugins/parsers"
+)
+
+// Names of the header tags in the order of the CEF header
+var headerTags = []string{
+	"version",
+	"device_vendor",
+	"device_product",
+	"device_version",
+	"device_event_class_id",
+	"name",
+	"severity",
+}
+
+// Extension keys consist of alphanumeric characters and a few separators
+var keyRe = regexp.MustCompile(`^[A-Za-z0-9_.\[\]-]+$`)
+
+// Parser decodes ArcSight Common Event Format (CEF) messages, one message per
+// line, into metrics
+type Parser struct {
+	TimestampKey string            `toml:"cef_timestamp_key"`
+	DefaultTags  map[string]string `toml:"-"`
+
+	metricName string
+}
+
+func (p *Parser) Init() error {
+	if p.TimestampKey == "" {
+		p.TimestampKey = "rt"
+	}
+	return nil
+}
+
+func (p *Parser) Parse(buf []byte) ([]telegraf.Metric, error) {
+	return siem.ParseLines(buf, p.parse)
+}
+
+func (p *Parser) ParseLine(line string) (telegraf.Metric, error) {
+	return siem.ParseLine(line, p.parse)
+}
+
+func (p *Parser) SetDefaultTags(tags map[string]string) {
+	p.DefaultTags = tags
+}
+
+func (p *Parser) parse(line string) (telegraf.Metric, error) {
+	// Skip any prefix such as a syslog header
+	start := strings.Index(line, "CEF:")
+	if start < 0 {
+		return nil, fmt.Errorf("no CEF header found in %q", line)
+	}
+
+	headers, extension, err := siem.SplitHeader(line[start+len("CEF:"):], len(headerTags))
+	if err != nil {
+		return nil, fmt.Errorf("parsing %q failed: %w", line, err)
+	}
+	tags := siem.Tags(headerTags, headers, p.DefaultTags)
+
+	fields := parseExtension(extension)
+	if len(fields) == 0 {
+		// Metrics require at least one field
+		fields["extension"] = ""
+	}
+
+	t := time.Now()
+	if v, found := fields[p.TimestampKey]; found {
+		if ts, err := siem.ParseTime(v.(string)); err == nil {
+			t = ts
+		}
+	}
+
+	return metric.New(p.metricName, tags, fields, t), nil
+}
+
+// parseExtension parses the space-separated key=value pairs of the extension.
+// Values may contain spaces so a value ends at the start of the next key.
+func parseExtension(s string) map[string]interface{} {
+	type pair struct {
+		keyStart int
+		sep      int
+	}
+
+	// Locate all unescaped equal signs preceded by a valid key
+	var pairs []pair
+	for i := 0; i < len(s); i++ {
+		switch s[i] {
+		case '\\':
+			i++
+		case '=':
+			keyStart := strings.LastIndexByte(s[:i], ' ') + 1
+			if len(pairs) > 0 && keyStart <= pairs[len(pairs)-1].sep {
+				continue
+			}
+			if !keyRe.MatchString(s[keyStart:i]) {
+				continue
+			}
+			pairs = append(pairs, pair{keyStart: keyStart, sep: i})
+		}
+	}
+
+	fields := make(map[string]interface{}, len(pairs))
+	for i, p := range pairs {
+		end := len(s)
+		if i+1 < len(pairs) {
+			end = pairs[i+1].keyStart
+		}
+		fields[s[p.keyStart:p.sep]] = unescapeValue(strings.TrimRight(s[p.sep+1:end], " "))
+	}
+	return fields
+}
+
+func unescapeValue(s string) string {
+	if !strings.Contains(s, `\`) {
+		return s
+	}
+
+	var b strings.Builder
+	b.Grow(len(s))
+	for i := 0; i < len(s); i++ {
+		if s[i] != '\\' || i+1 == len(s) {
+			b.WriteByte(s[i])
+			continue
+		}
+		i++
+		switch s[i] {
+		case 'n':
+			b.WriteByte('\n')
+		case 'r':
+			b.WriteByte('\r')
+		case '=', '\\', '|':
+			b.WriteByte(s[i])
+		default:
+			b.WriteByte('\\')
+			b.WriteByte(s[i])
+		}
+	}
+	return b.String()
+}
+
+func init() {
+	parsers.Add("cef",
+		func(defaultMetricName string) telegraf.Parser {
+			return &Parser{metricName: defaultMetricName}
+		},
+	)
+}
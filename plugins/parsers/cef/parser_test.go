@@ -0,0 +1,156 @@
+package cef
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/metric"
+	"github.com/influxdata/telegraf/testutil"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected []telegraf.Metric
+	}{
+		{
+			name: "simple",
+			input: `CEF:0|Security|threatmanager|1.0|100|worm successfully stopped|10|` +
+				`src=10.0.0.1 dst=2.1.2.2 spt=1232 rt=1700000000123`,
+			expected: []telegraf.Metric{
+				metric.New(
+					"cef",
+					map[string]string{
+						"version":               "0",
+						"device_vendor":         "Security",
+						"device_product":        "threatmanager",
+						"device_version":        "1.0",
+						"device_event_class_id": "100",
+						"name":                  "worm successfully stopped",
+						"severity":              "10",
+					},
+					map[string]interface{}{
+						"src": "10.0.0.1",
+						"dst": "2.1.2.2",
+						"spt": "1232",
+						"rt":  "1700000000123",
+					},
+					time.UnixMilli(1700000000123),
+				),
+			},
+		},
+		{
+			name: "escaping and spaces",
+			input: `Sep 19 08:26:10 host CEF:0|Vendor\|Inc|Product\\X|1.0|42|Detected a threat. No action needed.|Low|` +
+				`msg=Detected a threat.\nNo action \= needed. fname=C:\\temp\\a b.exe request=http://x?a=b rt=Nov 14 2023 22:13:20`,
+			expected: []telegraf.Metric{
+				metric.New(
+					"cef",
+					map[string]string{
+						"version":               "0",
+						"device_vendor":         "Vendor|Inc",
+						"device_product":        `Product\X`,
+						"device_version":        "1.0",
+						"device_event_class_id": "42",
+						"name":                  "Detected a threat. No action needed.",
+						"severity":              "Low",
+					},
+					map[string]interface{}{
+						"msg":     "Detected a threat.\nNo action = needed.",
+						"fname":   `C:\temp\a b.exe`,
+						"request": "http://x?a=b",
+						"rt":      "Nov 14 2023 22:13:20",
+					},
+					time.Date(2023, time.November, 14, 22, 13, 20, 0, time.UTC),
+				),
+			},
+		},
+		{
+			name:  "multiple lines without extension",
+			input: "CEF:0|a|b|c|d|e|1|\n\nCEF:1|a|b|c|d|e|2|act=blocked rt=1000\n",
+			expected: []telegraf.Metric{
+				metric.New(
+					"cef",
+					map[string]string{
+						"version":               "0",
+						"device_vendor":         "a",
+						"device_product":        "b",
+						"device_version":        "c",
+						"device_event_class_id": "d",
+						"name":                  "e",
+						"severity":              "1",
+					},
+					map[string]interface{}{"extension": ""},
+					time.Unix(0, 0),
+				),
+				metric.New(
+					"cef",
+					map[string]string{
+						"version":               "1",
+						"device_vendor":         "a",
+						"device_product":        "b",
+						"device_version":        "c",
+						"device_event_class_id": "d",
+						"name":                  "e",
+						"severity":              "2",
+					},
+					map[string]interface{}{"act": "blocked", "rt": "1000"},
+					time.Unix(1, 0),
+				),
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			parser := &Parser{metricName: "cef"}
+			require.NoError(t, parser.Init())
+
+			actual, err := parser.Parse([]byte(tt.input))
+			require.NoError(t, err)
+
+			// Messages without timestamp get the current time
+			for i, m := range actual {
+				if _, found := m.GetField("rt"); !found {
+					require.WithinDuration(t, time.Now(), m.Time(), time.Minute)
+					m.SetTime(tt.expected[i].Time())
+				}
+			}
+			testutil.RequireMetricsEqual(t, tt.expected, actual)
+		})
+	}
+}
+
+func TestParseDefaultTags(t *testing.T) {
+	parser := &Parser{metricName: "cef"}
+	require.NoError(t, parser.Init())
+	parser.SetDefaultTags(map[string]string{"severity": "ignored", "source": "firewall"})
+
+	m, err := parser.ParseLine(`CEF:0|a|b|c|d|e|7|act=blocked`)
+	require.NoError(t, err)
+	require.Equal(t, map[string]string{
+		"version":               "0",
+		"device_vendor":         "a",
+		"device_product":        "b",
+		"device_version":        "c",
+		"device_event_class_id": "d",
+		"name":                  "e",
+		"severity":              "7",
+		"source":                "firewall",
+	}, m.Tags())
+}
+
+func TestParseInvalid(t *testing.T) {
+	parser := &Parser{metricName: "cef"}
+	require.NoError(t, parser.Init())
+
+	_, err := parser.Parse([]byte("not a CEF message"))
+	require.ErrorContains(t, err, "no CEF header found")
+
+	_, err = parser.Parse([]byte("CEF:0|a|b|c"))
+	require.ErrorContains(t, err, "expected 7 header fields but found 3")
+}
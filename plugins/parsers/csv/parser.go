@@ -264,7 +264,88 @@ func (p *Parser) ParseLine(line string) (telegraf.Metric, error) {
 	return nil, nil
 }
 
+// ParseStream parses the CSV data read from the given reader record by record
+// and calls the given function for each metric.
+func (p *Parser) ParseStream(r io.Reader, fn func(telegraf.Metric) error) error {
+	// Reset the parser according to the specified mode
+	if p.ResetMode == "always" {
+		p.Reset()
+	}
+
+	// Replacing an invalid delimiter requires the whole data
+	if p.invalidDelimiter {
+		buf, err := io.ReadAll(r)
+		if err != nil {
+			return err
+		}
+		metrics, err := p.Parse(buf)
+		for _, m := range metrics {
+			if err := fn(m); err != nil {
+				return err
+			}
+		}
+		return err
+	}
+
+	csvReader, err := p.readPreamble(r)
+	if err != nil {
+		if errors.Is(err, io.EOF) {
+			return parsers.ErrEOF
+		}
+		return err
+	}
+
+	for {
+		record, err := csvReader.Read()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		m, err := p.parseRecord(record)
+		if err != nil {
+			if p.SkipErrors {
+				p.Log.Debugf("Parsing error: %v", err)
+				continue
+			}
+			return err
+		}
+		if err := fn(m); err != nil {
+			return err
+		}
+	}
+}
+
 func parseCSV(p *Parser, r io.Reader) ([]telegraf.Metric, error) {
+	csvReader, err := p.readPreamble(r)
+	if err != nil {
+		return nil, err
+	}
+
+	table, err := csvReader.ReadAll()
+	if err != nil {
+		return nil, err
+	}
+
+	metrics := make([]telegraf.Metric, 0)
+	for _, record := range table {
+		m, err := p.parseRecord(record)
+		if err != nil {
+			if p.SkipErrors {
+				p.Log.Debugf("Parsing error: %v", err)
+				continue
+			}
+			return metrics, err
+		}
+		metrics = append(metrics, m)
+	}
+	return metrics, nil
+}
+
+// readPreamble consumes the rows to skip, the metadata and the header rows
+// still remaining and returns a reader for the data records.
+func (p *Parser) readPreamble(r io.Reader) (*csv.Reader, error) {
 	lineReader := bufio.NewReader(r)
 	// skip first rows
 	for p.remainingSkipRows > 0 {
@@ -320,24 +401,7 @@ func parseCSV(p *Parser, r io.Reader) ([]telegraf.Metric, error) {
 		p.gotColumnNames = true
 	}
 
-	table, err := csvReader.ReadAll()
-	if err != nil {
-		return nil, err
-	}
-
-	metrics := make([]telegraf.Metric, 0)
-	for _, record := range table {
-		m, err := p.parseRecord(record)
-		if err != nil {
-			if p.SkipErrors {
-				p.Log.Debugf("Parsing error: %v", err)
-				continue
-			}
-			return metrics, err
-		}
-		metrics = append(metrics, m)
-	}
-	return metrics, nil
+	return csvReader, nil
 }
 
 func (p *Parser) parseRecord(record []string) (telegraf.Metric, error) {
@@ -1031,6 +1031,60 @@ timestamp,type,name,status
 	require.Equal(t, expectedTags[1], m.Tags())
 }
 
+func TestParseStreamReader(t *testing.T) {
+	testCSV := `garbage nonsense that needs be skipped
+# version= 1.0
+timestamp,type,name,status
+2020-11-23T08:19:27+10:00,Reader,R002,1
+2020-11-04T13:23:04+10:00,Reader,R031,invalid
+2020-11-04T13:29:47+10:00,Coordinator,C001,0
+`
+	newParser := func() *Parser {
+		p := &Parser{
+			MetricName:         "csv",
+			HeaderRowCount:     1,
+			SkipRows:           1,
+			MetadataRows:       1,
+			TagColumns:         []string{"type"},
+			ColumnTypes:        []string{"string", "string", "string", "int"},
+			MetadataSeparators: []string{"="},
+			MetadataTrimSet:    " #",
+			TimestampColumn:    "timestamp",
+			TimestampFormat:    "2006-01-02T15:04:05Z07:00",
+			SkipErrors:         true,
+			Log:                testutil.Logger{},
+		}
+		require.NoError(t, p.Init())
+		return p
+	}
+
+	// Streaming must produce the same metrics as parsing at once
+	expected, err := newParser().Parse([]byte(testCSV))
+	require.NoError(t, err)
+	require.Len(t, expected, 2)
+
+	var actual []telegraf.Metric
+	require.NoError(t, newParser().ParseStream(strings.NewReader(testCSV), func(m telegraf.Metric) error {
+		actual = append(actual, m)
+		return nil
+	}))
+	testutil.RequireMetricsEqual(t, expected, actual)
+
+	// Errors of the callback abort parsing
+	errStop := errors.New("stop")
+	var calls int
+	err = newParser().ParseStream(strings.NewReader(testCSV), func(telegraf.Metric) error {
+		calls++
+		return errStop
+	})
+	require.ErrorIs(t, err, errStop)
+	require.Equal(t, 1, calls)
+
+	// Empty data results in an EOF error as for parsing at once
+	err = newParser().ParseStream(strings.NewReader(""), func(telegraf.Metric) error { return nil })
+	require.ErrorIs(t, err, parsers.ErrEOF)
+}
+
 func TestOverwriteDefaultTagsAndMetaDataTags(t *testing.T) {
 	csv := []byte(`second=orange
 fourth=plain
@@ -127,6 +127,45 @@ func (p *Parser) ParseLine(line string) (telegraf.Metric, error) {
 	return metrics[0], nil
 }
 
+// ParseStream parses the line protocol read from the given reader line by
+// line and calls the given function for each metric.
+func (p *Parser) ParseStream(r io.Reader, fn func(telegraf.Metric) error) error {
+	// The series machine is not available for streams
+	if p.Type == "series" {
+		buf, err := io.ReadAll(r)
+		if err != nil {
+			return err
+		}
+		metrics, err := p.Parse(buf)
+		if err != nil {
+			return err
+		}
+		for _, m := range metrics {
+			if err := fn(m); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	sp := NewStreamParser(r)
+	sp.SetTimeFunc(p.handler.timeFunc)
+	sp.SetTimePrecision(p.handler.timePrecision)
+	for {
+		m, err := sp.Next()
+		if errors.Is(err, EOF) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		p.applyDefaultTagsSingle(m)
+		if err := fn(m); err != nil {
+			return err
+		}
+	}
+}
+
 func (p *Parser) SetDefaultTags(tags map[string]string) {
 	p.DefaultTags = tags
 }
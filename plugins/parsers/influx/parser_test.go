@@ -752,6 +752,44 @@ func TestStreamParser(t *testing.T) {
 	}
 }
 
+func TestParserParseStream(t *testing.T) {
+	input := "cpu value=1 1234567890\ncpu,host=a value=2 1234567891\n"
+	expected := []telegraf.Metric{
+		metric.New(
+			"cpu",
+			map[string]string{"host": "localhost"},
+			map[string]any{"value": float64(1)},
+			time.Unix(1234567890, 0),
+		),
+		metric.New(
+			"cpu",
+			map[string]string{"host": "a"},
+			map[string]any{"value": float64(2)},
+			time.Unix(1234567891, 0),
+		),
+	}
+
+	parser := Parser{InfluxTimestampPrecision: config.Duration(time.Second)}
+	require.NoError(t, parser.Init())
+	parser.SetDefaultTags(map[string]string{"host": "localhost"})
+
+	var actual []telegraf.Metric
+	require.NoError(t, parser.ParseStream(strings.NewReader(input), func(m telegraf.Metric) error {
+		actual = append(actual, m)
+		return nil
+	}))
+	testutil.RequireMetricsEqual(t, expected, actual)
+
+	// Metrics before an invalid line are emitted
+	actual = nil
+	err := parser.ParseStream(strings.NewReader(input+"cpu value=\n"), func(m telegraf.Metric) error {
+		actual = append(actual, m)
+		return nil
+	})
+	require.ErrorContains(t, err, "metric parse error")
+	testutil.RequireMetricsEqual(t, expected, actual)
+}
+
 func TestSeriesParser(t *testing.T) {
 	var tests = []struct {
 		name     string
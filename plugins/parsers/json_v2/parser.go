@@ -1,6 +1,8 @@
 package json_v2
 
 import (
+	"bufio"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
@@ -21,6 +23,7 @@ import (
 // Parser adheres to the parser interface, contains the parser configuration, and data required to parse JSON
 type Parser struct {
 	Configs           []Config          `toml:"json_v2"`
+	StreamArrays      bool              `toml:"json_v2_stream_arrays"`
 	DefaultMetricName string            `toml:"-"`
 	DefaultTags       map[string]string `toml:"-"`
 	Log               telegraf.Logger   `toml:"-"`
@@ -131,6 +134,79 @@ func (p *Parser) Parse(input []byte) ([]telegraf.Metric, error) {
 	return p.parseCriticalPath(input)
 }
 
+// ParseStream parses the JSON data read from the given reader and calls the
+// given function for each metric. If streaming of arrays is enabled and the
+// data is a top-level array, each element is parsed as a separate JSON
+// document without reading the whole array into memory. Otherwise the data is
+// parsed at once.
+func (p *Parser) ParseStream(r io.Reader, fn func(telegraf.Metric) error) error {
+	body, _ := utfbom.Skip(r)
+	reader := bufio.NewReader(body)
+
+	// Check for a top-level array by peeking at the first non-whitespace byte
+	var isArray bool
+	if p.StreamArrays {
+		for {
+			b, err := reader.ReadByte()
+			if err != nil {
+				if errors.Is(err, io.EOF) {
+					break
+				}
+				return err
+			}
+			if b == ' ' || b == '\t' || b == '\r' || b == '\n' {
+				continue
+			}
+			isArray = b == '['
+			if err := reader.UnreadByte(); err != nil {
+				return err
+			}
+			break
+		}
+	}
+
+	if !isArray {
+		input, err := io.ReadAll(reader)
+		if err != nil {
+			return err
+		}
+		metrics, err := p.Parse(input)
+		if err != nil {
+			return err
+		}
+		for _, m := range metrics {
+			if err := fn(m); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	decoder := json.NewDecoder(reader)
+	if _, err := decoder.Token(); err != nil {
+		return fmt.Errorf("reading array start failed: %w", err)
+	}
+	for decoder.More() {
+		var element json.RawMessage
+		if err := decoder.Decode(&element); err != nil {
+			return fmt.Errorf("decoding array element failed: %w", err)
+		}
+		metrics, err := p.Parse(element)
+		if err != nil {
+			return err
+		}
+		for _, m := range metrics {
+			if err := fn(m); err != nil {
+				return err
+			}
+		}
+	}
+	if _, err := decoder.Token(); err != nil {
+		return fmt.Errorf("reading array end failed: %w", err)
+	}
+	return nil
+}
+
 func (p *Parser) parseCriticalPath(input []byte) ([]telegraf.Metric, error) {
 	p.parseMutex.Lock()
 	defer p.parseMutex.Unlock()
@@ -0,0 +1,265 @@
+package leef
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/metric"
+	"github.com/influxdata/telegraf/plugins/common/siem"
+	"github.com/influxdata/telegraf/plugins/parsers"
+)
+
+// Names of the header tags in the order of the LEEF header
+var headerTags = []string{
+	"version",
+	"vendor",
+	"product",
+	"product_version",
+	"event_id",
+}
+
+// Parser decodes IBM Log Event Extended Format (LEEF) messages, one message
+// per line, into metrics
+type Parser struct {
+	TimestampKey string            `toml:"leef_timestamp_key"`
+	DefaultTags  map[string]string `toml:"-"`
+
+	metricName string
+}
+
+func (p *Parser) Init() error {
+	if p.TimestampKey == "" {
+		p.TimestampKey = "devTime"
+	}
+	return nil
+}
+
+func (p *Parser) Parse(buf []byte) ([]telegraf.Metric, error) {
+	return siem.ParseLines(buf, p.parse)
+}
+
+func (p *Parser) ParseLine(line string) (telegraf.Metric, error) {
+	return siem.ParseLine(line, p.parse)
+}
+
+func (p *Parser) SetDefaultTags(tags map[string]string) {
+	p.DefaultTags = tags
+}
+
+func (p *Parser) parse(line string) (telegraf.Metric, error) {
+	// Skip any prefix such as a syslog header
+	start := strings.Index(line, "LEEF:")
+	if start < 0 {
+		return nil, fmt.Errorf("no LEEF header found in %q", line)
+	}
+	s := line[start+len("LEEF:"):]
+
+	headers, s, err := siem.SplitHeader(s, len(headerTags))
+	if err != nil {
+		return nil, fmt.Errorf("parsing %q failed: %w", line, err)
+	}
+
+	// LEEF 2.0 allows to specify the attribute delimiter in an optional
+	// header field, LEEF 1.0 always uses tabs. The delimiter field is missing
+	// if the remainder does not contain a pipe or the text up to the pipe is
+	// an attribute.
+	delimiter := "\t"
+	if strings.HasPrefix(headers[0], "2") {
+		if field, remainder, found := strings.Cut(s, "|"); found && !strings.Contains(field, "=") {
+			s = remainder
+			if field = strings.TrimSpace(field); field != "" {
+				if delimiter, err = parseDelimiter(field); err != nil {
+					return nil, fmt.Errorf("parsing %q failed: %w", line, err)
+				}
+			}
+		}
+	}
+	tags := siem.Tags(headerTags, headers, p.DefaultTags)
+
+	fields := make(map[string]interface{})
+	for _, attr := range strings.Split(s, delimiter) {
+		key, value, found := strings.Cut(attr, "=")
+		key = strings.TrimSpace(key)
+		if !found || key == "" {
+			continue
+		}
+		fields[key] = value
+	}
+	if len(fields) == 0 {
+		// Metrics require at least one field
+		fields["attributes"] = ""
+	}
+
+	t := time.Now()
+	if v, found := fields[p.TimestampKey]; found {
+		if ts, err := parseTime(v.(string), fields["devTimeFormat"]); err == nil {
+			t = ts
+		}
+	}
+
+	return metric.New(p.metricName, tags, fields, t), nil
+}
+
+// parseDelimiter parses the delimiter given as single character or as
+// hexadecimal character code prefixed by "x" or "0x"
+func parseDelimiter(s string) (string, error) {
+	if len(s) == 1 {
+		return s, nil
+	}
+	var code string
+	switch lower := strings.ToLower(s); {
+	case strings.HasPrefix(lower, "0x"):
+		code = lower[2:]
+	case strings.HasPrefix(lower, "x"):
+		code = lower[1:]
+	default:
+		return "", fmt.Errorf("invalid delimiter %q", s)
+	}
+	c, err := strconv.ParseUint(code, 16, 8)
+	if err != nil {
+		return "", fmt.Errorf("invalid delimiter %q: %w", s, err)
+	}
+	return string(rune(c)), nil
+}
+
+// parseTime parses the time using the custom format given by the
+// 'devTimeFormat' attribute if any, falling back to milliseconds since epoch
+// or one of the default layouts of the LEEF specification
+func parseTime(s string, format interface{}) (time.Time, error) {
+	if f, ok := format.(string); ok && f != "" {
+		layout, err := convertLayout(f)
+		if err != nil {
+			return time.Time{}, err
+		}
+		if t, err := time.Parse(layout, s); err == nil {
+			return t, nil
+		}
+	}
+	return siem.ParseTime(s)
+}
+
+// convertLayout converts the Java SimpleDateFormat pattern used by
+// 'devTimeFormat' to a Go time layout
+func convertLayout(format string) (string, error) {
+	var layout strings.Builder
+	for i := 0; i < len(format); {
+		c := format[i]
+
+		// Quoted literal text with two single quotes representing a quote
+		if c == '\'' {
+			end := i + 1
+			for ; end < len(format); end++ {
+				if format[end] == '\'' {
+					if end+1 < len(format) && format[end+1] == '\'' {
+						layout.WriteByte('\'')
+						end++
+						continue
+					}
+					break
+				}
+				layout.WriteByte(format[end])
+			}
+			if end == i+1 {
+				layout.WriteByte('\'')
+			}
+			i = end + 1
+			continue
+		}
+
+		if (c < 'a' || c > 'z') && (c < 'A' || c > 'Z') {
+			layout.WriteByte(c)
+			i++
+			continue
+		}
+
+		// Count the repetitions of the pattern letter
+		n := 1
+		for i+n < len(format) && format[i+n] == c {
+			n++
+		}
+		i += n
+
+		switch c {
+		case 'y':
+			if n == 2 {
+				layout.WriteString("06")
+			} else {
+				layout.WriteString("2006")
+			}
+		case 'M':
+			switch {
+			case n >= 4:
+				layout.WriteString("January")
+			case n == 3:
+				layout.WriteString("Jan")
+			case n == 2:
+				layout.WriteString("01")
+			default:
+				layout.WriteString("1")
+			}
+		case 'd':
+			if n >= 2 {
+				layout.WriteString("02")
+			} else {
+				layout.WriteString("2")
+			}
+		case 'E':
+			if n >= 4 {
+				layout.WriteString("Monday")
+			} else {
+				layout.WriteString("Mon")
+			}
+		case 'H':
+			layout.WriteString("15")
+		case 'h':
+			if n >= 2 {
+				layout.WriteString("03")
+			} else {
+				layout.WriteString("3")
+			}
+		case 'm':
+			if n >= 2 {
+				layout.WriteString("04")
+			} else {
+				layout.WriteString("4")
+			}
+		case 's':
+			if n >= 2 {
+				layout.WriteString("05")
+			} else {
+				layout.WriteString("5")
+			}
+		case 'S':
+			layout.WriteString(strings.Repeat("0", n))
+		case 'a':
+			layout.WriteString("PM")
+		case 'z':
+			layout.WriteString("MST")
+		case 'Z':
+			layout.WriteString("-0700")
+		case 'X':
+			switch n {
+			case 1:
+				layout.WriteString("Z07")
+			case 2:
+				layout.WriteString("Z0700")
+			default:
+				layout.WriteString("Z07:00")
+			}
+		default:
+			return "", fmt.Errorf("unsupported pattern letter %q in time format %q", c, format)
+		}
+	}
+	return layout.String(), nil
+}
+
+func init() {
+	parsers.Add("leef",
+		func(defaultMetricName string) telegraf.Parser {
+			return &Parser{metricName: defaultMetricName}
+		},
+	)
+}
@@ -0,0 +1,239 @@
+package leef
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/metric"
+	"github.com/influxdata/telegraf/testutil"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected telegraf.Metric
+	}{
+		{
+			name:  "LEEF 1.0",
+			input: "LEEF:1.0|Microsoft|MSExchange|4.0 SP1|15345|src=192.0.2.0\tdst=172.50.123.1\tsev=5\tcat=anomaly\tmsg=this is a message\tdevTime=1700000000123",
+			expected: metric.New(
+				"leef",
+				map[string]string{
+					"version":         "1.0",
+					"vendor":          "Microsoft",
+					"product":         "MSExchange",
+					"product_version": "4.0 SP1",
+					"event_id":        "15345",
+				},
+				map[string]interface{}{
+					"src":     "192.0.2.0",
+					"dst":     "172.50.123.1",
+					"sev":     "5",
+					"cat":     "anomaly",
+					"msg":     "this is a message",
+					"devTime": "1700000000123",
+				},
+				time.UnixMilli(1700000000123),
+			),
+		},
+		{
+			name:  "LEEF 2.0 with character delimiter and syslog prefix",
+			input: "<13>Nov 14 22:13:20 host LEEF:2.0|Lancope|StealthWatch|1.0|41|^|src=10.0.1.8^dst=10.0.0.5^devTime=Nov 14 2023 22:13:20",
+			expected: metric.New(
+				"leef",
+				map[string]string{
+					"version":         "2.0",
+					"vendor":          "Lancope",
+					"product":         "StealthWatch",
+					"product_version": "1.0",
+					"event_id":        "41",
+				},
+				map[string]interface{}{
+					"src":     "10.0.1.8",
+					"dst":     "10.0.0.5",
+					"devTime": "Nov 14 2023 22:13:20",
+				},
+				time.Date(2023, time.November, 14, 22, 13, 20, 0, time.UTC),
+			),
+		},
+		{
+			name:  "LEEF 2.0 with hex delimiter and escaped header",
+			input: "LEEF:2.0|Ven\\|dor|Product|1.0|41|0x7C|src=10.0.1.8|devTime=1000",
+			expected: metric.New(
+				"leef",
+				map[string]string{
+					"version":         "2.0",
+					"vendor":          "Ven|dor",
+					"product":         "Product",
+					"product_version": "1.0",
+					"event_id":        "41",
+				},
+				map[string]interface{}{
+					"src":     "10.0.1.8",
+					"devTime": "1000",
+				},
+				time.UnixMilli(1000),
+			),
+		},
+		{
+			name:  "LEEF 2.0 with default delimiter",
+			input: "LEEF:2.0|Vendor|Product|1.0|41||src=10.0.1.8\tdevTime=1000",
+			expected: metric.New(
+				"leef",
+				map[string]string{
+					"version":         "2.0",
+					"vendor":          "Vendor",
+					"product":         "Product",
+					"product_version": "1.0",
+					"event_id":        "41",
+				},
+				map[string]interface{}{
+					"src":     "10.0.1.8",
+					"devTime": "1000",
+				},
+				time.UnixMilli(1000),
+			),
+		},
+		{
+			name:  "LEEF 2.0 without delimiter field",
+			input: "LEEF:2.0|Vendor|Product|1.0|41|src=10.0.1.8\tdevTime=1000",
+			expected: metric.New(
+				"leef",
+				map[string]string{
+					"version":         "2.0",
+					"vendor":          "Vendor",
+					"product":         "Product",
+					"product_version": "1.0",
+					"event_id":        "41",
+				},
+				map[string]interface{}{
+					"src":     "10.0.1.8",
+					"devTime": "1000",
+				},
+				time.UnixMilli(1000),
+			),
+		},
+		{
+			name:  "LEEF 2.0 without delimiter field and pipe in attribute",
+			input: "LEEF:2.0|Vendor|Product|1.0|41|msg=a|b\tdevTime=1000",
+			expected: metric.New(
+				"leef",
+				map[string]string{
+					"version":         "2.0",
+					"vendor":          "Vendor",
+					"product":         "Product",
+					"product_version": "1.0",
+					"event_id":        "41",
+				},
+				map[string]interface{}{
+					"msg":     "a|b",
+					"devTime": "1000",
+				},
+				time.UnixMilli(1000),
+			),
+		},
+		{
+			name:  "LEEF 1.0 with custom time format",
+			input: "LEEF:1.0|Vendor|Product|1.0|41|devTime=2023-11-14 22:13:20.123 +0100\tdevTimeFormat=yyyy-MM-dd HH:mm:ss.SSS Z",
+			expected: metric.New(
+				"leef",
+				map[string]string{
+					"version":         "1.0",
+					"vendor":          "Vendor",
+					"product":         "Product",
+					"product_version": "1.0",
+					"event_id":        "41",
+				},
+				map[string]interface{}{
+					"devTime":       "2023-11-14 22:13:20.123 +0100",
+					"devTimeFormat": "yyyy-MM-dd HH:mm:ss.SSS Z",
+				},
+				time.Date(2023, time.November, 14, 21, 13, 20, 123000000, time.UTC),
+			),
+		},
+		{
+			name:  "LEEF 2.0 with custom time format and quoted text",
+			input: "LEEF:2.0|Vendor|Product|1.0|41|^|devTime=14/11/23 at 10:13:20 PM^devTimeFormat=dd/MM/yy 'at' hh:mm:ss a",
+			expected: metric.New(
+				"leef",
+				map[string]string{
+					"version":         "2.0",
+					"vendor":          "Vendor",
+					"product":         "Product",
+					"product_version": "1.0",
+					"event_id":        "41",
+				},
+				map[string]interface{}{
+					"devTime":       "14/11/23 at 10:13:20 PM",
+					"devTimeFormat": "dd/MM/yy 'at' hh:mm:ss a",
+				},
+				time.Date(2023, time.November, 14, 22, 13, 20, 0, time.UTC),
+			),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			parser := &Parser{metricName: "leef"}
+			require.NoError(t, parser.Init())
+
+			actual, err := parser.ParseLine(tt.input)
+			require.NoError(t, err)
+			testutil.RequireMetricsEqual(t, []telegraf.Metric{tt.expected}, []telegraf.Metric{actual})
+		})
+	}
+}
+
+func TestParseInvalid(t *testing.T) {
+	parser := &Parser{metricName: "leef"}
+	require.NoError(t, parser.Init())
+
+	_, err := parser.Parse([]byte("not a LEEF message"))
+	require.ErrorContains(t, err, "no LEEF header found")
+
+	_, err = parser.Parse([]byte("LEEF:1.0|a|b"))
+	require.ErrorContains(t, err, "expected 5 header fields but found 2")
+
+	_, err = parser.Parse([]byte("LEEF:2.0|a|b|c|d|xZZ|src=1"))
+	require.ErrorContains(t, err, `invalid delimiter "xZZ"`)
+}
+
+func TestParseDelimiter(t *testing.T) {
+	for input, expected := range map[string]string{"^": "^", "x09": "\t", "0x5E": "^", "X7c": "|"} {
+		actual, err := parseDelimiter(input)
+		require.NoError(t, err, input)
+		require.Equal(t, expected, actual, input)
+	}
+	_, err := parseDelimiter("09")
+	require.Error(t, err)
+}
+
+func TestConvertLayout(t *testing.T) {
+	for format, expected := range map[string]string{
+		"MMM dd yyyy HH:mm:ss.SSS zzz":  "Jan 02 2006 15:04:05.000 MST",
+		"yyyy-MM-dd'T'HH:mm:ss.SSSXXX":  "2006-01-02T15:04:05.000Z07:00",
+		"EEE, d MMMM yy h:mm a Z":       "Mon, 2 January 06 3:04 PM -0700",
+		"dd/MM/yyyy 'o''clock' HH:mm":   "02/01/2006 o'clock 15:04",
+		"yyyy.MM.dd HH:mm:ss.SSS ''X''": "2006.01.02 15:04:05.000 'Z07'",
+	} {
+		actual, err := convertLayout(format)
+		require.NoError(t, err, format)
+		require.Equal(t, expected, actual, format)
+	}
+	_, err := convertLayout("yyyy-MM-dd G")
+	require.ErrorContains(t, err, "unsupported pattern letter 'G'")
+}
+
+func TestParseInvalidTimeFormat(t *testing.T) {
+	parser := &Parser{metricName: "leef"}
+	require.NoError(t, parser.Init())
+
+	// Fall back to the default formats if the custom format does not match
+	actual, err := parser.ParseLine("LEEF:1.0|a|b|c|d|devTime=1000\tdevTimeFormat=yyyy-MM-dd")
+	require.NoError(t, err)
+	require.Equal(t, time.UnixMilli(1000), actual.Time())
+}
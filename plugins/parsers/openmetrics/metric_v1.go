@@ -113,6 +113,13 @@ func (p *Parser) extractMetricsV1(ometrics *MetricFamily) []telegraf.Metric {
 			case MetricType_HISTOGRAM, MetricType_GAUGE_HISTOGRAM:
 				histogram := omp.GetHistogramValue()
 
+				// Keep the histogram as a single field if requested
+				if p.NativeHistograms {
+					fields := map[string]interface{}{"histogram": histogramValue(histogram)}
+					metrics = append(metrics, metric.New(metricName, tags, fields, t, telegraf.Histogram))
+					continue
+				}
+
 				// Collect the fields
 				fields := make(map[string]interface{}, len(histogram.Buckets)+3)
 				fields["count"] = float64(histogram.GetCount())
@@ -111,6 +111,13 @@ func (p *Parser) extractMetricsV2(ometrics *MetricFamily) []telegraf.Metric {
 			case MetricType_HISTOGRAM, MetricType_GAUGE_HISTOGRAM:
 				histogram := omp.GetHistogramValue()
 
+				// Keep the histogram as a single field if requested
+				if p.NativeHistograms {
+					fields := map[string]interface{}{metricName: histogramValue(histogram)}
+					metrics = append(metrics, metric.New("openmetric", tags, fields, t, telegraf.Histogram))
+					continue
+				}
+
 				// Add an overall metric containing the number of samples and and its sum
 				histFields := make(map[string]interface{})
 				histFields[metricName+"_count"] = float64(histogram.GetCount())
@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"errors"
 	"fmt"
+	"math"
 	"mime"
 	"net/http"
 
@@ -11,6 +12,7 @@ import (
 	"google.golang.org/protobuf/proto"
 
 	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/metric"
 	"github.com/influxdata/telegraf/plugins/parsers"
 )
 
@@ -33,11 +35,12 @@ func AcceptsContent(header http.Header) bool {
 }
 
 type Parser struct {
-	IgnoreTimestamp bool              `toml:"openmetrics_ignore_timestamp"`
-	MetricVersion   int               `toml:"openmetrics_metric_version"`
-	Header          http.Header       `toml:"-"` // set by the input plugin
-	DefaultTags     map[string]string `toml:"-"`
-	Log             telegraf.Logger   `toml:"-"`
+	IgnoreTimestamp  bool              `toml:"openmetrics_ignore_timestamp"`
+	MetricVersion    int               `toml:"openmetrics_metric_version"`
+	NativeHistograms bool              `toml:"openmetrics_native_histograms"`
+	Header           http.Header       `toml:"-"` // set by the input plugin
+	DefaultTags      map[string]string `toml:"-"`
+	Log              telegraf.Logger   `toml:"-"`
 }
 
 func (p *Parser) SetDefaultTags(tags map[string]string) {
@@ -134,6 +137,28 @@ func getTagsFromLabels(m *Metric, defaultTags map[string]string) map[string]stri
 	return result
 }
 
+// histogramValue converts the histogram into a native histogram field value
+func histogramValue(h *HistogramValue) metric.Histogram {
+	value := metric.Histogram{
+		Count:   float64(h.GetCount()),
+		Buckets: make([]metric.Bucket, 0, len(h.GetBuckets())),
+	}
+	switch v := h.GetSum().(type) {
+	case *HistogramValue_DoubleValue:
+		value.Sum = v.DoubleValue
+	case *HistogramValue_IntValue:
+		value.Sum = float64(v.IntValue)
+	}
+	for _, b := range h.GetBuckets() {
+		// The +Inf bucket is implicitly given by the count
+		if math.IsInf(b.GetUpperBound(), +1) {
+			continue
+		}
+		value.Buckets = append(value.Buckets, metric.Bucket{UpperBound: b.GetUpperBound(), Count: float64(b.GetCount())})
+	}
+	return value
+}
+
 func init() {
 	parsers.Add("openmetrics",
 		func(string) telegraf.Parser {
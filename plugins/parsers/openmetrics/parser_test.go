@@ -5,11 +5,14 @@ import (
 	"os"
 	"path/filepath"
 	"testing"
+	"time"
 
 	"github.com/google/go-cmp/cmp"
 	"github.com/stretchr/testify/require"
 
+	"github.com/influxdata/telegraf"
 	"github.com/influxdata/telegraf/config"
+	"github.com/influxdata/telegraf/metric"
 	"github.com/influxdata/telegraf/models"
 	"github.com/influxdata/telegraf/testutil"
 	test "github.com/influxdata/telegraf/testutil/plugin_input"
@@ -151,6 +154,67 @@ func TestCases(t *testing.T) {
 	}
 }
 
+func TestNativeHistograms(t *testing.T) {
+	input := `# TYPE http_request_duration_seconds histogram
+http_request_duration_seconds_bucket{code="200",le="0.1"} 4 1700000000
+http_request_duration_seconds_bucket{code="200",le="1"} 9 1700000000
+http_request_duration_seconds_bucket{code="200",le="+Inf"} 10 1700000000
+http_request_duration_seconds_sum{code="200"} 3.5 1700000000
+http_request_duration_seconds_count{code="200"} 10 1700000000
+# EOF
+`
+	value := metric.Histogram{
+		Count: 10,
+		Sum:   3.5,
+		Buckets: []metric.Bucket{
+			{UpperBound: 0.1, Count: 4},
+			{UpperBound: 1, Count: 9},
+		},
+	}
+
+	tests := []struct {
+		name     string
+		version  int
+		expected telegraf.Metric
+	}{
+		{
+			name:    "v1",
+			version: 1,
+			expected: metric.New(
+				"http_request_duration_seconds",
+				map[string]string{"code": "200"},
+				map[string]interface{}{"histogram": value},
+				time.Unix(1700000000, 0),
+				telegraf.Histogram,
+			),
+		},
+		{
+			name:    "v2",
+			version: 2,
+			expected: metric.New(
+				"openmetric",
+				map[string]string{"code": "200"},
+				map[string]interface{}{"http_request_duration_seconds": value},
+				time.Unix(1700000000, 0),
+				telegraf.Histogram,
+			),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			parser := &Parser{
+				MetricVersion:    tt.version,
+				NativeHistograms: true,
+				Log:              testutil.Logger{},
+			}
+			actual, err := parser.Parse([]byte(input))
+			require.NoError(t, err)
+			testutil.RequireMetricsEqual(t, []telegraf.Metric{tt.expected}, actual)
+		})
+	}
+}
+
 func BenchmarkParsingMetricVersion1(b *testing.B) {
 	plugin := &Parser{MetricVersion: 1}
 
@@ -0,0 +1,146 @@
+package otlp
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/influxdata/influxdb-observability/common"
+	"github.com/influxdata/influxdb-observability/otel2influx"
+	"go.opentelemetry.io/collector/pdata/pmetric/pmetricotlp"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/metric"
+	common_otel "github.com/influxdata/telegraf/plugins/common/opentelemetry"
+	"github.com/influxdata/telegraf/plugins/parsers"
+)
+
+// Parser decodes OTLP metrics export requests in protobuf or JSON encoding
+type Parser struct {
+	Encoding      string            `toml:"otlp_encoding"`
+	MetricsSchema string            `toml:"otlp_metrics_schema"`
+	DefaultTags   map[string]string `toml:"-"`
+	Log           telegraf.Logger   `toml:"-"`
+
+	schema common.MetricsSchema
+}
+
+func (p *Parser) Init() error {
+	switch p.Encoding {
+	case "":
+		p.Encoding = "protobuf"
+	case "protobuf", "json":
+	default:
+		return fmt.Errorf("invalid 'otlp_encoding' %q", p.Encoding)
+	}
+
+	if p.MetricsSchema == "" {
+		p.MetricsSchema = common_otel.DefaultMetricsSchema
+	}
+	schema, found := common_otel.MetricsSchemata[p.MetricsSchema]
+	if !found {
+		return fmt.Errorf("invalid 'otlp_metrics_schema' %q", p.MetricsSchema)
+	}
+	p.schema = schema
+
+	return nil
+}
+
+func (p *Parser) Parse(buf []byte) ([]telegraf.Metric, error) {
+	req := pmetricotlp.NewExportRequest()
+	switch p.Encoding {
+	case "json":
+		if err := req.UnmarshalJSON(buf); err != nil {
+			return nil, fmt.Errorf("decoding JSON failed: %w", err)
+		}
+	default:
+		if err := req.UnmarshalProto(buf); err != nil {
+			return nil, fmt.Errorf("decoding protobuf failed: %w", err)
+		}
+	}
+
+	// Create a converter for each call as the writer collecting the metrics
+	// is part of the converter's configuration
+	writer := &metricCollector{defaultTags: p.DefaultTags}
+	cfg := otel2influx.DefaultOtelMetricsToLineProtocolConfig()
+	cfg.Logger = &common_otel.Logger{Logger: p.Log}
+	cfg.Writer = writer
+	cfg.Schema = p.schema
+	converter, err := otel2influx.NewOtelMetricsToLineProtocol(cfg)
+	if err != nil {
+		return nil, err
+	}
+	if err := converter.WriteMetrics(context.Background(), req.Metrics()); err != nil {
+		return nil, err
+	}
+
+	return writer.metrics, nil
+}
+
+func (p *Parser) ParseLine(line string) (telegraf.Metric, error) {
+	metrics, err := p.Parse([]byte(line))
+	if err != nil {
+		return nil, err
+	}
+
+	if len(metrics) < 1 {
+		return nil, errors.New("no metrics in line")
+	}
+
+	if len(metrics) > 1 {
+		return nil, errors.New("more than one metric in line")
+	}
+
+	return metrics[0], nil
+}
+
+func (p *Parser) SetDefaultTags(tags map[string]string) {
+	p.DefaultTags = tags
+}
+
+// metricCollector collects the converted data points as Telegraf metrics
+type metricCollector struct {
+	defaultTags map[string]string
+	metrics     []telegraf.Metric
+}
+
+func (c *metricCollector) NewBatch() otel2influx.InfluxWriterBatch {
+	return c
+}
+
+func (c *metricCollector) EnqueuePoint(
+	_ context.Context,
+	measurement string,
+	tags map[string]string,
+	fields map[string]interface{},
+	ts time.Time,
+	vType common.InfluxMetricValueType,
+) error {
+	tp, err := common_otel.MetricType(vType)
+	if err != nil {
+		return err
+	}
+	if tags == nil {
+		tags = make(map[string]string, len(c.defaultTags))
+	}
+	for k, v := range c.defaultTags {
+		if _, found := tags[k]; !found {
+			tags[k] = v
+		}
+	}
+	c.metrics = append(c.metrics, metric.New(measurement, tags, fields, ts, tp))
+	return nil
+}
+
+func (*metricCollector) WriteBatch(context.Context) error {
+	return nil
+}
+
+func init() {
+	parsers.Add("otlp",
+		func(string) telegraf.Parser {
+			return &Parser{}
+		},
+	)
+}
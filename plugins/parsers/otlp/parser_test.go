@@ -0,0 +1,133 @@
+package otlp
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/collector/pdata/pcommon"
+	"go.opentelemetry.io/collector/pdata/pmetric"
+	"go.opentelemetry.io/collector/pdata/pmetric/pmetricotlp"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/metric"
+	"github.com/influxdata/telegraf/testutil"
+)
+
+func createRequest() pmetricotlp.ExportRequest {
+	md := pmetric.NewMetrics()
+	rm := md.ResourceMetrics().AppendEmpty()
+	rm.Resource().Attributes().PutStr("service.name", "test")
+	sm := rm.ScopeMetrics().AppendEmpty()
+	sm.Scope().SetName("telegraf")
+
+	gauge := sm.Metrics().AppendEmpty()
+	gauge.SetName("cpu_temperature")
+	dp := gauge.SetEmptyGauge().DataPoints().AppendEmpty()
+	dp.Attributes().PutStr("core", "0")
+	dp.SetTimestamp(pcommon.NewTimestampFromTime(time.Unix(1700000000, 0)))
+	dp.SetDoubleValue(42.5)
+
+	sum := sm.Metrics().AppendEmpty()
+	sum.SetName("requests")
+	s := sum.SetEmptySum()
+	s.SetIsMonotonic(true)
+	s.SetAggregationTemporality(pmetric.AggregationTemporalityCumulative)
+	dp = s.DataPoints().AppendEmpty()
+	dp.SetTimestamp(pcommon.NewTimestampFromTime(time.Unix(1700000000, 0)))
+	dp.SetIntValue(7)
+
+	return pmetricotlp.NewExportRequestFromMetrics(md)
+}
+
+func TestParse(t *testing.T) {
+	expected := []telegraf.Metric{
+		metric.New(
+			"cpu_temperature",
+			map[string]string{"core": "0", "otel.library.name": "telegraf", "service.name": "test", "host": "localhost"},
+			map[string]interface{}{"gauge": 42.5},
+			time.Unix(1700000000, 0),
+			telegraf.Gauge,
+		),
+		metric.New(
+			"requests",
+			map[string]string{"otel.library.name": "telegraf", "service.name": "test", "host": "localhost"},
+			map[string]interface{}{"counter": int64(7)},
+			time.Unix(1700000000, 0),
+			telegraf.Counter,
+		),
+	}
+
+	req := createRequest()
+	protobuf, err := req.MarshalProto()
+	require.NoError(t, err)
+	jsonBuf, err := req.MarshalJSON()
+	require.NoError(t, err)
+
+	tests := []struct {
+		encoding string
+		input    []byte
+	}{
+		{encoding: "protobuf", input: protobuf},
+		{encoding: "json", input: jsonBuf},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.encoding, func(t *testing.T) {
+			parser := &Parser{Encoding: tt.encoding, Log: testutil.Logger{}}
+			require.NoError(t, parser.Init())
+			parser.SetDefaultTags(map[string]string{"host": "localhost"})
+
+			actual, err := parser.Parse(tt.input)
+			require.NoError(t, err)
+			testutil.RequireMetricsEqual(t, expected, actual, testutil.SortMetrics())
+		})
+	}
+}
+
+func TestParseSchemaV2(t *testing.T) {
+	req := createRequest()
+	buf, err := req.MarshalProto()
+	require.NoError(t, err)
+
+	parser := &Parser{MetricsSchema: "prometheus-v2", Log: testutil.Logger{}}
+	require.NoError(t, parser.Init())
+
+	expected := []telegraf.Metric{
+		metric.New(
+			"prometheus",
+			map[string]string{"core": "0", "otel.library.name": "telegraf", "service.name": "test"},
+			map[string]interface{}{"cpu_temperature": 42.5},
+			time.Unix(1700000000, 0),
+			telegraf.Gauge,
+		),
+		metric.New(
+			"prometheus",
+			map[string]string{"otel.library.name": "telegraf", "service.name": "test"},
+			map[string]interface{}{"requests": int64(7)},
+			time.Unix(1700000000, 0),
+			telegraf.Counter,
+		),
+	}
+
+	actual, err := parser.Parse(buf)
+	require.NoError(t, err)
+	testutil.RequireMetricsEqual(t, expected, actual, testutil.SortMetrics())
+}
+
+func TestParseInvalid(t *testing.T) {
+	parser := &Parser{Log: testutil.Logger{}}
+	require.NoError(t, parser.Init())
+	_, err := parser.Parse([]byte("\xff\xff\xff"))
+	require.ErrorContains(t, err, "decoding protobuf failed")
+
+	parser = &Parser{Encoding: "json", Log: testutil.Logger{}}
+	require.NoError(t, parser.Init())
+	_, err = parser.Parse([]byte("{"))
+	require.ErrorContains(t, err, "decoding JSON failed")
+}
+
+func TestInitInvalid(t *testing.T) {
+	require.ErrorContains(t, (&Parser{Encoding: "xml"}).Init(), `invalid 'otlp_encoding' "xml"`)
+	require.ErrorContains(t, (&Parser{MetricsSchema: "foo"}).Init(), `invalid 'otlp_metrics_schema' "foo"`)
+}
@@ -1,9 +1,12 @@
 package prometheus
 
 import (
+	"math"
+
 	dto "github.com/prometheus/client_model/go"
 
 	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/metric"
 )
 
 func mapValueType(mt dto.MetricType) telegraf.ValueType {
@@ -35,3 +38,99 @@ func getTagsFromLabels(m *dto.Metric, defaultTags map[string]string) map[string]
 
 	return result
 }
+
+// histogramValue converts the histogram into a native histogram field value.
+// Histograms with a schema are converted to exponential histograms, all
+// others to histograms with explicit buckets.
+func histogramValue(h *dto.Histogram) interface{} {
+	if h.Schema == nil {
+		buckets := make([]metric.Bucket, 0, len(h.Bucket))
+		for _, b := range h.Bucket {
+			// The +Inf bucket is implicitly given by the count
+			if math.IsInf(b.GetUpperBound(), +1) {
+				continue
+			}
+			count := b.GetCumulativeCountFloat()
+			if count == 0 {
+				count = float64(b.GetCumulativeCount())
+			}
+			buckets = append(buckets, metric.Bucket{UpperBound: b.GetUpperBound(), Count: count})
+		}
+		return metric.Histogram{
+			Count:   histogramCount(h),
+			Sum:     h.GetSampleSum(),
+			Buckets: buckets,
+		}
+	}
+
+	zeroCount := h.GetZeroCountFloat()
+	if zeroCount == 0 {
+		zeroCount = float64(h.GetZeroCount())
+	}
+	return metric.ExponentialHistogram{
+		Count:         histogramCount(h),
+		Sum:           h.GetSampleSum(),
+		Scale:         h.GetSchema(),
+		ZeroThreshold: h.GetZeroThreshold(),
+		ZeroCount:     zeroCount,
+		Positive:      expandSpans(h.PositiveSpan, h.PositiveDelta, h.PositiveCount),
+		Negative:      expandSpans(h.NegativeSpan, h.NegativeDelta, h.NegativeCount),
+	}
+}
+
+func histogramCount(h *dto.Histogram) float64 {
+	if count := h.GetSampleCountFloat(); count > 0 {
+		return count
+	}
+	return float64(h.GetSampleCount())
+}
+
+// expandSpans converts the sparse buckets of a Prometheus native histogram,
+// given either as delta-encoded integer counts or as absolute float counts,
+// into consecutive buckets
+func expandSpans(spans []*dto.BucketSpan, deltas []int64, counts []float64) metric.ExponentialBuckets {
+	if len(deltas) == 0 && len(counts) == 0 {
+		return metric.ExponentialBuckets{}
+	}
+
+	// Determine the sparse bucket indices
+	indices := make([]int32, 0, max(len(deltas), len(counts)))
+	var idx int32
+	for i, span := range spans {
+		if i == 0 {
+			idx = span.GetOffset()
+		} else {
+			idx += span.GetOffset()
+		}
+		for range span.GetLength() {
+			indices = append(indices, idx)
+			idx++
+		}
+	}
+	if len(indices) == 0 {
+		return metric.ExponentialBuckets{}
+	}
+
+	// Prometheus bucket i covers (base^(i-1), base^i] so shift the index by
+	// one to follow the OpenTelemetry convention
+	offset := indices[0] - 1
+	dense := make([]float64, indices[len(indices)-1]-indices[0]+1)
+	var current int64
+	for i, idx := range indices {
+		var c float64
+		if len(counts) > 0 {
+			if i >= len(counts) {
+				break
+			}
+			c = counts[i]
+		} else {
+			if i >= len(deltas) {
+				break
+			}
+			current += deltas[i]
+			c = float64(current)
+		}
+		dense[idx-indices[0]] = c
+	}
+	return metric.ExponentialBuckets{Offset: offset, Counts: dense}
+}
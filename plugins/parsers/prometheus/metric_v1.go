@@ -51,6 +51,13 @@ func (p *Parser) extractMetricsV1(prommetrics *dto.MetricFamily) []telegraf.Metr
 		case dto.MetricType_HISTOGRAM:
 			histogram := pm.GetHistogram()
 
+			// Keep the histogram as a single field if requested
+			if p.NativeHistograms {
+				fields := map[string]interface{}{"histogram": histogramValue(histogram)}
+				metrics = append(metrics, metric.New(metricName, tags, fields, t, telegraf.Histogram))
+				continue
+			}
+
 			// Collect the fields
 			fields := make(map[string]interface{}, len(histogram.Bucket)+2)
 			fields["count"] = float64(pm.GetHistogram().GetSampleCount())
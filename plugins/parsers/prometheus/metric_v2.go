@@ -55,6 +55,13 @@ func (p *Parser) extractMetricsV2(prommetrics *dto.MetricFamily) []telegraf.Metr
 		case dto.MetricType_HISTOGRAM:
 			histogram := pm.GetHistogram()
 
+			// Keep the histogram as a single field if requested
+			if p.NativeHistograms {
+				fields := map[string]interface{}{metricName: histogramValue(histogram)}
+				metrics = append(metrics, metric.New("prometheus", tags, fields, t, telegraf.Histogram))
+				continue
+			}
+
 			// Add an overall metric containing the number of samples and and its sum
 			histFields := make(map[string]interface{})
 			histFields[metricName+"_count"] = float64(histogram.GetSampleCount())
@@ -19,11 +19,12 @@ func AcceptsContent(header http.Header) bool {
 }
 
 type Parser struct {
-	IgnoreTimestamp bool              `toml:"prometheus_ignore_timestamp"`
-	MetricVersion   int               `toml:"prometheus_metric_version"`
-	Header          http.Header       `toml:"-"` // set by the prometheus input
-	DefaultTags     map[string]string `toml:"-"`
-	Log             telegraf.Logger   `toml:"-"`
+	IgnoreTimestamp  bool              `toml:"prometheus_ignore_timestamp"`
+	MetricVersion    int               `toml:"prometheus_metric_version"`
+	NativeHistograms bool              `toml:"prometheus_native_histograms"`
+	Header           http.Header       `toml:"-"` // set by the prometheus input
+	DefaultTags      map[string]string `toml:"-"`
+	Log              telegraf.Logger   `toml:"-"`
 }
 
 func (p *Parser) SetDefaultTags(tags map[string]string) {
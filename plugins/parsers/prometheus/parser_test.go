@@ -1,15 +1,23 @@
 package prometheus
 
 import (
+	"bytes"
+	"math"
 	"net/http"
 	"os"
 	"path/filepath"
 	"testing"
+	"time"
 
 	"github.com/google/go-cmp/cmp"
+	dto "github.com/prometheus/client_model/go"
 	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/encoding/protodelim"
+	"google.golang.org/protobuf/proto"
 
+	"github.com/influxdata/telegraf"
 	"github.com/influxdata/telegraf/config"
+	"github.com/influxdata/telegraf/metric"
 	"github.com/influxdata/telegraf/models"
 	"github.com/influxdata/telegraf/testutil"
 	test "github.com/influxdata/telegraf/testutil/plugin_input"
@@ -151,6 +159,135 @@ func TestCases(t *testing.T) {
 	}
 }
 
+func TestNativeHistograms(t *testing.T) {
+	// Encode a classic and a native histogram as delimited protocol-buffer
+	classic := &dto.MetricFamily{
+		Name: proto.String("http_request_duration_seconds"),
+		Type: dto.MetricType_HISTOGRAM.Enum(),
+		Metric: []*dto.Metric{{
+			Label: []*dto.LabelPair{{Name: proto.String("code"), Value: proto.String("200")}},
+			Histogram: &dto.Histogram{
+				SampleCount: proto.Uint64(10),
+				SampleSum:   proto.Float64(3.5),
+				Bucket: []*dto.Bucket{
+					{UpperBound: proto.Float64(0.1), CumulativeCount: proto.Uint64(4)},
+					{UpperBound: proto.Float64(1), CumulativeCount: proto.Uint64(9)},
+					{UpperBound: proto.Float64(math.Inf(1)), CumulativeCount: proto.Uint64(10)},
+				},
+			},
+			TimestampMs: proto.Int64(1700000000000),
+		}},
+	}
+	native := &dto.MetricFamily{
+		Name: proto.String("rpc_latency_seconds"),
+		Type: dto.MetricType_HISTOGRAM.Enum(),
+		Metric: []*dto.Metric{{
+			Histogram: &dto.Histogram{
+				SampleCount:   proto.Uint64(7),
+				SampleSum:     proto.Float64(12),
+				Schema:        proto.Int32(0),
+				ZeroThreshold: proto.Float64(0.001),
+				ZeroCount:     proto.Uint64(1),
+				PositiveSpan: []*dto.BucketSpan{
+					{Offset: proto.Int32(1), Length: proto.Uint32(2)},
+					{Offset: proto.Int32(1), Length: proto.Uint32(1)},
+				},
+				PositiveDelta: []int64{2, 1, -2},
+				NegativeSpan:  []*dto.BucketSpan{{Offset: proto.Int32(0), Length: proto.Uint32(1)}},
+				NegativeDelta: []int64{1},
+			},
+			TimestampMs: proto.Int64(1700000000000),
+		}},
+	}
+	var buf bytes.Buffer
+	_, err := protodelim.MarshalTo(&buf, classic)
+	require.NoError(t, err)
+	_, err = protodelim.MarshalTo(&buf, native)
+	require.NoError(t, err)
+
+	classicValue := metric.Histogram{
+		Count: 10,
+		Sum:   3.5,
+		Buckets: []metric.Bucket{
+			{UpperBound: 0.1, Count: 4},
+			{UpperBound: 1, Count: 9},
+		},
+	}
+	nativeValue := metric.ExponentialHistogram{
+		Count:         7,
+		Sum:           12,
+		Scale:         0,
+		ZeroThreshold: 0.001,
+		ZeroCount:     1,
+		// Prometheus indices 1, 2 and 4 are shifted to 0, 1 and 3
+		Positive: metric.ExponentialBuckets{Offset: 0, Counts: []float64{2, 3, 0, 1}},
+		Negative: metric.ExponentialBuckets{Offset: -1, Counts: []float64{1}},
+	}
+
+	tests := []struct {
+		name     string
+		version  int
+		expected []telegraf.Metric
+	}{
+		{
+			name:    "v1",
+			version: 1,
+			expected: []telegraf.Metric{
+				metric.New(
+					"http_request_duration_seconds",
+					map[string]string{"code": "200"},
+					map[string]interface{}{"histogram": classicValue},
+					time.UnixMilli(1700000000000),
+					telegraf.Histogram,
+				),
+				metric.New(
+					"rpc_latency_seconds",
+					map[string]string{},
+					map[string]interface{}{"histogram": nativeValue},
+					time.UnixMilli(1700000000000),
+					telegraf.Histogram,
+				),
+			},
+		},
+		{
+			name:    "v2",
+			version: 2,
+			expected: []telegraf.Metric{
+				metric.New(
+					"prometheus",
+					map[string]string{"code": "200"},
+					map[string]interface{}{"http_request_duration_seconds": classicValue},
+					time.UnixMilli(1700000000000),
+					telegraf.Histogram,
+				),
+				metric.New(
+					"prometheus",
+					map[string]string{},
+					map[string]interface{}{"rpc_latency_seconds": nativeValue},
+					time.UnixMilli(1700000000000),
+					telegraf.Histogram,
+				),
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			parser := &Parser{
+				MetricVersion:    tt.version,
+				NativeHistograms: true,
+				Header: http.Header{
+					"Content-Type": []string{"application/vnd.google.protobuf;proto=io.prometheus.client.MetricFamily;encoding=delimited"},
+				},
+				Log: testutil.Logger{},
+			}
+			actual, err := parser.Parse(buf.Bytes())
+			require.NoError(t, err)
+			testutil.RequireMetricsEqual(t, tt.expected, actual)
+		})
+	}
+}
+
 func BenchmarkParsingMetricVersion1(b *testing.B) {
 	plugin := &Parser{MetricVersion: 1}
 
@@ -0,0 +1,290 @@
+package statsd
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/internal/templating"
+	"github.com/influxdata/telegraf/metric"
+	"github.com/influxdata/telegraf/plugins/parsers"
+)
+
+var ErrNoMetric = errors.New("no metric in line")
+
+// metricType contains the name of the statsd type used in the "metric_type"
+// tag and the value type of the resulting metric
+type metricType struct {
+	name      string
+	valueType telegraf.ValueType
+}
+
+// Types of the samples, timings, histograms and distributions are single
+// observations and thus gauges as no aggregation is done
+var metricTypes = map[string]metricType{
+	"c":  {name: "counter", valueType: telegraf.Counter},
+	"g":  {name: "gauge", valueType: telegraf.Gauge},
+	"s":  {name: "set", valueType: telegraf.Untyped},
+	"ms": {name: "timing", valueType: telegraf.Gauge},
+	"h":  {name: "histogram", valueType: telegraf.Gauge},
+	"d":  {name: "distribution", valueType: telegraf.Gauge},
+}
+
+// Parser decodes statsd lines into one metric per sample without any
+// aggregation
+type Parser struct {
+	Templates               []string          `toml:"statsd_templates"`
+	MetricSeparator         string            `toml:"statsd_metric_separator"`
+	DataDogExtensions       bool              `toml:"statsd_datadog_extensions"`
+	DataDogKeepContainerTag bool              `toml:"statsd_datadog_keep_container_tag"`
+	DefaultTags             map[string]string `toml:"-"`
+	Log                     telegraf.Logger   `toml:"-"`
+
+	templateEngine *templating.Engine
+}
+
+func (p *Parser) Init() error {
+	if p.MetricSeparator == "" {
+		p.MetricSeparator = "_"
+	}
+
+	defaultTemplate, err := templating.NewDefaultTemplateWithPattern("measurement*")
+	if err != nil {
+		return fmt.Errorf("creating template failed: %w", err)
+	}
+	p.templateEngine, err = templating.NewEngine(p.MetricSeparator, defaultTemplate, p.Templates)
+	if err != nil {
+		return fmt.Errorf("creating template engine failed: %w", err)
+	}
+
+	return nil
+}
+
+func (p *Parser) Parse(buf []byte) ([]telegraf.Metric, error) {
+	now := time.Now()
+
+	metrics := make([]telegraf.Metric, 0)
+	scanner := bufio.NewScanner(bytes.NewReader(buf))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+
+		// DataDog events and service checks are not samples
+		if p.DataDogExtensions && (strings.HasPrefix(line, "_e{") || strings.HasPrefix(line, "_sc|")) {
+			p.Log.Debugf("Skipping DataDog event or service check %q", line)
+			continue
+		}
+
+		m, err := p.parseLine(line, now)
+		if err != nil {
+			return nil, err
+		}
+		metrics = append(metrics, m...)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return metrics, nil
+}
+
+func (p *Parser) ParseLine(line string) (telegraf.Metric, error) {
+	metrics, err := p.Parse([]byte(line))
+	if err != nil {
+		return nil, err
+	}
+
+	if len(metrics) < 1 {
+		return nil, ErrNoMetric
+	}
+	return metrics[0], nil
+}
+
+func (p *Parser) SetDefaultTags(tags map[string]string) {
+	p.DefaultTags = tags
+}
+
+// parseLine parses a line of the form
+//
+//	<bucket>:<value>|<type>[|@<sample rate>][:<value>|<type>...]
+//
+// with the DataDog extensions allowing for additional tag, container and
+// timestamp segments as well as multiple values sharing the type.
+func (p *Parser) parseLine(line string, now time.Time) ([]telegraf.Metric, error) {
+	t := now
+	lineTags := make(map[string]string)
+	if p.DataDogExtensions {
+		// Remove the DataDog-specific segments such as
+		//   users.online:1|c|@0.5|#country:china,environment:production|T1656581400
+		segments := strings.Split(line, "|")
+		remaining := make([]string, 0, len(segments))
+		for i, segment := range segments {
+			switch {
+			case i == 0:
+				remaining = append(remaining, segment)
+			case strings.HasPrefix(segment, "#"):
+				parseDataDogTags(lineTags, segment[1:])
+			case strings.HasPrefix(segment, "c:"):
+				if p.DataDogKeepContainerTag {
+					lineTags["container"] = segment[2:]
+				}
+			case strings.HasPrefix(segment, "T"):
+				sec, err := strconv.ParseInt(segment[1:], 10, 64)
+				if err != nil {
+					return nil, fmt.Errorf("invalid timestamp %q in line %q: %w", segment, line, err)
+				}
+				t = time.Unix(sec, 0)
+			default:
+				remaining = append(remaining, segment)
+			}
+		}
+		line = strings.Join(remaining, "|")
+	}
+
+	bucket, rest, found := strings.Cut(line, ":")
+	if !found || bucket == "" {
+		return nil, fmt.Errorf("missing bucket name in line %q", line)
+	}
+	name, field, tags := p.parseBucket(bucket)
+	for k, v := range lineTags {
+		tags[k] = v
+	}
+	for k, v := range p.DefaultTags {
+		if _, found := tags[k]; !found {
+			tags[k] = v
+		}
+	}
+
+	// Values without type take the type of the next value, so DataDog's
+	// packed form "<bucket>:<value>:<value>|<type>" is supported
+	var metrics []telegraf.Metric
+	var pending []string
+	for _, bit := range strings.Split(rest, ":") {
+		value, meta, found := strings.Cut(bit, "|")
+		if !found {
+			pending = append(pending, value)
+			continue
+		}
+
+		parts := strings.Split(meta, "|")
+		mtype, found := metricTypes[parts[0]]
+		if !found {
+			return nil, fmt.Errorf("unsupported metric type %q in line %q", parts[0], line)
+		}
+		var rate float64
+		for _, part := range parts[1:] {
+			if !strings.HasPrefix(part, "@") {
+				continue
+			}
+			r, err := strconv.ParseFloat(part[1:], 64)
+			if err != nil {
+				return nil, fmt.Errorf("invalid sample rate %q in line %q: %w", part, line, err)
+			}
+			rate = r
+		}
+
+		for _, raw := range append(pending, value) {
+			v, err := parseValue(parts[0], raw)
+			if err != nil {
+				return nil, fmt.Errorf("invalid value in line %q: %w", line, err)
+			}
+			fields := map[string]interface{}{field: v}
+			if rate > 0 {
+				fields["sample_rate"] = rate
+			}
+
+			mtags := make(map[string]string, len(tags)+1)
+			for k, v := range tags {
+				mtags[k] = v
+			}
+			mtags["metric_type"] = mtype.name
+			metrics = append(metrics, metric.New(name, mtags, fields, t, mtype.valueType))
+		}
+		pending = nil
+	}
+	if len(pending) > 0 {
+		return nil, fmt.Errorf("missing metric type in line %q", line)
+	}
+
+	return metrics, nil
+}
+
+// parseBucket extracts the tags given as "<name>,<key>=<value>,..." and
+// applies the templates to determine the measurement and field name
+func (p *Parser) parseBucket(bucket string) (name, field string, tags map[string]string) {
+	parts := strings.Split(bucket, ",")
+	bucketTags := make(map[string]string, len(parts)-1)
+	for _, part := range parts[1:] {
+		k, v, found := strings.Cut(part, "=")
+		if found && k != "" {
+			bucketTags[k] = v
+		}
+	}
+
+	name, tags, field, err := p.templateEngine.Apply(parts[0])
+	if err != nil {
+		name, tags, field = parts[0], make(map[string]string), ""
+	}
+	for k, v := range bucketTags {
+		if _, found := tags[k]; !found {
+			tags[k] = v
+		}
+	}
+	if field == "" {
+		field = "value"
+	}
+
+	return name, field, tags
+}
+
+// parseValue converts the value according to the metric type with counters
+// being integers, sets being strings and all other types being floats
+func parseValue(mtype, raw string) (interface{}, error) {
+	if (strings.HasPrefix(raw, "+") || strings.HasPrefix(raw, "-")) && mtype != "g" && mtype != "c" {
+		return nil, fmt.Errorf("signed value %q is only supported for gauges and counters", raw)
+	}
+
+	switch mtype {
+	case "c":
+		if v, err := strconv.ParseInt(raw, 10, 64); err == nil {
+			return v, nil
+		}
+		v, err := strconv.ParseFloat(raw, 64)
+		if err != nil {
+			return nil, err
+		}
+		return int64(v), nil
+	case "s":
+		return raw, nil
+	}
+	return strconv.ParseFloat(raw, 64)
+}
+
+// parseDataDogTags parses the comma-separated DataDog tags where tags
+// without a value are set to "true"
+func parseDataDogTags(tags map[string]string, s string) {
+	for _, tag := range strings.Split(s, ",") {
+		if tag == "" {
+			continue
+		}
+		k, v, _ := strings.Cut(tag, ":")
+		if v == "" {
+			v = "true"
+		}
+		tags[k] = v
+	}
+}
+
+func init() {
+	parsers.Add("statsd",
+		func(string) telegraf.Parser {
+			return &Parser{}
+		},
+	)
+}
@@ -0,0 +1,229 @@
+package statsd
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/metric"
+	"github.com/influxdata/telegraf/testutil"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected []telegraf.Metric
+	}{
+		{
+			name:  "counter",
+			input: "cpu.load:2|c",
+			expected: []telegraf.Metric{
+				metric.New("cpu_load", map[string]string{"metric_type": "counter"}, map[string]interface{}{"value": int64(2)}, time.Unix(0, 0), telegraf.Counter),
+			},
+		},
+		{
+			name:  "counter with float value and sample rate",
+			input: "requests:3.7|c|@0.1",
+			expected: []telegraf.Metric{
+				metric.New("requests", map[string]string{"metric_type": "counter"}, map[string]interface{}{"value": int64(3), "sample_rate": 0.1}, time.Unix(0, 0), telegraf.Counter),
+			},
+		},
+		{
+			name:  "gauge with sign",
+			input: "temperature:-1.5|g",
+			expected: []telegraf.Metric{
+				metric.New("temperature", map[string]string{"metric_type": "gauge"}, map[string]interface{}{"value": -1.5}, time.Unix(0, 0), telegraf.Gauge),
+			},
+		},
+		{
+			name:  "set",
+			input: "users:alice|s",
+			expected: []telegraf.Metric{
+				metric.New("users", map[string]string{"metric_type": "set"}, map[string]interface{}{"value": "alice"}, time.Unix(0, 0), telegraf.Untyped),
+			},
+		},
+		{
+			name:  "timing, histogram and distribution",
+			input: "latency:320|ms\nsize:12|h\nduration:0.5|d",
+			expected: []telegraf.Metric{
+				metric.New("latency", map[string]string{"metric_type": "timing"}, map[string]interface{}{"value": 320.0}, time.Unix(0, 0), telegraf.Gauge),
+				metric.New("size", map[string]string{"metric_type": "histogram"}, map[string]interface{}{"value": 12.0}, time.Unix(0, 0), telegraf.Gauge),
+				metric.New("duration", map[string]string{"metric_type": "distribution"}, map[string]interface{}{"value": 0.5}, time.Unix(0, 0), telegraf.Gauge),
+			},
+		},
+		{
+			name:  "multiple values",
+			input: "latency,host=a:320|ms:100|ms|@0.5",
+			expected: []telegraf.Metric{
+				metric.New("latency", map[string]string{"host": "a", "metric_type": "timing"}, map[string]interface{}{"value": 320.0}, time.Unix(0, 0), telegraf.Gauge),
+				metric.New("latency", map[string]string{"host": "a", "metric_type": "timing"}, map[string]interface{}{"value": 100.0, "sample_rate": 0.5}, time.Unix(0, 0), telegraf.Gauge),
+			},
+		},
+		{
+			name:  "bucket tags",
+			input: "cpu.idle,host=server01,region=eu:42|g",
+			expected: []telegraf.Metric{
+				metric.New("cpu_idle", map[string]string{"host": "server01", "region": "eu", "metric_type": "gauge"}, map[string]interface{}{"value": 42.0}, time.Unix(0, 0), telegraf.Gauge),
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			parser := &Parser{Log: testutil.Logger{}}
+			require.NoError(t, parser.Init())
+
+			actual, err := parser.Parse([]byte(tt.input))
+			require.NoError(t, err)
+			testutil.RequireMetricsEqual(t, tt.expected, actual, testutil.IgnoreTime())
+		})
+	}
+}
+
+func TestParseDataDog(t *testing.T) {
+	parser := &Parser{
+		DataDogExtensions:       true,
+		DataDogKeepContainerTag: true,
+		Log:                     testutil.Logger{},
+	}
+	require.NoError(t, parser.Init())
+
+	input := `users.online:1|c|@0.5|#country:china,environment:production,canary
+page.views:1:2|d|c:abc123|T1656581400
+_e{5,4}:title|text|#tag
+_sc|redis.can_connect|0`
+	expected := []telegraf.Metric{
+		metric.New(
+			"users_online",
+			map[string]string{"country": "china", "environment": "production", "canary": "true", "metric_type": "counter"},
+			map[string]interface{}{"value": int64(1), "sample_rate": 0.5},
+			time.Unix(0, 0),
+			telegraf.Counter,
+		),
+		metric.New(
+			"page_views",
+			map[string]string{"container": "abc123", "metric_type": "distribution"},
+			map[string]interface{}{"value": 1.0},
+			time.Unix(1656581400, 0),
+			telegraf.Gauge,
+		),
+		metric.New(
+			"page_views",
+			map[string]string{"container": "abc123", "metric_type": "distribution"},
+			map[string]interface{}{"value": 2.0},
+			time.Unix(1656581400, 0),
+			telegraf.Gauge,
+		),
+	}
+
+	actual, err := parser.Parse([]byte(input))
+	require.NoError(t, err)
+	require.Len(t, actual, 3)
+	require.Equal(t, time.Unix(1656581400, 0), actual[1].Time())
+	testutil.RequireMetricsEqual(t, expected, actual, testutil.IgnoreTime())
+}
+
+func TestParseTemplates(t *testing.T) {
+	parser := &Parser{
+		Templates: []string{"measurement.measurement.field.region"},
+		Log:       testutil.Logger{},
+	}
+	require.NoError(t, parser.Init())
+
+	m, err := parser.ParseLine("cpu.load.idle.eu,host=server01:42|g")
+	require.NoError(t, err)
+
+	expected := metric.New(
+		"cpu_load",
+		map[string]string{"host": "server01", "region": "eu", "metric_type": "gauge"},
+		map[string]interface{}{"idle": 42.0},
+		time.Unix(0, 0),
+		telegraf.Gauge,
+	)
+	testutil.RequireMetricEqual(t, expected, m, testutil.IgnoreTime())
+}
+
+func TestParseValueTypes(t *testing.T) {
+	parser := &Parser{Log: testutil.Logger{}}
+	require.NoError(t, parser.Init())
+
+	for input, expected := range map[string]telegraf.ValueType{
+		"a:1|c":     telegraf.Counter,
+		"a:1|g":     telegraf.Gauge,
+		"a:x|s":     telegraf.Untyped,
+		"a:1|ms":    telegraf.Gauge,
+		"a:1|h":     telegraf.Gauge,
+		"a:1|d":     telegraf.Gauge,
+		"a:1:2|c":   telegraf.Counter,
+		"a:1|g|@.5": telegraf.Gauge,
+	} {
+		metrics, err := parser.Parse([]byte(input))
+		require.NoError(t, err, input)
+		require.NotEmpty(t, metrics, input)
+		for _, m := range metrics {
+			require.Equal(t, expected, m.Type(), input)
+		}
+	}
+}
+
+func TestParseDefaultTags(t *testing.T) {
+	parser := &Parser{Log: testutil.Logger{}}
+	require.NoError(t, parser.Init())
+	parser.SetDefaultTags(map[string]string{"host": "default", "source": "kafka"})
+
+	m, err := parser.ParseLine("requests,host=server01:1|c")
+	require.NoError(t, err)
+	require.Equal(t, map[string]string{"host": "server01", "source": "kafka", "metric_type": "counter"}, m.Tags())
+}
+
+func TestParseInvalid(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected string
+	}{
+		{
+			name:     "missing value",
+			input:    "requests",
+			expected: "missing bucket name",
+		},
+		{
+			name:     "missing type",
+			input:    "requests:1",
+			expected: "missing metric type",
+		},
+		{
+			name:     "unknown type",
+			input:    "requests:1|x",
+			expected: `unsupported metric type "x"`,
+		},
+		{
+			name:     "invalid value",
+			input:    "requests:abc|c",
+			expected: "invalid value",
+		},
+		{
+			name:     "signed timing",
+			input:    "latency:-10|ms",
+			expected: "only supported for gauges and counters",
+		},
+		{
+			name:     "invalid sample rate",
+			input:    "requests:1|c|@abc",
+			expected: "invalid sample rate",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			parser := &Parser{Log: testutil.Logger{}}
+			require.NoError(t, parser.Init())
+
+			_, err := parser.Parse([]byte(tt.input))
+			require.ErrorContains(t, err, tt.expected)
+		})
+	}
+}
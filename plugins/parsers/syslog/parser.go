@@ -0,0 +1,131 @@
+package syslog
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/leodido/go-syslog/v4"
+	"github.com/leodido/go-syslog/v4/rfc3164"
+	"github.com/leodido/go-syslog/v4/rfc5424"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/metric"
+	common_syslog "github.com/influxdata/telegraf/plugins/common/syslog"
+	"github.com/influxdata/telegraf/plugins/parsers"
+)
+
+var ErrNoMetric = errors.New("no metric in line")
+
+// Parser decodes syslog messages, one message per line, into metrics
+type Parser struct {
+	SyslogStandard string            `toml:"syslog_standard"`
+	BestEffort     bool              `toml:"syslog_best_effort"`
+	Separator      string            `toml:"syslog_sdparam_separator"`
+	DefaultTags    map[string]string `toml:"-"`
+
+	metricName string
+	machine    syslog.Machine
+}
+
+func (p *Parser) Init() error {
+	switch p.SyslogStandard {
+	case "", "RFC5424":
+		p.SyslogStandard = "RFC5424"
+		p.machine = rfc5424.NewParser()
+	case "RFC3164":
+		p.machine = rfc3164.NewParser(rfc3164.WithYear(rfc3164.CurrentYear{}))
+	default:
+		return fmt.Errorf("invalid 'syslog_standard' %q", p.SyslogStandard)
+	}
+	if p.BestEffort {
+		p.machine.WithBestEffort()
+	}
+
+	if p.Separator == "" {
+		p.Separator = "_"
+	}
+
+	return nil
+}
+
+func (p *Parser) Parse(buf []byte) ([]telegraf.Metric, error) {
+	metrics := make([]telegraf.Metric, 0)
+	scanner := bufio.NewScanner(bytes.NewReader(buf))
+	for scanner.Scan() {
+		line := bytes.TrimRight(scanner.Bytes(), "\r")
+		if len(bytes.TrimSpace(line)) == 0 {
+			continue
+		}
+		m, err := p.parse(line)
+		if err != nil {
+			return nil, err
+		}
+		metrics = append(metrics, m)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return metrics, nil
+}
+
+func (p *Parser) ParseLine(line string) (telegraf.Metric, error) {
+	metrics, err := p.Parse([]byte(line))
+	if err != nil {
+		return nil, err
+	}
+
+	if len(metrics) < 1 {
+		return nil, ErrNoMetric
+	}
+	return metrics[0], nil
+}
+
+func (p *Parser) SetDefaultTags(tags map[string]string) {
+	p.DefaultTags = tags
+}
+
+func (p *Parser) parse(line []byte) (telegraf.Metric, error) {
+	msg, err := p.machine.Parse(line)
+	if msg == nil {
+		if err == nil {
+			err = errors.New("no message")
+		}
+		return nil, fmt.Errorf("parsing %q failed: %w", string(line), err)
+	}
+	// In best-effort mode, partial messages are returned along with an error
+	if err != nil && !p.BestEffort {
+		return nil, fmt.Errorf("parsing %q failed: %w", string(line), err)
+	}
+
+	// Use the timestamp of the message if available
+	t := time.Now()
+	switch msg := msg.(type) {
+	case *rfc5424.SyslogMessage:
+		if msg.Timestamp != nil {
+			t = *msg.Timestamp
+		}
+	case *rfc3164.SyslogMessage:
+		if msg.Timestamp != nil {
+			t = *msg.Timestamp
+		}
+	}
+
+	tags := common_syslog.Tags(msg, "")
+	for k, v := range p.DefaultTags {
+		if _, found := tags[k]; !found {
+			tags[k] = v
+		}
+	}
+	return metric.New(p.metricName, tags, common_syslog.Fields(msg, p.Separator), t), nil
+}
+
+func init() {
+	parsers.Add("syslog",
+		func(defaultMetricName string) telegraf.Parser {
+			return &Parser{metricName: defaultMetricName}
+		},
+	)
+}
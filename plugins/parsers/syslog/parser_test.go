@@ -0,0 +1,104 @@
+package syslog
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/metric"
+	"github.com/influxdata/telegraf/testutil"
+)
+
+func TestParseRFC5424(t *testing.T) {
+	parser := &Parser{metricName: "syslog"}
+	require.NoError(t, parser.Init())
+	parser.SetDefaultTags(map[string]string{"source": "file"})
+
+	input := `<34>1 2023-11-14T22:13:20.123Z mymachine.example.com su 1234 ID47 [exampleSDID@32473 iut="3" eventSource="Application"] 'su root' failed` + "\n" +
+		`<165>1 2023-11-14T22:13:21Z host app - - - hello` + "\n"
+	actual, err := parser.Parse([]byte(input))
+	require.NoError(t, err)
+
+	ts := time.Date(2023, time.November, 14, 22, 13, 20, 123000000, time.UTC)
+	expected := []telegraf.Metric{
+		metric.New(
+			"syslog",
+			map[string]string{
+				"severity": "crit",
+				"facility": "auth",
+				"hostname": "mymachine.example.com",
+				"appname":  "su",
+				"source":   "file",
+			},
+			map[string]interface{}{
+				"facility_code":                 4,
+				"severity_code":                 2,
+				"version":                       uint16(1),
+				"timestamp":                     ts.UnixNano(),
+				"procid":                        "1234",
+				"msgid":                         "ID47",
+				"message":                       "'su root' failed",
+				"exampleSDID@32473_iut":         "3",
+				"exampleSDID@32473_eventSource": "Application",
+			},
+			ts,
+		),
+		metric.New(
+			"syslog",
+			map[string]string{
+				"severity": "notice",
+				"facility": "local4",
+				"hostname": "host",
+				"appname":  "app",
+				"source":   "file",
+			},
+			map[string]interface{}{
+				"facility_code": 20,
+				"severity_code": 5,
+				"version":       uint16(1),
+				"timestamp":     time.Unix(1700000001, 0).UnixNano(),
+				"message":       "hello",
+			},
+			time.Unix(1700000001, 0),
+		),
+	}
+	testutil.RequireMetricsEqual(t, expected, actual)
+}
+
+func TestParseRFC3164(t *testing.T) {
+	parser := &Parser{SyslogStandard: "RFC3164", metricName: "syslog"}
+	require.NoError(t, parser.Init())
+
+	m, err := parser.ParseLine(`<13>Nov 14 22:13:20 host app[42]: Test`)
+	require.NoError(t, err)
+	require.Equal(t, map[string]string{"severity": "notice", "facility": "user", "hostname": "host", "appname": "app"}, m.Tags())
+	msg, found := m.GetField("message")
+	require.True(t, found)
+	require.Equal(t, "Test", msg)
+	procid, found := m.GetField("procid")
+	require.True(t, found)
+	require.Equal(t, "42", procid)
+	require.Equal(t, time.November, m.Time().Month())
+}
+
+func TestParseBestEffort(t *testing.T) {
+	input := []byte(`<34>1 2023-11-14T22:13:20Z host`)
+
+	parser := &Parser{metricName: "syslog"}
+	require.NoError(t, parser.Init())
+	_, err := parser.Parse(input)
+	require.Error(t, err)
+
+	parser = &Parser{BestEffort: true, metricName: "syslog"}
+	require.NoError(t, parser.Init())
+	m, err := parser.ParseLine(string(input))
+	require.NoError(t, err)
+	require.Equal(t, map[string]string{"severity": "crit", "facility": "auth"}, m.Tags())
+	require.Equal(t, time.Unix(1700000000, 0).UTC(), m.Time().UTC())
+}
+
+func TestInitInvalid(t *testing.T) {
+	require.ErrorContains(t, (&Parser{SyslogStandard: "RFC1234"}).Init(), `invalid 'syslog_standard' "RFC1234"`)
+}
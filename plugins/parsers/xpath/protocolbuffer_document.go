@@ -1,7 +1,6 @@
 package xpath
 
 import (
-	"context"
 	"encoding/hex"
 	"errors"
 	"fmt"
@@ -11,15 +10,14 @@ import (
 	"strings"
 
 	path "github.com/antchfx/xpath"
-	"github.com/bufbuild/protocompile"
 	"github.com/srebhan/protobufquery"
 	"google.golang.org/protobuf/encoding/protowire"
 	"google.golang.org/protobuf/proto"
 	"google.golang.org/protobuf/reflect/protoreflect"
-	"google.golang.org/protobuf/reflect/protoregistry"
 	"google.golang.org/protobuf/types/dynamicpb"
 
 	"github.com/influxdata/telegraf"
+	common_protobuf "github.com/influxdata/telegraf/plugins/common/protobuf"
 )
 
 type protobufDocument struct {
@@ -43,30 +41,14 @@ func (d *protobufDocument) Init() error {
 	}
 
 	// Load the file descriptors from the given protocol-buffer definition
-	ctx := context.Background()
-	resolver := &protocompile.SourceResolver{ImportPaths: d.ImportPaths}
-	compiler := &protocompile.Compiler{
-		Resolver: protocompile.WithStandardImports(resolver),
-	}
-	files, err := compiler.Compile(ctx, d.MessageFiles...)
+	registry, err := common_protobuf.Compile(d.MessageFiles, d.ImportPaths)
 	if err != nil {
-		return fmt.Errorf("parsing protocol-buffer definition failed: %w", err)
-	}
-	if len(files) < 1 {
-		return errors.New("files do not contain a file descriptor")
-	}
-
-	// Register all definitions in the file in the global registry
-	var registry protoregistry.Files
-	for _, f := range files {
-		if err := registry.RegisterFile(f); err != nil {
-			return fmt.Errorf("adding file %q to registry failed: %w", f.Path(), err)
-		}
+		return err
 	}
 
 	d.unmarshaller = proto.UnmarshalOptions{
 		RecursionLimit: protowire.DefaultRecursionLimit,
-		Resolver:       dynamicpb.NewTypes(&registry),
+		Resolver:       dynamicpb.NewTypes(registry),
 	}
 
 	// Lookup given type in the loaded file descriptors
@@ -0,0 +1,5 @@
+//go:build !custom || processors || processors.expression
+
+package all
+
+import _ "github.com/influxdata/telegraf/plugins/processors/expression" // register plugin
@@ -0,0 +1,268 @@
+//go:generate ../../../tools/readme_config_includer/generator
+package anomaly
+
+import (
+	_ "embed"
+	"errors"
+	"fmt"
+	"maps"
+	"math"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/config"
+	"github.com/influxdata/telegraf/filter"
+	"github.com/influxdata/telegraf/plugins/processors"
+)
+
+//go:embed sample.conf
+var sampleConfig string
+
+type Anomaly struct {
+	Fields       []string        `toml:"fields"`
+	Algorithm    string          `toml:"algorithm"`
+	Threshold    float64         `toml:"threshold"`
+	Warmup       int             `toml:"warmup"`
+	Alpha        float64         `toml:"alpha"`
+	Beta         float64         `toml:"beta"`
+	Gamma        float64         `toml:"gamma"`
+	Window       int             `toml:"window"`
+	SeasonLength int             `toml:"season_length"`
+	Expiry       config.Duration `toml:"expiry"`
+	Log          telegraf.Logger `toml:"-"`
+
+	filter    filter.Filter
+	detect    detector
+	series    map[uint64]*series
+	lastPurge time.Time
+
+	// Protects the series as the state might be collected while processing
+	seriesLock sync.Mutex
+}
+
+// series holds the models of all selected fields of a series
+type series struct {
+	LastSeen time.Time         `json:"last_seen"`
+	Models   map[string]*model `json:"models"`
+}
+
+// state is the persisted state of the plugin. The models are only restored if
+// the algorithm settings match the current configuration.
+type state struct {
+	Algorithm    string             `json:"algorithm"`
+	SeasonLength int                `json:"season_length,omitempty"`
+	Series       map[uint64]*series `json:"series"`
+}
+
+func (*Anomaly) SampleConfig() string {
+	return sampleConfig
+}
+
+func (p *Anomaly) Init() error {
+	if len(p.Fields) == 0 {
+		return errors.New("no fields configured")
+	}
+	f, err := filter.Compile(p.Fields)
+	if err != nil {
+		return fmt.Errorf("creating field filter failed: %w", err)
+	}
+	p.filter = f
+
+	if p.Threshold <= 0 {
+		return fmt.Errorf("threshold %v must be positive", p.Threshold)
+	}
+	if p.Warmup < 0 {
+		return fmt.Errorf("warmup %d must not be negative", p.Warmup)
+	}
+	if p.Alpha <= 0 || p.Alpha > 1 {
+		return fmt.Errorf("alpha %v out of range (0,1]", p.Alpha)
+	}
+
+	switch p.Algorithm {
+	case "", "zscore":
+		p.Algorithm = "zscore"
+		p.detect = p.zscore
+	case "mad":
+		if p.Window < 3 {
+			return fmt.Errorf("window %d must be at least 3", p.Window)
+		}
+		p.detect = p.mad
+	case "holt_winters":
+		if p.SeasonLength < 2 {
+			return fmt.Errorf("season length %d must be at least 2", p.SeasonLength)
+		}
+		if p.Beta <= 0 || p.Beta > 1 {
+			return fmt.Errorf("beta %v out of range (0,1]", p.Beta)
+		}
+		if p.Gamma <= 0 || p.Gamma > 1 {
+			return fmt.Errorf("gamma %v out of range (0,1]", p.Gamma)
+		}
+		p.detect = p.holtWinters
+	default:
+		return fmt.Errorf("unknown algorithm %q", p.Algorithm)
+	}
+
+	p.series = make(map[uint64]*series)
+	p.lastPurge = time.Now()
+
+	return nil
+}
+
+func (p *Anomaly) Apply(in ...telegraf.Metric) []telegraf.Metric {
+	p.seriesLock.Lock()
+	defer p.seriesLock.Unlock()
+
+	now := time.Now()
+	for _, m := range in {
+		var s *series
+		for _, field := range m.FieldList() {
+			if !p.filter.Match(field.Key) {
+				continue
+			}
+			// Non-finite values would spoil the model
+			value, ok := toFloat(field.Value)
+			if !ok || math.IsNaN(value) || math.IsInf(value, 0) {
+				continue
+			}
+
+			if s == nil {
+				id := m.HashID()
+				if s = p.series[id]; s == nil {
+					s = &series{Models: make(map[string]*model)}
+					p.series[id] = s
+				}
+				s.LastSeen = now
+			}
+			mdl, found := s.Models[field.Key]
+			if !found {
+				mdl = &model{}
+				s.Models[field.Key] = mdl
+			}
+
+			score, ready := p.detect(mdl, value)
+			if !ready {
+				continue
+			}
+			m.AddField(field.Key+"_anomaly_score", score)
+			m.AddField(field.Key+"_is_anomaly", score > p.Threshold)
+		}
+	}
+	p.purge(now)
+
+	return in
+}
+
+// purge removes all series not seen within the expiry duration
+func (p *Anomaly) purge(now time.Time) {
+	expiry := time.Duration(p.Expiry)
+	// No need to check the series too often
+	if expiry <= 0 || now.Sub(p.lastPurge) < expiry/10 {
+		return
+	}
+	p.lastPurge = now
+
+	maps.DeleteFunc(p.series, func(_ uint64, s *series) bool {
+		return now.Sub(s.LastSeen) > expiry
+	})
+}
+
+func (p *Anomaly) GetState() interface{} {
+	p.seriesLock.Lock()
+	defer p.seriesLock.Unlock()
+
+	// Copy the models to avoid modifications while serializing the state
+	s := state{
+		Algorithm:    p.Algorithm,
+		SeasonLength: p.SeasonLength,
+		Series:       make(map[uint64]*series, len(p.series)),
+	}
+	for id, entry := range p.series {
+		models := make(map[string]*model, len(entry.Models))
+		for name, m := range entry.Models {
+			c := *m
+			c.Window = slices.Clone(m.Window)
+			c.Seasonal = slices.Clone(m.Seasonal)
+			models[name] = &c
+		}
+		s.Series[id] = &series{LastSeen: entry.LastSeen, Models: models}
+	}
+	return s
+}
+
+func (p *Anomaly) SetState(s interface{}) error {
+	restored, ok := s.(state)
+	if !ok {
+		return fmt.Errorf("state has wrong type %T", s)
+	}
+
+	// Models of a different algorithm or season cannot be used
+	if restored.Algorithm != p.Algorithm {
+		p.Log.Warnf("Discarding state of algorithm %q", restored.Algorithm)
+		return nil
+	}
+	if p.Algorithm == "holt_winters" && restored.SeasonLength != p.SeasonLength {
+		p.Log.Warnf("Discarding state with season length %d", restored.SeasonLength)
+		return nil
+	}
+
+	p.seriesLock.Lock()
+	defer p.seriesLock.Unlock()
+
+	p.series = make(map[uint64]*series, len(restored.Series))
+	for id, entry := range restored.Series {
+		if entry == nil {
+			continue
+		}
+		if entry.Models == nil {
+			entry.Models = make(map[string]*model)
+		}
+		// Skip missing models and inconsistent seasonal models as those
+		// cannot be used for forecasting
+		maps.DeleteFunc(entry.Models, func(_ string, m *model) bool {
+			if m == nil {
+				return true
+			}
+			return p.Algorithm == "holt_winters" && uint64(len(m.Seasonal)) != min(m.Count, uint64(p.SeasonLength))
+		})
+		p.series[id] = entry
+	}
+
+	return nil
+}
+
+func (*Anomaly) StateVersion() int {
+	return 1
+}
+
+func (*Anomaly) MigrateState(version int, _ []byte) ([]byte, error) {
+	return nil, fmt.Errorf("unsupported state version %d", version)
+}
+
+func toFloat(v interface{}) (float64, bool) {
+	switch v := v.(type) {
+	case float64:
+		return v, true
+	case int64:
+		return float64(v), true
+	case uint64:
+		return float64(v), true
+	}
+	return 0, false
+}
+
+func init() {
+	processors.Add("anomaly", func() telegraf.Processor {
+		return &Anomaly{
+			Algorithm: "zscore",
+			Threshold: 3.0,
+			Warmup:    10,
+			Alpha:     0.1,
+			Beta:      0.01,
+			Gamma:     0.1,
+			Window:    100,
+			Expiry:    config.Duration(24 * time.Hour),
+		}
+	})
+}
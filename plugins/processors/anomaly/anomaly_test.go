@@ -0,0 +1,350 @@
+package anomaly
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/config"
+	"github.com/influxdata/telegraf/metric"
+	"github.com/influxdata/telegraf/plugins/processors"
+	"github.com/influxdata/telegraf/testutil"
+)
+
+func TestInitFail(t *testing.T) {
+	tests := []struct {
+		name     string
+		modify   func(p *Anomaly)
+		expected string
+	}{
+		{
+			name:     "no fields",
+			modify:   func(p *Anomaly) { p.Fields = nil },
+			expected: "no fields configured",
+		},
+		{
+			name:     "invalid threshold",
+			modify:   func(p *Anomaly) { p.Threshold = 0 },
+			expected: "threshold 0 must be positive",
+		},
+		{
+			name:     "invalid alpha",
+			modify:   func(p *Anomaly) { p.Alpha = 1.5 },
+			expected: "alpha 1.5 out of range (0,1]",
+		},
+		{
+			name:     "unknown algorithm",
+			modify:   func(p *Anomaly) { p.Algorithm = "foo" },
+			expected: `unknown algorithm "foo"`,
+		},
+		{
+			name: "window too small",
+			modify: func(p *Anomaly) {
+				p.Algorithm = "mad"
+				p.Window = 2
+			},
+			expected: "window 2 must be at least 3",
+		},
+		{
+			name:     "missing season length",
+			modify:   func(p *Anomaly) { p.Algorithm = "holt_winters" },
+			expected: "season length 0 must be at least 2",
+		},
+		{
+			name: "invalid gamma",
+			modify: func(p *Anomaly) {
+				p.Algorithm = "holt_winters"
+				p.SeasonLength = 4
+				p.Gamma = 0
+			},
+			expected: "gamma 0 out of range (0,1]",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			plugin := newPlugin()
+			plugin.Fields = []string{"value"}
+			tt.modify(plugin)
+			require.EqualError(t, plugin.Init(), tt.expected)
+		})
+	}
+}
+
+func TestDetection(t *testing.T) {
+	for _, algorithm := range []string{"zscore", "mad", "holt_winters"} {
+		t.Run(algorithm, func(t *testing.T) {
+			plugin := newPlugin()
+			plugin.Fields = []string{"value"}
+			plugin.Algorithm = algorithm
+			plugin.SeasonLength = 4
+			require.NoError(t, plugin.Init())
+
+			// Train the models with a noisy seasonal pattern
+			for i := range 200 {
+				plugin.Apply(newMetric("a", seasonal(i)))
+			}
+
+			// Normal values should not be reported
+			normal := plugin.Apply(newMetric("a", seasonal(200)))
+			require.Len(t, normal, 1)
+			require.Equal(t, false, normal[0].Fields()["value_is_anomaly"])
+
+			// Spikes should be reported
+			spike := plugin.Apply(newMetric("a", 1000.0))
+			require.Len(t, spike, 1)
+			require.Equal(t, true, spike[0].Fields()["value_is_anomaly"])
+			require.Greater(t, spike[0].Fields()["value_anomaly_score"], plugin.Threshold)
+		})
+	}
+}
+
+func TestHoltWintersSeasonality(t *testing.T) {
+	plugin := newPlugin()
+	plugin.Fields = []string{"value"}
+	plugin.Algorithm = "holt_winters"
+	plugin.SeasonLength = 4
+	require.NoError(t, plugin.Init())
+
+	for i := range 202 {
+		plugin.Apply(newMetric("a", seasonal(i)))
+	}
+
+	// A value that is normal at the seasonal peak is anomalous in the trough
+	peak := plugin.Apply(newMetric("a", 31.0))
+	require.Equal(t, false, peak[0].Fields()["value_is_anomaly"])
+	plugin.Apply(newMetric("a", seasonal(203)))
+	trough := plugin.Apply(newMetric("a", 31.0))
+	require.Equal(t, true, trough[0].Fields()["value_is_anomaly"])
+}
+
+func TestWarmup(t *testing.T) {
+	plugin := newPlugin()
+	plugin.Fields = []string{"value"}
+	plugin.Warmup = 5
+	require.NoError(t, plugin.Init())
+
+	for i := range 5 {
+		actual := plugin.Apply(newMetric("a", float64(i%2)))
+		testutil.RequireMetricsEqual(t, []telegraf.Metric{newMetric("a", float64(i%2))}, actual)
+	}
+	actual := plugin.Apply(newMetric("a", 0.0))
+	require.Contains(t, actual[0].Fields(), "value_anomaly_score")
+	require.Contains(t, actual[0].Fields(), "value_is_anomaly")
+
+	// Other series need their own warmup
+	actual = plugin.Apply(newMetric("b", 0.0))
+	testutil.RequireMetricsEqual(t, []telegraf.Metric{newMetric("b", 0.0)}, actual)
+}
+
+func TestFieldSelection(t *testing.T) {
+	plugin := newPlugin()
+	plugin.Fields = []string{"usage_*"}
+	plugin.Warmup = 0
+	require.NoError(t, plugin.Init())
+
+	fields := map[string]interface{}{
+		"usage_user":   int64(1),
+		"usage_system": uint64(2),
+		"usage_state":  "ok",
+		"idle":         3.0,
+	}
+	plugin.Apply(metric.New("cpu", map[string]string{}, fields, time.Unix(0, 0)))
+	actual := plugin.Apply(metric.New("cpu", map[string]string{}, fields, time.Unix(0, 0)))
+
+	expected := []telegraf.Metric{
+		metric.New(
+			"cpu",
+			map[string]string{},
+			map[string]interface{}{
+				"usage_user":                 int64(1),
+				"usage_user_anomaly_score":   0.0,
+				"usage_user_is_anomaly":      false,
+				"usage_system":               uint64(2),
+				"usage_system_anomaly_score": 0.0,
+				"usage_system_is_anomaly":    false,
+				"usage_state":                "ok",
+				"idle":                       3.0,
+			},
+			time.Unix(0, 0),
+		),
+	}
+	testutil.RequireMetricsEqual(t, expected, actual)
+}
+
+func TestExpiry(t *testing.T) {
+	plugin := newPlugin()
+	plugin.Fields = []string{"value"}
+	plugin.Expiry = config.Duration(time.Hour)
+	require.NoError(t, plugin.Init())
+
+	plugin.Apply(newMetric("a", 1.0), newMetric("b", 1.0))
+	require.Len(t, plugin.series, 2)
+
+	// Pretend the series "a" was not seen for a long time
+	plugin.series[newMetric("a", 1.0).HashID()].LastSeen = time.Now().Add(-2 * time.Hour)
+	plugin.lastPurge = time.Now().Add(-time.Hour)
+
+	plugin.Apply(newMetric("b", 1.0))
+	require.Len(t, plugin.series, 1)
+	require.Contains(t, plugin.series, newMetric("b", 1.0).HashID())
+}
+
+func TestExpiryDefault(t *testing.T) {
+	plugin := processors.Processors["anomaly"]().(processors.HasUnwrap).Unwrap().(*Anomaly)
+	plugin.Fields = []string{"value"}
+	plugin.Log = testutil.Logger{}
+	require.NoError(t, plugin.Init())
+
+	plugin.Apply(newMetric("a", 1.0), newMetric("b", 1.0))
+	require.Len(t, plugin.series, 2)
+
+	// Series not seen for more than a day must be removed by default
+	plugin.series[newMetric("a", 1.0).HashID()].LastSeen = time.Now().Add(-25 * time.Hour)
+	plugin.lastPurge = time.Now().Add(-24 * time.Hour)
+
+	plugin.Apply(newMetric("b", 1.0))
+	require.Len(t, plugin.series, 1)
+	require.Contains(t, plugin.series, newMetric("b", 1.0).HashID())
+}
+
+func TestStatePersistence(t *testing.T) {
+	for _, algorithm := range []string{"zscore", "mad", "holt_winters"} {
+		t.Run(algorithm, func(t *testing.T) {
+			plugin := newPlugin()
+			plugin.Fields = []string{"value"}
+			plugin.Algorithm = algorithm
+			plugin.SeasonLength = 4
+			require.NoError(t, plugin.Init())
+
+			for i := range 50 {
+				plugin.Apply(newMetric("a", seasonal(i)), newMetric("b", seasonal(i+1)))
+			}
+
+			// Serialize and restore the state the same way the persister does
+			var pi telegraf.StatefulPlugin = plugin
+			buf, err := json.Marshal(pi.GetState())
+			require.NoError(t, err)
+
+			restored := newPlugin()
+			restored.Fields = []string{"value"}
+			restored.Algorithm = algorithm
+			restored.SeasonLength = 4
+			require.NoError(t, restored.Init())
+
+			nstate := reflect.New(reflect.TypeOf(restored.GetState())).Interface()
+			require.NoError(t, json.Unmarshal(buf, &nstate))
+			require.NoError(t, restored.SetState(reflect.ValueOf(nstate).Elem().Interface()))
+
+			// Both instances should continue with the same models
+			for i := 50; i < 60; i++ {
+				expected := plugin.Apply(newMetric("a", seasonal(i)), newMetric("b", 1000.0))
+				actual := restored.Apply(newMetric("a", seasonal(i)), newMetric("b", 1000.0))
+				testutil.RequireMetricsEqual(t, expected, actual)
+				require.Contains(t, actual[0].Fields(), "value_anomaly_score")
+			}
+		})
+	}
+}
+
+func TestStateMismatch(t *testing.T) {
+	plugin := newPlugin()
+	plugin.Fields = []string{"value"}
+	require.NoError(t, plugin.Init())
+	for i := range 20 {
+		plugin.Apply(newMetric("a", seasonal(i)))
+	}
+
+	logger := &testutil.CaptureLogger{}
+	restored := newPlugin()
+	restored.Fields = []string{"value"}
+	restored.Algorithm = "holt_winters"
+	restored.SeasonLength = 4
+	restored.Log = logger
+	require.NoError(t, restored.Init())
+	require.NoError(t, restored.SetState(plugin.GetState()))
+	require.Empty(t, restored.series)
+	require.Len(t, logger.Warnings(), 1)
+	require.Contains(t, logger.Warnings()[0], `Discarding state of algorithm "zscore"`)
+
+	require.ErrorContains(t, restored.SetState([]byte("foo")), "state has wrong type []uint8")
+}
+
+func TestStateCorrupt(t *testing.T) {
+	for _, algorithm := range []string{"zscore", "mad", "holt_winters"} {
+		t.Run(algorithm, func(t *testing.T) {
+			plugin := newPlugin()
+			plugin.Fields = []string{"value"}
+			plugin.Algorithm = algorithm
+			plugin.SeasonLength = 4
+			require.NoError(t, plugin.Init())
+
+			// Simulate a hand-edited state file with a null model
+			buf := []byte(`{"algorithm":"` + algorithm + `","season_length":4,"series":{"1":{"models":{"value":null}}}}`)
+			var s state
+			require.NoError(t, json.Unmarshal(buf, &s))
+			require.NoError(t, plugin.SetState(s))
+			require.Empty(t, plugin.series[1].Models)
+
+			require.NotPanics(t, func() {
+				plugin.series[newMetric("a", 1.0).HashID()] = plugin.series[1]
+				plugin.Apply(newMetric("a", 1.0))
+			})
+		})
+	}
+}
+
+func TestTracking(t *testing.T) {
+	plugin := newPlugin()
+	plugin.Fields = []string{"value"}
+	plugin.Warmup = 0
+	require.NoError(t, plugin.Init())
+
+	var delivered int
+	notify := func(telegraf.DeliveryInfo) { delivered++ }
+	input := make([]telegraf.Metric, 0, 3)
+	for i := range 3 {
+		m, _ := metric.WithTracking(newMetric("a", float64(i)), notify)
+		input = append(input, m)
+	}
+
+	for _, m := range plugin.Apply(input...) {
+		m.Accept()
+	}
+	require.Eventually(t, func() bool { return delivered == 3 }, time.Second, 100*time.Millisecond)
+}
+
+func newPlugin() *Anomaly {
+	return &Anomaly{
+		Algorithm: "zscore",
+		Threshold: 3.0,
+		Warmup:    10,
+		Alpha:     0.1,
+		Beta:      0.01,
+		Gamma:     0.1,
+		Window:    100,
+		Expiry:    config.Duration(24 * time.Hour),
+		Log:       testutil.Logger{},
+	}
+}
+
+func newMetric(host string, value interface{}) telegraf.Metric {
+	return metric.New(
+		"test",
+		map[string]string{"host": host},
+		map[string]interface{}{"value": value},
+		time.Unix(0, 0),
+	)
+}
+
+// seasonal returns the value of a seasonal pattern with a period of four and
+// some deterministic noise
+func seasonal(i int) float64 {
+	pattern := []float64{10, 20, 30, 20}
+	noise := []float64{0.5, -0.3, 0.2, -0.6, 0.4, -0.1, 0.3}
+	return pattern[i%len(pattern)] + noise[i%len(noise)]
+}
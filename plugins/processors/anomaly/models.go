@@ -0,0 +1,141 @@
+package anomaly
+
+import (
+	"math"
+	"slices"
+)
+
+// Minimum deviation to avoid division by zero for constant series
+const minDeviation = 1e-9
+
+// model is the rolling state of a single field of a series. The model
+// contains the state of all algorithms to allow persisting it as JSON,
+// only the state of the configured algorithm is used.
+type model struct {
+	Count uint64 `json:"count"`
+
+	// Exponentially weighted mean and variance of the values (zscore) or
+	// the forecast residuals (holt_winters)
+	Mean     float64 `json:"mean,omitempty"`
+	Variance float64 `json:"variance,omitempty"`
+
+	// Rolling window of the most recent values (mad)
+	Window []float64 `json:"window,omitempty"`
+
+	// Additive Holt-Winters components (holt_winters)
+	Level    float64   `json:"level,omitempty"`
+	Trend    float64   `json:"trend,omitempty"`
+	Seasonal []float64 `json:"seasonal,omitempty"`
+}
+
+// detector computes the anomaly score of the value with respect to the
+// model before updating the model with the value. The returned flag is
+// false if the model is not yet ready for scoring.
+type detector func(m *model, value float64) (score float64, ready bool)
+
+// zscore scores the value by its distance to the exponentially weighted mean
+// in units of the exponentially weighted standard deviation
+func (p *Anomaly) zscore(m *model, value float64) (float64, bool) {
+	m.Count++
+	if m.Count == 1 {
+		m.Mean = value
+		return 0, false
+	}
+
+	diff := value - m.Mean
+	score := math.Abs(diff) / max(math.Sqrt(m.Variance), minDeviation)
+
+	ready := m.Count > uint64(p.Warmup)
+	updateEWMA(m, diff, p.Alpha)
+	return score, ready
+}
+
+// mad scores the value by its distance to the median of the window in units
+// of the scaled median absolute deviation
+func (p *Anomaly) mad(m *model, value float64) (float64, bool) {
+	m.Count++
+
+	var score float64
+	ready := len(m.Window) > 0 && m.Count > uint64(p.Warmup)
+	if ready {
+		values := slices.Clone(m.Window)
+		med := median(values)
+		for i, v := range values {
+			values[i] = math.Abs(v - med)
+		}
+		// Scale the MAD to be a consistent estimator of the standard
+		// deviation for normally distributed data
+		deviation := 1.4826 * median(values)
+		score = math.Abs(value-med) / max(deviation, minDeviation)
+	}
+
+	m.Window = append(m.Window, value)
+	if len(m.Window) > p.Window {
+		m.Window = slices.Delete(m.Window, 0, len(m.Window)-p.Window)
+	}
+	return score, ready
+}
+
+// holtWinters scores the value by the deviation from the forecast of an
+// additive Holt-Winters model in units of the exponentially weighted standard
+// deviation of the forecast residuals
+func (p *Anomaly) holtWinters(m *model, value float64) (float64, bool) {
+	m.Count++
+	season := uint64(p.SeasonLength)
+
+	// Collect the first season for initializing the model
+	if m.Count <= season {
+		m.Seasonal = append(m.Seasonal, value)
+		if m.Count == season {
+			var sum float64
+			for _, v := range m.Seasonal {
+				sum += v
+			}
+			m.Level = sum / float64(season)
+			m.Trend = 0
+			for i := range m.Seasonal {
+				m.Seasonal[i] -= m.Level
+			}
+		}
+		return 0, false
+	}
+
+	idx := (m.Count - 1) % season
+	seasonal := m.Seasonal[idx]
+	residual := value - (m.Level + m.Trend + seasonal)
+
+	// The first residual initializes the deviation
+	var score float64
+	ready := m.Count > season+uint64(p.Warmup)
+	if m.Count == season+1 {
+		m.Variance = residual * residual
+	} else {
+		score = math.Abs(residual) / max(math.Sqrt(m.Variance), minDeviation)
+		updateEWMA(m, residual, p.Alpha)
+	}
+
+	level := m.Level
+	m.Level = p.Alpha*(value-seasonal) + (1-p.Alpha)*(m.Level+m.Trend)
+	m.Trend = p.Beta*(m.Level-level) + (1-p.Beta)*m.Trend
+	m.Seasonal[idx] = p.Gamma*(value-m.Level) + (1-p.Gamma)*seasonal
+
+	return score, ready
+}
+
+// updateEWMA updates the exponentially weighted mean and variance with the
+// given difference of the new value to the current mean
+func updateEWMA(m *model, diff, alpha float64) {
+	incr := alpha * diff
+	m.Mean += incr
+	m.Variance = (1 - alpha) * (m.Variance + diff*incr)
+}
+
+// median returns the median of the values, sorting the values in place
+func median(values []float64) float64 {
+	slices.Sort(values)
+	n := len(values)
+	if n%2 == 1 {
+		return values[n/2]
+	}
+	return (values[n/2-1] + values[n/2]) / 2
+}
@@ -0,0 +1,386 @@
+//go:generate ../../../tools/readme_config_includer/generator
+package cardinality
+
+import (
+	"context"
+	_ "embed"
+	"errors"
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/config"
+	"github.com/influxdata/telegraf/metric"
+	"github.com/influxdata/telegraf/plugins/processors"
+)
+
+//go:embed sample.conf
+var sampleConfig string
+
+// overflowValue is the tag value used for collapsing series exceeding the limit
+const overflowValue = "__overflow__"
+
+type Cardinality struct {
+	Limit          int             `toml:"limit"`
+	GroupBy        []string        `toml:"group_by"`
+	Window         config.Duration `toml:"window"`
+	Action         string          `toml:"action"`
+	OverflowTags   []string        `toml:"overflow_tags"`
+	ReportInterval config.Duration `toml:"report_interval"`
+	ReportTop      int             `toml:"report_top"`
+	Log            telegraf.Logger `toml:"-"`
+
+	groups map[string]*group
+	now    func() time.Time
+
+	// Protects the groups as reports are created in the background
+	groupsLock sync.Mutex
+	cancel     context.CancelFunc
+	wg         sync.WaitGroup
+}
+
+// group contains the series of a measurement or a group of tags
+type group struct {
+	measurement string
+	tags        map[string]string
+	series      map[uint64]*series
+	rejected    int64
+	limited     bool
+
+	// Unique values of the tag keys of rejected series since the last report,
+	// capped at the limit per key to bound the memory
+	rejectedValues map[string]map[string]bool
+}
+
+// series contains the tags of a tracked series and the last time it was seen
+type series struct {
+	tags     []telegraf.Tag
+	lastSeen time.Time
+}
+
+func (*Cardinality) SampleConfig() string {
+	return sampleConfig
+}
+
+func (c *Cardinality) Init() error {
+	if c.Limit < 1 {
+		return errors.New("limit must be positive")
+	}
+	if c.Window <= 0 {
+		return errors.New("window must be positive")
+	}
+	if c.ReportInterval < 0 {
+		return errors.New("report interval must not be negative")
+	}
+	if c.ReportTop < 0 {
+		return errors.New("report top must not be negative")
+	}
+
+	switch c.Action {
+	case "":
+		c.Action = "drop"
+	case "drop", "overflow":
+	default:
+		return fmt.Errorf("invalid action %q", c.Action)
+	}
+
+	c.groups = make(map[string]*group)
+	c.now = time.Now
+
+	return nil
+}
+
+func (c *Cardinality) Start(acc telegraf.Accumulator) error {
+	// Expire series and report independently of incoming metrics so memory
+	// is released and the report is also emitted if the series stop sending
+	ctx, cancel := context.WithCancel(context.Background())
+	c.cancel = cancel
+
+	c.wg.Add(1)
+	go func() {
+		defer c.wg.Done()
+
+		expiry := time.NewTicker(time.Duration(c.Window))
+		defer expiry.Stop()
+
+		var report <-chan time.Time
+		if c.ReportInterval > 0 {
+			ticker := time.NewTicker(time.Duration(c.ReportInterval))
+			defer ticker.Stop()
+			report = ticker.C
+		}
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-expiry.C:
+				c.groupsLock.Lock()
+				c.sweep(c.now())
+				c.groupsLock.Unlock()
+			case <-report:
+				c.emitReport(acc)
+			}
+		}
+	}()
+
+	return nil
+}
+
+func (c *Cardinality) Stop() {
+	if c.cancel != nil {
+		c.cancel()
+	}
+	c.wg.Wait()
+}
+
+func (c *Cardinality) Add(m telegraf.Metric, acc telegraf.Accumulator) error {
+	c.groupsLock.Lock()
+	defer c.groupsLock.Unlock()
+
+	if c.admit(m, c.now()) {
+		acc.AddMetric(m)
+	} else {
+		m.Drop()
+	}
+	return nil
+}
+
+// emitReport adds the report metrics to the given accumulator
+func (c *Cardinality) emitReport(acc telegraf.Accumulator) {
+	c.groupsLock.Lock()
+	defer c.groupsLock.Unlock()
+
+	for _, m := range c.report(c.now()) {
+		acc.AddMetric(m)
+	}
+}
+
+// admit tracks the series of the given metric and returns false if the metric
+// should be dropped. Metrics of new series exceeding the limit are modified in
+// place for the overflow action.
+func (c *Cardinality) admit(m telegraf.Metric, now time.Time) bool {
+	g := c.group(m)
+
+	id := m.HashID()
+	if s, found := g.series[id]; found {
+		s.lastSeen = now
+		return true
+	}
+
+	// Make room by forgetting the series not seen within the window
+	if len(g.series) >= c.Limit {
+		c.expire(g, now)
+	}
+
+	if len(g.series) < c.Limit {
+		g.limited = false
+		tags := make([]telegraf.Tag, 0, len(m.TagList()))
+		for _, tag := range m.TagList() {
+			tags = append(tags, *tag)
+		}
+		g.series[id] = &series{tags: tags, lastSeen: now}
+		return true
+	}
+
+	if !g.limited {
+		g.limited = true
+		c.Log.Warnf("Limit of %d series reached for %s", c.Limit, g)
+	}
+	g.rejected++
+	if c.ReportInterval > 0 && c.ReportTop > 0 {
+		c.trackRejected(g, m)
+	}
+
+	if c.Action == "drop" {
+		return false
+	}
+
+	// Collapse the series into the overflow series
+	keys := make([]string, 0, len(m.TagList()))
+	for _, tag := range m.TagList() {
+		if len(c.OverflowTags) > 0 && !slices.Contains(c.OverflowTags, tag.Key) {
+			continue
+		}
+		if slices.Contains(c.GroupBy, tag.Key) {
+			continue
+		}
+		keys = append(keys, tag.Key)
+	}
+	for _, k := range keys {
+		m.AddTag(k, overflowValue)
+	}
+	return true
+}
+
+func (c *Cardinality) group(m telegraf.Metric) *group {
+	var key strings.Builder
+	key.WriteString(m.Name())
+	tags := make(map[string]string, len(c.GroupBy))
+	for _, k := range c.GroupBy {
+		v, _ := m.GetTag(k)
+		tags[k] = v
+		key.WriteByte(0)
+		key.WriteString(v)
+	}
+
+	g, found := c.groups[key.String()]
+	if !found {
+		g = &group{
+			measurement: m.Name(),
+			tags:        tags,
+			series:      make(map[uint64]*series),
+		}
+		c.groups[key.String()] = g
+	}
+	return g
+}
+
+// trackRejected records the tag values of the rejected metric for the report
+func (c *Cardinality) trackRejected(g *group, m telegraf.Metric) {
+	if g.rejectedValues == nil {
+		g.rejectedValues = make(map[string]map[string]bool)
+	}
+	for _, tag := range m.TagList() {
+		if _, found := g.tags[tag.Key]; found {
+			continue
+		}
+		values := g.rejectedValues[tag.Key]
+		if values == nil {
+			values = make(map[string]bool)
+			g.rejectedValues[tag.Key] = values
+		}
+		if len(values) < c.Limit {
+			values[tag.Value] = true
+		}
+	}
+}
+
+func (c *Cardinality) expire(g *group, now time.Time) {
+	threshold := now.Add(-time.Duration(c.Window))
+	for id, s := range g.series {
+		if s.lastSeen.Before(threshold) {
+			delete(g.series, id)
+		}
+	}
+}
+
+// sweep removes the series not seen within the window and the groups without
+// series unless rejections are pending for the next report
+func (c *Cardinality) sweep(now time.Time) {
+	pending := c.ReportInterval > 0
+	for key, g := range c.groups {
+		c.expire(g, now)
+		if len(g.series) == 0 && (g.rejected == 0 || !pending) {
+			delete(c.groups, key)
+		}
+	}
+}
+
+// report creates metrics for all groups that rejected series since the last
+// report including the tag keys with the most unique values. Furthermore,
+// expired series and empty groups are removed.
+func (c *Cardinality) report(now time.Time) []telegraf.Metric {
+	var out []telegraf.Metric
+	for _, g := range c.groups {
+		if g.rejected > 0 {
+			out = append(out, c.reportGroup(g, now)...)
+			g.rejected = 0
+			g.rejectedValues = nil
+		}
+	}
+	c.sweep(now)
+	return out
+}
+
+func (c *Cardinality) reportGroup(g *group, now time.Time) []telegraf.Metric {
+	tags := make(map[string]string, len(g.tags)+1)
+	for k, v := range g.tags {
+		tags[k] = v
+	}
+	tags["measurement"] = g.measurement
+
+	fields := map[string]interface{}{
+		"series":   int64(len(g.series)),
+		"limit":    int64(c.Limit),
+		"rejected": g.rejected,
+	}
+	out := []telegraf.Metric{metric.New("cardinality", tags, fields, now)}
+
+	// Determine the number of unique values for each tag key of the tracked
+	// and the rejected series
+	values := make(map[string]map[string]bool, len(g.rejectedValues))
+	for k, v := range g.rejectedValues {
+		values[k] = make(map[string]bool, len(v))
+		for tv := range v {
+			values[k][tv] = true
+		}
+	}
+	for _, s := range g.series {
+		for _, tag := range s.tags {
+			if _, found := g.tags[tag.Key]; found {
+				continue
+			}
+			if values[tag.Key] == nil {
+				values[tag.Key] = make(map[string]bool)
+			}
+			values[tag.Key][tag.Value] = true
+		}
+	}
+	keys := make([]string, 0, len(values))
+	for k := range values {
+		keys = append(keys, k)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if len(values[keys[i]]) != len(values[keys[j]]) {
+			return len(values[keys[i]]) > len(values[keys[j]])
+		}
+		return keys[i] < keys[j]
+	})
+	if len(keys) > c.ReportTop {
+		keys = keys[:c.ReportTop]
+	}
+
+	for _, k := range keys {
+		keyTags := make(map[string]string, len(tags)+1)
+		for tk, tv := range tags {
+			keyTags[tk] = tv
+		}
+		keyTags["tag_key"] = k
+		keyFields := map[string]interface{}{"unique_values": int64(len(values[k]))}
+		out = append(out, metric.New("cardinality_tag", keyTags, keyFields, now))
+	}
+
+	return out
+}
+
+func (g *group) String() string {
+	if len(g.tags) == 0 {
+		return fmt.Sprintf("measurement %q", g.measurement)
+	}
+	keys := make([]string, 0, len(g.tags))
+	for k := range g.tags {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	parts := make([]string, 0, len(keys))
+	for _, k := range keys {
+		parts = append(parts, k+"="+g.tags[k])
+	}
+	return fmt.Sprintf("measurement %q with %s", g.measurement, strings.Join(parts, ","))
+}
+
+func init() {
+	processors.AddStreaming("cardinality", func() telegraf.StreamingProcessor {
+		return &Cardinality{
+			Limit:          1000,
+			Window:         config.Duration(time.Hour),
+			ReportInterval: config.Duration(time.Minute),
+			ReportTop:      5,
+		}
+	})
+}
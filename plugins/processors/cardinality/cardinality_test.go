@@ -0,0 +1,285 @@
+package cardinality
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/config"
+	"github.com/influxdata/telegraf/metric"
+	"github.com/influxdata/telegraf/testutil"
+)
+
+func TestInitFail(t *testing.T) {
+	tests := []struct {
+		name     string
+		plugin   *Cardinality
+		expected string
+	}{
+		{
+			name:     "no limit",
+			plugin:   &Cardinality{Window: config.Duration(time.Hour)},
+			expected: "limit must be positive",
+		},
+		{
+			name:     "no window",
+			plugin:   &Cardinality{Limit: 10},
+			expected: "window must be positive",
+		},
+		{
+			name:     "negative report top",
+			plugin:   &Cardinality{Limit: 10, Window: config.Duration(time.Hour), ReportTop: -1},
+			expected: "report top must not be negative",
+		},
+		{
+			name:     "invalid action",
+			plugin:   &Cardinality{Limit: 10, Window: config.Duration(time.Hour), Action: "foo"},
+			expected: `invalid action "foo"`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require.ErrorContains(t, tt.plugin.Init(), tt.expected)
+		})
+	}
+}
+
+func TestDrop(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	input := []telegraf.Metric{
+		metric.New("cpu", map[string]string{"host": "a"}, map[string]interface{}{"value": 1}, now),
+		metric.New("cpu", map[string]string{"host": "b"}, map[string]interface{}{"value": 2}, now),
+		metric.New("cpu", map[string]string{"host": "c"}, map[string]interface{}{"value": 3}, now),
+		metric.New("cpu", map[string]string{"host": "a"}, map[string]interface{}{"value": 4}, now),
+		metric.New("mem", map[string]string{"host": "c"}, map[string]interface{}{"value": 5}, now),
+	}
+	expected := []telegraf.Metric{
+		metric.New("cpu", map[string]string{"host": "a"}, map[string]interface{}{"value": 1}, now),
+		metric.New("cpu", map[string]string{"host": "b"}, map[string]interface{}{"value": 2}, now),
+		metric.New("cpu", map[string]string{"host": "a"}, map[string]interface{}{"value": 4}, now),
+		metric.New("mem", map[string]string{"host": "c"}, map[string]interface{}{"value": 5}, now),
+	}
+
+	plugin := &Cardinality{
+		Limit:  2,
+		Window: config.Duration(time.Hour),
+		Log:    testutil.Logger{},
+	}
+	require.NoError(t, plugin.Init())
+
+	actual := apply(t, plugin, input...)
+	testutil.RequireMetricsEqual(t, expected, actual)
+}
+
+func TestOverflow(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	input := []telegraf.Metric{
+		metric.New("http", map[string]string{"service": "a", "path": "/1", "code": "200"}, map[string]interface{}{"value": 1}, now),
+		metric.New("http", map[string]string{"service": "a", "path": "/2", "code": "200"}, map[string]interface{}{"value": 2}, now),
+		metric.New("http", map[string]string{"service": "b", "path": "/3", "code": "200"}, map[string]interface{}{"value": 3}, now),
+	}
+	expected := []telegraf.Metric{
+		metric.New("http", map[string]string{"service": "a", "path": "/1", "code": "200"}, map[string]interface{}{"value": 1}, now),
+		metric.New("http", map[string]string{"service": "a", "path": "__overflow__", "code": "200"}, map[string]interface{}{"value": 2}, now),
+		metric.New("http", map[string]string{"service": "b", "path": "/3", "code": "200"}, map[string]interface{}{"value": 3}, now),
+	}
+
+	plugin := &Cardinality{
+		Limit:        1,
+		GroupBy:      []string{"service"},
+		Window:       config.Duration(time.Hour),
+		Action:       "overflow",
+		OverflowTags: []string{"path"},
+		Log:          testutil.Logger{},
+	}
+	require.NoError(t, plugin.Init())
+
+	actual := apply(t, plugin, input...)
+	testutil.RequireMetricsEqual(t, expected, actual)
+}
+
+func TestWindow(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+
+	plugin := &Cardinality{
+		Limit:  1,
+		Window: config.Duration(time.Minute),
+		Log:    testutil.Logger{},
+	}
+	require.NoError(t, plugin.Init())
+	plugin.now = func() time.Time { return now }
+
+	a := metric.New("cpu", map[string]string{"host": "a"}, map[string]interface{}{"value": 1}, now)
+	b := metric.New("cpu", map[string]string{"host": "b"}, map[string]interface{}{"value": 1}, now)
+	require.Len(t, apply(t, plugin, a.Copy()), 1)
+	require.Empty(t, apply(t, plugin, b.Copy()))
+
+	// Series "a" is still within the window
+	now = now.Add(30 * time.Second)
+	require.Len(t, apply(t, plugin, a.Copy()), 1)
+	require.Empty(t, apply(t, plugin, b.Copy()))
+
+	// Series "a" expired so "b" is accepted
+	now = now.Add(2 * time.Minute)
+	require.Len(t, apply(t, plugin, b.Copy()), 1)
+	require.Empty(t, apply(t, plugin, a.Copy()))
+}
+
+func TestReport(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+
+	plugin := &Cardinality{
+		Limit:          3,
+		Window:         config.Duration(time.Hour),
+		ReportInterval: config.Duration(time.Minute),
+		ReportTop:      1,
+		Log:            testutil.Logger{},
+	}
+	require.NoError(t, plugin.Init())
+	plugin.now = func() time.Time { return now }
+
+	input := []telegraf.Metric{
+		metric.New("cpu", map[string]string{"host": "a", "pod": "1"}, map[string]interface{}{"value": 1}, now),
+		metric.New("cpu", map[string]string{"host": "a", "pod": "2"}, map[string]interface{}{"value": 1}, now),
+		metric.New("cpu", map[string]string{"host": "a", "pod": "3"}, map[string]interface{}{"value": 1}, now),
+		metric.New("cpu", map[string]string{"host": "a", "pod": "4"}, map[string]interface{}{"value": 1}, now),
+		metric.New("cpu", map[string]string{"host": "a", "pod": "5"}, map[string]interface{}{"value": 1}, now),
+		metric.New("mem", map[string]string{"host": "a"}, map[string]interface{}{"value": 1}, now),
+	}
+	require.Len(t, apply(t, plugin, input...), 4)
+
+	// Trigger the report
+	now = now.Add(time.Minute)
+	expected := []telegraf.Metric{
+		metric.New(
+			"cardinality",
+			map[string]string{"measurement": "cpu"},
+			map[string]interface{}{"series": int64(3), "limit": int64(3), "rejected": int64(2)},
+			now,
+		),
+		metric.New(
+			"cardinality_tag",
+			map[string]string{"measurement": "cpu", "tag_key": "pod"},
+			map[string]interface{}{"unique_values": int64(5)},
+			now,
+		),
+	}
+	var acc testutil.Accumulator
+	plugin.emitReport(&acc)
+	testutil.RequireMetricsEqual(t, expected, acc.GetTelegrafMetrics())
+
+	// No report without rejected series
+	acc.ClearMetrics()
+	now = now.Add(time.Minute)
+	plugin.emitReport(&acc)
+	require.Empty(t, acc.GetTelegrafMetrics())
+}
+
+func TestExpireWithoutReport(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+
+	plugin := &Cardinality{
+		Limit:  10,
+		Window: config.Duration(time.Minute),
+		Log:    testutil.Logger{},
+	}
+	require.NoError(t, plugin.Init())
+	plugin.now = func() time.Time { return now }
+
+	input := []telegraf.Metric{
+		metric.New("cpu", map[string]string{"host": "a"}, map[string]interface{}{"value": 1}, now),
+		metric.New("mem", map[string]string{"host": "a"}, map[string]interface{}{"value": 1}, now),
+	}
+	require.Len(t, apply(t, plugin, input...), 2)
+	require.Len(t, plugin.groups, 2)
+
+	// Groups below the limit must be removed once all series expired even
+	// if reporting is disabled
+	now = now.Add(30 * time.Second)
+	require.Len(t, apply(t, plugin, input[0].Copy()), 1)
+	now = now.Add(time.Minute)
+	plugin.sweep(now)
+	require.Len(t, plugin.groups, 1)
+	require.Contains(t, plugin.groups, "cpu")
+
+	now = now.Add(time.Minute)
+	plugin.sweep(now)
+	require.Empty(t, plugin.groups)
+
+	// Groups that rejected series must be removed as well as there is no
+	// report pending
+	plugin.Limit = 1
+	b := metric.New("cpu", map[string]string{"host": "b"}, map[string]interface{}{"value": 1}, now)
+	require.Len(t, apply(t, plugin, input[0].Copy()), 1)
+	require.Empty(t, apply(t, plugin, b))
+	now = now.Add(2 * time.Minute)
+	plugin.sweep(now)
+	require.Empty(t, plugin.groups)
+}
+
+func TestExpireBackground(t *testing.T) {
+	plugin := &Cardinality{
+		Limit:  10,
+		Window: config.Duration(10 * time.Millisecond),
+		Log:    testutil.Logger{},
+	}
+	require.NoError(t, plugin.Init())
+
+	var acc testutil.Accumulator
+	require.NoError(t, plugin.Start(&acc))
+	defer plugin.Stop()
+
+	require.NoError(t, plugin.Add(metric.New("cpu", map[string]string{"host": "a"}, map[string]interface{}{"value": 1}, time.Now()), &acc))
+	require.Eventually(t, func() bool {
+		plugin.groupsLock.Lock()
+		defer plugin.groupsLock.Unlock()
+		return len(plugin.groups) == 0
+	}, time.Second, 10*time.Millisecond)
+}
+
+func TestReportWithoutMetrics(t *testing.T) {
+	plugin := &Cardinality{
+		Limit:          1,
+		Window:         config.Duration(time.Hour),
+		ReportInterval: config.Duration(10 * time.Millisecond),
+		ReportTop:      0,
+		Log:            testutil.Logger{},
+	}
+	require.NoError(t, plugin.Init())
+
+	var acc testutil.Accumulator
+	require.NoError(t, plugin.Start(&acc))
+	defer plugin.Stop()
+
+	now := time.Now()
+	require.NoError(t, plugin.Add(metric.New("cpu", map[string]string{"host": "a"}, map[string]interface{}{"value": 1}, now), &acc))
+	require.NoError(t, plugin.Add(metric.New("cpu", map[string]string{"host": "b"}, map[string]interface{}{"value": 1}, now), &acc))
+
+	// The report must be emitted even though no further metrics arrive
+	expected := []telegraf.Metric{
+		metric.New("cpu", map[string]string{"host": "a"}, map[string]interface{}{"value": 1}, now),
+		metric.New(
+			"cardinality",
+			map[string]string{"measurement": "cpu"},
+			map[string]interface{}{"series": int64(1), "limit": int64(1), "rejected": int64(1)},
+			now,
+		),
+	}
+	require.Eventually(t, func() bool {
+		return acc.NMetrics() >= uint64(len(expected))
+	}, time.Second, 10*time.Millisecond)
+	testutil.RequireMetricsEqual(t, expected, acc.GetTelegrafMetrics(), testutil.IgnoreTime())
+}
+
+func apply(t *testing.T, plugin *Cardinality, in ...telegraf.Metric) []telegraf.Metric {
+	t.Helper()
+
+	var acc testutil.Accumulator
+	for _, m := range in {
+		require.NoError(t, plugin.Add(m, &acc))
+	}
+	return acc.GetTelegrafMetrics()
+}
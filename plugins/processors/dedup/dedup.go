@@ -4,6 +4,7 @@ package dedup
 import (
 	_ "embed"
 	"fmt"
+	"sync"
 	"time"
 
 	"github.com/influxdata/telegraf"
@@ -21,6 +22,9 @@ type Dedup struct {
 	FlushTime     time.Time
 	Cache         map[uint64]telegraf.Metric
 	Log           telegraf.Logger `toml:"-"`
+
+	// Protects the cache as the state might be collected while processing
+	cacheLock sync.Mutex
 }
 
 // Remove expired items from cache
@@ -51,6 +55,9 @@ func (*Dedup) SampleConfig() string {
 
 // main processing method
 func (d *Dedup) Apply(metrics ...telegraf.Metric) []telegraf.Metric {
+	d.cacheLock.Lock()
+	defer d.cacheLock.Unlock()
+
 	idx := 0
 	for _, metric := range metrics {
 		id := metric.HashID()
@@ -123,10 +130,12 @@ func (d *Dedup) Apply(metrics ...telegraf.Metric) []telegraf.Metric {
 
 func (d *Dedup) GetState() interface{} {
 	s := &serializers_influx.Serializer{}
+	d.cacheLock.Lock()
 	v := make([]telegraf.Metric, 0, len(d.Cache))
 	for _, value := range d.Cache {
 		v = append(v, value)
 	}
+	d.cacheLock.Unlock()
 	state, err := s.SerializeBatch(v)
 	if err != nil {
 		d.Log.Errorf("dedup processor failed to serialize metric batch: %v", err)
@@ -150,6 +159,18 @@ func (d *Dedup) SetState(state interface{}) error {
 	return nil
 }
 
+func (*Dedup) StateVersion() int {
+	return 1
+}
+
+func (*Dedup) MigrateState(version int, state []byte) ([]byte, error) {
+	// The unversioned state is the same influx line-protocol batch
+	if version == 0 {
+		return state, nil
+	}
+	return nil, fmt.Errorf("unsupported state version %d", version)
+}
+
 func init() {
 	processors.Add("dedup", func() telegraf.Processor {
 		return &Dedup{
@@ -0,0 +1,233 @@
+//go:generate ../../../tools/readme_config_includer/generator
+package expression
+
+import (
+	_ "embed"
+	"errors"
+	"fmt"
+	"sort"
+
+	"github.com/google/cel-go/cel"
+	"github.com/google/cel-go/common/types"
+	"github.com/google/cel-go/common/types/ref"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/models"
+	"github.com/influxdata/telegraf/plugins/processors"
+)
+
+//go:embed sample.conf
+var sampleConfig string
+
+type Expression struct {
+	Drop   string            `toml:"drop"`
+	Name   string            `toml:"name"`
+	Fields map[string]string `toml:"fields"`
+	Tags   map[string]string `toml:"tags"`
+	Log    telegraf.Logger   `toml:"-"`
+
+	drop   cel.Program
+	name   cel.Program
+	fields []program
+	tags   []program
+}
+
+type program struct {
+	key  string
+	prog cel.Program
+}
+
+func (*Expression) SampleConfig() string {
+	return sampleConfig
+}
+
+func (e *Expression) Init() error {
+	if e.Drop == "" && e.Name == "" && len(e.Fields) == 0 && len(e.Tags) == 0 {
+		return errors.New("no expression defined")
+	}
+
+	env, err := models.NewMetricEnvironment()
+	if err != nil {
+		return fmt.Errorf("creating environment failed: %w", err)
+	}
+
+	if e.Drop != "" {
+		e.drop, err = compile(env, e.Drop, cel.BoolType)
+		if err != nil {
+			return fmt.Errorf("compiling drop expression failed: %w", err)
+		}
+	}
+
+	if e.Name != "" {
+		e.name, err = compile(env, e.Name, cel.StringType)
+		if err != nil {
+			return fmt.Errorf("compiling name expression failed: %w", err)
+		}
+	}
+
+	e.fields, err = compileAll(env, e.Fields)
+	if err != nil {
+		return fmt.Errorf("compiling field expression %w", err)
+	}
+
+	e.tags, err = compileAll(env, e.Tags)
+	if err != nil {
+		return fmt.Errorf("compiling tag expression %w", err)
+	}
+
+	return nil
+}
+
+func (e *Expression) Apply(in ...telegraf.Metric) []telegraf.Metric {
+	out := make([]telegraf.Metric, 0, len(in))
+	for _, m := range in {
+		// All expressions see the unmodified metric
+		vars := models.MetricActivation(m)
+
+		if e.drop != nil {
+			drop, err := evalBool(e.drop, vars)
+			if err != nil {
+				e.Log.Errorf("Evaluating drop expression for metric %q failed: %v", m.Name(), err)
+			} else if drop {
+				m.Drop()
+				continue
+			}
+		}
+
+		fields := make(map[string]interface{}, len(e.fields))
+		for _, p := range e.fields {
+			v, err := evalField(p.prog, vars)
+			if err != nil {
+				e.Log.Errorf("Evaluating field %q for metric %q failed: %v", p.key, m.Name(), err)
+				continue
+			}
+			if v != nil {
+				fields[p.key] = v
+			}
+		}
+
+		tags := make(map[string]string, len(e.tags))
+		for _, p := range e.tags {
+			v, found, err := evalString(p.prog, vars)
+			if err != nil {
+				e.Log.Errorf("Evaluating tag %q for metric %q failed: %v", p.key, m.Name(), err)
+				continue
+			}
+			if found {
+				tags[p.key] = v
+			}
+		}
+
+		if e.name != nil {
+			name, found, err := evalString(e.name, vars)
+			if err != nil {
+				e.Log.Errorf("Evaluating name expression for metric %q failed: %v", m.Name(), err)
+			} else if found && name != "" {
+				m.SetName(name)
+			}
+		}
+		for k, v := range fields {
+			m.AddField(k, v)
+		}
+		for k, v := range tags {
+			m.AddTag(k, v)
+		}
+
+		out = append(out, m)
+	}
+	return out
+}
+
+func compile(env *cel.Env, expression string, expected *cel.Type) (cel.Program, error) {
+	ast, issues := env.Compile(expression)
+	if issues.Err() != nil {
+		return nil, issues.Err()
+	}
+
+	// Dynamic results can only be checked at runtime
+	if t := ast.OutputType(); expected != nil && t != expected && t != cel.DynType {
+		return nil, fmt.Errorf("expression needs to return a %s but returns %s", expected, t)
+	}
+
+	return env.Program(ast, cel.EvalOptions(cel.OptOptimize))
+}
+
+func compileAll(env *cel.Env, expressions map[string]string) ([]program, error) {
+	// Evaluate the expressions in a deterministic order
+	keys := make([]string, 0, len(expressions))
+	for k := range expressions {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	programs := make([]program, 0, len(keys))
+	for _, k := range keys {
+		prog, err := compile(env, expressions[k], nil)
+		if err != nil {
+			return nil, fmt.Errorf("%q failed: %w", k, err)
+		}
+		programs = append(programs, program{key: k, prog: prog})
+	}
+	return programs, nil
+}
+
+func evalBool(prog cel.Program, vars map[string]interface{}) (bool, error) {
+	result, _, err := prog.Eval(vars)
+	if err != nil {
+		return false, err
+	}
+	if r, ok := result.Value().(bool); ok {
+		return r, nil
+	}
+	return false, fmt.Errorf("invalid result type %s", result.Type())
+}
+
+// evalString evaluates the program and converts the result to a string,
+// null results are reported as not found
+func evalString(prog cel.Program, vars map[string]interface{}) (string, bool, error) {
+	result, _, err := prog.Eval(vars)
+	if err != nil {
+		return "", false, err
+	}
+	if result.Type() == types.NullType {
+		return "", false, nil
+	}
+
+	converted := result.ConvertToType(types.StringType)
+	if types.IsError(converted) {
+		return "", false, fmt.Errorf("converting result of type %s failed: %v", result.Type(), converted)
+	}
+	return converted.Value().(string), true, nil
+}
+
+// evalField evaluates the program and converts the result to a field value,
+// null results are returned as nil
+func evalField(prog cel.Program, vars map[string]interface{}) (interface{}, error) {
+	result, _, err := prog.Eval(vars)
+	if err != nil {
+		return nil, err
+	}
+	return fieldValue(result)
+}
+
+func fieldValue(v ref.Val) (interface{}, error) {
+	switch v.Type() {
+	case types.NullType:
+		return nil, nil
+	case types.BoolType, types.IntType, types.UintType, types.DoubleType, types.StringType:
+		return v.Value(), nil
+	case types.BytesType:
+		return string(v.Value().([]byte)), nil
+	case types.TimestampType:
+		return v.(types.Timestamp).UnixNano(), nil
+	case types.DurationType:
+		return v.(types.Duration).Nanoseconds(), nil
+	}
+	return nil, fmt.Errorf("unsupported result type %s", v.Type())
+}
+
+func init() {
+	processors.Add("expression", func() telegraf.Processor {
+		return &Expression{}
+	})
+}
@@ -0,0 +1,254 @@
+package expression
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/metric"
+	"github.com/influxdata/telegraf/testutil"
+)
+
+func TestInitFail(t *testing.T) {
+	tests := []struct {
+		name     string
+		plugin   *Expression
+		expected string
+	}{
+		{
+			name:     "no expression",
+			plugin:   &Expression{},
+			expected: "no expression defined",
+		},
+		{
+			name:     "drop not boolean",
+			plugin:   &Expression{Drop: `name + "_x"`},
+			expected: "compiling drop expression failed: expression needs to return a bool but returns string",
+		},
+		{
+			name:     "name not string",
+			plugin:   &Expression{Name: `time`},
+			expected: "compiling name expression failed: expression needs to return a string but returns google.protobuf.Timestamp",
+		},
+		{
+			name:     "invalid field expression",
+			plugin:   &Expression{Fields: map[string]string{"x": `fields.`}},
+			expected: `compiling field expression "x" failed`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require.ErrorContains(t, tt.plugin.Init(), tt.expected)
+		})
+	}
+}
+
+func TestCases(t *testing.T) {
+	input := []telegraf.Metric{
+		metric.New(
+			"power",
+			map[string]string{"unit": "W", "source": "a"},
+			map[string]interface{}{"voltage": 230.0, "current": 2.5, "count": int64(3)},
+			time.Unix(1700000000, 0),
+		),
+		metric.New(
+			"power",
+			map[string]string{"unit": "kW", "source": "b"},
+			map[string]interface{}{"voltage": 400.0, "current": 100.0, "count": int64(-1)},
+			time.Unix(1700000000, 0),
+		),
+	}
+
+	tests := []struct {
+		name     string
+		plugin   *Expression
+		expected []telegraf.Metric
+	}{
+		{
+			name: "fields",
+			plugin: &Expression{
+				Fields: map[string]string{
+					"power":   `fields.voltage * fields.current`,
+					"double":  `fields.count * 2`,
+					"label":   `tags.source + "-" + name`,
+					"seconds": `int(time)`,
+				},
+			},
+			expected: []telegraf.Metric{
+				metric.New(
+					"power",
+					map[string]string{"unit": "W", "source": "a"},
+					map[string]interface{}{
+						"voltage": 230.0,
+						"current": 2.5,
+						"count":   int64(3),
+						"power":   575.0,
+						"double":  int64(6),
+						"label":   "a-power",
+						"seconds": int64(1700000000),
+					},
+					time.Unix(1700000000, 0),
+				),
+				metric.New(
+					"power",
+					map[string]string{"unit": "kW", "source": "b"},
+					map[string]interface{}{
+						"voltage": 400.0,
+						"current": 100.0,
+						"count":   int64(-1),
+						"power":   40000.0,
+						"double":  int64(-2),
+						"label":   "b-power",
+						"seconds": int64(1700000000),
+					},
+					time.Unix(1700000000, 0),
+				),
+			},
+		},
+		{
+			name: "tags",
+			plugin: &Expression{
+				Tags: map[string]string{
+					"level":  `fields.current > 10.0 ? "high" : "low"`,
+					"count":  `fields.count`,
+					"absent": `"missing" in fields ? dyn("yes") : null`,
+				},
+			},
+			expected: []telegraf.Metric{
+				metric.New(
+					"power",
+					map[string]string{"unit": "W", "source": "a", "level": "low", "count": "3"},
+					map[string]interface{}{"voltage": 230.0, "current": 2.5, "count": int64(3)},
+					time.Unix(1700000000, 0),
+				),
+				metric.New(
+					"power",
+					map[string]string{"unit": "kW", "source": "b", "level": "high", "count": "-1"},
+					map[string]interface{}{"voltage": 400.0, "current": 100.0, "count": int64(-1)},
+					time.Unix(1700000000, 0),
+				),
+			},
+		},
+		{
+			name: "rename",
+			plugin: &Expression{
+				Name: `name + "_" + tags.unit.lowerAscii()`,
+			},
+			expected: []telegraf.Metric{
+				metric.New(
+					"power_w",
+					map[string]string{"unit": "W", "source": "a"},
+					map[string]interface{}{"voltage": 230.0, "current": 2.5, "count": int64(3)},
+					time.Unix(1700000000, 0),
+				),
+				metric.New(
+					"power_kw",
+					map[string]string{"unit": "kW", "source": "b"},
+					map[string]interface{}{"voltage": 400.0, "current": 100.0, "count": int64(-1)},
+					time.Unix(1700000000, 0),
+				),
+			},
+		},
+		{
+			name: "drop",
+			plugin: &Expression{
+				Drop:   `fields.count < 0`,
+				Fields: map[string]string{"power": `fields.voltage * fields.current`},
+			},
+			expected: []telegraf.Metric{
+				metric.New(
+					"power",
+					map[string]string{"unit": "W", "source": "a"},
+					map[string]interface{}{"voltage": 230.0, "current": 2.5, "count": int64(3), "power": 575.0},
+					time.Unix(1700000000, 0),
+				),
+			},
+		},
+		{
+			name: "expressions see the original metric",
+			plugin: &Expression{
+				Name:   `"renamed"`,
+				Fields: map[string]string{"current": `fields.current * 2.0`, "original": `name`},
+				Tags:   map[string]string{"doubled": `string(fields.current)`},
+			},
+			expected: []telegraf.Metric{
+				metric.New(
+					"renamed",
+					map[string]string{"unit": "W", "source": "a", "doubled": "2.5"},
+					map[string]interface{}{"voltage": 230.0, "current": 5.0, "count": int64(3), "original": "power"},
+					time.Unix(1700000000, 0),
+				),
+				metric.New(
+					"renamed",
+					map[string]string{"unit": "kW", "source": "b", "doubled": "100"},
+					map[string]interface{}{"voltage": 400.0, "current": 200.0, "count": int64(-1), "original": "power"},
+					time.Unix(1700000000, 0),
+				),
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.plugin.Log = testutil.Logger{}
+			require.NoError(t, tt.plugin.Init())
+
+			metrics := make([]telegraf.Metric, 0, len(input))
+			for _, m := range input {
+				metrics = append(metrics, m.Copy())
+			}
+			actual := tt.plugin.Apply(metrics...)
+			testutil.RequireMetricsEqual(t, tt.expected, actual)
+		})
+	}
+}
+
+func TestEvaluationError(t *testing.T) {
+	logger := &testutil.CaptureLogger{}
+	plugin := &Expression{
+		Fields: map[string]string{
+			"ok":      `fields.value + 1`,
+			"missing": `fields.unknown + 1`,
+		},
+		Log: logger,
+	}
+	require.NoError(t, plugin.Init())
+
+	input := metric.New("test", map[string]string{}, map[string]interface{}{"value": int64(1)}, time.Unix(0, 0))
+	expected := []telegraf.Metric{
+		metric.New("test", map[string]string{}, map[string]interface{}{"value": int64(1), "ok": int64(2)}, time.Unix(0, 0)),
+	}
+
+	actual := plugin.Apply(input)
+	testutil.RequireMetricsEqual(t, expected, actual)
+	require.Len(t, logger.Errors(), 1)
+	require.Contains(t, logger.Errors()[0], `Evaluating field "missing" for metric "test" failed`)
+}
+
+func TestTracking(t *testing.T) {
+	var delivered int
+	notify := func(telegraf.DeliveryInfo) {
+		delivered++
+	}
+
+	input := []telegraf.Metric{
+		metric.New("test", map[string]string{}, map[string]interface{}{"value": int64(1)}, time.Unix(0, 0)),
+		metric.New("test", map[string]string{}, map[string]interface{}{"value": int64(-1)}, time.Unix(0, 0)),
+	}
+	for i, m := range input {
+		input[i], _ = metric.WithTracking(m, notify)
+	}
+
+	plugin := &Expression{Drop: `fields.value < 0`}
+	require.NoError(t, plugin.Init())
+
+	actual := plugin.Apply(input...)
+	require.Len(t, actual, 1)
+	for _, m := range actual {
+		m.Accept()
+	}
+	require.Eventually(t, func() bool { return delivered == 2 }, time.Second, 10*time.Millisecond)
+}
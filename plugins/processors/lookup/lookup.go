@@ -3,31 +3,63 @@ package lookup
 
 import (
 	"bytes"
+	"context"
 	_ "embed"
-	"encoding/csv"
-	"encoding/json"
 	"errors"
 	"fmt"
-	"io"
+	"net/http"
 	"os"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"text/template"
+	"time"
+
+	"github.com/hashicorp/golang-lru/v2/expirable"
 
 	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/config"
+	common_http "github.com/influxdata/telegraf/plugins/common/http"
 	"github.com/influxdata/telegraf/plugins/processors"
 )
 
 //go:embed sample.conf
 var sampleConfig string
 
+const (
+	defaultCacheSize       = 10000
+	defaultCacheTTL        = config.Duration(time.Hour)
+	defaultErrorBackoff    = config.Duration(30 * time.Second)
+	defaultOnDemandTimeout = config.Duration(500 * time.Millisecond)
+)
+
 type Processor struct {
-	Filenames   []string        `toml:"files"`
-	Fileformat  string          `toml:"format"`
-	KeyTemplate string          `toml:"key"`
-	Log         telegraf.Logger `toml:"-"`
+	Filenames      []string        `toml:"files"`
+	URLs           []string        `toml:"urls"`
+	SQL            *SQLSource      `toml:"sql"`
+	Fileformat     string          `toml:"format"`
+	KeyTemplate    string          `toml:"key"`
+	ReloadInterval config.Duration `toml:"reload_interval"`
+	WatchInterval  config.Duration `toml:"watch_interval"`
+	Mode           string          `toml:"mode"`
+	CacheSize      int             `toml:"cache_size"`
+	CacheTTL       config.Duration `toml:"cache_ttl"`
+	ErrorBackoff   config.Duration `toml:"error_backoff"`
+	Log            telegraf.Logger `toml:"-"`
+	common_http.HTTPClientConfig
 
 	tmpl     *template.Template
-	mappings map[string][]telegraf.Tag
+	mappings atomic.Pointer[table]
+	modified map[string]time.Time
+	client   *http.Client
+	cache    *expirable.LRU[string, []telegraf.Tag]
+
+	// Queries for uncached keys are skipped until this time after a failed
+	// query to not stall the processing while the database is unavailable
+	backoffUntil time.Time
+
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
 }
 
 func (*Processor) SampleConfig() string {
@@ -35,8 +67,8 @@ func (*Processor) SampleConfig() string {
 }
 
 func (p *Processor) Init() error {
-	if len(p.Filenames) < 1 {
-		return errors.New("missing 'files'")
+	if len(p.Filenames) < 1 && len(p.URLs) < 1 && p.SQL == nil {
+		return errors.New("missing 'files', 'urls' or 'sql'")
 	}
 
 	if p.KeyTemplate == "" {
@@ -49,167 +81,255 @@ func (p *Processor) Init() error {
 	}
 	p.tmpl = tmpl
 
-	p.mappings = make(map[string][]telegraf.Tag)
-	switch strings.ToLower(p.Fileformat) {
-	case "", "json":
-		return p.loadJSONFiles()
-	case "csv_key_name_value":
-		return p.loadCSVKeyNameValueFiles()
-	case "csv_key_values":
-		return p.loadCSVKeyValuesFiles()
+	p.Fileformat = strings.ToLower(p.Fileformat)
+	switch p.Fileformat {
+	case "", "json", "csv", "csv_key_name_value", "csv_key_values":
+	default:
+		return fmt.Errorf("invalid format %q", p.Fileformat)
 	}
 
-	return fmt.Errorf("invalid format %q", p.Fileformat)
-}
-
-func (p *Processor) Apply(in ...telegraf.Metric) []telegraf.Metric {
-	out := make([]telegraf.Metric, 0, len(in))
-	for _, raw := range in {
-		m := raw
-		if wm, ok := raw.(telegraf.UnwrappableMetric); ok {
-			m = wm.Unwrap()
+	switch p.Mode {
+	case "", "preload":
+	case "on_demand":
+		if p.SQL == nil {
+			return errors.New("mode 'on_demand' requires an 'sql' source")
 		}
-
-		var buf bytes.Buffer
-		if err := p.tmpl.Execute(&buf, m); err != nil {
-			p.Log.Errorf("generating key failed: %v", err)
-			p.Log.Debugf("metric was %v", m)
-		} else if tags, found := p.mappings[buf.String()]; found {
-			for _, tag := range tags {
-				m.AddTag(tag.Key, tag.Value)
-			}
+		if p.CacheSize <= 0 {
+			p.CacheSize = defaultCacheSize
+		}
+		if p.CacheTTL <= 0 {
+			p.CacheTTL = defaultCacheTTL
+		}
+		if p.ErrorBackoff <= 0 {
+			p.ErrorBackoff = defaultErrorBackoff
+		}
+		if p.SQL.Timeout <= 0 {
+			p.SQL.Timeout = defaultOnDemandTimeout
 		}
-		out = append(out, raw)
+		p.cache = expirable.NewLRU[string, []telegraf.Tag](p.CacheSize, nil, time.Duration(p.CacheTTL))
+	default:
+		return fmt.Errorf("invalid mode %q", p.Mode)
 	}
-	return out
-}
 
-func (p *Processor) loadJSONFiles() error {
-	for _, fn := range p.Filenames {
-		buf, err := os.ReadFile(fn)
+	if len(p.URLs) > 0 {
+		p.client, err = p.HTTPClientConfig.CreateClient(context.Background(), p.Log)
 		if err != nil {
-			return fmt.Errorf("loading %q failed: %w", fn, err)
+			return fmt.Errorf("creating HTTP client failed: %w", err)
 		}
+	}
 
-		var data map[string]map[string]string
-		if err := json.Unmarshal(buf, &data); err != nil {
-			return fmt.Errorf("parsing %q failed: %w", fn, err)
+	if p.SQL != nil {
+		if err := p.SQL.init(); err != nil {
+			return err
 		}
-
-		for key, tags := range data {
-			for k, v := range tags {
-				p.mappings[key] = append(p.mappings[key], telegraf.Tag{Key: k, Value: v})
-			}
+		if err := p.SQL.connect(); err != nil {
+			return fmt.Errorf("connecting to database failed: %w", err)
 		}
 	}
+
+	return p.load()
+}
+
+func (p *Processor) Start(telegraf.Accumulator) error {
+	watch := p.WatchInterval > 0 && len(p.Filenames) > 0
+	if p.ReloadInterval <= 0 && !watch {
+		return nil
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	p.cancel = cancel
+
+	p.wg.Add(1)
+	go func() {
+		defer p.wg.Done()
+		p.run(ctx, watch)
+	}()
+
 	return nil
 }
 
-func (p *Processor) loadCSVKeyNameValueFiles() error {
-	for _, fn := range p.Filenames {
-		if err := p.loadCSVKeyNameValueFile(fn); err != nil {
-			return err
+func (p *Processor) Stop() {
+	if p.cancel != nil {
+		p.cancel()
+	}
+	p.wg.Wait()
+
+	if p.SQL != nil {
+		if err := p.SQL.close(); err != nil {
+			p.Log.Errorf("Closing database connection failed: %v", err)
 		}
 	}
+}
+
+func (p *Processor) Add(m telegraf.Metric, acc telegraf.Accumulator) error {
+	p.apply(m)
+	acc.AddMetric(m)
 	return nil
 }
 
-func (p *Processor) loadCSVKeyNameValueFile(fn string) error {
-	f, err := os.Open(fn)
+func (p *Processor) apply(raw telegraf.Metric) {
+	m := raw
+	if wm, ok := raw.(telegraf.UnwrappableMetric); ok {
+		m = wm.Unwrap()
+	}
+
+	var buf bytes.Buffer
+	if err := p.tmpl.Execute(&buf, m); err != nil {
+		p.Log.Errorf("generating key failed: %v", err)
+		p.Log.Debugf("metric was %v", m)
+		return
+	}
+	key := buf.String()
+
+	tags, found := (*p.mappings.Load())[key]
+	if !found && p.cache != nil {
+		tags = p.lookup(key)
+	}
+	for _, tag := range tags {
+		m.AddTag(tag.Key, tag.Value)
+	}
+}
+
+// lookup resolves the key on-demand using the cache and the database
+func (p *Processor) lookup(key string) []telegraf.Tag {
+	if tags, found := p.cache.Get(key); found {
+		return tags
+	}
+
+	if time.Now().Before(p.backoffUntil) {
+		return nil
+	}
+
+	tags, err := p.SQL.lookup(key)
 	if err != nil {
-		return fmt.Errorf("loading %q failed: %w", fn, err)
+		p.Log.Errorf("Looking up %q failed, skipping lookups for %s: %v", key, p.ErrorBackoff, err)
+		p.backoffUntil = time.Now().Add(time.Duration(p.ErrorBackoff))
+		return nil
 	}
-	defer f.Close()
 
-	reader := csv.NewReader(f)
-	reader.Comment = '#'
-	reader.FieldsPerRecord = -1
-	reader.TrimLeadingSpace = true
+	// Also cache non-existing keys to avoid querying them over and over
+	p.cache.Add(key, tags)
+	return tags
+}
+
+func (p *Processor) run(ctx context.Context, watch bool) {
+	var reload, poll <-chan time.Time
+	if p.ReloadInterval > 0 {
+		ticker := time.NewTicker(time.Duration(p.ReloadInterval))
+		defer ticker.Stop()
+		reload = ticker.C
+	}
+	if watch {
+		ticker := time.NewTicker(time.Duration(p.WatchInterval))
+		defer ticker.Stop()
+		poll = ticker.C
+	}
 
-	line := 0
 	for {
-		line++
-		data, err := reader.Read()
-		if err != nil {
-			if errors.Is(err, io.EOF) {
-				break
+		select {
+		case <-ctx.Done():
+			return
+		case <-reload:
+			p.reload()
+		case <-poll:
+			if p.filesChanged() {
+				p.reload()
 			}
-			return fmt.Errorf("reading line %d in %q failed: %w", line, fn, err)
-		}
-		if len(data) < 3 {
-			return fmt.Errorf("line %d in %q has not enough columns, requiring at least `key,name,value`", line, fn)
-		}
-		if len(data)%2 != 1 {
-			return fmt.Errorf("line %d in %q has a tag-name without value", line, fn)
 		}
+	}
+}
 
-		key := data[0]
-		for i := 1; i < len(data)-1; i += 2 {
-			k, v := data[i], data[i+1]
-			p.mappings[key] = append(p.mappings[key], telegraf.Tag{Key: k, Value: v})
-		}
+func (p *Processor) reload() {
+	if err := p.load(); err != nil {
+		p.Log.Errorf("Reloading lookup table failed, keeping the previous table: %v", err)
+		return
 	}
+	p.Log.Debug("Reloaded lookup table")
+}
 
-	return nil
+// filesChanged checks if any file was modified since the last load
+func (p *Processor) filesChanged() bool {
+	for _, fn := range p.Filenames {
+		info, err := os.Stat(fn)
+		if err != nil {
+			// The file might be in the process of being replaced so
+			// check again at the next interval
+			p.Log.Debugf("Checking %q failed: %v", fn, err)
+			continue
+		}
+		if !info.ModTime().Equal(p.modified[fn]) {
+			return true
+		}
+	}
+	return false
 }
 
-func (p *Processor) loadCSVKeyValuesFiles() error {
+// load reads all sources into a new table and atomically replaces the
+// current one if all sources could be read successfully
+func (p *Processor) load() error {
+	t := make(table)
+
+	modified := make(map[string]time.Time, len(p.Filenames))
 	for _, fn := range p.Filenames {
-		if err := p.loadCSVKeyValuesFile(fn); err != nil {
+		mtime, err := p.loadFile(t, fn)
+		if err != nil {
+			return err
+		}
+		modified[fn] = mtime
+	}
+
+	for _, u := range p.URLs {
+		if err := p.loadURL(t, u); err != nil {
 			return err
 		}
 	}
+
+	if p.SQL != nil && p.cache == nil {
+		if err := p.SQL.load(t); err != nil {
+			return fmt.Errorf("loading SQL table failed: %w", err)
+		}
+	}
+
+	p.mappings.Store(&t)
+	p.modified = modified
+	if p.cache != nil {
+		p.cache.Purge()
+	}
+
 	return nil
 }
 
-func (p *Processor) loadCSVKeyValuesFile(fn string) error {
+func (p *Processor) loadFile(t table, fn string) (time.Time, error) {
 	f, err := os.Open(fn)
 	if err != nil {
-		return fmt.Errorf("loading %q failed: %w", fn, err)
+		return time.Time{}, fmt.Errorf("loading %q failed: %w", fn, err)
 	}
 	defer f.Close()
 
-	reader := csv.NewReader(f)
-	reader.Comment = '#'
-	reader.TrimLeadingSpace = true
-
-	// Read the first line which should be the header
-	header, err := reader.Read()
+	info, err := f.Stat()
 	if err != nil {
-		if errors.Is(err, io.EOF) {
-			return fmt.Errorf("missing header in %q", fn)
-		}
-		return fmt.Errorf("reading header in %q failed: %w", fn, err)
+		return time.Time{}, fmt.Errorf("loading %q failed: %w", fn, err)
 	}
-	if len(header) < 2 {
-		return fmt.Errorf("header in %q has not enough columns, requiring at least `key,value`", fn)
-	}
-	header = header[1:]
 
-	line := 1
-	for {
-		line++
-		data, err := reader.Read()
-		if err != nil {
-			if errors.Is(err, io.EOF) {
-				break
-			}
-			return fmt.Errorf("reading line %d in %q failed: %w", line, fn, err)
-		}
+	return info.ModTime(), t.parse(f, p.Fileformat, fn)
+}
 
-		key := data[0]
-		for i, v := range data[1:] {
-			v = strings.TrimSpace(v)
-			if v != "" {
-				p.mappings[key] = append(p.mappings[key], telegraf.Tag{Key: header[i], Value: v})
-			}
-		}
+func (p *Processor) loadURL(t table, u string) error {
+	resp, err := p.client.Get(u)
+	if err != nil {
+		return fmt.Errorf("loading %q failed: %w", u, err)
 	}
+	defer resp.Body.Close()
 
-	return nil
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("loading %q failed: received status code %d (%s)", u, resp.StatusCode, http.StatusText(resp.StatusCode))
+	}
+
+	return t.parse(resp.Body, p.Fileformat, u)
 }
+
 func init() {
-	processors.Add("lookup", func() telegraf.Processor {
+	processors.AddStreaming("lookup", func() telegraf.StreamingProcessor {
 		return &Processor{}
 	})
 }
@@ -1,6 +1,8 @@
 package lookup
 
 import (
+	"net/http"
+	"net/http/httptest"
 	"os"
 	"path/filepath"
 	"sync"
@@ -26,6 +28,13 @@ func TestInit(t *testing.T) {
 	}
 	require.ErrorContains(t, plugin.Init(), "missing 'key_template'")
 
+	plugin = &Processor{
+		Filenames:   []string{"blah.json"},
+		KeyTemplate: "lala",
+		Mode:        "on_demand",
+	}
+	require.ErrorContains(t, plugin.Init(), "mode 'on_demand' requires an 'sql' source")
+
 	plugin = &Processor{
 		Filenames:   []string{"blah.json"},
 		KeyTemplate: "lala",
@@ -49,7 +58,7 @@ func TestCases(t *testing.T) {
 	require.NotEmpty(t, folders)
 
 	// Set up for file inputs
-	processors.Add("lookup", func() telegraf.Processor {
+	processors.AddStreaming("lookup", func() telegraf.StreamingProcessor {
 		return &Processor{Log: testutil.Logger{}}
 	})
 
@@ -85,12 +94,18 @@ func TestCases(t *testing.T) {
 			require.NoError(t, cfg.LoadConfig(configFilename))
 			require.Len(t, cfg.Processors, 1, "wrong number of processors")
 
-			proc := cfg.Processors[0].Processor.(processors.HasUnwrap)
-			plugin := proc.Unwrap().(*Processor)
+			plugin := cfg.Processors[0].Processor.(*Processor)
 			require.NoError(t, plugin.Init())
 
 			// Process expected metrics and compare with resulting metrics
-			actual := plugin.Apply(input...)
+			var acc testutil.Accumulator
+			require.NoError(t, plugin.Start(&acc))
+			for _, m := range input {
+				require.NoError(t, plugin.Add(m, &acc))
+			}
+			plugin.Stop()
+
+			actual := acc.GetTelegrafMetrics()
 			testutil.RequireMetricsEqual(t, expected, actual)
 		})
 	}
@@ -105,7 +120,7 @@ func TestCasesTracking(t *testing.T) {
 	require.NotEmpty(t, folders)
 
 	// Set up for file inputs
-	processors.Add("lookup", func() telegraf.Processor {
+	processors.AddStreaming("lookup", func() telegraf.StreamingProcessor {
 		return &Processor{Log: testutil.Logger{}}
 	})
 
@@ -154,12 +169,18 @@ func TestCasesTracking(t *testing.T) {
 			require.NoError(t, cfg.LoadConfig(configFilename))
 			require.Len(t, cfg.Processors, 1, "wrong number of processors")
 
-			proc := cfg.Processors[0].Processor.(processors.HasUnwrap)
-			plugin := proc.Unwrap().(*Processor)
+			plugin := cfg.Processors[0].Processor.(*Processor)
 			require.NoError(t, plugin.Init())
 
 			// Process expected metrics and compare with resulting metrics
-			actual := plugin.Apply(input...)
+			var acc testutil.Accumulator
+			require.NoError(t, plugin.Start(&acc))
+			for _, m := range input {
+				require.NoError(t, plugin.Add(m, &acc))
+			}
+			plugin.Stop()
+
+			actual := acc.GetTelegrafMetrics()
 			testutil.RequireMetricsEqual(t, expected, actual)
 
 			// Simulate output acknowledging delivery
@@ -176,3 +197,88 @@ func TestCasesTracking(t *testing.T) {
 		})
 	}
 }
+
+func TestWatchFiles(t *testing.T) {
+	fn := filepath.Join(t.TempDir(), "lut.csv")
+	require.NoError(t, os.WriteFile(fn, []byte("host,team\nserverA,ops\n"), 0600))
+
+	plugin := &Processor{
+		Filenames:     []string{fn},
+		Fileformat:    "csv",
+		KeyTemplate:   `{{.Tag "host"}}`,
+		WatchInterval: config.Duration(10 * time.Millisecond),
+		Log:           testutil.Logger{},
+	}
+	require.NoError(t, plugin.Init())
+
+	var acc testutil.Accumulator
+	require.NoError(t, plugin.Start(&acc))
+	defer plugin.Stop()
+
+	input := metric.New("test", map[string]string{"host": "serverA"}, map[string]interface{}{"value": 42}, time.Unix(0, 0))
+	require.NoError(t, plugin.Add(input.Copy(), &acc))
+
+	// Modify the file and make sure the modification time changes
+	require.NoError(t, os.WriteFile(fn, []byte("host,team\nserverA,dev\n"), 0600))
+	mtime := time.Now().Add(time.Second)
+	require.NoError(t, os.Chtimes(fn, mtime, mtime))
+
+	require.Eventually(t, func() bool {
+		return (*plugin.mappings.Load())["serverA"][0].Value == "dev"
+	}, time.Second, 10*time.Millisecond)
+	require.NoError(t, plugin.Add(input.Copy(), &acc))
+
+	expected := []telegraf.Metric{
+		metric.New("test", map[string]string{"host": "serverA", "team": "ops"}, map[string]interface{}{"value": 42}, time.Unix(0, 0)),
+		metric.New("test", map[string]string{"host": "serverA", "team": "dev"}, map[string]interface{}{"value": 42}, time.Unix(0, 0)),
+	}
+	testutil.RequireMetricsEqual(t, expected, acc.GetTelegrafMetrics())
+}
+
+func TestReloadURL(t *testing.T) {
+	var mu sync.Mutex
+	response := "host,team\nserverA,ops\n"
+	status := http.StatusOK
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		w.WriteHeader(status)
+		if _, err := w.Write([]byte(response)); err != nil {
+			t.Error(err)
+		}
+	}))
+	defer server.Close()
+
+	logger := &testutil.CaptureLogger{}
+	plugin := &Processor{
+		URLs:           []string{server.URL},
+		Fileformat:     "csv",
+		KeyTemplate:    `{{.Tag "host"}}`,
+		ReloadInterval: config.Duration(10 * time.Millisecond),
+		Log:            logger,
+	}
+	require.NoError(t, plugin.Init())
+	require.Equal(t, "ops", (*plugin.mappings.Load())["serverA"][0].Value)
+
+	var acc testutil.Accumulator
+	require.NoError(t, plugin.Start(&acc))
+	defer plugin.Stop()
+
+	// Failing reloads should keep the previous table
+	mu.Lock()
+	status = http.StatusInternalServerError
+	mu.Unlock()
+	require.Eventually(t, func() bool {
+		return len(logger.Errors()) > 0
+	}, time.Second, 10*time.Millisecond)
+	require.Equal(t, "ops", (*plugin.mappings.Load())["serverA"][0].Value)
+
+	// Successful reloads should replace the table
+	mu.Lock()
+	status = http.StatusOK
+	response = "host,team\nserverA,dev\n"
+	mu.Unlock()
+	require.Eventually(t, func() bool {
+		return (*plugin.mappings.Load())["serverA"][0].Value == "dev"
+	}, time.Second, 10*time.Millisecond)
+}
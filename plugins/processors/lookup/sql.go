@@ -0,0 +1,161 @@
+package lookup
+
+import (
+	"context"
+	dbsql "database/sql"
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/config"
+)
+
+// SQLSource defines a database table used for the lookup
+type SQLSource struct {
+	Driver  string          `toml:"driver"`
+	Dsn     config.Secret   `toml:"dsn"`
+	Query   string          `toml:"query"`
+	Timeout config.Duration `toml:"timeout"`
+
+	driverName string
+	db         *dbsql.DB
+}
+
+func (s *SQLSource) init() error {
+	if s.Driver == "" {
+		return errors.New("missing SQL driver")
+	}
+	if s.Dsn.Empty() {
+		return errors.New("missing SQL DSN")
+	}
+	if s.Query == "" {
+		return errors.New("missing SQL query")
+	}
+	if s.Timeout <= 0 {
+		s.Timeout = config.Duration(5 * time.Second)
+	}
+
+	// Derive the sql-framework driver name from our config name
+	aliases := map[string]string{
+		"cockroach": "pgx",
+		"tidb":      "mysql",
+		"mssql":     "sqlserver",
+		"maria":     "mysql",
+		"postgres":  "pgx",
+	}
+	s.driverName = s.Driver
+	if driver, ok := aliases[s.Driver]; ok {
+		s.driverName = driver
+	}
+	if !slices.Contains(dbsql.Drivers(), s.driverName) {
+		return fmt.Errorf("driver %q not supported", s.Driver)
+	}
+
+	return nil
+}
+
+func (s *SQLSource) connect() error {
+	dsnSecret, err := s.Dsn.Get()
+	if err != nil {
+		return fmt.Errorf("getting DSN failed: %w", err)
+	}
+	dsn := dsnSecret.String()
+	dsnSecret.Destroy()
+
+	s.db, err = dbsql.Open(s.driverName, dsn)
+	return err
+}
+
+func (s *SQLSource) close() error {
+	if s.db == nil {
+		return nil
+	}
+	return s.db.Close()
+}
+
+// load queries the complete table with the first column being the key and
+// the remaining columns being the tags named by the column name
+func (s *SQLSource) load(t table) error {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Duration(s.Timeout))
+	defer cancel()
+
+	rows, err := s.db.QueryContext(ctx, s.Query)
+	if err != nil {
+		return fmt.Errorf("querying table failed: %w", err)
+	}
+	defer rows.Close()
+
+	columns, err := rows.Columns()
+	if err != nil {
+		return fmt.Errorf("getting columns failed: %w", err)
+	}
+	if len(columns) < 2 {
+		return errors.New("query has not enough columns, requiring at least `key,value`")
+	}
+
+	for rows.Next() {
+		values, err := scan(rows, len(columns))
+		if err != nil {
+			return err
+		}
+		if !values[0].Valid {
+			continue
+		}
+		key := values[0].String
+		t[key] = append(t[key], toTags(columns[1:], values[1:])...)
+	}
+	return rows.Err()
+}
+
+// lookup queries the tags for a single key with the key being the only
+// parameter of the query and all columns being tags named by the column name
+func (s *SQLSource) lookup(key string) ([]telegraf.Tag, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Duration(s.Timeout))
+	defer cancel()
+
+	rows, err := s.db.QueryContext(ctx, s.Query, key)
+	if err != nil {
+		return nil, fmt.Errorf("querying key %q failed: %w", key, err)
+	}
+	defer rows.Close()
+
+	columns, err := rows.Columns()
+	if err != nil {
+		return nil, fmt.Errorf("getting columns failed: %w", err)
+	}
+
+	var tags []telegraf.Tag
+	for rows.Next() {
+		values, err := scan(rows, len(columns))
+		if err != nil {
+			return nil, err
+		}
+		tags = append(tags, toTags(columns, values)...)
+	}
+	return tags, rows.Err()
+}
+
+func scan(rows *dbsql.Rows, n int) ([]dbsql.NullString, error) {
+	values := make([]dbsql.NullString, n)
+	ptrs := make([]interface{}, n)
+	for i := range values {
+		ptrs[i] = &values[i]
+	}
+	if err := rows.Scan(ptrs...); err != nil {
+		return nil, fmt.Errorf("scanning row failed: %w", err)
+	}
+	return values, nil
+}
+
+// toTags converts the non-null values to tags, skipping NULL values
+func toTags(columns []string, values []dbsql.NullString) []telegraf.Tag {
+	tags := make([]telegraf.Tag, 0, len(values))
+	for i, v := range values {
+		if v.Valid {
+			tags = append(tags, telegraf.Tag{Key: columns[i], Value: v.String})
+		}
+	}
+	return tags
+}
@@ -0,0 +1,177 @@
+//go:build !mips && !mipsle && !mips64 && !ppc64 && !riscv64 && !loong64 && !mips64le && !(windows && (386 || arm))
+
+package lookup
+
+import (
+	dbsql "database/sql"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/config"
+	"github.com/influxdata/telegraf/metric"
+	"github.com/influxdata/telegraf/testutil"
+)
+
+func createDatabase(t *testing.T) string {
+	t.Helper()
+
+	fn := filepath.Join(t.TempDir(), "cmdb.sqlite")
+	db, err := dbsql.Open("sqlite", fn)
+	require.NoError(t, err)
+	defer db.Close()
+
+	_, err = db.Exec(`
+		CREATE TABLE hosts (host TEXT PRIMARY KEY, team TEXT, tier INTEGER);
+		INSERT INTO hosts VALUES ('serverA', 'ops', 1), ('serverB', 'dev', NULL);
+	`)
+	require.NoError(t, err)
+
+	return fn
+}
+
+func TestSQL(t *testing.T) {
+	tests := []struct {
+		name  string
+		mode  string
+		query string
+	}{
+		{
+			name:  "preload",
+			query: "SELECT host, team, tier FROM hosts",
+		},
+		{
+			name:  "on demand",
+			mode:  "on_demand",
+			query: "SELECT team, tier FROM hosts WHERE host = ?",
+		},
+	}
+
+	input := []telegraf.Metric{
+		metric.New("test", map[string]string{"host": "serverA"}, map[string]interface{}{"value": 1}, time.Unix(0, 0)),
+		metric.New("test", map[string]string{"host": "serverB"}, map[string]interface{}{"value": 2}, time.Unix(0, 0)),
+		metric.New("test", map[string]string{"host": "serverC"}, map[string]interface{}{"value": 3}, time.Unix(0, 0)),
+	}
+	expected := []telegraf.Metric{
+		metric.New("test", map[string]string{"host": "serverA", "team": "ops", "tier": "1"}, map[string]interface{}{"value": 1}, time.Unix(0, 0)),
+		metric.New("test", map[string]string{"host": "serverB", "team": "dev"}, map[string]interface{}{"value": 2}, time.Unix(0, 0)),
+		metric.New("test", map[string]string{"host": "serverC"}, map[string]interface{}{"value": 3}, time.Unix(0, 0)),
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			plugin := &Processor{
+				SQL: &SQLSource{
+					Driver: "sqlite",
+					Dsn:    config.NewSecret([]byte(createDatabase(t))),
+					Query:  tt.query,
+				},
+				KeyTemplate: `{{.Tag "host"}}`,
+				Mode:        tt.mode,
+				Log:         testutil.Logger{},
+			}
+			require.NoError(t, plugin.Init())
+
+			var acc testutil.Accumulator
+			require.NoError(t, plugin.Start(&acc))
+			for _, m := range input {
+				require.NoError(t, plugin.Add(m.Copy(), &acc))
+			}
+			plugin.Stop()
+
+			testutil.RequireMetricsEqual(t, expected, acc.GetTelegrafMetrics())
+		})
+	}
+}
+
+func TestSQLOnDemandCache(t *testing.T) {
+	fn := createDatabase(t)
+	plugin := &Processor{
+		SQL: &SQLSource{
+			Driver: "sqlite",
+			Dsn:    config.NewSecret([]byte(fn)),
+			Query:  "SELECT team FROM hosts WHERE host = ?",
+		},
+		KeyTemplate: `{{.Tag "host"}}`,
+		Mode:        "on_demand",
+		Log:         testutil.Logger{},
+	}
+	require.NoError(t, plugin.Init())
+
+	var acc testutil.Accumulator
+	require.NoError(t, plugin.Start(&acc))
+	defer plugin.Stop()
+
+	input := metric.New("test", map[string]string{"host": "serverA"}, map[string]interface{}{"value": 1}, time.Unix(0, 0))
+	require.NoError(t, plugin.Add(input.Copy(), &acc))
+
+	// Modify the database, the cached entry should be used
+	db, err := dbsql.Open("sqlite", fn)
+	require.NoError(t, err)
+	defer db.Close()
+	_, err = db.Exec("UPDATE hosts SET team = 'sre' WHERE host = 'serverA'")
+	require.NoError(t, err)
+	require.NoError(t, plugin.Add(input.Copy(), &acc))
+
+	// Purging the cache should pick up the modification
+	plugin.cache.Purge()
+	require.NoError(t, plugin.Add(input.Copy(), &acc))
+
+	expected := []telegraf.Metric{
+		metric.New("test", map[string]string{"host": "serverA", "team": "ops"}, map[string]interface{}{"value": 1}, time.Unix(0, 0)),
+		metric.New("test", map[string]string{"host": "serverA", "team": "ops"}, map[string]interface{}{"value": 1}, time.Unix(0, 0)),
+		metric.New("test", map[string]string{"host": "serverA", "team": "sre"}, map[string]interface{}{"value": 1}, time.Unix(0, 0)),
+	}
+	testutil.RequireMetricsEqual(t, expected, acc.GetTelegrafMetrics())
+}
+
+func TestSQLOnDemandErrorBackoff(t *testing.T) {
+	plugin := &Processor{
+		SQL: &SQLSource{
+			Driver: "sqlite",
+			Dsn:    config.NewSecret([]byte(createDatabase(t))),
+			Query:  "SELECT team FROM hosts WHERE host = ?",
+		},
+		KeyTemplate: `{{.Tag "host"}}`,
+		Mode:        "on_demand",
+		Log:         testutil.Logger{},
+	}
+	require.NoError(t, plugin.Init())
+	require.Equal(t, defaultOnDemandTimeout, plugin.SQL.Timeout)
+	require.Equal(t, defaultErrorBackoff, plugin.ErrorBackoff)
+
+	var acc testutil.Accumulator
+	require.NoError(t, plugin.Start(&acc))
+	defer plugin.Stop()
+
+	// Simulate a database outage, the failed query should suspend the lookups
+	db := plugin.SQL.db
+	broken, err := dbsql.Open("sqlite", filepath.Join(t.TempDir(), "missing", "cmdb.sqlite"))
+	require.NoError(t, err)
+	defer broken.Close()
+	plugin.SQL.db = broken
+
+	input := metric.New("test", map[string]string{"host": "serverA"}, map[string]interface{}{"value": 1}, time.Unix(0, 0))
+	require.NoError(t, plugin.Add(input.Copy(), &acc))
+	require.True(t, plugin.backoffUntil.After(time.Now()))
+
+	// Queries are skipped during the backoff even if the database is back and
+	// failures are not cached
+	plugin.SQL.db = db
+	require.NoError(t, plugin.Add(input.Copy(), &acc))
+	require.Zero(t, plugin.cache.Len())
+
+	// Lookups continue after the backoff
+	plugin.backoffUntil = time.Time{}
+	require.NoError(t, plugin.Add(input.Copy(), &acc))
+
+	expected := []telegraf.Metric{
+		metric.New("test", map[string]string{"host": "serverA"}, map[string]interface{}{"value": 1}, time.Unix(0, 0)),
+		metric.New("test", map[string]string{"host": "serverA"}, map[string]interface{}{"value": 1}, time.Unix(0, 0)),
+		metric.New("test", map[string]string{"host": "serverA", "team": "ops"}, map[string]interface{}{"value": 1}, time.Unix(0, 0)),
+	}
+	testutil.RequireMetricsEqual(t, expected, acc.GetTelegrafMetrics())
+}
@@ -0,0 +1,122 @@
+package lookup
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/influxdata/telegraf"
+)
+
+// table maps lookup keys to the tags to add
+type table map[string][]telegraf.Tag
+
+// parse reads the lookup-table data from the given source in the given
+// format and adds the mappings to the table
+func (t table) parse(r io.Reader, format, source string) error {
+	switch format {
+	case "", "json":
+		return t.parseJSON(r, source)
+	case "csv_key_name_value":
+		return t.parseCSVKeyNameValue(r, source)
+	case "csv", "csv_key_values":
+		return t.parseCSVKeyValues(r, source)
+	}
+	return fmt.Errorf("invalid format %q", format)
+}
+
+func (t table) parseJSON(r io.Reader, source string) error {
+	buf, err := io.ReadAll(r)
+	if err != nil {
+		return fmt.Errorf("loading %q failed: %w", source, err)
+	}
+
+	var data map[string]map[string]string
+	if err := json.Unmarshal(buf, &data); err != nil {
+		return fmt.Errorf("parsing %q failed: %w", source, err)
+	}
+
+	for key, tags := range data {
+		for k, v := range tags {
+			t[key] = append(t[key], telegraf.Tag{Key: k, Value: v})
+		}
+	}
+	return nil
+}
+
+func (t table) parseCSVKeyNameValue(r io.Reader, source string) error {
+	reader := csv.NewReader(r)
+	reader.Comment = '#'
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	line := 0
+	for {
+		line++
+		data, err := reader.Read()
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				break
+			}
+			return fmt.Errorf("reading line %d in %q failed: %w", line, source, err)
+		}
+		if len(data) < 3 {
+			return fmt.Errorf("line %d in %q has not enough columns, requiring at least `key,name,value`", line, source)
+		}
+		if len(data)%2 != 1 {
+			return fmt.Errorf("line %d in %q has a tag-name without value", line, source)
+		}
+
+		key := data[0]
+		for i := 1; i < len(data)-1; i += 2 {
+			k, v := data[i], data[i+1]
+			t[key] = append(t[key], telegraf.Tag{Key: k, Value: v})
+		}
+	}
+
+	return nil
+}
+
+func (t table) parseCSVKeyValues(r io.Reader, source string) error {
+	reader := csv.NewReader(r)
+	reader.Comment = '#'
+	reader.TrimLeadingSpace = true
+
+	// Read the first line which should be the header
+	header, err := reader.Read()
+	if err != nil {
+		if errors.Is(err, io.EOF) {
+			return fmt.Errorf("missing header in %q", source)
+		}
+		return fmt.Errorf("reading header in %q failed: %w", source, err)
+	}
+	if len(header) < 2 {
+		return fmt.Errorf("header in %q has not enough columns, requiring at least `key,value`", source)
+	}
+	header = header[1:]
+
+	line := 1
+	for {
+		line++
+		data, err := reader.Read()
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				break
+			}
+			return fmt.Errorf("reading line %d in %q failed: %w", line, source, err)
+		}
+
+		key := data[0]
+		for i, v := range data[1:] {
+			v = strings.TrimSpace(v)
+			if v != "" {
+				t[key] = append(t[key], telegraf.Tag{Key: header[i], Value: v})
+			}
+		}
+	}
+
+	return nil
+}
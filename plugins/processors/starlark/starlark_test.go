@@ -3711,6 +3711,50 @@ def apply(metric):
 	require.EqualValues(t, expectedState, actualState, "mismatch in state")
 }
 
+func TestStateConcurrentCheckpoint(t *testing.T) {
+	source := `
+def apply(metric):
+  state[str(state.get("count", 0))] = metric.fields["value"]
+  state["count"] = state.get("count", 0) + 1
+  return metric
+`
+	plugin := &Starlark{
+		Common: common.Common{
+			StarlarkLoadFunc: testLoadFunc,
+			Source:           source,
+			Log:              testutil.Logger{},
+		},
+	}
+	require.NoError(t, plugin.Init())
+
+	var acc testutil.Accumulator
+	require.NoError(t, plugin.Start(&acc))
+	defer plugin.Stop()
+
+	// Checkpoint the state while processing metrics
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := 0; i < 100; i++ {
+			m := metric.New("test", map[string]string{}, map[string]interface{}{"value": i}, time.Unix(0, 0))
+			if err := plugin.Add(m, &acc); err != nil {
+				t.Errorf("adding metric failed: %v", err)
+			}
+		}
+	}()
+	for {
+		data, ok := plugin.GetState().([]byte)
+		require.True(t, ok, "state is not a bytes array")
+		require.NotEmpty(t, data)
+
+		select {
+		case <-done:
+			return
+		default:
+		}
+	}
+}
+
 func TestUsePredefinedStateName(t *testing.T) {
 	source := `
 def apply(metric):
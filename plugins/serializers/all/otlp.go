@@ -0,0 +1,7 @@
+//go:build !custom || serializers || serializers.otlp
+
+package all
+
+import (
+	_ "github.com/influxdata/telegraf/plugins/serializers/otlp" // register plugin
+)
@@ -0,0 +1,107 @@
+package arrow
+
+import (
+	"bytes"
+	"fmt"
+
+	"github.com/apache/arrow-go/v18/arrow/ipc"
+	"github.com/apache/arrow-go/v18/arrow/memory"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/internal"
+	"github.com/influxdata/telegraf/plugins/common/columnar"
+	"github.com/influxdata/telegraf/plugins/serializers"
+)
+
+// Serializer encodes metrics as Apache Arrow IPC streams with one stream per
+// measurement
+type Serializer struct {
+	TimestampColumn string `toml:"arrow_timestamp_column"`
+	Compression     string `toml:"arrow_compression"`
+
+	options []ipc.Option
+}
+
+func (s *Serializer) Init() error {
+	if s.TimestampColumn == "" {
+		s.TimestampColumn = "timestamp"
+	}
+
+	s.options = []ipc.Option{ipc.WithAllocator(memory.DefaultAllocator)}
+	switch s.Compression {
+	case "", "none":
+	case "lz4":
+		s.options = append(s.options, ipc.WithLZ4())
+	case "zstd":
+		s.options = append(s.options, ipc.WithZstd())
+	default:
+		return fmt.Errorf("invalid 'arrow_compression' %q", s.Compression)
+	}
+
+	return nil
+}
+
+func (s *Serializer) Serialize(metric telegraf.Metric) ([]byte, error) {
+	return s.SerializeBatch([]telegraf.Metric{metric})
+}
+
+func (s *Serializer) SerializeBatch(metrics []telegraf.Metric) ([]byte, error) {
+	// Reject metrics with tags or fields colliding with the timestamp column
+	// instead of failing the whole batch
+	werr := &internal.PartialWriteError{
+		MetricsAccept: make([]int, 0, len(metrics)),
+	}
+	accepted := make([]telegraf.Metric, 0, len(metrics))
+	for i, m := range metrics {
+		if m.HasTag(s.TimestampColumn) || m.HasField(s.TimestampColumn) {
+			werr.Err = internal.ErrSerialization
+			werr.MetricsReject = append(werr.MetricsReject, i)
+			werr.MetricsRejectErrors = append(werr.MetricsRejectErrors, fmt.Errorf("tag or field %q collides with column", s.TimestampColumn))
+			continue
+		}
+		werr.MetricsAccept = append(werr.MetricsAccept, i)
+		accepted = append(accepted, m)
+	}
+	if len(accepted) == 0 {
+		return nil, werr
+	}
+
+	var buf bytes.Buffer
+	for _, group := range columnar.GroupByMeasurement(accepted) {
+		if err := s.writeStream(&buf, group); err != nil {
+			return nil, fmt.Errorf("serializing %q failed: %w", group.Name, err)
+		}
+	}
+
+	if werr.Err != nil {
+		return buf.Bytes(), werr
+	}
+	return buf.Bytes(), nil
+}
+
+func (s *Serializer) writeStream(buf *bytes.Buffer, group *columnar.Group) error {
+	schema, err := columnar.InferSchema(group.Metrics, s.TimestampColumn)
+	if err != nil {
+		return err
+	}
+	record, err := columnar.BuildRecord(memory.DefaultAllocator, schema, group.Metrics)
+	if err != nil {
+		return err
+	}
+	defer record.Release()
+
+	writer := ipc.NewWriter(buf, append(s.options, ipc.WithSchema(schema))...)
+	if err := writer.Write(record); err != nil {
+		writer.Close() //nolint:errcheck // Ignore error as the write already failed
+		return err
+	}
+	return writer.Close()
+}
+
+func init() {
+	serializers.Add("arrow",
+		func() telegraf.Serializer {
+			return &Serializer{}
+		},
+	)
+}
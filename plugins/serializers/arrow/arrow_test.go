@@ -0,0 +1,135 @@
+package arrow
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/apache/arrow-go/v18/arrow"
+	"github.com/apache/arrow-go/v18/arrow/array"
+	"github.com/apache/arrow-go/v18/arrow/ipc"
+	"github.com/stretchr/testify/require"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/internal"
+	"github.com/influxdata/telegraf/metric"
+)
+
+func TestSerializeBatch(t *testing.T) {
+	input := []telegraf.Metric{
+		metric.New("cpu", map[string]string{"host": "a"}, map[string]interface{}{"usage": 1.5}, time.Unix(1, 0)),
+		metric.New("mem", map[string]string{"host": "a"}, map[string]interface{}{"used": int64(1024)}, time.Unix(1, 0)),
+		metric.New("cpu", map[string]string{"host": "b"}, map[string]interface{}{"usage": int64(3)}, time.Unix(2, 0)),
+	}
+
+	for _, compression := range []string{"none", "lz4", "zstd"} {
+		t.Run(compression, func(t *testing.T) {
+			s := &Serializer{Compression: compression}
+			require.NoError(t, s.Init())
+
+			buf, err := s.SerializeBatch(input)
+			require.NoError(t, err)
+
+			streams := readStreams(t, buf)
+			require.Len(t, streams, 2)
+
+			// First stream contains the cpu measurement with the usage widened
+			// to float
+			cpu := streams[0]
+			measurement, found := cpu.Schema().Metadata().GetValue("measurement")
+			require.True(t, found)
+			require.Equal(t, "cpu", measurement)
+			require.Equal(t, int64(2), cpu.NumRows())
+			require.Equal(t, "timestamp", cpu.ColumnName(0))
+			ts := cpu.Column(0).(*array.Timestamp)
+			require.Equal(t, arrow.Timestamp(time.Unix(2, 0).UnixNano()), ts.Value(1))
+			hosts := cpu.Column(1).(*array.String)
+			require.Equal(t, "a", hosts.Value(0))
+			require.Equal(t, "b", hosts.Value(1))
+			usage := cpu.Column(2).(*array.Float64)
+			require.InDelta(t, 1.5, usage.Value(0), 0)
+			require.InDelta(t, 3.0, usage.Value(1), 0)
+
+			mem := streams[1]
+			measurement, found = mem.Schema().Metadata().GetValue("measurement")
+			require.True(t, found)
+			require.Equal(t, "mem", measurement)
+			used := mem.Column(2).(*array.Int64)
+			require.Equal(t, int64(1024), used.Value(0))
+		})
+	}
+}
+
+func TestSerialize(t *testing.T) {
+	s := &Serializer{TimestampColumn: "time"}
+	require.NoError(t, s.Init())
+
+	m := metric.New("cpu", map[string]string{}, map[string]interface{}{"ok": true}, time.Unix(0, 0))
+	buf, err := s.Serialize(m)
+	require.NoError(t, err)
+
+	streams := readStreams(t, buf)
+	require.Len(t, streams, 1)
+	require.Equal(t, "time", streams[0].ColumnName(0))
+	require.True(t, streams[0].Column(1).(*array.Boolean).Value(0))
+}
+
+func TestSerializeColumnCollision(t *testing.T) {
+	s := &Serializer{}
+	require.NoError(t, s.Init())
+
+	input := []telegraf.Metric{
+		metric.New("cpu", map[string]string{}, map[string]interface{}{"value": 1.0}, time.Unix(0, 0)),
+		metric.New("cpu", map[string]string{"timestamp": "x"}, map[string]interface{}{"value": 2.0}, time.Unix(0, 0)),
+		metric.New("mem", map[string]string{}, map[string]interface{}{"timestamp": int64(5)}, time.Unix(0, 0)),
+		metric.New("mem", map[string]string{}, map[string]interface{}{"value": 3.0}, time.Unix(0, 0)),
+	}
+	buf, err := s.SerializeBatch(input)
+
+	// Colliding metrics are rejected while the others are still serialized
+	var werr *internal.PartialWriteError
+	require.ErrorAs(t, err, &werr)
+	require.Equal(t, []int{0, 3}, werr.MetricsAccept)
+	require.Equal(t, []int{1, 2}, werr.MetricsReject)
+	require.Len(t, werr.MetricsRejectErrors, 2)
+
+	streams := readStreams(t, buf)
+	require.Len(t, streams, 2)
+	require.Equal(t, int64(1), streams[0].NumRows())
+	require.Equal(t, int64(1), streams[1].NumRows())
+
+	// Rejecting all metrics must not return any data
+	buf, err = s.SerializeBatch(input[1:3])
+	require.ErrorAs(t, err, &werr)
+	require.Empty(t, werr.MetricsAccept)
+	require.Nil(t, buf)
+}
+
+func TestInitInvalid(t *testing.T) {
+	require.ErrorContains(t, (&Serializer{Compression: "gzip"}).Init(), `invalid 'arrow_compression' "gzip"`)
+}
+
+// readStreams reads the records of all concatenated IPC streams
+func readStreams(t *testing.T, buf []byte) []arrow.Record {
+	t.Helper()
+
+	var records []arrow.Record
+	r := bytes.NewReader(buf)
+	for r.Len() > 0 {
+		reader, err := ipc.NewReader(r)
+		require.NoError(t, err)
+		for reader.Next() {
+			rec := reader.Record()
+			rec.Retain()
+			t.Cleanup(rec.Release)
+			records = append(records, rec)
+		}
+		if err := reader.Err(); err != nil && !errors.Is(err, io.EOF) {
+			require.NoError(t, err)
+		}
+		reader.Release()
+	}
+	return records
+}
@@ -0,0 +1,209 @@
+package avro
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/linkedin/goavro/v2"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/plugins/serializers"
+)
+
+// If SchemaRegistry is set, the output will be in Confluent Wire Format
+// (https://docs.confluent.io/platform/current/schema-registry/serdes-develop/index.html#wire-format)
+// using the ID of the registered schema. Otherwise, the output is bare Avro
+// binary without an attached schema or schema fingerprint.
+
+type Serializer struct {
+	SchemaRegistry   string `toml:"avro_schema_registry"`
+	CaCertPath       string `toml:"avro_schema_registry_cert"`
+	SchemaSource     string `toml:"avro_schema_source"`
+	SubjectPrefix    string `toml:"avro_subject_prefix"`
+	SubjectSuffix    string `toml:"avro_subject_suffix"`
+	Namespace        string `toml:"avro_namespace"`
+	MeasurementField string `toml:"avro_measurement_field"`
+	Timestamp        string `toml:"avro_timestamp"`
+	TimestampFormat  string `toml:"avro_timestamp_format"`
+
+	registry *schemaRegistry
+
+	// Cache of the codecs and schema IDs, keyed by subject and schema for
+	// derived schemas and by subject for schemas looked up in the registry
+	cache map[string]*encoder
+	sync.Mutex
+}
+
+type encoder struct {
+	id     int
+	codec  *goavro.Codec
+	fields []schemaField
+}
+
+func (s *Serializer) Init() error {
+	switch s.SchemaSource {
+	case "":
+		s.SchemaSource = "derive"
+	case "derive":
+	case "registry":
+		if s.SchemaRegistry == "" {
+			return errors.New("'avro_schema_source = \"registry\"' requires 'avro_schema_registry'")
+		}
+	default:
+		return fmt.Errorf("invalid 'avro_schema_source' %q", s.SchemaSource)
+	}
+
+	if s.Timestamp == "" {
+		s.Timestamp = "timestamp"
+	}
+	switch s.TimestampFormat {
+	case "":
+		s.TimestampFormat = "unix"
+	case "unix", "unix_ms", "unix_us", "unix_ns":
+	default:
+		return fmt.Errorf("invalid 'avro_timestamp_format' %q", s.TimestampFormat)
+	}
+	if s.MeasurementField != "" && s.MeasurementField == s.Timestamp {
+		return errors.New("'avro_measurement_field' and 'avro_timestamp' must differ")
+	}
+
+	if s.SchemaRegistry != "" {
+		registry, err := newSchemaRegistry(s.SchemaRegistry, s.CaCertPath)
+		if err != nil {
+			return fmt.Errorf("error connecting to the schema registry %q: %w", s.SchemaRegistry, err)
+		}
+		s.registry = registry
+	}
+	s.cache = make(map[string]*encoder)
+
+	return nil
+}
+
+func (s *Serializer) Serialize(metric telegraf.Metric) ([]byte, error) {
+	return s.serialize(nil, metric)
+}
+
+func (s *Serializer) SerializeBatch(metrics []telegraf.Metric) ([]byte, error) {
+	buf := make([]byte, 0)
+	for _, m := range metrics {
+		var err error
+		buf, err = s.serialize(buf, m)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return buf, nil
+}
+
+func (s *Serializer) serialize(buf []byte, metric telegraf.Metric) ([]byte, error) {
+	var enc *encoder
+	var native map[string]interface{}
+	var err error
+	if s.SchemaSource == "registry" {
+		enc, err = s.lookupEncoder(metric.Name())
+		if err != nil {
+			return nil, err
+		}
+		native, err = s.nativeFromSchema(metric, enc.fields)
+	} else {
+		var schema string
+		schema, native, err = s.deriveSchema(metric)
+		if err != nil {
+			return nil, fmt.Errorf("deriving schema for %q failed: %w", metric.Name(), err)
+		}
+		enc, err = s.derivedEncoder(metric.Name(), schema)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	// Prefix the data with the magic byte and the schema ID if using the
+	// schema registry
+	if s.registry != nil {
+		buf = append(buf, 0)
+		buf = binary.BigEndian.AppendUint32(buf, uint32(enc.id)) //nolint:gosec // G115: schema IDs are positive 32-bit integers
+	}
+	return enc.codec.BinaryFromNative(buf, native)
+}
+
+func (s *Serializer) derivedEncoder(name, schema string) (*encoder, error) {
+	subject := s.subject(name)
+	key := subject + "\n" + schema
+
+	s.Lock()
+	defer s.Unlock()
+
+	if enc, found := s.cache[key]; found {
+		return enc, nil
+	}
+
+	codec, err := goavro.NewCodec(schema)
+	if err != nil {
+		return nil, fmt.Errorf("creating codec for %q failed: %w", name, err)
+	}
+	enc := &encoder{codec: codec}
+	if s.registry != nil {
+		id, err := s.registry.register(subject, schema)
+		if err != nil {
+			return nil, fmt.Errorf("registering schema for subject %q failed: %w", subject, err)
+		}
+		enc.id = id
+	}
+	s.cache[key] = enc
+
+	return enc, nil
+}
+
+func (s *Serializer) lookupEncoder(name string) (*encoder, error) {
+	subject := s.subject(name)
+
+	s.Lock()
+	defer s.Unlock()
+
+	if enc, found := s.cache[subject]; found {
+		return enc, nil
+	}
+
+	id, schema, err := s.registry.latest(subject)
+	if err != nil {
+		return nil, fmt.Errorf("looking up schema for subject %q failed: %w", subject, err)
+	}
+	fields, err := parseRecordFields(schema)
+	if err != nil {
+		return nil, fmt.Errorf("schema of subject %q: %w", subject, err)
+	}
+	codec, err := goavro.NewCodec(schema)
+	if err != nil {
+		return nil, fmt.Errorf("creating codec for subject %q failed: %w", subject, err)
+	}
+	enc := &encoder{id: id, codec: codec, fields: fields}
+	s.cache[subject] = enc
+
+	return enc, nil
+}
+
+func (s *Serializer) subject(name string) string {
+	return s.SubjectPrefix + name + s.SubjectSuffix
+}
+
+func (s *Serializer) timestamp(metric telegraf.Metric) int64 {
+	switch s.TimestampFormat {
+	case "unix_ms":
+		return metric.Time().UnixMilli()
+	case "unix_us":
+		return metric.Time().UnixMicro()
+	case "unix_ns":
+		return metric.Time().UnixNano()
+	}
+	return metric.Time().Unix()
+}
+
+func init() {
+	serializers.Add("avro",
+		func() telegraf.Serializer {
+			return &Serializer{SubjectSuffix: "-value"}
+		},
+	)
+}
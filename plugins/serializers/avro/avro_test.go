@@ -0,0 +1,401 @@
+package avro
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/linkedin/goavro/v2"
+	"github.com/stretchr/testify/require"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/metric"
+	"github.com/influxdata/telegraf/plugins/parsers/avro"
+	"github.com/influxdata/telegraf/testutil"
+)
+
+func TestSerializeWithoutRegistry(t *testing.T) {
+	s := &Serializer{TimestampFormat: "unix_ns"}
+	require.NoError(t, s.Init())
+
+	m := metric.New(
+		"cpu.load",
+		map[string]string{"host": "localhost"},
+		map[string]interface{}{"value": 42.5, "count": int64(3), "ok": true, "state": "running"},
+		time.Unix(1, 5),
+	)
+	buf, err := s.Serialize(m)
+	require.NoError(t, err)
+
+	schema, _, err := s.deriveSchema(m)
+	require.NoError(t, err)
+	expectedSchema := `{"fields":[` +
+		`{"name":"timestamp","type":"long"},` +
+		`{"default":null,"name":"host","type":["null","string"]},` +
+		`{"default":null,"name":"count","type":["null","long"]},` +
+		`{"default":null,"name":"ok","type":["null","boolean"]},` +
+		`{"default":null,"name":"state","type":["null","string"]},` +
+		`{"default":null,"name":"value","type":["null","double"]}` +
+		`],"name":"cpu_load","type":"record"}`
+	require.JSONEq(t, expectedSchema, schema)
+
+	codec, err := goavro.NewCodec(schema)
+	require.NoError(t, err)
+	native, remaining, err := codec.NativeFromBinary(buf)
+	require.NoError(t, err)
+	require.Empty(t, remaining)
+	expected := map[string]interface{}{
+		"timestamp": int64(1000000005),
+		"host":      map[string]interface{}{"string": "localhost"},
+		"count":     map[string]interface{}{"long": int64(3)},
+		"ok":        map[string]interface{}{"boolean": true},
+		"state":     map[string]interface{}{"string": "running"},
+		"value":     map[string]interface{}{"double": 42.5},
+	}
+	require.Equal(t, expected, native)
+}
+
+func TestSerializeNameCollision(t *testing.T) {
+	s := &Serializer{}
+	require.NoError(t, s.Init())
+
+	m := metric.New(
+		"cpu",
+		map[string]string{"a-b": "x"},
+		map[string]interface{}{"a_b": 1.0},
+		time.Unix(0, 0),
+	)
+	_, err := s.Serialize(m)
+	require.ErrorContains(t, err, `name "a_b" of "a_b" collides with another tag or field`)
+}
+
+func TestSerializeDerivedSchemaRoundtrip(t *testing.T) {
+	registry := newTestRegistry()
+	server := httptest.NewServer(registry)
+	defer server.Close()
+
+	s := &Serializer{
+		SchemaRegistry:   server.URL,
+		SubjectSuffix:    "-value",
+		MeasurementField: "measurement",
+		TimestampFormat:  "unix_ms",
+	}
+	require.NoError(t, s.Init())
+
+	input := []telegraf.Metric{
+		metric.New("cpu", map[string]string{"host": "a"}, map[string]interface{}{"usage": 12.5}, time.UnixMilli(1000)),
+		metric.New("cpu", map[string]string{"host": "b"}, map[string]interface{}{"usage": 17.5}, time.UnixMilli(2000)),
+		metric.New("cpu", map[string]string{"host": "c"}, map[string]interface{}{"usage": 5.0, "idle": 95.0}, time.UnixMilli(3000)),
+		metric.New("mem", map[string]string{"host": "a"}, map[string]interface{}{"used": int64(1024)}, time.UnixMilli(4000)),
+	}
+
+	messages := make([][]byte, 0, len(input))
+	for _, m := range input {
+		buf, err := s.Serialize(m)
+		require.NoError(t, err)
+		messages = append(messages, buf)
+	}
+
+	// The schema must only be registered once per distinct schema and
+	// subject
+	require.Equal(t, 3, registry.registrations)
+	require.Len(t, registry.subjects["cpu-value"], 2)
+	require.Len(t, registry.subjects["mem-value"], 1)
+
+	// Check the wire format
+	for i, buf := range messages {
+		require.Equal(t, byte(0), buf[0])
+		id := int(binary.BigEndian.Uint32(buf[1:5]))
+		require.Contains(t, registry.schemas, id, "message %d", i)
+	}
+	require.Equal(t, messages[0][:5], messages[1][:5])
+	require.NotEqual(t, messages[0][:5], messages[2][:5])
+
+	// Decode the messages using the Avro parser
+	parser := &avro.Parser{
+		SchemaRegistry:   server.URL,
+		MeasurementField: "measurement",
+		Tags:             []string{"host"},
+		Fields:           []string{"usage", "idle", "used"},
+		Timestamp:        "timestamp",
+		TimestampFormat:  "unix_ms",
+		UnionMode:        "nullable",
+		Log:              testutil.Logger{},
+	}
+	require.NoError(t, parser.Init())
+
+	actual := make([]telegraf.Metric, 0, len(messages))
+	for _, buf := range messages {
+		metrics, err := parser.Parse(buf)
+		require.NoError(t, err)
+		actual = append(actual, metrics...)
+	}
+	testutil.RequireMetricsEqual(t, input, actual, testutil.SortMetrics())
+}
+
+func TestSerializeRegistrySchema(t *testing.T) {
+	registry := newTestRegistry()
+	server := httptest.NewServer(registry)
+	defer server.Close()
+
+	schema := `{
+		"type": "record",
+		"name": "sensor",
+		"namespace": "example",
+		"fields": [
+			{"name": "time", "type": {"type": "long", "logicalType": "timestamp-millis"}},
+			{"name": "room", "type": "string"},
+			{"name": "temperature", "type": "float"},
+			{"name": "humidity", "type": ["null", "int"], "default": null},
+			{"name": "unit", "type": "string", "default": "celsius"}
+		]
+	}`
+	_, err := registry.register("sensors", schema)
+	require.NoError(t, err)
+
+	s := &Serializer{
+		SchemaRegistry:  server.URL,
+		SchemaSource:    "registry",
+		SubjectSuffix:   "s",
+		Timestamp:       "time",
+		TimestampFormat: "unix_ms",
+	}
+	require.NoError(t, s.Init())
+
+	m := metric.New(
+		"sensor",
+		map[string]string{"room": "kitchen"},
+		map[string]interface{}{"temperature": int64(21), "ignored": "value"},
+		time.UnixMilli(1500),
+	)
+	buf, err := s.Serialize(m)
+	require.NoError(t, err)
+	require.Equal(t, 1, registry.registrations, "no additional schema must be registered")
+	require.Equal(t, uint32(1), binary.BigEndian.Uint32(buf[1:5]))
+
+	codec, err := goavro.NewCodec(schema)
+	require.NoError(t, err)
+	native, _, err := codec.NativeFromBinary(buf[5:])
+	require.NoError(t, err)
+	expected := map[string]interface{}{
+		"time":        time.UnixMilli(1500).UTC(),
+		"room":        "kitchen",
+		"temperature": float32(21),
+		"humidity":    nil,
+		"unit":        "celsius",
+	}
+	require.Equal(t, expected, native)
+
+	// Missing required values must be reported
+	m = metric.New("sensor", map[string]string{}, map[string]interface{}{"temperature": 21.0}, time.Unix(0, 0))
+	_, err = s.Serialize(m)
+	require.ErrorContains(t, err, `no value for field "room" required by the schema`)
+
+	// Unknown subjects must be reported
+	m = metric.New("unknown", map[string]string{}, map[string]interface{}{"value": 1.0}, time.Unix(0, 0))
+	_, err = s.Serialize(m)
+	require.ErrorContains(t, err, `looking up schema for subject "unknowns" failed`)
+}
+
+func TestSerializeRegistrySchemaSanitizedNames(t *testing.T) {
+	registry := newTestRegistry()
+	server := httptest.NewServer(registry)
+	defer server.Close()
+
+	schema := `{
+		"type": "record",
+		"name": "cpu",
+		"fields": [
+			{"name": "timestamp", "type": "long"},
+			{"name": "data_center", "type": ["null", "string"], "default": null},
+			{"name": "usage_idle", "type": ["null", "double"], "default": null}
+		]
+	}`
+	_, err := registry.register("cpu-value", schema)
+	require.NoError(t, err)
+
+	s := &Serializer{
+		SchemaRegistry: server.URL,
+		SchemaSource:   "registry",
+		SubjectSuffix:  "-value",
+	}
+	require.NoError(t, s.Init())
+
+	// The names must match the schema in the same way as derived schemas
+	m := metric.New(
+		"cpu",
+		map[string]string{"data-center": "eu"},
+		map[string]interface{}{"usage.idle": 42.0},
+		time.Unix(1, 0),
+	)
+	buf, err := s.Serialize(m)
+	require.NoError(t, err)
+
+	codec, err := goavro.NewCodec(schema)
+	require.NoError(t, err)
+	native, _, err := codec.NativeFromBinary(buf[5:])
+	require.NoError(t, err)
+	expected := map[string]interface{}{
+		"timestamp":   int64(1),
+		"data_center": map[string]interface{}{"string": "eu"},
+		"usage_idle":  map[string]interface{}{"double": 42.0},
+	}
+	require.Equal(t, expected, native)
+
+	// Keys colliding after sanitizing must be reported
+	m = metric.New(
+		"cpu",
+		map[string]string{"usage-idle": "x"},
+		map[string]interface{}{"usage.idle": 42.0},
+		time.Unix(1, 0),
+	)
+	_, err = s.Serialize(m)
+	require.ErrorContains(t, err, "collides with another tag or field")
+}
+
+func TestSerializeBatch(t *testing.T) {
+	s := &Serializer{}
+	require.NoError(t, s.Init())
+
+	m := metric.New("cpu", map[string]string{}, map[string]interface{}{"value": 1.0}, time.Unix(0, 0))
+	single, err := s.Serialize(m)
+	require.NoError(t, err)
+	batch, err := s.SerializeBatch([]telegraf.Metric{m, m})
+	require.NoError(t, err)
+	require.Equal(t, append(single, single...), batch)
+}
+
+func TestInitInvalid(t *testing.T) {
+	tests := []struct {
+		name       string
+		serializer *Serializer
+		expected   string
+	}{
+		{
+			name:       "invalid schema source",
+			serializer: &Serializer{SchemaSource: "foo"},
+			expected:   `invalid 'avro_schema_source' "foo"`,
+		},
+		{
+			name:       "registry source without registry",
+			serializer: &Serializer{SchemaSource: "registry"},
+			expected:   "requires 'avro_schema_registry'",
+		},
+		{
+			name:       "invalid timestamp format",
+			serializer: &Serializer{TimestampFormat: "rfc3339"},
+			expected:   `invalid 'avro_timestamp_format' "rfc3339"`,
+		},
+		{
+			name:       "measurement field equals timestamp",
+			serializer: &Serializer{MeasurementField: "timestamp"},
+			expected:   "must differ",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require.ErrorContains(t, tt.serializer.Init(), tt.expected)
+		})
+	}
+}
+
+func TestSanitizeName(t *testing.T) {
+	require.Equal(t, "cpu_load", sanitizeName("cpu.load"))
+	require.Equal(t, "_1min", sanitizeName("1min"))
+	require.Equal(t, "usage_idle", sanitizeName("usage_idle"))
+	require.Equal(t, "_", sanitizeName(""))
+}
+
+// testRegistry is a minimal stand-in for a Confluent schema registry
+type testRegistry struct {
+	subjects      map[string][]int
+	schemas       map[int]string
+	registrations int
+	sync.Mutex
+}
+
+func newTestRegistry() *testRegistry {
+	return &testRegistry{
+		subjects: make(map[string][]int),
+		schemas:  make(map[int]string),
+	}
+}
+
+func (r *testRegistry) register(subject, schema string) (int, error) {
+	r.Lock()
+	defer r.Unlock()
+
+	r.registrations++
+	for _, id := range r.subjects[subject] {
+		if r.schemas[id] == schema {
+			return id, nil
+		}
+	}
+	id := len(r.schemas) + 1
+	r.schemas[id] = schema
+	r.subjects[subject] = append(r.subjects[subject], id)
+	return id, nil
+}
+
+func (r *testRegistry) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	parts := strings.Split(strings.Trim(req.URL.Path, "/"), "/")
+	switch {
+	case len(parts) == 3 && parts[0] == "subjects" && parts[2] == "versions" && req.Method == http.MethodPost:
+		var body struct {
+			Schema string `json:"schema"`
+		}
+		if err := json.NewDecoder(req.Body).Decode(&body); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		id, err := r.register(parts[1], body.Schema)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		writeJSON(w, map[string]interface{}{"id": id})
+	case len(parts) == 4 && parts[0] == "subjects" && parts[3] == "latest":
+		r.Lock()
+		ids := r.subjects[parts[1]]
+		r.Unlock()
+		if len(ids) == 0 {
+			http.Error(w, `{"error_code":40401,"message":"Subject not found."}`, http.StatusNotFound)
+			return
+		}
+		id := ids[len(ids)-1]
+		r.Lock()
+		schema := r.schemas[id]
+		r.Unlock()
+		writeJSON(w, map[string]interface{}{"subject": parts[1], "version": len(ids), "id": id, "schema": schema})
+	case len(parts) == 3 && parts[0] == "schemas" && parts[1] == "ids":
+		id, err := strconv.Atoi(parts[2])
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		r.Lock()
+		schema, found := r.schemas[id]
+		r.Unlock()
+		if !found {
+			http.Error(w, `{"error_code":40403,"message":"Schema not found"}`, http.StatusNotFound)
+			return
+		}
+		writeJSON(w, map[string]interface{}{"schema": schema})
+	default:
+		http.NotFound(w, req)
+	}
+}
+
+func writeJSON(w http.ResponseWriter, v interface{}) {
+	w.Header().Set("Content-Type", "application/vnd.schemaregistry.v1+json")
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
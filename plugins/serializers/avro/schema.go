@@ -0,0 +1,322 @@
+package avro
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/linkedin/goavro/v2"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/internal"
+	"github.com/influxdata/telegraf/metric"
+)
+
+// schemaField is a field of a record schema with the primitive types allowed
+// for the field. Unions result in multiple types.
+type schemaField struct {
+	name       string
+	types      []string
+	hasDefault bool
+}
+
+// deriveSchema creates a record schema for the given metric with the tags and
+// fields being optional to allow schema evolution. The function returns the
+// schema and the native data of the metric matching the schema.
+func (s *Serializer) deriveSchema(m telegraf.Metric) (string, map[string]interface{}, error) {
+	fields := make([]map[string]interface{}, 0, 2+len(m.TagList())+len(m.FieldList()))
+	native := make(map[string]interface{}, cap(fields))
+	add := func(key, typ string, value interface{}, optional bool) error {
+		name := sanitizeName(key)
+		if _, found := native[name]; found {
+			return fmt.Errorf("name %q of %q collides with another tag or field", name, key)
+		}
+		if optional {
+			fields = append(fields, map[string]interface{}{"name": name, "type": []string{"null", typ}, "default": nil})
+			native[name] = goavro.Union(typ, value)
+		} else {
+			fields = append(fields, map[string]interface{}{"name": name, "type": typ})
+			native[name] = value
+		}
+		return nil
+	}
+
+	if err := add(s.Timestamp, "long", s.timestamp(m), false); err != nil {
+		return "", nil, err
+	}
+	if s.MeasurementField != "" {
+		if err := add(s.MeasurementField, "string", m.Name(), false); err != nil {
+			return "", nil, err
+		}
+	}
+	for _, tag := range m.TagList() {
+		if err := add(tag.Key, "string", tag.Value, true); err != nil {
+			return "", nil, err
+		}
+	}
+
+	// Sort the fields to get the same schema independent of the field order
+	metricFields := slices.Clone(m.FieldList())
+	sort.Slice(metricFields, func(i, j int) bool { return metricFields[i].Key < metricFields[j].Key })
+	for _, field := range metricFields {
+		// Native histograms are not supported
+		if metric.IsHistogram(field.Value) {
+			continue
+		}
+		typ, value, err := nativeValue(field.Value)
+		if err != nil {
+			return "", nil, fmt.Errorf("field %q: %w", field.Key, err)
+		}
+		if err := add(field.Key, typ, value, true); err != nil {
+			return "", nil, err
+		}
+	}
+
+	record := map[string]interface{}{
+		"type":   "record",
+		"name":   sanitizeName(m.Name()),
+		"fields": fields,
+	}
+	if s.Namespace != "" {
+		record["namespace"] = s.Namespace
+	}
+	schema, err := json.Marshal(record)
+	if err != nil {
+		return "", nil, err
+	}
+	return string(schema), native, nil
+}
+
+// nativeFromSchema converts the metric to native data matching the given
+// fields of a record schema
+func (s *Serializer) nativeFromSchema(m telegraf.Metric, fields []schemaField) (map[string]interface{}, error) {
+	values, err := s.sanitizedValues(m)
+	if err != nil {
+		return nil, err
+	}
+
+	native := make(map[string]interface{}, len(fields))
+	for _, f := range fields {
+		value, found := values[f.name]
+		if !found {
+			switch {
+			case slices.Contains(f.types, "null"):
+				native[f.name] = nil
+			case f.hasDefault:
+				// Let the codec fill in the default value
+			default:
+				return nil, fmt.Errorf("no value for field %q required by the schema", f.name)
+			}
+			continue
+		}
+
+		typ, converted, err := convertValue(value, f.types)
+		if err != nil {
+			return nil, fmt.Errorf("field %q: %w", f.name, err)
+		}
+		if len(f.types) > 1 {
+			native[f.name] = goavro.Union(typ, converted)
+		} else {
+			native[f.name] = converted
+		}
+	}
+	return native, nil
+}
+
+// sanitizedValues maps the sanitized names of the timestamp, measurement, tags
+// and fields to their values the same way deriveSchema names the schema fields
+func (s *Serializer) sanitizedValues(m telegraf.Metric) (map[string]interface{}, error) {
+	values := make(map[string]interface{}, 2+len(m.TagList())+len(m.FieldList()))
+	add := func(key string, value interface{}) error {
+		name := sanitizeName(key)
+		if _, found := values[name]; found {
+			return fmt.Errorf("name %q of %q collides with another tag or field", name, key)
+		}
+		values[name] = value
+		return nil
+	}
+
+	if err := add(s.Timestamp, s.timestamp(m)); err != nil {
+		return nil, err
+	}
+	if s.MeasurementField != "" {
+		if err := add(s.MeasurementField, m.Name()); err != nil {
+			return nil, err
+		}
+	}
+	for _, tag := range m.TagList() {
+		if err := add(tag.Key, tag.Value); err != nil {
+			return nil, err
+		}
+	}
+	for _, field := range m.FieldList() {
+		// Native histograms are not supported
+		if metric.IsHistogram(field.Value) {
+			continue
+		}
+		if err := add(field.Key, field.Value); err != nil {
+			return nil, err
+		}
+	}
+	return values, nil
+}
+
+// parseRecordFields extracts the fields of the given record schema
+func parseRecordFields(schema string) ([]schemaField, error) {
+	var record struct {
+		Type   string `json:"type"`
+		Fields []struct {
+			Name    string          `json:"name"`
+			Type    json.RawMessage `json:"type"`
+			Default json.RawMessage `json:"default"`
+		} `json:"fields"`
+	}
+	if err := json.Unmarshal([]byte(schema), &record); err != nil {
+		return nil, fmt.Errorf("unmarshalling schema failed: %w", err)
+	}
+	if record.Type != "record" {
+		return nil, fmt.Errorf("schema type %q is not a record", record.Type)
+	}
+
+	fields := make([]schemaField, 0, len(record.Fields))
+	for _, f := range record.Fields {
+		types, err := primitiveTypes(f.Type, true)
+		if err != nil {
+			return nil, fmt.Errorf("field %q: %w", f.Name, err)
+		}
+		fields = append(fields, schemaField{
+			name:       f.Name,
+			types:      types,
+			hasDefault: f.Default != nil,
+		})
+	}
+	return fields, nil
+}
+
+func primitiveTypes(raw json.RawMessage, allowUnion bool) ([]string, error) {
+	// Plain type names
+	var name string
+	if err := json.Unmarshal(raw, &name); err == nil {
+		if !isPrimitive(name) {
+			return nil, fmt.Errorf("unsupported type %q", name)
+		}
+		return []string{name}, nil
+	}
+
+	// Unions of types
+	var union []json.RawMessage
+	if err := json.Unmarshal(raw, &union); err == nil {
+		if !allowUnion {
+			return nil, errors.New("nested unions are not supported")
+		}
+		types := make([]string, 0, len(union))
+		for _, element := range union {
+			t, err := primitiveTypes(element, false)
+			if err != nil {
+				return nil, err
+			}
+			types = append(types, t...)
+		}
+		return types, nil
+	}
+
+	// Types with attributes such as logical types
+	var object struct {
+		Type string `json:"type"`
+	}
+	if err := json.Unmarshal(raw, &object); err != nil {
+		return nil, fmt.Errorf("invalid type %s", string(raw))
+	}
+	if !isPrimitive(object.Type) {
+		return nil, fmt.Errorf("unsupported type %q", object.Type)
+	}
+	return []string{object.Type}, nil
+}
+
+func isPrimitive(name string) bool {
+	switch name {
+	case "null", "boolean", "int", "long", "float", "double", "string":
+		return true
+	}
+	return false
+}
+
+// nativeValue returns the Avro type and value of a metric field
+func nativeValue(value interface{}) (string, interface{}, error) {
+	switch v := value.(type) {
+	case float64:
+		return "double", v, nil
+	case int64:
+		return "long", v, nil
+	case uint64:
+		if v > math.MaxInt64 {
+			return "", nil, fmt.Errorf("value %d exceeds the range of long", v)
+		}
+		return "long", int64(v), nil
+	case bool:
+		return "boolean", v, nil
+	case string:
+		return "string", v, nil
+	}
+	return "", nil, fmt.Errorf("unsupported type %T", value)
+}
+
+// convertValue converts the value to the first of the given types possible,
+// preferring the type matching the value without conversion
+func convertValue(value interface{}, types []string) (string, interface{}, error) {
+	if typ, v, err := nativeValue(value); err == nil && slices.Contains(types, typ) {
+		return typ, v, nil
+	}
+
+	for _, typ := range types {
+		var v interface{}
+		var err error
+		switch typ {
+		case "boolean":
+			v, err = internal.ToBool(value)
+		case "int":
+			v, err = internal.ToInt32(value)
+		case "long":
+			v, err = internal.ToInt64(value)
+		case "float":
+			v, err = internal.ToFloat32(value)
+		case "double":
+			v, err = internal.ToFloat64(value)
+		case "string":
+			v, err = internal.ToString(value)
+		default:
+			continue
+		}
+		if err == nil {
+			return typ, v, nil
+		}
+	}
+	return "", nil, fmt.Errorf("cannot convert %v (%T) to any of %s", value, value, strings.Join(types, ", "))
+}
+
+// sanitizeName replaces all characters not allowed in Avro names
+func sanitizeName(name string) string {
+	var b strings.Builder
+	b.Grow(len(name) + 1)
+	for i, r := range name {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r == '_':
+			b.WriteRune(r)
+		case r >= '0' && r <= '9':
+			if i == 0 {
+				b.WriteRune('_')
+			}
+			b.WriteRune(r)
+		default:
+			b.WriteRune('_')
+		}
+	}
+	if b.Len() == 0 {
+		return "_"
+	}
+	return b.String()
+}
@@ -0,0 +1,138 @@
+package avro
+
+import (
+	"bytes"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+)
+
+const (
+	subjectVersions = "%s/subjects/%s/versions"
+	subjectLatest   = "%s/subjects/%s/versions/latest"
+)
+
+type schemaRegistry struct {
+	url      string
+	username string
+	password string
+	client   *http.Client
+}
+
+func newSchemaRegistry(addr, caCertPath string) (*schemaRegistry, error) {
+	var tlsCfg *tls.Config
+	if caCertPath != "" {
+		caCert, err := os.ReadFile(caCertPath)
+		if err != nil {
+			return nil, err
+		}
+		caCertPool := x509.NewCertPool()
+		caCertPool.AppendCertsFromPEM(caCert)
+		tlsCfg = &tls.Config{
+			RootCAs: caCertPool,
+		}
+	}
+	client := &http.Client{
+		Transport: &http.Transport{
+			TLSClientConfig: tlsCfg,
+			MaxIdleConns:    10,
+			IdleConnTimeout: 90 * time.Second,
+		},
+		Timeout: 10 * time.Second,
+	}
+
+	u, err := url.Parse(addr)
+	if err != nil {
+		return nil, fmt.Errorf("parsing registry URL failed: %w", err)
+	}
+
+	var username, password string
+	if u.User != nil {
+		username = u.User.Username()
+		password, _ = u.User.Password()
+		u.User = nil
+	}
+
+	registry := &schemaRegistry{
+		url:      strings.TrimSuffix(u.String(), "/"),
+		username: username,
+		password: password,
+		client:   client,
+	}
+
+	return registry, nil
+}
+
+// register registers the schema under the given subject and returns the ID
+// of the schema. Registering an already known schema returns the existing ID.
+func (sr *schemaRegistry) register(subject, schema string) (int, error) {
+	body, err := json.Marshal(map[string]string{"schema": schema})
+	if err != nil {
+		return 0, err
+	}
+
+	addr := fmt.Sprintf(subjectVersions, sr.url, url.PathEscape(subject))
+	req, err := http.NewRequest(http.MethodPost, addr, bytes.NewReader(body))
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Content-Type", "application/vnd.schemaregistry.v1+json")
+
+	var response struct {
+		ID int `json:"id"`
+	}
+	if err := sr.do(req, &response); err != nil {
+		return 0, err
+	}
+	return response.ID, nil
+}
+
+// latest returns the ID and the schema of the latest version registered
+// under the given subject
+func (sr *schemaRegistry) latest(subject string) (int, string, error) {
+	addr := fmt.Sprintf(subjectLatest, sr.url, url.PathEscape(subject))
+	req, err := http.NewRequest(http.MethodGet, addr, nil)
+	if err != nil {
+		return 0, "", err
+	}
+
+	var response struct {
+		ID     int    `json:"id"`
+		Schema string `json:"schema"`
+	}
+	if err := sr.do(req, &response); err != nil {
+		return 0, "", err
+	}
+	if response.Schema == "" {
+		return 0, "", errors.New("malformed response from schema registry: no 'schema' key")
+	}
+	return response.ID, response.Schema, nil
+}
+
+func (sr *schemaRegistry) do(req *http.Request, response interface{}) error {
+	req.Header.Set("Accept", "application/vnd.schemaregistry.v1+json")
+	if sr.username != "" {
+		req.SetBasicAuth(sr.username, sr.password)
+	}
+
+	resp, err := sr.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("schema registry returned status %d: %s", resp.StatusCode, strings.TrimSpace(string(body)))
+	}
+
+	return json.NewDecoder(resp.Body).Decode(response)
+}
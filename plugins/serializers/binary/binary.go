@@ -2,16 +2,19 @@ package binary
 
 import (
 	"encoding/binary"
+	"errors"
 	"fmt"
 
 	"github.com/influxdata/telegraf"
 	"github.com/influxdata/telegraf/internal"
+	common_binary "github.com/influxdata/telegraf/plugins/common/binary"
 	"github.com/influxdata/telegraf/plugins/serializers"
 )
 
 type Serializer struct {
-	Entries    []*Entry `toml:"entries"`
-	Endianness string   `toml:"endianness"`
+	Entries    []*Entry               `toml:"entries"`
+	Endianness string                 `toml:"endianness"`
+	Layouts    []common_binary.Config `toml:"binary"`
 
 	converter binary.ByteOrder
 }
@@ -29,6 +32,17 @@ func (s *Serializer) Init() error {
 		return fmt.Errorf("invalid endianness %q", s.Endianness)
 	}
 
+	if len(s.Layouts) > 0 {
+		if len(s.Entries) > 0 {
+			return errors.New("'entries' cannot be used together with 'binary' layouts")
+		}
+		for i := range s.Layouts {
+			if err := s.Layouts[i].Preprocess(); err != nil {
+				return fmt.Errorf("layout %d invalid: %w", i, err)
+			}
+		}
+	}
+
 	for i, entry := range s.Entries {
 		if err := entry.fillDefaults(); err != nil {
 			return fmt.Errorf("entry %d check failed: %w", i, err)
@@ -39,6 +53,10 @@ func (s *Serializer) Init() error {
 }
 
 func (s *Serializer) Serialize(metric telegraf.Metric) ([]byte, error) {
+	if len(s.Layouts) > 0 {
+		return s.serializeLayout(metric)
+	}
+
 	serialized := make([]byte, 0)
 
 	for _, entry := range s.Entries {
@@ -83,6 +101,18 @@ func (s *Serializer) Serialize(metric telegraf.Metric) ([]byte, error) {
 	return serialized, nil
 }
 
+// serializeLayout encodes the metric using the first layout matching the
+// metric name
+func (s *Serializer) serializeLayout(metric telegraf.Metric) ([]byte, error) {
+	for i := range s.Layouts {
+		layout := &s.Layouts[i]
+		if layout.MatchesMetric(metric) {
+			return layout.Serialize(metric, s.converter)
+		}
+	}
+	return nil, fmt.Errorf("no layout for metric %q", metric.Name())
+}
+
 func (s *Serializer) SerializeBatch(metrics []telegraf.Metric) ([]byte, error) {
 	serialized := make([]byte, 0)
 
@@ -7,7 +7,11 @@ import (
 
 	"github.com/stretchr/testify/require"
 
+	"github.com/influxdata/telegraf"
 	"github.com/influxdata/telegraf/metric"
+	common_binary "github.com/influxdata/telegraf/plugins/common/binary"
+	parsers_binary "github.com/influxdata/telegraf/plugins/parsers/binary"
+	"github.com/influxdata/telegraf/testutil"
 )
 
 func TestMetricSerialization(t *testing.T) {
@@ -119,3 +123,99 @@ func TestMetricSerialization(t *testing.T) {
 		})
 	}
 }
+
+func TestLayoutSerialization(t *testing.T) {
+	layouts := []common_binary.Config{
+		{
+			MetricName: "status",
+			Filter: &common_binary.Filter{
+				Selection: []common_binary.BinaryPart{{Offset: 0, Bits: 8, Match: "0x02"}},
+			},
+			Entries: []common_binary.Entry{
+				{Bits: 8, Omit: true},
+				{Name: "running", Type: "bool", Bits: 1},
+				{Name: "code", Type: "uint8", Bits: 7},
+				{Assignment: "checksum", Algorithm: "crc16-modbus", Endianness: "le"},
+			},
+		},
+		{
+			MetricName: "sensor",
+			Filter: &common_binary.Filter{
+				Selection: []common_binary.BinaryPart{{Offset: 0, Bits: 8, Match: "0x01"}},
+			},
+			Entries: []common_binary.Entry{
+				{Bits: 8, Omit: true},
+				{Name: "device", Type: "string", Terminator: "null", Assignment: "tag"},
+				{Name: "n", Type: "uint8"},
+				{Name: "temperature", Type: "float32", Count: "n"},
+				{Assignment: "checksum", Algorithm: "crc32"},
+			},
+		},
+	}
+
+	metrics := []telegraf.Metric{
+		metric.New(
+			"sensor",
+			map[string]string{"device": "A1"},
+			map[string]interface{}{"temperature_0": float32(21.5), "temperature_1": float32(-3.25)},
+			time.Unix(0, 0),
+		),
+		metric.New(
+			"status",
+			map[string]string{},
+			map[string]interface{}{"running": true, "code": uint8(5)},
+			time.Unix(0, 0),
+		),
+	}
+
+	serializer := &Serializer{
+		Endianness: "big",
+		Layouts:    layouts,
+	}
+	require.NoError(t, serializer.Init())
+
+	// Parse the data again using the same layouts
+	parser := &parsers_binary.Parser{
+		Endianness: "be",
+		Configs:    layouts,
+		Log:        testutil.Logger{},
+	}
+	require.NoError(t, parser.Init())
+
+	expected := []telegraf.Metric{
+		metric.New(
+			"sensor",
+			map[string]string{"device": "A1"},
+			map[string]interface{}{"n": uint8(2), "temperature_0": float32(21.5), "temperature_1": float32(-3.25)},
+			time.Unix(0, 0),
+		),
+		metric.New(
+			"status",
+			map[string]string{},
+			map[string]interface{}{"running": true, "code": uint8(5)},
+			time.Unix(0, 0),
+		),
+	}
+
+	var actual []telegraf.Metric
+	for _, m := range metrics {
+		buf, err := serializer.Serialize(m)
+		require.NoError(t, err)
+
+		parsed, err := parser.Parse(buf)
+		require.NoError(t, err)
+		actual = append(actual, parsed...)
+	}
+	testutil.RequireMetricsEqual(t, expected, actual, testutil.IgnoreTime())
+
+	_, err := serializer.Serialize(metric.New("unknown", nil, map[string]interface{}{"value": 1}, time.Unix(0, 0)))
+	require.EqualError(t, err, `no layout for metric "unknown"`)
+}
+
+func TestLayoutWithEntries(t *testing.T) {
+	serializer := &Serializer{
+		Entries: []*Entry{{Name: "value", DataFormat: "uint8"}},
+		Layouts: []common_binary.Config{{Entries: []common_binary.Entry{{Name: "value", Type: "uint8"}}}},
+	}
+	require.EqualError(t, serializer.Init(), "'entries' cannot be used together with 'binary' layouts")
+}
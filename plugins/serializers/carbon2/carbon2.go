@@ -9,6 +9,7 @@ import (
 
 	"github.com/influxdata/telegraf"
 	"github.com/influxdata/telegraf/internal"
+	telegraf_metric "github.com/influxdata/telegraf/metric"
 	"github.com/influxdata/telegraf/plugins/serializers"
 )
 
@@ -68,7 +69,8 @@ func (s *Serializer) createObject(metric telegraf.Metric) []byte {
 	var m bytes.Buffer
 
 	for fieldName, fieldValue := range metric.Fields() {
-		if _, ok := fieldValue.(string); ok {
+		// Strings and native histograms are not supported
+		if _, ok := fieldValue.(string); ok || telegraf_metric.IsHistogram(fieldValue) {
 			continue
 		}
 
@@ -11,6 +11,7 @@ import (
 	"github.com/gofrs/uuid/v5"
 
 	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/metric"
 	"github.com/influxdata/telegraf/plugins/serializers"
 )
 
@@ -103,7 +104,7 @@ func (s *Serializer) batchMetrics(metrics []telegraf.Metric) ([]byte, error) {
 		data = append(data, map[string]interface{}{
 			"name":      m.Name(),
 			"tags":      m.Tags(),
-			"fields":    m.Fields(),
+			"fields":    fields(m),
 			"timestamp": ts.UnixNano(),
 		})
 		if ts.Before(earliest) {
@@ -168,7 +169,7 @@ func (s *Serializer) createEvent(m telegraf.Metric) (*cloudevents.Event, error)
 	data := map[string]interface{}{
 		"name":      m.Name(),
 		"tags":      m.Tags(),
-		"fields":    m.Fields(),
+		"fields":    fields(m),
 		"timestamp": m.Time().UnixNano(),
 	}
 
@@ -190,6 +191,19 @@ func (s *Serializer) createEvent(m telegraf.Metric) (*cloudevents.Event, error)
 	return &evt, nil
 }
 
+// fields returns the fields of the metric skipping native histograms as those
+// are not supported
+func fields(m telegraf.Metric) map[string]interface{} {
+	fields := make(map[string]interface{}, len(m.FieldList()))
+	for _, field := range m.FieldList() {
+		if metric.IsHistogram(field.Value) {
+			continue
+		}
+		fields[field.Key] = field.Value
+	}
+	return fields
+}
+
 func init() {
 	serializers.Add("cloudevents",
 		func() telegraf.Serializer {
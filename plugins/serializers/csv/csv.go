@@ -13,6 +13,7 @@ import (
 
 	"github.com/influxdata/telegraf"
 	"github.com/influxdata/telegraf/internal"
+	telegraf_metric "github.com/influxdata/telegraf/metric"
 	"github.com/influxdata/telegraf/plugins/serializers"
 )
 
@@ -127,6 +128,10 @@ func (s *Serializer) writeHeader(metric telegraf.Metric) error {
 		return metric.FieldList()[i].Key < metric.FieldList()[j].Key
 	})
 	for _, field := range metric.FieldList() {
+		// Native histograms are not supported
+		if telegraf_metric.IsHistogram(field.Value) {
+			continue
+		}
 		if s.Prefix {
 			columns = append(columns, "field_"+field.Key)
 		} else {
@@ -182,6 +187,10 @@ func (s *Serializer) writeData(metric telegraf.Metric) error {
 		return metric.FieldList()[i].Key < metric.FieldList()[j].Key
 	})
 	for _, field := range metric.FieldList() {
+		// Native histograms are not supported
+		if telegraf_metric.IsHistogram(field.Value) {
+			continue
+		}
 		v, err := internal.ToString(field.Value)
 		if err != nil {
 			return fmt.Errorf("converting field %q to string failed: %w", field.Key, err)
@@ -222,7 +231,7 @@ func (s *Serializer) writeDataOrdered(metric telegraf.Metric) error {
 		case strings.HasPrefix(name, "field."):
 			var v string
 			field := strings.TrimPrefix(name, "field.")
-			if raw, ok := metric.GetField(field); ok {
+			if raw, ok := metric.GetField(field); ok && !telegraf_metric.IsHistogram(raw) {
 				var err error
 				v, err = internal.ToString(raw)
 				if err != nil {
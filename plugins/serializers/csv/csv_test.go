@@ -6,10 +6,12 @@ import (
 	"path/filepath"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/influxdata/toml"
 	"github.com/stretchr/testify/require"
 
+	"github.com/influxdata/telegraf/metric"
 	"github.com/influxdata/telegraf/plugins/parsers/influx"
 	"github.com/influxdata/telegraf/plugins/serializers"
 	"github.com/influxdata/telegraf/testutil"
@@ -259,3 +261,25 @@ func BenchmarkSerializeBatch(b *testing.B) {
 		require.NoError(b, err)
 	}
 }
+
+func TestSerializeSkipHistograms(t *testing.T) {
+	m := metric.New(
+		"http",
+		map[string]string{"path": "/"},
+		map[string]interface{}{
+			"requests": int64(3),
+			"latency": metric.Histogram{
+				Count:   3,
+				Sum:     4.5,
+				Buckets: []metric.Bucket{{UpperBound: 1, Count: 1}, {UpperBound: 2, Count: 2}},
+			},
+		},
+		time.Unix(0, 0),
+	)
+
+	s := &Serializer{Header: true}
+	require.NoError(t, s.Init())
+	buf, err := s.Serialize(m)
+	require.NoError(t, err)
+	require.Equal(t, "timestamp,measurement,path,requests\n0,http,/,3\n", string(buf))
+}
@@ -8,21 +8,26 @@ import (
 	"time"
 
 	"github.com/blues/jsonata-go"
+	"github.com/santhosh-tekuri/jsonschema/v5"
 
 	"github.com/influxdata/telegraf"
 	"github.com/influxdata/telegraf/config"
 	"github.com/influxdata/telegraf/filter"
+	telegraf_metric "github.com/influxdata/telegraf/metric"
 	"github.com/influxdata/telegraf/plugins/serializers"
 )
 
 type Serializer struct {
-	TimestampUnits      config.Duration `toml:"json_timestamp_units"`
-	TimestampFormat     string          `toml:"json_timestamp_format"`
-	Transformation      string          `toml:"json_transformation"`
-	NestedFieldsInclude []string        `toml:"json_nested_fields_include"`
-	NestedFieldsExclude []string        `toml:"json_nested_fields_exclude"`
+	TimestampUnits      config.Duration   `toml:"json_timestamp_units"`
+	TimestampFormat     string            `toml:"json_timestamp_format"`
+	Transformation      string            `toml:"json_transformation"`
+	NestedFieldsInclude []string          `toml:"json_nested_fields_include"`
+	NestedFieldsExclude []string          `toml:"json_nested_fields_exclude"`
+	Schemas             map[string]string `toml:"json_schemas"`
+	SchemaUnmatched     string            `toml:"json_schema_unmatched"`
 
 	nestedfields filter.Filter
+	schemas      map[string]*jsonschema.Schema
 }
 
 func (s *Serializer) Init() error {
@@ -51,10 +56,22 @@ func (s *Serializer) Init() error {
 		s.nestedfields = f
 	}
 
+	if len(s.Schemas) > 0 {
+		return s.compileSchemas()
+	}
+
 	return nil
 }
 
 func (s *Serializer) Serialize(metric telegraf.Metric) ([]byte, error) {
+	if s.schemas != nil {
+		serialized, err := s.serializeWithSchema(metric)
+		if err != nil {
+			return nil, err
+		}
+		return append(serialized, '\n'), nil
+	}
+
 	var obj interface{}
 	obj = s.createObject(metric)
 
@@ -78,6 +95,10 @@ func (s *Serializer) Serialize(metric telegraf.Metric) ([]byte, error) {
 }
 
 func (s *Serializer) SerializeBatch(metrics []telegraf.Metric) ([]byte, error) {
+	if s.schemas != nil {
+		return s.serializeBatchWithSchema(metrics)
+	}
+
 	objects := make([]interface{}, 0, len(metrics))
 	for _, metric := range metrics {
 		m := s.createObject(metric)
@@ -121,6 +142,9 @@ func (s *Serializer) createObject(metric telegraf.Metric) map[string]interface{}
 	for _, field := range metric.FieldList() {
 		val := field.Value
 		switch fv := field.Value.(type) {
+		case telegraf_metric.Histogram, telegraf_metric.ExponentialHistogram:
+			// Native histograms are not supported
+			continue
 		case float64:
 			// JSON does not support these special values
 			if math.IsNaN(fv) || math.IsInf(fv, 0) {
@@ -15,6 +15,7 @@ import (
 
 	"github.com/influxdata/telegraf"
 	"github.com/influxdata/telegraf/config"
+	"github.com/influxdata/telegraf/internal"
 	"github.com/influxdata/telegraf/metric"
 	"github.com/influxdata/telegraf/plugins/parsers/influx"
 	"github.com/influxdata/telegraf/plugins/serializers"
@@ -461,6 +462,113 @@ type Config struct {
 	JSONNestedFieldsExclude []string      `toml:"json_nested_fields_exclude"`
 }
 
+func TestSerializeSchema(t *testing.T) {
+	serializer := &Serializer{
+		Schemas: map[string]string{"cpu": "testcases/schema_cpu.json"},
+	}
+	require.NoError(t, serializer.Init())
+
+	m := metric.New(
+		"cpu",
+		map[string]string{"hostname": "server01", "region": "eu"},
+		map[string]interface{}{"idle": int64(42), "cores": "8", "ignored": true},
+		time.Unix(1525478795, 123000000),
+	)
+	buf, err := serializer.Serialize(m)
+	require.NoError(t, err)
+
+	expected := `{"host":{"hostname":"server01","region":"eu"},"name":"cpu",` +
+		`"timestamp":"2018-05-05T00:06:35.123Z","unit":"percent","usage":{"cores":8,"idle":42}}` + "\n"
+	require.Equal(t, expected, string(buf))
+}
+
+func TestSerializeSchemaInvalid(t *testing.T) {
+	serializer := &Serializer{
+		Schemas: map[string]string{"cpu": "testcases/schema_cpu.json"},
+	}
+	require.NoError(t, serializer.Init())
+
+	// The required "hostname" tag is missing
+	m := metric.New("cpu", map[string]string{}, map[string]interface{}{"idle": 42.0}, time.Unix(0, 0))
+	_, err := serializer.Serialize(m)
+	require.ErrorContains(t, err, `metric "cpu" does not match schema`)
+
+	// Values not convertible to the schema type are reported
+	m = metric.New("cpu", map[string]string{"hostname": "server01"}, map[string]interface{}{"idle": "n/a"}, time.Unix(0, 0))
+	_, err = serializer.Serialize(m)
+	require.ErrorContains(t, err, `metric "cpu" does not match schema`)
+
+	// Measurements without a schema are rejected by default
+	m = metric.New("mem", map[string]string{}, map[string]interface{}{"free": 42}, time.Unix(0, 0))
+	_, err = serializer.Serialize(m)
+	require.ErrorContains(t, err, `no schema for measurement "mem"`)
+}
+
+func TestSerializeSchemaUnmatchedStandard(t *testing.T) {
+	serializer := &Serializer{
+		Schemas:         map[string]string{"cpu": "testcases/schema_cpu.json"},
+		SchemaUnmatched: "standard",
+	}
+	require.NoError(t, serializer.Init())
+
+	m := metric.New("mem", map[string]string{}, map[string]interface{}{"free": 42}, time.Unix(0, 0))
+	buf, err := serializer.Serialize(m)
+	require.NoError(t, err)
+	require.Equal(t, `{"fields":{"free":42},"name":"mem","tags":{},"timestamp":0}`+"\n", string(buf))
+}
+
+func TestSerializeSchemaBatchReject(t *testing.T) {
+	serializer := &Serializer{
+		Schemas: map[string]string{"*": "testcases/schema_cpu.json"},
+	}
+	require.NoError(t, serializer.Init())
+
+	metrics := []telegraf.Metric{
+		metric.New("cpu", map[string]string{"hostname": "a"}, map[string]interface{}{"idle": 1.5}, time.Unix(0, 0)),
+		metric.New("cpu", map[string]string{}, map[string]interface{}{"idle": 2.5}, time.Unix(0, 0)),
+		metric.New("cpu", map[string]string{"hostname": "c"}, map[string]interface{}{"idle": 3.5}, time.Unix(0, 0)),
+	}
+	buf, err := serializer.SerializeBatch(metrics)
+
+	var werr *internal.PartialWriteError
+	require.ErrorAs(t, err, &werr)
+	require.ErrorIs(t, err, internal.ErrSerialization)
+	require.Equal(t, []int{0, 2}, werr.MetricsAccept)
+	require.Equal(t, []int{1}, werr.MetricsReject)
+	require.Len(t, werr.MetricsRejectErrors, 1)
+
+	var actual []map[string]interface{}
+	require.NoError(t, json.Unmarshal(buf, &actual))
+	require.Len(t, actual, 2)
+	require.Equal(t, map[string]interface{}{"hostname": "a"}, actual[0]["host"])
+	require.Equal(t, map[string]interface{}{"hostname": "c"}, actual[1]["host"])
+
+	// Rejecting all metrics does not return any data
+	buf, err = serializer.SerializeBatch(metrics[1:2])
+	require.ErrorAs(t, err, &werr)
+	require.Empty(t, werr.MetricsAccept)
+	require.Nil(t, buf)
+}
+
+func TestSerializeSchemaInvalidConfig(t *testing.T) {
+	serializer := &Serializer{
+		Schemas:        map[string]string{"cpu": "testcases/schema_cpu.json"},
+		Transformation: "metrics",
+	}
+	require.ErrorContains(t, serializer.Init(), "cannot be used together")
+
+	serializer = &Serializer{
+		Schemas:         map[string]string{"cpu": "testcases/schema_cpu.json"},
+		SchemaUnmatched: "drop",
+	}
+	require.ErrorContains(t, serializer.Init(), "invalid 'json_schema_unmatched'")
+
+	serializer = &Serializer{
+		Schemas: map[string]string{"cpu": "testcases/does_not_exist.json"},
+	}
+	require.ErrorContains(t, serializer.Init(), `compiling schema for measurement "cpu" failed`)
+}
+
 func loadTestConfiguration(filename string) (*Config, []string, error) {
 	buf, err := os.ReadFile(filename)
 	if err != nil {
@@ -512,3 +620,26 @@ func BenchmarkSerializeBatch(b *testing.B) {
 		require.NoError(b, err)
 	}
 }
+
+func TestSerializeSkipHistograms(t *testing.T) {
+	m := metric.New(
+		"http",
+		map[string]string{"path": "/"},
+		map[string]interface{}{
+			"requests": int64(3),
+			"latency": metric.Histogram{
+				Count:   3,
+				Sum:     4.5,
+				Buckets: []metric.Bucket{{UpperBound: 1, Count: 1}, {UpperBound: 2, Count: 2}},
+			},
+			"size": metric.ExponentialHistogram{Count: 1, Sum: 2},
+		},
+		time.Unix(0, 0),
+	)
+
+	s := Serializer{}
+	require.NoError(t, s.Init())
+	buf, err := s.Serialize(m)
+	require.NoError(t, err)
+	require.JSONEq(t, `{"fields":{"requests":3},"name":"http","tags":{"path":"/"},"timestamp":0}`, string(buf))
+}
@@ -0,0 +1,291 @@
+package json
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/santhosh-tekuri/jsonschema/v5"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/internal"
+	telegraf_metric "github.com/influxdata/telegraf/metric"
+)
+
+// compileSchemas loads the JSON schemas for the configured measurements
+func (s *Serializer) compileSchemas() error {
+	if s.Transformation != "" {
+		return errors.New("'json_transformation' cannot be used together with 'json_schemas'")
+	}
+
+	switch s.SchemaUnmatched {
+	case "":
+		s.SchemaUnmatched = "reject"
+	case "reject", "standard":
+	default:
+		return fmt.Errorf("invalid 'json_schema_unmatched' %q", s.SchemaUnmatched)
+	}
+
+	compiler := jsonschema.NewCompiler()
+	compiler.ExtractAnnotations = true
+
+	s.schemas = make(map[string]*jsonschema.Schema, len(s.Schemas))
+	for measurement, filename := range s.Schemas {
+		schema, err := compiler.Compile(filename)
+		if err != nil {
+			return fmt.Errorf("compiling schema for measurement %q failed: %w", measurement, err)
+		}
+		s.schemas[measurement] = schema
+	}
+	return nil
+}
+
+// lookupSchema returns the schema for the measurement falling back to the
+// catch-all schema if any
+func (s *Serializer) lookupSchema(name string) *jsonschema.Schema {
+	if schema, found := s.schemas[name]; found {
+		return schema
+	}
+	return s.schemas["*"]
+}
+
+// serializeWithSchema shapes the metric according to its schema and
+// validates the resulting document
+func (s *Serializer) serializeWithSchema(metric telegraf.Metric) ([]byte, error) {
+	schema := s.lookupSchema(metric.Name())
+	if schema == nil {
+		if s.SchemaUnmatched == "standard" {
+			return json.Marshal(s.createObject(metric))
+		}
+		return nil, fmt.Errorf("no schema for measurement %q", metric.Name())
+	}
+
+	doc, _ := s.shape(schema, "", metric)
+	serialized, err := json.Marshal(doc)
+	if err != nil {
+		return nil, err
+	}
+
+	// Decode the document again to pass the generic JSON representation
+	// expected by the validator
+	decoder := json.NewDecoder(bytes.NewReader(serialized))
+	decoder.UseNumber()
+	var v interface{}
+	if err := decoder.Decode(&v); err != nil {
+		return nil, err
+	}
+	if err := schema.Validate(v); err != nil {
+		return nil, fmt.Errorf("metric %q does not match schema: %w", metric.Name(), err)
+	}
+
+	return serialized, nil
+}
+
+// serializeBatchWithSchema serializes the metrics as a JSON array. Metrics
+// failing to serialize are rejected using a partial write error while the
+// remaining metrics are still returned.
+func (s *Serializer) serializeBatchWithSchema(metrics []telegraf.Metric) ([]byte, error) {
+	werr := &internal.PartialWriteError{
+		MetricsAccept: make([]int, 0, len(metrics)),
+	}
+
+	var buf bytes.Buffer
+	buf.WriteByte('[')
+	for i, m := range metrics {
+		serialized, err := s.serializeWithSchema(m)
+		if err != nil {
+			werr.Err = internal.ErrSerialization
+			werr.MetricsReject = append(werr.MetricsReject, i)
+			werr.MetricsRejectErrors = append(werr.MetricsRejectErrors, err)
+			continue
+		}
+		if len(werr.MetricsAccept) > 0 {
+			buf.WriteByte(',')
+		}
+		buf.Write(serialized)
+		werr.MetricsAccept = append(werr.MetricsAccept, i)
+	}
+	buf.WriteString("]\n")
+
+	if werr.Err == nil {
+		return buf.Bytes(), nil
+	}
+	if len(werr.MetricsAccept) == 0 {
+		return nil, werr
+	}
+	return buf.Bytes(), werr
+}
+
+// shape builds the value for the given schema and property path from the
+// metric. The returned flag indicates if a value was found.
+func (s *Serializer) shape(schema *jsonschema.Schema, path string, metric telegraf.Metric) (interface{}, bool) {
+	schema = resolveRef(schema)
+
+	if len(schema.Properties) > 0 && (len(schema.Types) == 0 || hasType(schema, "object")) {
+		obj := make(map[string]interface{}, len(schema.Properties))
+		for key, property := range schema.Properties {
+			p := key
+			if path != "" {
+				p = path + "." + key
+			}
+			if v, found := s.shape(property, p, metric); found {
+				obj[key] = v
+			}
+		}
+
+		// Fill in missing required properties with their defaults
+		for _, key := range schema.Required {
+			if _, found := obj[key]; found {
+				continue
+			}
+			if property, found := schema.Properties[key]; found {
+				if d := resolveRef(property).Default; d != nil {
+					obj[key] = d
+				}
+			}
+		}
+		return obj, path == "" || len(obj) > 0
+	}
+
+	v, found := s.lookupValue(path, metric)
+	if !found {
+		return nil, false
+	}
+	if t, ok := v.(time.Time); ok {
+		return s.coerceTime(schema, t), true
+	}
+	return coerce(schema, v)
+}
+
+// lookupValue returns the field or tag for the dotted property path falling
+// back to the last path element. The top-level "name" and "timestamp"
+// properties refer to the metric name and time unless a field or tag with
+// that name exists. Native histogram fields are not supported and treated as
+// missing.
+func (*Serializer) lookupValue(path string, metric telegraf.Metric) (interface{}, bool) {
+	if v, found := metric.GetField(path); found && !telegraf_metric.IsHistogram(v) {
+		return v, true
+	}
+	if v, found := metric.GetTag(path); found {
+		return v, true
+	}
+
+	idx := strings.LastIndexByte(path, '.')
+	if idx < 0 {
+		switch path {
+		case "name":
+			return metric.Name(), true
+		case "timestamp":
+			return metric.Time(), true
+		}
+		return nil, false
+	}
+
+	key := path[idx+1:]
+	if v, found := metric.GetField(key); found && !telegraf_metric.IsHistogram(v) {
+		return v, true
+	}
+	if v, found := metric.GetTag(key); found {
+		return v, true
+	}
+	return nil, false
+}
+
+// coerceTime converts the timestamp to the type requested by the schema
+func (s *Serializer) coerceTime(schema *jsonschema.Schema, t time.Time) interface{} {
+	switch {
+	case hasType(schema, "integer"):
+		return t.UnixNano() / int64(s.TimestampUnits)
+	case hasType(schema, "number"):
+		return float64(t.UnixNano()) / float64(s.TimestampUnits)
+	case hasType(schema, "string"), schema.Format == "date-time":
+		if s.TimestampFormat == "" || schema.Format == "date-time" {
+			return t.UTC().Format(time.RFC3339Nano)
+		}
+		return t.UTC().Format(s.TimestampFormat)
+	case s.TimestampFormat != "":
+		return t.UTC().Format(s.TimestampFormat)
+	}
+	return t.UnixNano() / int64(s.TimestampUnits)
+}
+
+// coerce converts the value to the first type allowed by the schema it can
+// be converted to. Values not convertible are kept as-is and are reported
+// by the validation.
+func coerce(schema *jsonschema.Schema, v interface{}) (interface{}, bool) {
+	// JSON does not support these special values
+	if fv, ok := v.(float64); ok && (math.IsNaN(fv) || math.IsInf(fv, 0)) {
+		return nil, false
+	}
+
+	if len(schema.Types) == 0 || matchesType(schema, v) {
+		return v, true
+	}
+
+	for _, t := range schema.Types {
+		switch t {
+		case "integer":
+			// Do not truncate fractional numbers
+			if fv, ok := v.(float64); ok && fv != math.Trunc(fv) {
+				continue
+			}
+			if iv, err := internal.ToInt64(v); err == nil {
+				return iv, true
+			}
+		case "number":
+			if fv, err := internal.ToFloat64(v); err == nil {
+				return fv, true
+			}
+		case "string":
+			if sv, err := internal.ToString(v); err == nil {
+				return sv, true
+			}
+		case "boolean":
+			if bv, err := internal.ToBool(v); err == nil {
+				return bv, true
+			}
+		case "array", "object":
+			// Decode string values containing JSON
+			if sv, ok := v.(string); ok && json.Valid([]byte(sv)) {
+				var nested interface{}
+				if err := json.Unmarshal([]byte(sv), &nested); err == nil {
+					return nested, true
+				}
+			}
+		}
+	}
+	return v, true
+}
+
+// matchesType checks if the value already has one of the types allowed by
+// the schema
+func matchesType(schema *jsonschema.Schema, v interface{}) bool {
+	switch v := v.(type) {
+	case int64, uint64:
+		return hasType(schema, "integer") || hasType(schema, "number")
+	case float64:
+		return hasType(schema, "number") || (hasType(schema, "integer") && v == math.Trunc(v))
+	case string:
+		return hasType(schema, "string")
+	case bool:
+		return hasType(schema, "boolean")
+	}
+	return false
+}
+
+func hasType(schema *jsonschema.Schema, t string) bool {
+	return slices.Contains(schema.Types, t)
+}
+
+// resolveRef follows the references of the schema
+func resolveRef(schema *jsonschema.Schema) *jsonschema.Schema {
+	for schema.Ref != nil {
+		schema = schema.Ref
+	}
+	return schema
+}
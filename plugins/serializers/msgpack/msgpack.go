@@ -2,18 +2,27 @@ package msgpack
 
 import (
 	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/metric"
 	"github.com/influxdata/telegraf/plugins/serializers"
 )
 
 // Serializer encodes metrics in MessagePack format
 type Serializer struct{}
 
-func marshalMetric(buf []byte, metric telegraf.Metric) ([]byte, error) {
+func marshalMetric(buf []byte, m telegraf.Metric) ([]byte, error) {
+	// Native histograms are not supported
+	fields := make(map[string]interface{}, len(m.FieldList()))
+	for _, field := range m.FieldList() {
+		if !metric.IsHistogram(field.Value) {
+			fields[field.Key] = field.Value
+		}
+	}
+
 	return (&Metric{
-		Name:   metric.Name(),
-		Time:   MessagePackTime{time: metric.Time()},
-		Tags:   metric.Tags(),
-		Fields: metric.Fields(),
+		Name:   m.Name(),
+		Time:   MessagePackTime{time: m.Time()},
+		Tags:   m.Tags(),
+		Fields: fields,
 	}).MarshalMsg(buf)
 }
 
@@ -6,6 +6,7 @@ import (
 	"time"
 
 	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/metric"
 	"github.com/influxdata/telegraf/plugins/serializers"
 )
 
@@ -101,7 +102,7 @@ func createObject(metric telegraf.Metric) OIMetrics {
 	// Loop of fields value pair and build datapoint for each of them
 	for _, field := range metric.FieldList() {
 		if !verifyValue(field.Value) {
-			// Ignore String
+			// Ignore String and native histograms
 			continue
 		}
 
@@ -124,8 +125,11 @@ func createObject(metric telegraf.Metric) OIMetrics {
 }
 
 func verifyValue(v interface{}) bool {
-	_, ok := v.(string)
-	return !ok
+	switch v.(type) {
+	case string, metric.Histogram, metric.ExponentialHistogram:
+		return false
+	}
+	return true
 }
 
 func init() {
@@ -0,0 +1,95 @@
+package otlp
+
+import (
+	"fmt"
+
+	"github.com/influxdata/influxdb-observability/influx2otel"
+	"go.opentelemetry.io/collector/pdata/pmetric"
+	"go.opentelemetry.io/collector/pdata/pmetric/pmetricotlp"
+
+	"github.com/influxdata/telegraf"
+	common_otel "github.com/influxdata/telegraf/plugins/common/opentelemetry"
+	"github.com/influxdata/telegraf/plugins/serializers"
+)
+
+// Serializer encodes metrics as OTLP metrics export request in protobuf or
+// JSON encoding
+type Serializer struct {
+	Encoding   string            `toml:"otlp_encoding"`
+	Attributes map[string]string `toml:"otlp_attributes"`
+	Log        telegraf.Logger   `toml:"-"`
+
+	converter *influx2otel.LineProtocolToOtelMetrics
+}
+
+func (s *Serializer) Init() error {
+	switch s.Encoding {
+	case "":
+		s.Encoding = "protobuf"
+	case "protobuf", "json":
+	default:
+		return fmt.Errorf("invalid 'otlp_encoding' %q", s.Encoding)
+	}
+
+	converter, err := influx2otel.NewLineProtocolToOtelMetrics(&common_otel.Logger{Logger: s.Log})
+	if err != nil {
+		return err
+	}
+	s.converter = converter
+
+	return nil
+}
+
+func (s *Serializer) Serialize(metric telegraf.Metric) ([]byte, error) {
+	return s.SerializeBatch([]telegraf.Metric{metric})
+}
+
+func (s *Serializer) SerializeBatch(metrics []telegraf.Metric) ([]byte, error) {
+	batch := s.converter.NewBatch()
+
+	// Native histogram fields are encoded as OpenTelemetry histograms directly
+	histograms := pmetric.NewMetrics()
+	histogramSlice := histograms.ResourceMetrics().AppendEmpty().ScopeMetrics().AppendEmpty().Metrics()
+
+	for _, m := range metrics {
+		vType, err := common_otel.ValueType(m.Type())
+		if err != nil {
+			s.Log.Warn(err)
+			continue
+		}
+		fields := common_otel.AppendHistograms(histogramSlice, m)
+		if len(fields) == 0 {
+			continue
+		}
+		if err := batch.AddPoint(m.Name(), m.Tags(), fields, m.Time(), vType); err != nil {
+			s.Log.Warnf("Failed to add point: %v", err)
+			continue
+		}
+	}
+
+	converted := batch.GetMetrics()
+	if histogramSlice.Len() > 0 {
+		histograms.ResourceMetrics().MoveAndAppendTo(converted.ResourceMetrics())
+	}
+	req := pmetricotlp.NewExportRequestFromMetrics(converted)
+	if len(s.Attributes) > 0 {
+		for i := 0; i < req.Metrics().ResourceMetrics().Len(); i++ {
+			for k, v := range s.Attributes {
+				req.Metrics().ResourceMetrics().At(i).Resource().Attributes().PutStr(k, v)
+			}
+		}
+	}
+
+	if s.Encoding == "json" {
+		return req.MarshalJSON()
+	}
+	return req.MarshalProto()
+}
+
+func init() {
+	serializers.Add("otlp",
+		func() telegraf.Serializer {
+			return &Serializer{}
+		},
+	)
+}
@@ -0,0 +1,137 @@
+package otlp
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/collector/pdata/pmetric"
+	"go.opentelemetry.io/collector/pdata/pmetric/pmetricotlp"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/metric"
+	"github.com/influxdata/telegraf/plugins/parsers/otlp"
+	"github.com/influxdata/telegraf/testutil"
+)
+
+func TestSerializeRoundtrip(t *testing.T) {
+	input := []telegraf.Metric{
+		metric.New(
+			"cpu_temperature",
+			map[string]string{"core": "0"},
+			map[string]interface{}{"gauge": 42.5},
+			time.Unix(1700000000, 0),
+			telegraf.Gauge,
+		),
+		metric.New(
+			"requests",
+			map[string]string{"method": "GET"},
+			map[string]interface{}{"counter": 7.0},
+			time.Unix(1700000000, 0),
+			telegraf.Counter,
+		),
+	}
+
+	for _, encoding := range []string{"protobuf", "json"} {
+		t.Run(encoding, func(t *testing.T) {
+			serializer := &Serializer{Encoding: encoding, Log: testutil.Logger{}}
+			require.NoError(t, serializer.Init())
+			buf, err := serializer.SerializeBatch(input)
+			require.NoError(t, err)
+
+			parser := &otlp.Parser{Encoding: encoding, Log: testutil.Logger{}}
+			require.NoError(t, parser.Init())
+			actual, err := parser.Parse(buf)
+			require.NoError(t, err)
+			testutil.RequireMetricsEqual(t, input, actual, testutil.SortMetrics())
+		})
+	}
+}
+
+func TestSerializeAttributes(t *testing.T) {
+	serializer := &Serializer{
+		Encoding:   "json",
+		Attributes: map[string]string{"service.name": "telegraf"},
+		Log:        testutil.Logger{},
+	}
+	require.NoError(t, serializer.Init())
+
+	m := metric.New("cpu", map[string]string{}, map[string]interface{}{"gauge": 1.0}, time.Unix(0, 0), telegraf.Gauge)
+	buf, err := serializer.Serialize(m)
+	require.NoError(t, err)
+
+	req := pmetricotlp.NewExportRequest()
+	require.NoError(t, req.UnmarshalJSON(buf))
+	require.Equal(t, 1, req.Metrics().ResourceMetrics().Len())
+	rm := req.Metrics().ResourceMetrics().At(0)
+	v, found := rm.Resource().Attributes().Get("service.name")
+	require.True(t, found)
+	require.Equal(t, "telegraf", v.Str())
+
+	metrics := rm.ScopeMetrics().At(0).Metrics()
+	require.Equal(t, 1, metrics.Len())
+	require.Equal(t, "cpu", metrics.At(0).Name())
+	require.Equal(t, pmetric.MetricTypeGauge, metrics.At(0).Type())
+}
+
+func TestInitInvalid(t *testing.T) {
+	require.ErrorContains(t, (&Serializer{Encoding: "xml"}).Init(), `invalid 'otlp_encoding' "xml"`)
+}
+
+func TestSerializeHistograms(t *testing.T) {
+	input := metric.New(
+		"http",
+		map[string]string{"path": "/"},
+		map[string]interface{}{
+			"requests": 3.0,
+			"latency": metric.Histogram{
+				Count:   3,
+				Sum:     4.5,
+				Buckets: []metric.Bucket{{UpperBound: 1, Count: 1}, {UpperBound: 2, Count: 2}},
+			},
+			"size": metric.ExponentialHistogram{
+				Count:    2,
+				Sum:      3,
+				Scale:    1,
+				Positive: metric.ExponentialBuckets{Offset: 1, Counts: []float64{1, 1}},
+			},
+		},
+		time.Unix(1700000000, 0),
+	)
+
+	serializer := &Serializer{Log: testutil.Logger{}}
+	require.NoError(t, serializer.Init())
+	buf, err := serializer.Serialize(input)
+	require.NoError(t, err)
+
+	req := pmetricotlp.NewExportRequest()
+	require.NoError(t, req.UnmarshalProto(buf))
+
+	// Collect the metrics by name
+	actual := make(map[string]pmetric.Metric)
+	rms := req.Metrics().ResourceMetrics()
+	for i := range rms.Len() {
+		sms := rms.At(i).ScopeMetrics()
+		for j := range sms.Len() {
+			ms := sms.At(j).Metrics()
+			for k := range ms.Len() {
+				actual[ms.At(k).Name()] = ms.At(k)
+			}
+		}
+	}
+	require.Len(t, actual, 3)
+	require.Contains(t, actual, "http_requests")
+
+	require.Contains(t, actual, "http_latency")
+	require.Equal(t, pmetric.MetricTypeHistogram, actual["http_latency"].Type())
+	dp := actual["http_latency"].Histogram().DataPoints().At(0)
+	require.Equal(t, uint64(3), dp.Count())
+	require.Equal(t, []float64{1, 2}, dp.ExplicitBounds().AsRaw())
+	require.Equal(t, []uint64{1, 1, 1}, dp.BucketCounts().AsRaw())
+
+	require.Contains(t, actual, "http_size")
+	require.Equal(t, pmetric.MetricTypeExponentialHistogram, actual["http_size"].Type())
+	edp := actual["http_size"].ExponentialHistogram().DataPoints().At(0)
+	require.Equal(t, int32(1), edp.Scale())
+	require.Equal(t, []uint64{1, 1}, edp.Positive().BucketCounts().AsRaw())
+}
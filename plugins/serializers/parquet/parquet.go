@@ -0,0 +1,149 @@
+package parquet
+
+import (
+	"bytes"
+	"fmt"
+
+	"github.com/apache/arrow-go/v18/arrow"
+	"github.com/apache/arrow-go/v18/arrow/memory"
+	"github.com/apache/arrow-go/v18/parquet"
+	"github.com/apache/arrow-go/v18/parquet/compress"
+	"github.com/apache/arrow-go/v18/parquet/pqarrow"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/internal"
+	"github.com/influxdata/telegraf/plugins/common/columnar"
+	"github.com/influxdata/telegraf/plugins/serializers"
+)
+
+// Serializer encodes a batch of metrics as Apache Parquet file
+type Serializer struct {
+	TimestampColumn   string `toml:"parquet_timestamp_column"`
+	MeasurementColumn string `toml:"parquet_measurement_column"`
+	Compression       string `toml:"parquet_compression"`
+
+	codec compress.Compression
+}
+
+func (s *Serializer) Init() error {
+	if s.TimestampColumn == "" {
+		s.TimestampColumn = "timestamp"
+	}
+	if s.MeasurementColumn == "" {
+		s.MeasurementColumn = "measurement"
+	}
+	if s.MeasurementColumn == s.TimestampColumn {
+		return fmt.Errorf("measurement column %q collides with timestamp column", s.MeasurementColumn)
+	}
+
+	switch s.Compression {
+	case "", "snappy":
+		s.codec = compress.Codecs.Snappy
+	case "none":
+		s.codec = compress.Codecs.Uncompressed
+	case "gzip":
+		s.codec = compress.Codecs.Gzip
+	case "brotli":
+		s.codec = compress.Codecs.Brotli
+	case "lz4":
+		s.codec = compress.Codecs.Lz4Raw
+	case "zstd":
+		s.codec = compress.Codecs.Zstd
+	default:
+		return fmt.Errorf("invalid 'parquet_compression' %q", s.Compression)
+	}
+
+	return nil
+}
+
+func (s *Serializer) Serialize(metric telegraf.Metric) ([]byte, error) {
+	return s.SerializeBatch([]telegraf.Metric{metric})
+}
+
+func (s *Serializer) SerializeBatch(metrics []telegraf.Metric) ([]byte, error) {
+	if len(metrics) == 0 {
+		return nil, nil
+	}
+
+	// A Parquet file has a single schema so batches of multiple measurements
+	// are written using a union schema with an additional measurement column
+	reserved := []string{s.TimestampColumn}
+	union := len(columnar.GroupByMeasurement(metrics)) > 1
+	if union {
+		reserved = append(reserved, s.MeasurementColumn)
+	}
+
+	// Reject metrics with tags or fields colliding with the reserved columns
+	// instead of failing the whole batch
+	werr := &internal.PartialWriteError{
+		MetricsAccept: make([]int, 0, len(metrics)),
+	}
+	accepted := make([]telegraf.Metric, 0, len(metrics))
+	for i, m := range metrics {
+		if name, found := collision(m, reserved); found {
+			werr.Err = internal.ErrSerialization
+			werr.MetricsReject = append(werr.MetricsReject, i)
+			werr.MetricsRejectErrors = append(werr.MetricsRejectErrors, fmt.Errorf("tag or field %q collides with column", name))
+			continue
+		}
+		werr.MetricsAccept = append(werr.MetricsAccept, i)
+		accepted = append(accepted, m)
+	}
+	if len(accepted) == 0 {
+		return nil, werr
+	}
+
+	var schema *arrow.Schema
+	var err error
+	if union {
+		schema, err = columnar.InferUnionSchema(accepted, s.TimestampColumn, s.MeasurementColumn)
+	} else {
+		schema, err = columnar.InferSchema(accepted, s.TimestampColumn)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("inferring schema failed: %w", err)
+	}
+	record, err := columnar.BuildRecord(memory.DefaultAllocator, schema, accepted)
+	if err != nil {
+		return nil, fmt.Errorf("building record failed: %w", err)
+	}
+	defer record.Release()
+
+	var buf bytes.Buffer
+	props := parquet.NewWriterProperties(parquet.WithCompression(s.codec))
+	writer, err := pqarrow.NewFileWriter(schema, &buf, props, pqarrow.NewArrowWriterProperties(pqarrow.WithStoreSchema()))
+	if err != nil {
+		return nil, fmt.Errorf("creating writer failed: %w", err)
+	}
+	if err := writer.Write(record); err != nil {
+		writer.Close() //nolint:errcheck // Ignore error as the write already failed
+		return nil, fmt.Errorf("writing record failed: %w", err)
+	}
+	if err := writer.Close(); err != nil {
+		return nil, fmt.Errorf("closing writer failed: %w", err)
+	}
+
+	if werr.Err != nil {
+		return buf.Bytes(), werr
+	}
+	return buf.Bytes(), nil
+}
+
+// collision returns the first of the given column names used as tag or field
+// of the metric
+func collision(m telegraf.Metric, columns []string) (string, bool) {
+	for _, name := range columns {
+		if m.HasTag(name) || m.HasField(name) {
+			return name, true
+		}
+	}
+	return "", false
+}
+
+func init() {
+	serializers.Add("parquet",
+		func() telegraf.Serializer {
+			return &Serializer{}
+		},
+	)
+}
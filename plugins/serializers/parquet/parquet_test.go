@@ -0,0 +1,139 @@
+package parquet
+
+import (
+	"bytes"
+	"context"
+	"testing"
+	"time"
+
+	"github.com/apache/arrow-go/v18/arrow"
+	"github.com/apache/arrow-go/v18/arrow/array"
+	"github.com/apache/arrow-go/v18/arrow/memory"
+	"github.com/apache/arrow-go/v18/parquet/file"
+	"github.com/apache/arrow-go/v18/parquet/pqarrow"
+	"github.com/stretchr/testify/require"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/internal"
+	"github.com/influxdata/telegraf/metric"
+)
+
+func TestSerializeBatch(t *testing.T) {
+	input := []telegraf.Metric{
+		metric.New("cpu", map[string]string{"host": "a"}, map[string]interface{}{"usage": int64(1)}, time.Unix(1, 0)),
+		metric.New("cpu", map[string]string{"host": "b"}, map[string]interface{}{"usage": 2.5, "ok": true}, time.Unix(2, 0)),
+	}
+
+	for _, compression := range []string{"none", "snappy", "gzip", "brotli", "lz4", "zstd"} {
+		t.Run(compression, func(t *testing.T) {
+			s := &Serializer{Compression: compression}
+			require.NoError(t, s.Init())
+
+			buf, err := s.SerializeBatch(input)
+			require.NoError(t, err)
+
+			table, measurement := readTable(t, buf)
+			require.Equal(t, int64(2), table.NumRows())
+
+			schema := table.Schema()
+			names := make([]string, 0, len(schema.Fields()))
+			for _, f := range schema.Fields() {
+				names = append(names, f.Name)
+			}
+			require.Equal(t, []string{"timestamp", "host", "ok", "usage"}, names)
+			require.Equal(t, arrow.PrimitiveTypes.Float64, schema.Field(3).Type)
+			require.Equal(t, "cpu", measurement)
+
+			ts := table.Column(0).Data().Chunk(0).(*array.Timestamp)
+			require.Equal(t, arrow.Timestamp(time.Unix(2, 0).UnixNano()), ts.Value(1))
+			ok := table.Column(2).Data().Chunk(0).(*array.Boolean)
+			require.True(t, ok.IsNull(0))
+			require.True(t, ok.Value(1))
+			usage := table.Column(3).Data().Chunk(0).(*array.Float64)
+			require.InDelta(t, 1.0, usage.Value(0), 0)
+			require.InDelta(t, 2.5, usage.Value(1), 0)
+		})
+	}
+}
+
+func TestSerializeMultipleMeasurements(t *testing.T) {
+	s := &Serializer{}
+	require.NoError(t, s.Init())
+
+	input := []telegraf.Metric{
+		metric.New("cpu", map[string]string{"host": "a"}, map[string]interface{}{"usage": 1.0}, time.Unix(0, 0)),
+		metric.New("mem", map[string]string{"host": "a"}, map[string]interface{}{"free": int64(2)}, time.Unix(1, 0)),
+	}
+	buf, err := s.SerializeBatch(input)
+	require.NoError(t, err)
+
+	table, measurement := readTable(t, buf)
+	require.Equal(t, int64(2), table.NumRows())
+	require.Empty(t, measurement)
+
+	schema := table.Schema()
+	names := make([]string, 0, len(schema.Fields()))
+	for _, f := range schema.Fields() {
+		names = append(names, f.Name)
+	}
+	require.Equal(t, []string{"timestamp", "measurement", "host", "free", "usage"}, names)
+
+	measurements := table.Column(1).Data().Chunk(0).(*array.String)
+	require.Equal(t, "cpu", measurements.Value(0))
+	require.Equal(t, "mem", measurements.Value(1))
+}
+
+func TestSerializeColumnCollision(t *testing.T) {
+	s := &Serializer{}
+	require.NoError(t, s.Init())
+
+	input := []telegraf.Metric{
+		metric.New("cpu", map[string]string{}, map[string]interface{}{"value": 1.0}, time.Unix(0, 0)),
+		metric.New("mem", map[string]string{"measurement": "x"}, map[string]interface{}{"value": 2.0}, time.Unix(0, 0)),
+		metric.New("mem", map[string]string{}, map[string]interface{}{"timestamp": int64(5)}, time.Unix(0, 0)),
+		metric.New("disk", map[string]string{}, map[string]interface{}{"value": 3.0}, time.Unix(0, 0)),
+	}
+	buf, err := s.SerializeBatch(input)
+
+	// Colliding metrics are rejected while the others are still serialized
+	var werr *internal.PartialWriteError
+	require.ErrorAs(t, err, &werr)
+	require.Equal(t, []int{0, 3}, werr.MetricsAccept)
+	require.Equal(t, []int{1, 2}, werr.MetricsReject)
+
+	table, _ := readTable(t, buf)
+	require.Equal(t, int64(2), table.NumRows())
+
+	// Rejecting all metrics must not return any data
+	buf, err = s.SerializeBatch(input[2:3])
+	require.ErrorAs(t, err, &werr)
+	require.Empty(t, werr.MetricsAccept)
+	require.Nil(t, buf)
+}
+
+func TestInitInvalid(t *testing.T) {
+	require.ErrorContains(t, (&Serializer{Compression: "lzo"}).Init(), `invalid 'parquet_compression' "lzo"`)
+	require.ErrorContains(t, (&Serializer{MeasurementColumn: "timestamp"}).Init(), `measurement column "timestamp" collides with timestamp column`)
+}
+
+// readTable reads the file and returns the table and the measurement stored
+// in the file metadata if any
+func readTable(t *testing.T, buf []byte) (arrow.Table, string) {
+	t.Helper()
+
+	reader, err := file.NewParquetReader(bytes.NewReader(buf))
+	require.NoError(t, err)
+	defer reader.Close()
+
+	var measurement string
+	if v := reader.MetaData().KeyValueMetadata().FindValue("measurement"); v != nil {
+		measurement = *v
+	}
+
+	fileReader, err := pqarrow.NewFileReader(reader, pqarrow.ArrowReadProperties{}, memory.DefaultAllocator)
+	require.NoError(t, err)
+	table, err := fileReader.ReadTable(context.Background())
+	require.NoError(t, err)
+	t.Cleanup(table.Release)
+	return table, measurement
+}
@@ -0,0 +1,122 @@
+package prometheusremotewrite
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/prometheus/prometheus/model/histogram"
+	"github.com/prometheus/prometheus/prompb"
+
+	"github.com/influxdata/telegraf/metric"
+	"github.com/influxdata/telegraf/plugins/serializers/prometheus"
+)
+
+// Schema limits of Prometheus native histograms
+const (
+	minSchema = -4
+	maxSchema = 8
+)
+
+// histogramName returns the name of the histogram stored in the given field
+// with the field being the histogram name for the "prometheus" measurement
+// and the measurement being the name for fields called "histogram"
+func histogramName(measurement, field string) string {
+	switch {
+	case measurement == "prometheus":
+		return field
+	case field == "histogram":
+		return measurement
+	}
+	return measurement + "_" + field
+}
+
+// histogramSeries converts the native histogram field values to time series.
+// Exponential histograms are converted to Prometheus native histograms while
+// histograms with explicit buckets result in the classic bucket, sum and
+// count series. The function returns false if the value is not a histogram.
+func histogramSeries(measurement, field string, value interface{}, labels []prompb.Label, ts time.Time) (map[MetricKey]prompb.TimeSeries, bool, error) {
+	switch v := value.(type) {
+	case metric.Histogram:
+		name, ok := prometheus.SanitizeMetricName(histogramName(measurement, field))
+		if !ok {
+			return nil, true, fmt.Errorf("failed to parse metric name %q", histogramName(measurement, field))
+		}
+
+		series := make(map[MetricKey]prompb.TimeSeries, len(v.Buckets)+3)
+		for _, b := range v.Buckets {
+			le := prompb.Label{Name: "le", Value: strconv.FormatFloat(b.UpperBound, 'g', -1, 64)}
+			key, promts := getPromTS(name+"_bucket", labels, b.Count, ts, le)
+			series[key] = promts
+		}
+		key, promts := getPromTS(name+"_bucket", labels, v.Count, ts, prompb.Label{Name: "le", Value: "+Inf"})
+		series[key] = promts
+		key, promts = getPromTS(name+"_sum", labels, v.Sum, ts)
+		series[key] = promts
+		key, promts = getPromTS(name+"_count", labels, v.Count, ts)
+		series[key] = promts
+		return series, true, nil
+	case metric.ExponentialHistogram:
+		name, ok := prometheus.SanitizeMetricName(histogramName(measurement, field))
+		if !ok {
+			return nil, true, fmt.Errorf("failed to parse metric name %q", histogramName(measurement, field))
+		}
+
+		fh, err := toFloatHistogram(v)
+		if err != nil {
+			return nil, true, fmt.Errorf("failed to convert %q: %w", name, err)
+		}
+
+		labelscopy := make([]prompb.Label, len(labels), len(labels)+1)
+		copy(labelscopy, labels)
+		labelscopy = append(labelscopy, prompb.Label{Name: "__name__", Value: name})
+		sort.Sort(sortableLabels(labelscopy))
+
+		promts := prompb.TimeSeries{
+			Labels:     labelscopy,
+			Histograms: []prompb.Histogram{prompb.FromFloatHistogram(ts.UnixMilli(), fh)},
+		}
+		return map[MetricKey]prompb.TimeSeries{MakeMetricKey(labelscopy): promts}, true, nil
+	}
+	return nil, false, nil
+}
+
+// toFloatHistogram converts the exponential histogram to a Prometheus float
+// histogram reducing the resolution if the scale exceeds the Prometheus limit
+func toFloatHistogram(h metric.ExponentialHistogram) (*histogram.FloatHistogram, error) {
+	if h.Scale < minSchema {
+		return nil, fmt.Errorf("scale %d below supported minimum %d", h.Scale, minSchema)
+	}
+	if h.Scale > maxSchema {
+		downscaled, err := h.Merge(metric.ExponentialHistogram{Scale: maxSchema, ZeroThreshold: h.ZeroThreshold})
+		if err != nil {
+			return nil, err
+		}
+		h = downscaled
+	}
+
+	fh := &histogram.FloatHistogram{
+		Count:         h.Count,
+		Sum:           h.Sum,
+		Schema:        h.Scale,
+		ZeroThreshold: h.ZeroThreshold,
+		ZeroCount:     h.ZeroCount,
+	}
+	fh.PositiveSpans, fh.PositiveBuckets = toSpans(h.Positive)
+	fh.NegativeSpans, fh.NegativeBuckets = toSpans(h.Negative)
+	if err := fh.Validate(); err != nil {
+		return nil, err
+	}
+	return fh, nil
+}
+
+// toSpans converts the buckets to a single span. Prometheus uses a bucket
+// index shifted by one compared to OpenTelemetry.
+func toSpans(b metric.ExponentialBuckets) ([]histogram.Span, []float64) {
+	if len(b.Counts) == 0 {
+		return nil, nil
+	}
+	spans := []histogram.Span{{Offset: b.Offset + 1, Length: uint32(len(b.Counts))}} //nolint:gosec // G115: bucket counts are far below the uint32 limit
+	return spans, append([]float64(nil), b.Counts...)
+}
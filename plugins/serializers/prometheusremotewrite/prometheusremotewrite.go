@@ -67,6 +67,24 @@ func (s *Serializer) SerializeBatch(metrics []telegraf.Metric) ([]byte, error) {
 
 		// If it's not a native histogram, we parse field by field as per normal.
 		for _, field := range metric.FieldList() {
+			// Native histogram field values are converted independent of
+			// the metric type
+			series, isHistogram, err := histogramSeries(metric.Name(), field.Key, field.Value, labels, metric.Time())
+			if isHistogram {
+				if err != nil {
+					traceAndKeepErr("%w", err)
+					continue
+				}
+				for key, promts := range series {
+					if m, found := entries[key]; found && metric.Time().UnixMilli() < seriesTimestamp(m) {
+						traceAndKeepErr("metric %q has histograms with timestamp %v older than already registered before", metric.Name(), metric.Time())
+						continue
+					}
+					entries[key] = promts
+				}
+				continue
+			}
+
 			rawName := prometheus.MetricName(metric.Name(), field.Key, metric.Type())
 			metricName, ok := prometheus.SanitizeMetricName(rawName)
 			if !ok {
@@ -532,6 +550,17 @@ func tryConvertToNativeHistogram(metric telegraf.Metric, labels []prompb.Label)
 	return MakeMetricKey(labelscopy), &prompb.TimeSeries{Labels: labelscopy, Histograms: histograms}
 }
 
+// seriesTimestamp returns the timestamp of the first sample or histogram
+func seriesTimestamp(ts prompb.TimeSeries) int64 {
+	if len(ts.Histograms) > 0 {
+		return ts.Histograms[0].Timestamp
+	}
+	if len(ts.Samples) > 0 {
+		return ts.Samples[0].Timestamp
+	}
+	return 0
+}
+
 type sortableLabels []prompb.Label
 
 func (sl sortableLabels) Len() int { return len(sl) }
@@ -13,6 +13,7 @@ import (
 	"github.com/stretchr/testify/require"
 
 	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/metric"
 	"github.com/influxdata/telegraf/plugins/serializers"
 	"github.com/influxdata/telegraf/testutil"
 )
@@ -804,6 +805,52 @@ func TestRemoteWriteSerializeNativeHistogram(t *testing.T) {
 			),
 			expected: []byte(`
 rpc_duration_seconds{host="example.org", node="node1"} {count:20, sum:10, [-2,-1):6, [-1,-0.5):4, [-0.001,0.001]:2, (0.5,1]:3, (1,2]:5}
+`),
+		},
+		{
+			name: "exponential histogram field",
+			metric: testutil.MustMetric(
+				"prometheus",
+				map[string]string{
+					"host": "example.org",
+					"node": "node1",
+				},
+				map[string]interface{}{
+					"rpc_duration_seconds": metric.ExponentialHistogram{
+						Count:         20,
+						Sum:           10,
+						Scale:         0,
+						ZeroThreshold: 0.001,
+						ZeroCount:     2,
+						Positive:      metric.ExponentialBuckets{Offset: -1, Counts: []float64{3, 5}},
+						Negative:      metric.ExponentialBuckets{Offset: -1, Counts: []float64{4, 6}},
+					},
+				},
+				time.Unix(0, 0),
+				telegraf.Histogram,
+			),
+			expected: []byte(`
+rpc_duration_seconds{host="example.org", node="node1"} {count:20, sum:10, [-2,-1):6, [-1,-0.5):4, [-0.001,0.001]:2, (0.5,1]:3, (1,2]:5}
+`),
+		},
+		{
+			name: "exponential histogram field above maximum scale",
+			metric: testutil.MustMetric(
+				"rpc",
+				map[string]string{"host": "example.org"},
+				map[string]interface{}{
+					"histogram": metric.ExponentialHistogram{
+						Count:    4,
+						Sum:      5,
+						Scale:    9,
+						Positive: metric.ExponentialBuckets{Offset: 0, Counts: []float64{1, 3}},
+					},
+				},
+				time.Unix(0, 0),
+				telegraf.Histogram,
+			),
+			expected: []byte(`
+rpc{host="example.org"} {count:4, sum:5, (1,1.0027112750502025]:4}
 `),
 		},
 	}
@@ -826,6 +873,40 @@ rpc_duration_seconds{host="example.org", node="node1"} {count:20, sum:10, [-2,-1
 	}
 }
 
+func TestRemoteWriteSerializeHistogramField(t *testing.T) {
+	m := testutil.MustMetric(
+		"http",
+		map[string]string{"host": "example.org"},
+		map[string]interface{}{
+			"latency": metric.Histogram{
+				Count:   6,
+				Sum:     12.5,
+				Buckets: []metric.Bucket{{UpperBound: 0.5, Count: 1}, {UpperBound: 5, Count: 4}},
+			},
+		},
+		time.Unix(0, 0),
+		telegraf.Histogram,
+	)
+
+	s := &Serializer{
+		Log:         &testutil.CaptureLogger{},
+		SortMetrics: true,
+	}
+	data, err := s.Serialize(m)
+	require.NoError(t, err)
+	actual, err := prompbToText(data)
+	require.NoError(t, err)
+
+	expected := `
+http_latency_count{host="example.org"} 6
+http_latency_sum{host="example.org"} 12.5
+http_latency_bucket{host="example.org", le="+Inf"} 6
+http_latency_bucket{host="example.org", le="0.5"} 1
+http_latency_bucket{host="example.org", le="5"} 4
+`
+	require.Equal(t, strings.TrimSpace(expected), strings.TrimSpace(string(actual)))
+}
+
 func prompbToText(data []byte) ([]byte, error) {
 	var buf = bytes.Buffer{}
 	protobuff, err := snappy.Decode(nil, data)
@@ -0,0 +1,185 @@
+package protobuf
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"google.golang.org/protobuf/reflect/protoreflect"
+
+	"github.com/influxdata/telegraf/internal"
+)
+
+// fieldPath is the chain of field descriptors from the root message to the
+// target field with all but the last field being singular message fields
+type fieldPath []protoreflect.FieldDescriptor
+
+// resolvePath resolves a dot-separated path of field names starting at the
+// given message
+func resolvePath(desc protoreflect.MessageDescriptor, path string) (fieldPath, error) {
+	if path == "" {
+		return nil, errors.New("empty path")
+	}
+
+	parts := strings.Split(path, ".")
+	fp := make(fieldPath, 0, len(parts))
+	for i, name := range parts {
+		if desc == nil {
+			return nil, fmt.Errorf("%q is not a message field", strings.Join(parts[:i], "."))
+		}
+		fd := desc.Fields().ByName(protoreflect.Name(name))
+		if fd == nil {
+			return nil, fmt.Errorf("message %q has no field %q", desc.FullName(), name)
+		}
+		fp = append(fp, fd)
+
+		desc = nil
+		if fd.Kind() == protoreflect.MessageKind && !fd.IsList() && !fd.IsMap() {
+			desc = fd.Message()
+		}
+	}
+	return fp, nil
+}
+
+// resolveScalar resolves the path and checks that the target is a singular
+// scalar field
+func resolveScalar(desc protoreflect.MessageDescriptor, path string) (fieldPath, error) {
+	fp, err := resolvePath(desc, path)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkScalar(fp.leaf()); err != nil {
+		return nil, fmt.Errorf("field %q: %w", path, err)
+	}
+	return fp, nil
+}
+
+// resolveMap resolves the path and checks that the target is a map with
+// string keys and scalar values
+func resolveMap(desc protoreflect.MessageDescriptor, path string) (fieldPath, error) {
+	fp, err := resolvePath(desc, path)
+	if err != nil {
+		return nil, err
+	}
+	fd := fp.leaf()
+	if !fd.IsMap() {
+		return nil, fmt.Errorf("field %q is not a map", path)
+	}
+	if fd.MapKey().Kind() != protoreflect.StringKind {
+		return nil, fmt.Errorf("map %q must have string keys", path)
+	}
+	if err := checkScalar(fd.MapValue()); err != nil {
+		return nil, fmt.Errorf("values of map %q: %w", path, err)
+	}
+	return fp, nil
+}
+
+func checkScalar(fd protoreflect.FieldDescriptor) error {
+	if fd.IsList() || fd.IsMap() {
+		return errors.New("repeated fields are not supported")
+	}
+	switch fd.Kind() {
+	case protoreflect.MessageKind, protoreflect.GroupKind:
+		return fmt.Errorf("message type %q is not supported", fd.Message().FullName())
+	}
+	return nil
+}
+
+func checkTimestamp(fd protoreflect.FieldDescriptor) error {
+	if fd.Kind() == protoreflect.MessageKind && !fd.IsList() && !fd.IsMap() {
+		if fd.Message().FullName() != "google.protobuf.Timestamp" {
+			return fmt.Errorf("message type %q is not supported", fd.Message().FullName())
+		}
+		return nil
+	}
+	if err := checkScalar(fd); err != nil {
+		return err
+	}
+	switch fd.Kind() {
+	case protoreflect.BoolKind, protoreflect.BytesKind, protoreflect.EnumKind:
+		return fmt.Errorf("type %q is not supported", fd.Kind())
+	}
+	return nil
+}
+
+func (fp fieldPath) leaf() protoreflect.FieldDescriptor {
+	return fp[len(fp)-1]
+}
+
+// parent returns the message containing the leaf field, creating the
+// intermediate messages if necessary
+func (fp fieldPath) parent(msg protoreflect.Message) protoreflect.Message {
+	for _, fd := range fp[:len(fp)-1] {
+		msg = msg.Mutable(fd).Message()
+	}
+	return msg
+}
+
+// set converts the value to the type of the leaf field and sets the field
+func (fp fieldPath) set(msg protoreflect.Message, value interface{}) error {
+	fd := fp.leaf()
+	v, err := scalarValue(fd, value)
+	if err != nil {
+		return err
+	}
+	fp.parent(msg).Set(fd, v)
+	return nil
+}
+
+// setMapEntry converts the value to the type of the map values and sets the
+// entry of the leaf map field
+func (fp fieldPath) setMapEntry(msg protoreflect.Message, key string, value interface{}) error {
+	fd := fp.leaf()
+	v, err := scalarValue(fd.MapValue(), value)
+	if err != nil {
+		return err
+	}
+	fp.parent(msg).Mutable(fd).Map().Set(protoreflect.ValueOfString(key).MapKey(), v)
+	return nil
+}
+
+// scalarValue converts the value to the kind of the given scalar field
+func scalarValue(fd protoreflect.FieldDescriptor, value interface{}) (protoreflect.Value, error) {
+	switch fd.Kind() {
+	case protoreflect.BoolKind:
+		v, err := internal.ToBool(value)
+		return protoreflect.ValueOfBool(v), err
+	case protoreflect.Int32Kind, protoreflect.Sint32Kind, protoreflect.Sfixed32Kind:
+		v, err := internal.ToInt32(value)
+		return protoreflect.ValueOfInt32(v), err
+	case protoreflect.Int64Kind, protoreflect.Sint64Kind, protoreflect.Sfixed64Kind:
+		v, err := internal.ToInt64(value)
+		return protoreflect.ValueOfInt64(v), err
+	case protoreflect.Uint32Kind, protoreflect.Fixed32Kind:
+		v, err := internal.ToUint32(value)
+		return protoreflect.ValueOfUint32(v), err
+	case protoreflect.Uint64Kind, protoreflect.Fixed64Kind:
+		v, err := internal.ToUint64(value)
+		return protoreflect.ValueOfUint64(v), err
+	case protoreflect.FloatKind:
+		v, err := internal.ToFloat32(value)
+		return protoreflect.ValueOfFloat32(v), err
+	case protoreflect.DoubleKind:
+		v, err := internal.ToFloat64(value)
+		return protoreflect.ValueOfFloat64(v), err
+	case protoreflect.StringKind:
+		v, err := internal.ToString(value)
+		return protoreflect.ValueOfString(v), err
+	case protoreflect.BytesKind:
+		v, err := internal.ToString(value)
+		return protoreflect.ValueOfBytes([]byte(v)), err
+	case protoreflect.EnumKind:
+		// Accept the name of the enum value or its number
+		if name, ok := value.(string); ok {
+			if ev := fd.Enum().Values().ByName(protoreflect.Name(name)); ev != nil {
+				return protoreflect.ValueOfEnum(ev.Number()), nil
+			}
+		}
+		v, err := internal.ToInt32(value)
+		if err != nil {
+			return protoreflect.Value{}, fmt.Errorf("%v is not a value of enum %q", value, fd.Enum().FullName())
+		}
+		return protoreflect.ValueOfEnum(protoreflect.EnumNumber(v)), nil
+	}
+	return protoreflect.Value{}, fmt.Errorf("unsupported field type %q", fd.Kind())
+}
@@ -0,0 +1,314 @@
+package protobuf
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"google.golang.org/protobuf/encoding/protowire"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/reflect/protoregistry"
+	"google.golang.org/protobuf/types/dynamicpb"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/metric"
+	common_protobuf "github.com/influxdata/telegraf/plugins/common/protobuf"
+	"github.com/influxdata/telegraf/plugins/serializers"
+)
+
+// Serializer encodes metrics as protocol-buffer messages of a user-supplied
+// message type by mapping the metric's name, tags, fields and timestamp to
+// fields of the message
+type Serializer struct {
+	MessageFiles    []string          `toml:"protobuf_files"`
+	ImportPaths     []string          `toml:"protobuf_import_paths"`
+	MessageType     string            `toml:"protobuf_type"`
+	BatchType       string            `toml:"protobuf_batch_type"`
+	BatchField      string            `toml:"protobuf_batch_field"`
+	Framing         string            `toml:"protobuf_framing"`
+	Measurement     string            `toml:"protobuf_measurement"`
+	Timestamp       string            `toml:"protobuf_timestamp"`
+	TimestampFormat string            `toml:"protobuf_timestamp_format"`
+	Tags            map[string]string `toml:"protobuf_tags"`
+	Fields          map[string]string `toml:"protobuf_fields"`
+	TagsField       string            `toml:"protobuf_tags_field"`
+	FieldsField     string            `toml:"protobuf_fields_field"`
+	Log             telegraf.Logger   `toml:"-"`
+
+	msgDesc    protoreflect.MessageDescriptor
+	batchDesc  protoreflect.MessageDescriptor
+	batchField protoreflect.FieldDescriptor
+
+	measurement fieldPath
+	timestamp   fieldPath
+	tags        map[string]fieldPath
+	fields      map[string]fieldPath
+	tagsMap     fieldPath
+	fieldsMap   fieldPath
+
+	marshaller proto.MarshalOptions
+}
+
+func (s *Serializer) Init() error {
+	if s.MessageType == "" {
+		return errors.New("'protobuf_type' not set")
+	}
+
+	switch s.Framing {
+	case "":
+		s.Framing = "none"
+	case "none", "length_delimited":
+	default:
+		return fmt.Errorf("invalid 'protobuf_framing' %q", s.Framing)
+	}
+
+	switch s.TimestampFormat {
+	case "":
+		s.TimestampFormat = "unix_ns"
+	case "unix", "unix_ms", "unix_us", "unix_ns":
+	default:
+		return fmt.Errorf("invalid 'protobuf_timestamp_format' %q", s.TimestampFormat)
+	}
+
+	// Load the message definitions
+	registry, err := common_protobuf.Compile(s.MessageFiles, s.ImportPaths)
+	if err != nil {
+		return err
+	}
+	if s.msgDesc, err = findMessage(registry, s.MessageType); err != nil {
+		return err
+	}
+	if s.BatchType != "" {
+		if s.batchDesc, err = findMessage(registry, s.BatchType); err != nil {
+			return err
+		}
+		if s.batchField, err = s.findBatchField(); err != nil {
+			return err
+		}
+	} else if s.BatchField != "" {
+		return errors.New("'protobuf_batch_field' requires 'protobuf_batch_type'")
+	}
+
+	// Resolve the field mappings
+	if s.Measurement != "" {
+		if s.measurement, err = resolveScalar(s.msgDesc, s.Measurement); err != nil {
+			return fmt.Errorf("invalid 'protobuf_measurement': %w", err)
+		}
+	}
+	if s.Timestamp != "" {
+		if s.timestamp, err = resolvePath(s.msgDesc, s.Timestamp); err != nil {
+			return fmt.Errorf("invalid 'protobuf_timestamp': %w", err)
+		}
+		if err := checkTimestamp(s.timestamp.leaf()); err != nil {
+			return fmt.Errorf("invalid 'protobuf_timestamp': %w", err)
+		}
+	}
+	s.tags = make(map[string]fieldPath, len(s.Tags))
+	for tag, path := range s.Tags {
+		if s.tags[tag], err = resolveScalar(s.msgDesc, path); err != nil {
+			return fmt.Errorf("invalid mapping for tag %q: %w", tag, err)
+		}
+	}
+	s.fields = make(map[string]fieldPath, len(s.Fields))
+	for field, path := range s.Fields {
+		if s.fields[field], err = resolveScalar(s.msgDesc, path); err != nil {
+			return fmt.Errorf("invalid mapping for field %q: %w", field, err)
+		}
+	}
+	if s.TagsField != "" {
+		if s.tagsMap, err = resolveMap(s.msgDesc, s.TagsField); err != nil {
+			return fmt.Errorf("invalid 'protobuf_tags_field': %w", err)
+		}
+		if s.tagsMap.leaf().MapValue().Kind() != protoreflect.StringKind {
+			return errors.New("invalid 'protobuf_tags_field': map values must be strings")
+		}
+	}
+	if s.FieldsField != "" {
+		if s.fieldsMap, err = resolveMap(s.msgDesc, s.FieldsField); err != nil {
+			return fmt.Errorf("invalid 'protobuf_fields_field': %w", err)
+		}
+	}
+
+	s.marshaller = proto.MarshalOptions{Deterministic: true}
+
+	return nil
+}
+
+func (s *Serializer) Serialize(metric telegraf.Metric) ([]byte, error) {
+	return s.SerializeBatch([]telegraf.Metric{metric})
+}
+
+func (s *Serializer) SerializeBatch(metrics []telegraf.Metric) ([]byte, error) {
+	// Wrap all metrics in a single batch message if configured
+	if s.batchDesc != nil {
+		batch := dynamicpb.NewMessage(s.batchDesc)
+		list := batch.Mutable(s.batchField).List()
+		for _, m := range metrics {
+			msg := dynamicpb.NewMessage(s.msgDesc)
+			if err := s.fill(msg, m); err != nil {
+				return nil, err
+			}
+			list.Append(protoreflect.ValueOfMessage(msg))
+		}
+		return s.marshal(nil, batch)
+	}
+
+	if len(metrics) > 1 && s.Framing == "none" {
+		return nil, errors.New("serializing multiple metrics requires 'protobuf_batch_type' or length-delimited framing")
+	}
+
+	var buf []byte
+	for _, m := range metrics {
+		msg := dynamicpb.NewMessage(s.msgDesc)
+		if err := s.fill(msg, m); err != nil {
+			return nil, err
+		}
+		var err error
+		if buf, err = s.marshal(buf, msg); err != nil {
+			return nil, err
+		}
+	}
+	return buf, nil
+}
+
+func (s *Serializer) marshal(buf []byte, msg proto.Message) ([]byte, error) {
+	if s.Framing == "length_delimited" {
+		buf = protowire.AppendVarint(buf, uint64(s.marshaller.Size(msg)))
+	}
+	return s.marshaller.MarshalAppend(buf, msg)
+}
+
+// fill sets the message fields according to the configured mappings
+func (s *Serializer) fill(msg protoreflect.Message, m telegraf.Metric) error {
+	if s.measurement != nil {
+		if err := s.measurement.set(msg, m.Name()); err != nil {
+			return fmt.Errorf("setting measurement of %q failed: %w", m.Name(), err)
+		}
+	}
+	if s.timestamp != nil {
+		if err := s.setTimestamp(msg, m.Time()); err != nil {
+			return fmt.Errorf("setting timestamp of %q failed: %w", m.Name(), err)
+		}
+	}
+
+	for _, tag := range m.TagList() {
+		if path, found := s.tags[tag.Key]; found {
+			if err := path.set(msg, tag.Value); err != nil {
+				return fmt.Errorf("setting tag %q of %q failed: %w", tag.Key, m.Name(), err)
+			}
+			continue
+		}
+		if s.tagsMap != nil {
+			if err := s.tagsMap.setMapEntry(msg, tag.Key, tag.Value); err != nil {
+				return fmt.Errorf("setting tag %q of %q failed: %w", tag.Key, m.Name(), err)
+			}
+		}
+	}
+
+	for _, field := range m.FieldList() {
+		// Native histograms are not supported
+		if metric.IsHistogram(field.Value) {
+			continue
+		}
+		if path, found := s.fields[field.Key]; found {
+			if err := path.set(msg, field.Value); err != nil {
+				return fmt.Errorf("setting field %q of %q failed: %w", field.Key, m.Name(), err)
+			}
+			continue
+		}
+		if s.fieldsMap != nil {
+			if err := s.fieldsMap.setMapEntry(msg, field.Key, field.Value); err != nil {
+				s.Log.Debugf("Skipping field %q of %q: %v", field.Key, m.Name(), err)
+			}
+		}
+	}
+
+	return nil
+}
+
+func (s *Serializer) setTimestamp(msg protoreflect.Message, t time.Time) error {
+	fd := s.timestamp.leaf()
+	switch fd.Kind() {
+	case protoreflect.MessageKind:
+		parent := s.timestamp.parent(msg)
+		ts := parent.Mutable(fd).Message()
+		desc := ts.Descriptor().Fields()
+		ts.Set(desc.ByName("seconds"), protoreflect.ValueOfInt64(t.Unix()))
+		ts.Set(desc.ByName("nanos"), protoreflect.ValueOfInt32(int32(t.Nanosecond()))) //nolint:gosec // G115: nanoseconds are always below 1e9
+		return nil
+	case protoreflect.StringKind:
+		return s.timestamp.set(msg, t.UTC().Format(time.RFC3339Nano))
+	case protoreflect.FloatKind, protoreflect.DoubleKind:
+		return s.timestamp.set(msg, float64(t.UnixNano())/float64(s.timestampUnit()))
+	}
+	return s.timestamp.set(msg, t.UnixNano()/int64(s.timestampUnit()))
+}
+
+func (s *Serializer) timestampUnit() time.Duration {
+	switch s.TimestampFormat {
+	case "unix":
+		return time.Second
+	case "unix_ms":
+		return time.Millisecond
+	case "unix_us":
+		return time.Microsecond
+	}
+	return time.Nanosecond
+}
+
+// findBatchField returns the repeated field of the batch message holding
+// the metric messages
+func (s *Serializer) findBatchField() (protoreflect.FieldDescriptor, error) {
+	if s.BatchField != "" {
+		fd := s.batchDesc.Fields().ByName(protoreflect.Name(s.BatchField))
+		if fd == nil {
+			return nil, fmt.Errorf("batch message %q has no field %q", s.BatchType, s.BatchField)
+		}
+		if !isRepeatedMessage(fd, s.msgDesc) {
+			return nil, fmt.Errorf("field %q of batch message %q is not a repeated %q field", s.BatchField, s.BatchType, s.MessageType)
+		}
+		return fd, nil
+	}
+
+	var found protoreflect.FieldDescriptor
+	fields := s.batchDesc.Fields()
+	for i := 0; i < fields.Len(); i++ {
+		fd := fields.Get(i)
+		if !isRepeatedMessage(fd, s.msgDesc) {
+			continue
+		}
+		if found != nil {
+			return nil, fmt.Errorf("batch message %q has multiple repeated %q fields, set 'protobuf_batch_field'", s.BatchType, s.MessageType)
+		}
+		found = fd
+	}
+	if found == nil {
+		return nil, fmt.Errorf("batch message %q has no repeated %q field", s.BatchType, s.MessageType)
+	}
+	return found, nil
+}
+
+func isRepeatedMessage(fd protoreflect.FieldDescriptor, desc protoreflect.MessageDescriptor) bool {
+	return fd.IsList() && fd.Kind() == protoreflect.MessageKind && fd.Message().FullName() == desc.FullName()
+}
+
+func findMessage(registry *protoregistry.Files, name string) (protoreflect.MessageDescriptor, error) {
+	descriptor, err := registry.FindDescriptorByName(protoreflect.FullName(name))
+	if err != nil {
+		return nil, fmt.Errorf("looking up message %q failed: %w", name, err)
+	}
+	desc, ok := descriptor.(protoreflect.MessageDescriptor)
+	if !ok {
+		return nil, fmt.Errorf("%q is not a message descriptor (%T)", name, descriptor)
+	}
+	return desc, nil
+}
+
+func init() {
+	serializers.Add("protobuf",
+		func() telegraf.Serializer {
+			return &Serializer{}
+		},
+	)
+}
@@ -0,0 +1,257 @@
+package protobuf
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/encoding/protodelim"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/dynamicpb"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/metric"
+	common_protobuf "github.com/influxdata/telegraf/plugins/common/protobuf"
+	"github.com/influxdata/telegraf/testutil"
+)
+
+func newSerializer() *Serializer {
+	return &Serializer{
+		MessageFiles: []string{"testdata/metrics.proto"},
+		MessageType:  "test.Metric",
+		Measurement:  "name",
+		Timestamp:    "time",
+		Tags:         map[string]string{"host": "source.host", "port": "source.port"},
+		Fields:       map[string]string{"usage": "usage", "status": "status"},
+		TagsField:    "labels",
+		FieldsField:  "values",
+		Log:          testutil.Logger{},
+	}
+}
+
+func newMetric() telegraf.Metric {
+	return metric.New(
+		"cpu",
+		map[string]string{"host": "server01", "port": "8080", "region": "eu"},
+		map[string]interface{}{
+			"usage":  int64(42),
+			"status": "FAILED",
+			"idle":   57.5,
+			"state":  "running",
+		},
+		time.Unix(1700000000, 123456789),
+	)
+}
+
+func decode(t *testing.T, msgType string, buf []byte) string {
+	t.Helper()
+
+	registry, err := common_protobuf.Compile([]string{"testdata/metrics.proto"}, nil)
+	require.NoError(t, err)
+	desc, err := registry.FindDescriptorByName(protoreflect.FullName(msgType))
+	require.NoError(t, err)
+
+	msg := dynamicpb.NewMessage(desc.(protoreflect.MessageDescriptor))
+	require.NoError(t, proto.Unmarshal(buf, msg))
+	out, err := protojson.Marshal(msg)
+	require.NoError(t, err)
+	return string(out)
+}
+
+func TestSerialize(t *testing.T) {
+	s := newSerializer()
+	require.NoError(t, s.Init())
+
+	buf, err := s.Serialize(newMetric())
+	require.NoError(t, err)
+
+	expected := `{
+		"name": "cpu",
+		"time": "2023-11-14T22:13:20.123456789Z",
+		"source": {"host": "server01", "port": 8080},
+		"labels": {"region": "eu"},
+		"values": {"idle": 57.5},
+		"usage": 42,
+		"status": "FAILED"
+	}`
+	require.JSONEq(t, expected, decode(t, "test.Metric", buf))
+}
+
+func TestSerializeTimestampFormat(t *testing.T) {
+	s := &Serializer{
+		MessageFiles:    []string{"testdata/metrics.proto"},
+		MessageType:     "test.Metric",
+		Timestamp:       "time_ms",
+		TimestampFormat: "unix_ms",
+	}
+	require.NoError(t, s.Init())
+
+	buf, err := s.Serialize(newMetric())
+	require.NoError(t, err)
+	require.JSONEq(t, `{"timeMs": "1700000000123"}`, decode(t, "test.Metric", buf))
+}
+
+func TestSerializeBatchWrapper(t *testing.T) {
+	s := newSerializer()
+	s.BatchType = "test.Batch"
+	require.NoError(t, s.Init())
+
+	m1 := metric.New("cpu", map[string]string{"host": "a"}, map[string]interface{}{"usage": 1.5}, time.Unix(1, 0))
+	m2 := metric.New("mem", map[string]string{"host": "b"}, map[string]interface{}{"usage": 2.5}, time.Unix(2, 0))
+	buf, err := s.SerializeBatch([]telegraf.Metric{m1, m2})
+	require.NoError(t, err)
+
+	expected := `{
+		"metrics": [
+			{"name": "cpu", "time": "1970-01-01T00:00:01Z", "source": {"host": "a"}, "usage": 1.5},
+			{"name": "mem", "time": "1970-01-01T00:00:02Z", "source": {"host": "b"}, "usage": 2.5}
+		]
+	}`
+	require.JSONEq(t, expected, decode(t, "test.Batch", buf))
+}
+
+func TestSerializeLengthDelimited(t *testing.T) {
+	s := newSerializer()
+	s.Framing = "length_delimited"
+	require.NoError(t, s.Init())
+
+	m1 := metric.New("cpu", map[string]string{}, map[string]interface{}{"usage": 1.5}, time.Unix(1, 0))
+	m2 := metric.New("mem", map[string]string{}, map[string]interface{}{"usage": 2.5}, time.Unix(2, 0))
+	buf, err := s.SerializeBatch([]telegraf.Metric{m1, m2})
+	require.NoError(t, err)
+
+	registry, err := common_protobuf.Compile([]string{"testdata/metrics.proto"}, nil)
+	require.NoError(t, err)
+	desc, err := registry.FindDescriptorByName("test.Metric")
+	require.NoError(t, err)
+
+	var names []string
+	reader := protodelim.UnmarshalOptions{MaxSize: -1}
+	r := bufio.NewReader(bytes.NewReader(buf))
+	for {
+		msg := dynamicpb.NewMessage(desc.(protoreflect.MessageDescriptor))
+		err := reader.UnmarshalFrom(r, msg)
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		require.NoError(t, err)
+		names = append(names, msg.Get(msg.Descriptor().Fields().ByName("name")).String())
+	}
+	require.Equal(t, []string{"cpu", "mem"}, names)
+}
+
+func TestSerializeBatchWithoutFraming(t *testing.T) {
+	s := newSerializer()
+	require.NoError(t, s.Init())
+
+	_, err := s.SerializeBatch([]telegraf.Metric{newMetric(), newMetric()})
+	require.ErrorContains(t, err, "requires 'protobuf_batch_type' or length-delimited framing")
+}
+
+func TestSerializeConversionError(t *testing.T) {
+	s := newSerializer()
+	require.NoError(t, s.Init())
+
+	m := metric.New("cpu", map[string]string{}, map[string]interface{}{"status": "BROKEN"}, time.Unix(0, 0))
+	_, err := s.Serialize(m)
+	require.ErrorContains(t, err, `setting field "status" of "cpu" failed`)
+}
+
+func TestInitInvalid(t *testing.T) {
+	tests := []struct {
+		name     string
+		modify   func(*Serializer)
+		expected string
+	}{
+		{
+			name:     "missing type",
+			modify:   func(s *Serializer) { s.MessageType = "" },
+			expected: "'protobuf_type' not set",
+		},
+		{
+			name:     "unknown type",
+			modify:   func(s *Serializer) { s.MessageType = "test.Unknown" },
+			expected: `looking up message "test.Unknown" failed`,
+		},
+		{
+			name:     "invalid framing",
+			modify:   func(s *Serializer) { s.Framing = "xml" },
+			expected: `invalid 'protobuf_framing' "xml"`,
+		},
+		{
+			name:     "unknown field",
+			modify:   func(s *Serializer) { s.Fields = map[string]string{"usage": "source.unknown"} },
+			expected: `message "test.Source" has no field "unknown"`,
+		},
+		{
+			name:     "path through scalar",
+			modify:   func(s *Serializer) { s.Measurement = "usage.value" },
+			expected: `"usage" is not a message field`,
+		},
+		{
+			name:     "repeated field",
+			modify:   func(s *Serializer) { s.Tags = map[string]string{"host": "notes"} },
+			expected: "repeated fields are not supported",
+		},
+		{
+			name:     "message field",
+			modify:   func(s *Serializer) { s.Tags = map[string]string{"host": "source"} },
+			expected: `message type "test.Source" is not supported`,
+		},
+		{
+			name:     "tags field not a map",
+			modify:   func(s *Serializer) { s.TagsField = "name" },
+			expected: `field "name" is not a map`,
+		},
+		{
+			name:     "tags field without string values",
+			modify:   func(s *Serializer) { s.TagsField = "values" },
+			expected: "map values must be strings",
+		},
+		{
+			name:     "invalid timestamp field",
+			modify:   func(s *Serializer) { s.Timestamp = "source" },
+			expected: `message type "test.Source" is not supported`,
+		},
+		{
+			name:     "batch type without repeated field",
+			modify:   func(s *Serializer) { s.BatchType = "test.Source" },
+			expected: `batch message "test.Source" has no repeated "test.Metric" field`,
+		},
+		{
+			name:     "ambiguous batch type",
+			modify:   func(s *Serializer) { s.BatchType = "test.AmbiguousBatch" },
+			expected: "set 'protobuf_batch_field'",
+		},
+		{
+			name:     "batch field without batch type",
+			modify:   func(s *Serializer) { s.BatchField = "metrics" },
+			expected: "'protobuf_batch_field' requires 'protobuf_batch_type'",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newSerializer()
+			tt.modify(s)
+			require.ErrorContains(t, s.Init(), tt.expected)
+		})
+	}
+}
+
+func TestInitAmbiguousBatchWithField(t *testing.T) {
+	s := newSerializer()
+	s.BatchType = "test.AmbiguousBatch"
+	s.BatchField = "second"
+	require.NoError(t, s.Init())
+
+	buf, err := s.SerializeBatch([]telegraf.Metric{newMetric()})
+	require.NoError(t, err)
+	require.Contains(t, decode(t, "test.AmbiguousBatch", buf), `"second"`)
+}
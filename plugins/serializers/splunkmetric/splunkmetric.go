@@ -5,6 +5,7 @@ import (
 	"log"
 
 	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/metric"
 	"github.com/influxdata/telegraf/plugins/serializers"
 )
 
@@ -188,7 +189,7 @@ func (s *Serializer) createObject(metric telegraf.Metric) ([]byte, error) {
 
 func verifyValue(v interface{}) (value interface{}, valid bool) {
 	switch v.(type) {
-	case string:
+	case string, metric.Histogram, metric.ExponentialHistogram:
 		valid = false
 		value = v
 	case bool: